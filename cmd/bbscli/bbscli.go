@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/skycoin/bbs/src/misc/tag"
 	"github.com/skycoin/bbs/src/rpc"
 	"github.com/skycoin/bbs/src/store"
@@ -17,6 +19,7 @@ const (
 var (
 	Port = 8996
 	Address = ""
+	JSON = false
 )
 
 func address() string {
@@ -28,15 +31,36 @@ func address() string {
 }
 
 func call(method string, in interface{}) error {
+	if JSON {
+		out, e := rpc.Call(address(), method, in)
+		return printJSON(out, e)
+	}
 	log.Println(rpc.Send(address())(method, in))
 	return nil
 }
 
 func do(out interface{}, e error) error {
+	if JSON {
+		if e != nil {
+			return printJSON("", e)
+		}
+		data, e := json.MarshalIndent(out, "", "  ")
+		return printJSON(string(data), e)
+	}
 	log.Println(rpc.Do(out, e))
 	return nil
 }
 
+// printJSON prints the result to stdout for scripts, or exits with a non-zero
+// status after printing the error to stderr.
+func printJSON(out string, e error) error {
+	if e != nil {
+		return cli.NewExitError(e.Error(), 1)
+	}
+	fmt.Println(out)
+	return nil
+}
+
 func main() {
 	app := cli.NewApp()
 	app.Name = "bbscli"
@@ -57,6 +81,12 @@ func main() {
 			Value: Address,
 			Destination: &Address,
 		},
+		cli.BoolFlag{
+			Name:        "json, j",
+			Usage:       "print results as plain JSON to stdout and exit with a non-zero status on failure, for use in scripts",
+			EnvVar:      "BBS_CLI_JSON",
+			Destination: &JSON,
+		},
 	}
 	app.Commands = cli.Commands{
 		{
@@ -213,6 +243,36 @@ func main() {
 						}))
 					},
 				},
+				{
+					Name:  "enable",
+					Usage: "enables reconnecting to a connection",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  "address, a",
+							Usage: "address to enable",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						return call(rpc.EnableConnection(&store.ConnectionIn{
+							Address: ctx.String("address"),
+						}))
+					},
+				},
+				{
+					Name:  "disable",
+					Usage: "disables reconnecting to a connection, and closes it",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  "address, a",
+							Usage: "address to disable",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						return call(rpc.DisableConnection(&store.ConnectionIn{
+							Address: ctx.String("address"),
+						}))
+					},
+				},
 			},
 		},
 		{
@@ -254,6 +314,157 @@ func main() {
 						}))
 					},
 				},
+				{
+					Name:  "set_options",
+					Usage: "sets options of a subscription",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name: "public-key, pk",
+						},
+						cli.StringFlag{
+							Name:  "auto-connect",
+							Usage: "whether to dial peers discovered from the board (true or false)",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						return call(rpc.SetSubscriptionOptions(&store.SubscriptionOptionsIn{
+							PubKeyStr:      ctx.String("public-key"),
+							AutoConnectStr: ctx.String("auto-connect"),
+						}))
+					},
+				},
+			},
+		},
+		{
+			Name:  "registry",
+			Usage: "announces boards to registry boards and discovers announced boards",
+			Subcommands: cli.Commands{
+				{
+					Name:  "set",
+					Usage: "sets whether a master board is a registry, which accepts and lists board announcements",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  "public-key, pk",
+							Usage: "public key of the master board",
+						},
+						cli.BoolFlag{
+							Name:  "disable",
+							Usage: "(optional) stop accepting board announcements",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						return call(rpc.SetRegistry(&store.RegistryIn{
+							BoardPubKeyStr: ctx.String("public-key"),
+							Registry:       !ctx.Bool("disable"),
+						}))
+					},
+				},
+				{
+					Name:  "announce",
+					Usage: "announces a master board with it's name, description and metadata",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  "public-key, pk",
+							Usage: "public key of the master board to announce",
+						},
+						cli.StringFlag{
+							Name:  "registry, r",
+							Usage: "(optional) public key of registry board, leave blank for all configured registries",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						return call(rpc.AnnounceBoard(&store.AnnounceBoardIn{
+							BoardPubKeyStr:    ctx.String("public-key"),
+							RegistryPubKeyStr: ctx.String("registry"),
+						}))
+					},
+				},
+				{
+					Name:  "discover",
+					Usage: "lists announced boards, most recently announced first",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  "query, q",
+							Usage: "(optional) space separated terms that all need to be in an announcement",
+						},
+						cli.StringFlag{
+							Name:  "category, c",
+							Usage: "(optional) category of announced boards",
+						},
+						cli.StringFlag{
+							Name:  "registry, r",
+							Usage: "(optional) public key of registry board, leave blank for all configured registries",
+						},
+						cli.StringFlag{
+							Name:  "start-index",
+							Usage: "(optional) index of first board of page",
+						},
+						cli.StringFlag{
+							Name:  "page-size",
+							Usage: "(optional) maximum number of boards of page",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						return call(rpc.DiscoverBoards(&store.DiscoverBoardsIn{
+							Query:             ctx.String("query"),
+							Category:          ctx.String("category"),
+							RegistryPubKeyStr: ctx.String("registry"),
+							StartIndexStr:     ctx.String("start-index"),
+							PageSizeStr:       ctx.String("page-size"),
+						}))
+					},
+				},
+			},
+		},
+		{
+			Name:  "logs",
+			Usage: "inspects the node's recent logs and sets log levels",
+			Subcommands: cli.Commands{
+				{
+					Name:  "get",
+					Usage: "gets recent log entries, oldest first",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  "module, m",
+							Usage: "(optional) module of entries, leave blank for all modules",
+						},
+						cli.StringFlag{
+							Name:  "level, l",
+							Usage: "(optional) minimum level of entries, one of 'debug', 'info', 'warn' or 'error'",
+						},
+						cli.StringFlag{
+							Name:  "count, c",
+							Usage: "(optional) maximum number of most recent entries, leave blank for all",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						return call(rpc.GetLogs(&store.LogsIn{
+							Module:   ctx.String("module"),
+							LevelStr: ctx.String("level"),
+							CountStr: ctx.String("count"),
+						}))
+					},
+				},
+				{
+					Name:  "set_level",
+					Usage: "sets the minimum level of entries logged by a module",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  "module, m",
+							Usage: "module to set level of, leave blank for modules that have none set",
+						},
+						cli.StringFlag{
+							Name:  "level, l",
+							Usage: "minimum level of entries, one of 'debug', 'info', 'warn' or 'error'",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						return call(rpc.SetLogLevel(&store.LogLevelIn{
+							Module:   ctx.String("module"),
+							LevelStr: ctx.String("level"),
+						}))
+					},
+				},
 			},
 		},
 		{
@@ -280,6 +491,26 @@ func main() {
 							Name:  "seed, s",
 							Usage: "seed to generate key pair of the board",
 						},
+						cli.StringFlag{
+							Name:  "description",
+							Usage: "(optional) description of the board",
+						},
+						cli.StringFlag{
+							Name:  "rules",
+							Usage: "(optional) newline separated rules of the board",
+						},
+						cli.StringFlag{
+							Name:  "tags",
+							Usage: "(optional) comma separated tags of the board",
+						},
+						cli.StringFlag{
+							Name:  "category",
+							Usage: "(optional) category of the board",
+						},
+						cli.StringFlag{
+							Name:  "icon",
+							Usage: "(optional) hash of the board's icon attachment",
+						},
 					},
 					Action: func(ctx *cli.Context) error {
 						return call(rpc.NewBoard(&store.NewBoardIn{
@@ -287,6 +518,13 @@ func main() {
 							Body: ctx.String("body"),
 							TS:   ctx.Int64("timestamp"),
 							Seed: ctx.String("seed"),
+							BoardMetaIn: store.BoardMetaIn{
+								Description: ctx.String("description"),
+								RulesStr:    ctx.String("rules"),
+								TagsStr:     ctx.String("tags"),
+								Category:    ctx.String("category"),
+								IconStr:     ctx.String("icon"),
+							},
 						}))
 					},
 				},
@@ -340,6 +578,46 @@ func main() {
 						}))
 					},
 				},
+				{
+					Name:  "export_archive",
+					Usage: "exports a portable archive of a board's content",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  "public-key, pk",
+							Usage: "public key of the board to export",
+						},
+						cli.StringFlag{
+							Name:  "file-path, fp",
+							Usage: "full path of file to export archive to",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						return call(rpc.ExportArchive(&store.ExportBoardIn{
+							PubKeyStr: ctx.String("public-key"),
+							FilePath:  ctx.String("file-path"),
+						}))
+					},
+				},
+				{
+					Name:  "import_archive",
+					Usage: "seeds a master board from a board archive",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  "file-path, fp",
+							Usage: "full path of file to import archive from",
+						},
+						cli.StringFlag{
+							Name:  "secret-key, sk",
+							Usage: "secret key of the archived board",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						return call(rpc.ImportArchive(&store.ImportArchiveIn{
+							FilePath:  ctx.String("file-path"),
+							SecKeyStr: ctx.String("secret-key"),
+						}))
+					},
+				},
 				{
 					Name:  "get_boards",
 					Usage: "gets a list of hosted boards on the node",
@@ -417,6 +695,26 @@ func main() {
 						}))
 					},
 				},
+				{
+					Name:  "query",
+					Usage: "executes a GraphQL query against subscribed boards",
+					Flags: cli.FlagsByName{
+						cli.StringFlag{
+							Name:  "query, q",
+							Usage: "the GraphQL query to execute",
+						},
+						cli.StringFlag{
+							Name:  "variables, v",
+							Usage: "(optional) JSON object of variables of the query",
+						},
+					},
+					Action: func(ctx *cli.Context) error {
+						return call(rpc.Query(&store.GraphQLIn{
+							Query:        ctx.String("query"),
+							VariablesStr: ctx.String("variables"),
+						}))
+					},
+				},
 				{
 					Name:  "new_thread",
 					Usage: "submits a new thread to specified board",
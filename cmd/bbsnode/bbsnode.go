@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/skycoin/bbs/src/http"
+	"github.com/skycoin/bbs/src/misc/inform"
 	"github.com/skycoin/bbs/src/rpc"
 	"github.com/skycoin/bbs/src/store"
 	"github.com/skycoin/bbs/src/store/cxo"
@@ -33,7 +34,8 @@ const (
 )
 
 var (
-	compilerInternal = 1
+	compilerInternal      = 1
+	compilerUpdateTimeout = 30
 )
 
 // Config represents configuration for node.
@@ -55,6 +57,19 @@ type Config struct {
 	WebTLSCertFile             string          `json:"web-tls-cert-file"`            // Path for TLS Certificate file.
 	WebTLSKeyFile              string          `json:"web-tls-key-file"`             // Path for TLS Key file.
 	Browser                    bool            `json:"open-browser"`                 // Whether to open browser on GUI start.
+	DebugUpdates               bool            `json:"debug-updates"`                // Whether to log diagnostics of malformed content.
+	MaxPins                    int             `json:"max-pins"`                     // Maximum number of pinned threads per board.
+	MaxCompiles                int             `json:"max-compiles"`                 // Maximum number of boards compiled concurrently.
+	SubmitRate                 int             `json:"submit-rate"`                  // Maximum submissions per user per minute, 0 for no limit.
+	SubmitBurst                int             `json:"submit-burst"`                 // Maximum submissions per user at once.
+	SpamThreshold              float64         `json:"spam-threshold"`               // Spam score at or above which threads and posts are hidden, 0 to never hide.
+	GCRetention                int             `json:"gc-retention"`                 // Hours before deleted content of master boards is removed, 0 to keep.
+	LogFormat                  string          `json:"log-format"`                   // Format of logs, 'text' or 'json'.
+	LogLevel                   string          `json:"log-level"`                    // Minimum level of logged entries.
+	Passphrase                 string          `json:"-"`                            // Passphrase to encrypt board secret keys at rest, empty for none.
+	PEX                        bool            `json:"pex"`                          // Whether to exchange peers of boards.
+	PEXAddress                 string          `json:"pex-address,omitempty"`        // CXO address of node advertised by master boards.
+	Registries                 cli.StringSlice `json:"registries"`                   // Public keys of registry boards to discover boards from.
 }
 
 // NewDefaultConfig returns a default configuration for BBS node.
@@ -74,6 +89,13 @@ func NewDefaultConfig() *Config {
 		WebGUI:                     true,
 		WebGUIDir:                  defaultStaticSubDir, // --> Action: set as '$HOME/.skybbs/static/dist'
 		Browser:                    false,
+		MaxPins:                    state.DefaultMaxPins,
+		MaxCompiles:                state.DefaultMaxCompiles,
+		SpamThreshold:              state.DefaultSpamThreshold,
+		LogFormat:                  "text",
+		LogLevel:                   inform.InfoLevel.String(),
+		PEX:                        true,
+		Registries:                 []string{},
 	}
 }
 
@@ -84,6 +106,16 @@ func (c *Config) Print() {
 
 // PostProcess checks the flags and processes them.
 func (c *Config) PostProcess() error {
+	format, e := inform.ParseFormat(c.LogFormat)
+	if e != nil {
+		return e
+	}
+	level, e := inform.ParseLevel(c.LogLevel)
+	if e != nil {
+		return e
+	}
+	inform.SetFormat(format)
+	inform.SetLevel("", level)
 	if !c.Memory {
 		if c.ConfigDir == "" {
 			c.ConfigDir = filepath.Join(file.UserHome(), defaultConfigSubDir)
@@ -127,9 +159,21 @@ func (c *Config) GenerateAction() cli.ActionFunc {
 							CXOPort:                    &c.CXOPort,
 							CXORPCEnable:               &c.CXORPC,
 							CXORPCPort:                 &c.CXORPCPort,
+							Passphrase:                 &c.Passphrase,
+							PEX:                        &c.PEX,
+							PEXAddress:                 &c.PEXAddress,
+							Registries:                 c.Registries,
 						},
 						&state.CompilerConfig{
 							UpdateInterval: &compilerInternal,
+							UpdateTimeout:  &compilerUpdateTimeout,
+							DebugUpdates:   &c.DebugUpdates,
+							MaxPins:        &c.MaxPins,
+							MaxCompiles:    &c.MaxCompiles,
+							SubmitRate:     &c.SubmitRate,
+							SubmitBurst:    &c.SubmitBurst,
+							SpamThreshold:  &c.SpamThreshold,
+							GCRetention:    &c.GCRetention,
 						},
 					),
 					Medial: medial.NewServer(&medial.ServerConfig{
@@ -285,6 +329,80 @@ func main() {
 			Destination: &config.Browser,
 			Usage:       "whether to open a browser window",
 		},
+		cli.BoolFlag{
+			Name:        "debug-updates",
+			Destination: &config.DebugUpdates,
+			Usage:       "whether to log diagnostics of malformed content when compiling boards",
+		},
+		cli.IntFlag{
+			Name:        "max-pins",
+			Destination: &config.MaxPins,
+			Value:       config.MaxPins,
+			Usage:       "maximum number of pinned threads per master board",
+		},
+		cli.IntFlag{
+			Name:        "max-compiles",
+			Destination: &config.MaxCompiles,
+			Value:       config.MaxCompiles,
+			Usage:       "maximum number of boards compiled concurrently",
+		},
+		cli.IntFlag{
+			Name:        "submit-rate",
+			Destination: &config.SubmitRate,
+			Value:       config.SubmitRate,
+			Usage:       "maximum submissions per user per minute to master boards, 0 for no limit",
+		},
+		cli.IntFlag{
+			Name:        "submit-burst",
+			Destination: &config.SubmitBurst,
+			Value:       config.SubmitBurst,
+			Usage:       "maximum submissions per user at once, when submissions are rate limited",
+		},
+		cli.Float64Flag{
+			Name:        "spam-threshold",
+			Destination: &config.SpamThreshold,
+			Value:       config.SpamThreshold,
+			Usage:       "spam score (0 to 1) at or above which threads and posts are hidden, 0 to never hide",
+		},
+		cli.IntFlag{
+			Name:        "gc-retention",
+			Destination: &config.GCRetention,
+			Value:       config.GCRetention,
+			Usage:       "hours before deleted threads and posts of master boards are removed, 0 to keep them",
+		},
+		cli.StringFlag{
+			Name:        "log-format",
+			Destination: &config.LogFormat,
+			Value:       config.LogFormat,
+			Usage:       "format of logs, either 'text' or 'json'",
+		},
+		cli.StringFlag{
+			Name:        "log-level",
+			Destination: &config.LogLevel,
+			Value:       config.LogLevel,
+			Usage:       "minimum level of logged entries, one of 'debug', 'info', 'warn' or 'error'",
+		},
+		cli.StringFlag{
+			Name:        "passphrase",
+			Destination: &config.Passphrase,
+			EnvVar:      "BBS_PASSPHRASE",
+			Usage:       "passphrase to encrypt saved board secret keys with, and to unlock them on start",
+		},
+		cli.BoolTFlag{
+			Name:        "pex",
+			Destination: &config.PEX,
+			Usage:       "whether to exchange peers of boards, and dial peers discovered from remote boards",
+		},
+		cli.StringFlag{
+			Name:        "pex-address",
+			Destination: &config.PEXAddress,
+			Usage:       "CXO address (host:port) of this node for master boards to advertise to peers",
+		},
+		cli.StringSliceFlag{
+			Name:  "registries",
+			Value: &config.Registries,
+			Usage: "list of public keys of registry boards to announce and discover boards with",
+		},
 	}
 	app := cli.NewApp()
 	app.Name = "bbsnode"
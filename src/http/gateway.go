@@ -1,16 +1,20 @@
 package http
 
 import (
-	"bytes"
 	"encoding/json"
 	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/gql"
 	"github.com/skycoin/bbs/src/misc/inform"
 	"github.com/skycoin/bbs/src/store"
 	"log"
+	"mime"
 	"net/http"
 	"os"
 )
 
+// MaxGraphQLBodySize is the maximum size of the body of GraphQL requests.
+const MaxGraphQLBodySize = 1 << 20
+
 // Gateway represents what is exposed to HTTP interface.
 type Gateway struct {
 	l        *log.Logger
@@ -21,86 +25,72 @@ type Gateway struct {
 func (g *Gateway) host(mux *http.ServeMux) error {
 	g.l = inform.NewLogger(true, os.Stdout, "GATEWAY")
 
-	// For tools.
-	RegisterToolsHandlers(mux, g)
-
-	// For submission.
-	RegisterSubmissionHandlers(mux, g)
-
-	// Gets a list of boards; remote and master (boards that this node owns).
-	mux.HandleFunc("/api/get_boards",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.GetBoards(r.Context()))
-		})
-
-	// Gets a single board.
-	mux.HandleFunc("/api/get_board",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.GetBoard(r.Context(), &store.BoardIn{
-				PubKeyStr:     r.FormValue("board_public_key"),
-				UserPubKeyStr: r.FormValue("perspective"),
-			}))
-		})
-
-	// Obtains a view of a board including it's children threads.
-	mux.HandleFunc("/api/get_board_page",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.GetBoardPage(r.Context(), &store.BoardIn{
-				PubKeyStr:     r.FormValue("board_public_key"),
-				UserPubKeyStr: r.FormValue("perspective"),
-			}))
-		})
-
-	// Gets a view of a thread including it's children posts.
-	mux.HandleFunc("/api/get_thread_page",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.GetThreadPage(r.Context(), &store.ThreadIn{
-				BoardPubKeyStr: r.FormValue("board_public_key"),
-				ThreadRefStr:   r.FormValue("thread_ref"),
-				UserPubKeyStr:  r.FormValue("perspective"),
-			}))
-		})
-
-	// Gets a view of following/avoiding of specified user.
-	mux.HandleFunc("/api/get_user_profile",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.GetFollowPage(r.Context(), &store.UserIn{
-				BoardPubKeyStr: r.FormValue("board_public_key"),
-				UserPubKeyStr:  r.FormValue("user_public_key"),
-			}))
-		})
-
-	// Gets a view of all participating users.
-	mux.HandleFunc("/api/get_participants",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.GetParticipants(r.Context(), &store.BoardIn{
-				PubKeyStr: r.FormValue("board_public_key"),
-			}))
-		})
-
-	// Lists boards that have been discovered, but not subscribed to.
-	mux.HandleFunc("/api/get_available_boards",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.GetAvailableBoards(r.Context()))
-		})
+	// Routes of the REST API, along with their legacy handlers.
+	if e := RegisterRESTHandlers(mux, g.routes()); e != nil {
+		return e
+	}
+
+	// Executes GraphQL queries against the boards of the node. Queries are accepted
+	// as form values, or as a JSON body of 'query' and 'variables' as GraphQL clients send.
+	// Responses are in the GraphQL format of 'data' and 'errors', rather than 'Response'.
+	// Not a route of the REST API, as queries are described by the GraphQL schema
+	// rather than the OpenAPI definition.
+	graphQL := func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, MaxGraphQLBodySize)
+		in := &store.GraphQLIn{}
+		if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "application/json" {
+			var body struct {
+				Query     string                 `json:"query"`
+				Variables map[string]interface{} `json:"variables"`
+			}
+			if e := json.NewDecoder(r.Body).Decode(&body); e != nil {
+				sendGraphQLErr(w, boo.WrapType(e, boo.InvalidInput, "failed to decode request"))
+				return
+			}
+			in.Query, in.Variables = body.Query, body.Variables
+		} else {
+			in.Query, in.VariablesStr = r.FormValue("query"), r.FormValue("variables")
+		}
+		out, e := g.Access.Query(r.Context(), in)
+		if e != nil {
+			sendGraphQLErr(w, e)
+			return
+		}
+		sendStatus(w, out, http.StatusOK)
+	}
+	mux.HandleFunc(RESTPrefix+"/graphql", graphQL)
+	mux.HandleFunc("/api/graphql", graphQL)
 
 	return nil
 }
 
+// routes obtains the routes of the REST API.
+func (g *Gateway) routes() []*Route {
+	var routes []*Route
+	routes = append(routes, toolsRoutes(g)...)
+	routes = append(routes, contentRoutes(g)...)
+	routes = append(routes, submissionRoutes(g)...)
+	routes = append(routes, nodeRoutes(g)...)
+	routes = append(routes, streamRoutes(g)...)
+	return routes
+}
+
 /*
 	<<< HELPER FUNCTIONS >>>
 */
 
-type Error struct {
-	Type    int    `json:"type"`
-	Title   string `json:"title"`
-	Details string `json:"details"`
+type Response struct {
+	Okay  bool            `json:"okay"`
+	Data  interface{}     `json:"data,omitempty"`
+	Error *store.ErrorOut `json:"error,omitempty"`
 }
 
-type Response struct {
-	Okay  bool        `json:"okay"`
-	Data  interface{} `json:"data,omitempty"`
-	Error *Error      `json:"error,omitempty"`
+// sendGraphQLErr writes an error in the GraphQL format.
+func sendGraphQLErr(w http.ResponseWriter, e error) error {
+	out := store.NewErrorOut(e)
+	return sendStatus(w, &gql.Result{
+		Errors: []*gql.Error{{Message: out.Details}},
+	}, out.Status)
 }
 
 func send(w http.ResponseWriter) func(v interface{}, e error) error {
@@ -121,37 +111,8 @@ func sendErr(w http.ResponseWriter, e error) error {
 	if e == nil {
 		return sendOK(w, true)
 	}
-
-	eType := boo.Type(e)
-	eTitle := boo.Message(eType)
-
-	var status int
-
-	switch eType {
-	case boo.Unknown, boo.Internal:
-		status = http.StatusInternalServerError
-	case boo.NotAuthorised, boo.NotMaster:
-		status = http.StatusUnauthorized
-	case boo.NotFound:
-		status = http.StatusNotFound
-	case boo.AlreadyExists:
-		status = http.StatusConflict
-	default:
-		status = http.StatusBadRequest
-	}
-
-	d := e.Error()
-	details := string(bytes.ToUpper([]byte{d[0]})) + d[1:] + "."
-
-	response := Response{
-		Okay: false,
-		Error: &Error{
-			Type:    eType,
-			Title:   eTitle,
-			Details: details,
-		},
-	}
-	return sendStatus(w, response, status)
+	out := store.NewErrorOut(e)
+	return sendStatus(w, Response{Okay: false, Error: out}, out.Status)
 }
 
 func sendStatus(w http.ResponseWriter, v interface{}, status int) error {
@@ -0,0 +1,575 @@
+package http
+
+import (
+	"github.com/skycoin/bbs/src/store"
+	"net/http"
+)
+
+// Parameters shared by routes of content.
+var (
+	perspectiveParam = Param{Name: "perspective", Type: ParamString, Description: "Public key of user to view as."}
+	hideBlockedParam = Param{Name: "hide_blocked", Type: ParamBoolean, Description: "Whether to exclude votes of users blocked by perspective."}
+	sinceParam       = Param{Name: "since", Type: ParamInteger, Description: "Unix time after which content is counted as unread."}
+	sortByParam      = Param{Name: "sort_by", Type: ParamString, Description: "Name of sorter to order with."}
+	mutedParam       = Param{Name: "muted", Type: ParamString, Description: "How content of users muted by perspective is shown, 'hide' or 'collapse'."}
+	minTrustParam    = Param{Name: "min_trust", Type: ParamNumber, Description: "Minimum trust score of creators, from perspective."}
+	showSpamParam    = Param{Name: "show_spam", Type: ParamBoolean, Description: "Whether to include content at or above the spam threshold."}
+)
+
+// boardPageParams are the parameters of views of a board's threads.
+var boardPageParams = []Param{
+	perspectiveParam,
+	sinceParam,
+	hideBlockedParam,
+	sortByParam,
+	{Name: "non_empty_only", Type: ParamBoolean, Description: "Whether to exclude threads with no posts."},
+	{Name: "include_top_reply", Type: ParamBoolean, Description: "Whether to attach the highest scored post of each thread."},
+	{Name: "include_archived", Type: ParamBoolean, Description: "Whether to include archived threads."},
+	mutedParam,
+	minTrustParam,
+	showSpamParam,
+}
+
+// boardPageIn obtains the input of views of a board's threads.
+func boardPageIn(p Params) *store.BoardIn {
+	return &store.BoardIn{
+		PubKeyStr:       p.Get("board_public_key"),
+		UserPubKeyStr:   p.Get("perspective"),
+		SinceUnixStr:    p.Get("since"),
+		HideBlocked:     p.Bool("hide_blocked"),
+		SortBy:          p.Get("sort_by"),
+		NonEmptyOnly:    p.Bool("non_empty_only"),
+		IncludeTopReply: p.Bool("include_top_reply"),
+		IncludeArchived: p.Bool("include_archived"),
+		Muted:           p.Get("muted"),
+		MinTrustStr:     p.Get("min_trust"),
+		ShowSpam:        p.Bool("show_spam"),
+	}
+}
+
+func contentRoutes(g *Gateway) []*Route {
+	return []*Route{
+
+		/*
+			<<< NODE >>>
+		*/
+
+		{
+			Method:      http.MethodGet,
+			Path:        "/stats",
+			OperationID: "GetStats",
+			Tag:         "node",
+			Summary:     "Gets totals across all boards tracked by this node.",
+			Legacy:      "/api/get_stats",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetStats(r.Context())
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/rate_limits/{user_public_key}",
+			OperationID: "GetRateLimit",
+			Tag:         "node",
+			Summary:     "Gets the submission allowance of a user, with the seconds until they may submit again.",
+			Legacy:      "/api/get_rate_limit",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetRateLimit(r.Context(), &store.RateLimitIn{
+					UserPubKeyStr: p.Get("user_public_key"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/watchlists/{user_public_key}",
+			OperationID: "GetUserWatchlist",
+			Tag:         "node",
+			Summary:     "Gets threads that a user created or posted in, across all boards.",
+			Params: []Param{
+				{Name: "last_seen", Type: ParamString, Description: "JSON object of thread hash to unix time last seen."},
+			},
+			Legacy: "/api/get_user_watchlist",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetUserWatchlist(r.Context(), &store.WatchlistIn{
+					UserPubKeyStr: p.Get("user_public_key"),
+					LastSeenStr:   p.Get("last_seen"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/feed",
+			OperationID: "GetAggregatedFeed",
+			Tag:         "node",
+			Summary:     "Gets threads of multiple boards, newest first.",
+			Params: []Param{
+				{Name: "board_public_keys", Type: ParamString, Required: true, Description: "Comma separated public keys of boards."},
+				{Name: "dedup", Type: ParamBoolean, Description: "Whether to exclude threads that are in multiple boards."},
+			},
+			Legacy: "/api/get_aggregated_feed",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetAggregatedFeed(r.Context(), &store.FeedIn{
+					BoardPubKeysStr: p.Get("board_public_keys"),
+					Dedup:           p.Bool("dedup"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/search",
+			OperationID: "SearchAll",
+			Tag:         "node",
+			Summary:     "Searches threads and posts across boards, ordered by relevance.",
+			Params: []Param{
+				{Name: "board_public_keys", Type: ParamString, Description: "Comma separated public keys of boards, all boards if not set."},
+				{Name: "query", Type: ParamString, Required: true, Description: "Terms to search for."},
+				perspectiveParam,
+				hideBlockedParam,
+				{Name: "per_board_limit", Type: ParamInteger, Description: "Maximum number of results per board."},
+			},
+			Legacy: "/api/search",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.SearchAll(r.Context(), &store.SearchAllIn{
+					BoardPubKeysStr:  p.Get("board_public_keys"),
+					Query:            p.Get("query"),
+					UserPubKeyStr:    p.Get("perspective"),
+					HideBlocked:      p.Bool("hide_blocked"),
+					PerBoardLimitStr: p.Get("per_board_limit"),
+				})
+			},
+		},
+
+		/*
+			<<< BOARDS >>>
+		*/
+
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards",
+			OperationID: "GetBoards",
+			Tag:         "boards",
+			Summary:     "Gets a list of boards; remote and master (boards that this node owns).",
+			Legacy:      "/api/get_boards",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetBoards(r.Context())
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/board_summaries",
+			OperationID: "GetBoardsPage",
+			Tag:         "boards",
+			Summary:     "Gets a page of summaries of compiled boards.",
+			Params: []Param{
+				sortByParam,
+				{Name: "start_index", Type: ParamInteger, Description: "Index of first board of page."},
+				{Name: "page_size", Type: ParamInteger, Description: "Maximum number of boards of page."},
+			},
+			Legacy: "/api/get_boards_page",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetBoardsPage(r.Context(), &store.BoardsPageIn{
+					SortBy:        p.Get("sort_by"),
+					StartIndexStr: p.Get("start_index"),
+					PageSizeStr:   p.Get("page_size"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/available_boards",
+			OperationID: "GetAvailableBoards",
+			Tag:         "boards",
+			Summary:     "Lists boards that have been discovered, but not subscribed to.",
+			Legacy:      "/api/get_available_boards",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetAvailableBoards(r.Context())
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}",
+			OperationID: "GetBoard",
+			Tag:         "boards",
+			Summary:     "Gets a single board.",
+			Params:      []Param{perspectiveParam},
+			Legacy:      "/api/get_board",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetBoard(r.Context(), &store.BoardIn{
+					PubKeyStr:     p.Get("board_public_key"),
+					UserPubKeyStr: p.Get("perspective"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/version",
+			OperationID: "GetBoardVersion",
+			Tag:         "boards",
+			Summary:     "Gets the version of a board's views, which changes whenever they change.",
+			Legacy:      "/api/get_board_version",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetBoardVersion(r.Context(), &store.BoardIn{
+					PubKeyStr: p.Get("board_public_key"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/sync_status",
+			OperationID: "GetBoardSyncStatus",
+			Tag:         "boards",
+			Summary:     "Gets how far the compiled state of a board is behind the latest root known of it.",
+			Legacy:      "/api/get_board_sync_status",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetBoardSyncStatus(r.Context(), &store.BoardIn{
+					PubKeyStr: p.Get("board_public_key"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/threads",
+			OperationID: "GetBoardPage",
+			Tag:         "boards",
+			Summary:     "Obtains a view of a board including it's children threads.",
+			Params: append(boardPageParams,
+				Param{Name: "hashes_only", Type: ParamBoolean, Description: "Whether to only obtain thread hashes and pagination metadata."}),
+			Legacy: "/api/get_board_page",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				in := boardPageIn(p)
+				in.HashesOnly = p.Bool("hashes_only")
+				return g.Access.GetBoardPage(r.Context(), in)
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/diff",
+			OperationID: "GetBoardPageDiff",
+			Tag:         "boards",
+			Summary:     "Gets the threads of a board page that changed since a version.",
+			Params: append(boardPageParams,
+				Param{Name: "since_version", Type: ParamInteger, Required: true, Description: "Version of board to obtain changes since."}),
+			Legacy: "/api/get_board_page_diff",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				in := boardPageIn(p)
+				in.SinceVersionStr = p.Get("since_version")
+				return g.Access.GetBoardPageDiff(r.Context(), in)
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/activity",
+			OperationID: "GetActivityFeed",
+			Tag:         "boards",
+			Summary:     "Gets threads and posts of a board, newest first.",
+			Params: []Param{
+				perspectiveParam,
+				hideBlockedParam,
+				{Name: "max_per_thread", Type: ParamInteger, Description: "Maximum number of posts per thread."},
+			},
+			Legacy: "/api/get_activity_feed",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetActivityFeed(r.Context(), &store.BoardIn{
+					PubKeyStr:       p.Get("board_public_key"),
+					UserPubKeyStr:   p.Get("perspective"),
+					HideBlocked:     p.Bool("hide_blocked"),
+					MaxPerThreadStr: p.Get("max_per_thread"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/participants",
+			OperationID: "GetParticipants",
+			Tag:         "boards",
+			Summary:     "Gets a view of all participating users.",
+			Params: []Param{
+				{Name: "min_reputation", Type: ParamInteger, Description: "Minimum reputation of users."},
+			},
+			Legacy: "/api/get_participants",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetParticipants(r.Context(), &store.BoardIn{
+					PubKeyStr:        p.Get("board_public_key"),
+					MinReputationStr: p.Get("min_reputation"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/trust_graph",
+			OperationID: "GetTrustGraph",
+			Tag:         "boards",
+			Summary:     "Gets all trust, spam and block relations between users of a board.",
+			Legacy:      "/api/get_trust_graph",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetTrustGraph(r.Context(), &store.BoardIn{
+					PubKeyStr: p.Get("board_public_key"),
+				})
+			},
+		},
+
+		/*
+			<<< THREADS & POSTS >>>
+		*/
+
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/threads/{thread_ref}",
+			OperationID: "GetThreadPage",
+			Tag:         "threads",
+			Summary:     "Gets a view of a thread including it's children posts.",
+			Params: []Param{
+				perspectiveParam,
+				sinceParam,
+				{Name: "hashes_only", Type: ParamBoolean, Description: "Whether to only obtain post hashes."},
+				hideBlockedParam,
+				{Name: "collapse_below", Type: ParamInteger, Description: "Score below which posts are collapsed."},
+				mutedParam,
+				minTrustParam,
+				showSpamParam,
+			},
+			Legacy: "/api/get_thread_page",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetThreadPage(r.Context(), &store.ThreadIn{
+					BoardPubKeyStr:   p.Get("board_public_key"),
+					ThreadRefStr:     p.Get("thread_ref"),
+					UserPubKeyStr:    p.Get("perspective"),
+					SinceUnixStr:     p.Get("since"),
+					HashesOnly:       p.Bool("hashes_only"),
+					HideBlocked:      p.Bool("hide_blocked"),
+					CollapseBelowStr: p.Get("collapse_below"),
+					Muted:            p.Get("muted"),
+					MinTrustStr:      p.Get("min_trust"),
+					ShowSpam:         p.Bool("show_spam"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/threads/{thread_ref}/participants",
+			OperationID: "GetThreadParticipants",
+			Tag:         "threads",
+			Summary:     "Gets a view of users participating in a thread.",
+			Params: []Param{
+				{Name: "with_profiles", Type: ParamBoolean, Description: "Whether to include profiles of users."},
+			},
+			Legacy: "/api/get_thread_participants",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetThreadParticipants(r.Context(), &store.ThreadIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					ThreadRefStr:   p.Get("thread_ref"),
+					WithProfiles:   p.Bool("with_profiles"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/posts/{post_ref}/replies",
+			OperationID: "GetReplies",
+			Tag:         "threads",
+			Summary:     "Gets the direct replies of a post.",
+			Params:      []Param{perspectiveParam},
+			Legacy:      "/api/get_replies",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetReplies(r.Context(), &store.PostIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					PostRefStr:     p.Get("post_ref"),
+					UserPubKeyStr:  p.Get("perspective"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/content/{content_ref}/path",
+			OperationID: "GetContentPath",
+			Tag:         "threads",
+			Summary:     "Gets the chain of content from board to specified content.",
+			Legacy:      "/api/get_content_path",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetContentPath(r.Context(), &store.ContentIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					ContentRefStr:  p.Get("content_ref"),
+				})
+			},
+		},
+
+		/*
+			<<< VOTES >>>
+		*/
+
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/votes/{vote_ref}",
+			OperationID: "ResolveVote",
+			Tag:         "votes",
+			Summary:     "Gets the content or user a vote is cast on, along with the voter.",
+			Legacy:      "/api/resolve_vote",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.ResolveVote(r.Context(), &store.ContentIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					ContentRefStr:  p.Get("vote_ref"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/thread_votes",
+			OperationID: "GetAllThreadVotes",
+			Tag:         "votes",
+			Summary:     "Gets votes of all threads of a board.",
+			Params:      []Param{perspectiveParam, hideBlockedParam, sortByParam},
+			Legacy:      "/api/get_all_thread_votes",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetAllThreadVotes(r.Context(), &store.BoardIn{
+					PubKeyStr:     p.Get("board_public_key"),
+					UserPubKeyStr: p.Get("perspective"),
+					HideBlocked:   p.Bool("hide_blocked"),
+					SortBy:        p.Get("sort_by"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/tagged/{tag}",
+			OperationID: "GetContentByVoteTag",
+			Tag:         "votes",
+			Summary:     "Gets threads and posts voted with a tag (e.g. spam), most tagged first.",
+			Params:      []Param{perspectiveParam, hideBlockedParam},
+			Legacy:      "/api/get_content_by_vote_tag",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetContentByVoteTag(r.Context(), &store.BoardIn{
+					PubKeyStr:     p.Get("board_public_key"),
+					UserPubKeyStr: p.Get("perspective"),
+					HideBlocked:   p.Bool("hide_blocked"),
+					VoteTag:       p.Get("tag"),
+				})
+			},
+		},
+
+		/*
+			<<< USERS >>>
+		*/
+
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/users/{user_public_key}",
+			OperationID: "GetUserProfile",
+			Tag:         "users",
+			Summary:     "Gets a view of following/avoiding of specified user.",
+			Params: []Param{
+				{Name: "fields", Type: ParamString, Description: "Comma separated fields of profile to obtain, all if not set."},
+				{Name: "recent", Type: ParamInteger, Description: "Number of recent threads and posts of user to include."},
+				perspectiveParam,
+				hideBlockedParam,
+			},
+			Legacy: "/api/get_user_profile",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetFollowPage(r.Context(), &store.UserIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					UserPubKeyStr:  p.Get("user_public_key"),
+					FieldsStr:      p.Get("fields"),
+					RecentStr:      p.Get("recent"),
+					PerspectiveStr: p.Get("perspective"),
+					HideBlocked:    p.Bool("hide_blocked"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/users/{user_public_key}/trust_network",
+			OperationID: "GetTrustNetwork",
+			Tag:         "users",
+			Summary:     "Gets users reachable from specified user along a profile relation.",
+			Params: []Param{
+				{Name: "relation", Type: ParamString, Description: "Profile relation to follow."},
+				{Name: "max_depth", Type: ParamInteger, Description: "Maximum number of relations followed."},
+			},
+			Legacy: "/api/get_trust_network",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetTrustNetwork(r.Context(), &store.UserIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					UserPubKeyStr:  p.Get("user_public_key"),
+					Relation:       p.Get("relation"),
+					MaxDepthStr:    p.Get("max_depth"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/users/{user_public_key}/trust_score",
+			OperationID: "GetTrustScore",
+			Tag:         "users",
+			Summary:     "Gets the trust score from the perspective user to specified user, transitive along trust with decay.",
+			Params:      []Param{perspectiveParam},
+			Legacy:      "/api/get_trust_score",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetTrustScore(r.Context(), &store.UserIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					UserPubKeyStr:  p.Get("user_public_key"),
+					PerspectiveStr: p.Get("perspective"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/users/{user_public_key}/activity",
+			OperationID: "GetUserActivity",
+			Tag:         "users",
+			Summary:     "Gets the threads created, posts written and votes cast by specified user.",
+			Params:      []Param{perspectiveParam, hideBlockedParam},
+			Legacy:      "/api/get_user_activity",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetUserActivity(r.Context(), &store.UserIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					UserPubKeyStr:  p.Get("user_public_key"),
+					PerspectiveStr: p.Get("perspective"),
+					HideBlocked:    p.Bool("hide_blocked"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/users/{user_public_key}/mentions",
+			OperationID: "GetMentions",
+			Tag:         "users",
+			Summary:     "Gets the posts that mention specified user.",
+			Params:      []Param{perspectiveParam, hideBlockedParam},
+			Legacy:      "/api/get_mentions",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetMentions(r.Context(), &store.UserIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					UserPubKeyStr:  p.Get("user_public_key"),
+					PerspectiveStr: p.Get("perspective"),
+					HideBlocked:    p.Bool("hide_blocked"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/users/{user_public_key}/identity",
+			OperationID: "GetIdentity",
+			Tag:         "users",
+			Summary:     "Gets the identity of specified user, with the public keys they rotated through.",
+			Legacy:      "/api/get_identity",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetIdentity(r.Context(), &store.UserIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					UserPubKeyStr:  p.Get("user_public_key"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/users/{user_public_key}/vote_count",
+			OperationID: "GetUserVoteCount",
+			Tag:         "users",
+			Summary:     "Gets the number of votes cast by specified user.",
+			Legacy:      "/api/get_user_vote_count",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetUserVoteCount(r.Context(), &store.UserIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					UserPubKeyStr:  p.Get("user_public_key"),
+				})
+			},
+		},
+	}
+}
@@ -0,0 +1,194 @@
+package http
+
+import (
+	"github.com/skycoin/bbs/src/store"
+	"net/http"
+)
+
+func nodeRoutes(g *Gateway) []*Route {
+	var (
+		address = Param{Name: "address", Type: ParamString, Required: true, Description: "Address of CXO peer, as host:port."}
+		board   = Param{Name: "board_public_key", Type: ParamString, Required: true, Description: "Public key of board."}
+	)
+	return []*Route{
+
+		/*
+			<<< CONNECTIONS >>>
+		*/
+
+		{
+			Method:      http.MethodGet,
+			Path:        "/connections",
+			OperationID: "GetConnections",
+			Tag:         "connections",
+			Summary:     "Gets active, saved and discovered connections of the CXO node, with their live state.",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetConnections(r.Context())
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/connections",
+			OperationID: "NewConnection",
+			Tag:         "connections",
+			Summary:     "Connects to, and saves, a CXO peer.",
+			Params:      []Param{address},
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.NewConnection(r.Context(), &store.ConnectionIn{
+					Address: p.Get("address"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/connections/{address}",
+			OperationID: "DeleteConnection",
+			Tag:         "connections",
+			Summary:     "Disconnects from, and forgets, a CXO peer.",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.DeleteConnection(r.Context(), &store.ConnectionIn{
+					Address: p.Get("address"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/connections/{address}/enable",
+			OperationID: "EnableConnection",
+			Tag:         "connections",
+			Summary:     "Enables reconnection to a saved CXO peer.",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.EnableConnection(r.Context(), &store.ConnectionIn{
+					Address: p.Get("address"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/connections/{address}/disable",
+			OperationID: "DisableConnection",
+			Tag:         "connections",
+			Summary:     "Disconnects from a saved CXO peer, and disables reconnection to it.",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.DisableConnection(r.Context(), &store.ConnectionIn{
+					Address: p.Get("address"),
+				})
+			},
+		},
+
+		/*
+			<<< SUBSCRIPTIONS >>>
+		*/
+
+		{
+			Method:      http.MethodGet,
+			Path:        "/subscriptions",
+			OperationID: "GetSubscriptions",
+			Tag:         "subscriptions",
+			Summary:     "Gets subscribed boards, with their options.",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.GetSubscriptions(r.Context())
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/subscriptions",
+			OperationID: "NewSubscription",
+			Tag:         "subscriptions",
+			Summary:     "Subscribes to a remote board.",
+			Params:      []Param{board},
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.NewSubscription(r.Context(), &store.BoardIn{
+					PubKeyStr: p.Get("board_public_key"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/subscriptions/{board_public_key}",
+			OperationID: "IsSubscribed",
+			Tag:         "subscriptions",
+			Summary:     "Determines whether the node is subscribed to a board.",
+			Legacy:      "/api/is_subscribed",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.IsSubscribed(r.Context(), &store.BoardIn{
+					PubKeyStr: p.Get("board_public_key"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/subscriptions/{board_public_key}",
+			OperationID: "DeleteSubscription",
+			Tag:         "subscriptions",
+			Summary:     "Unsubscribes from a remote board.",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.DeleteSubscription(r.Context(), &store.BoardIn{
+					PubKeyStr: p.Get("board_public_key"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/subscriptions/{board_public_key}/options",
+			OperationID: "SetSubscriptionOptions",
+			Tag:         "subscriptions",
+			Summary:     "Sets options of a subscription, keeping those not provided as is.",
+			Params: []Param{
+				{Name: "auto_connect", Type: ParamBoolean, Description: "Whether to dial peers discovered from the board."},
+			},
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.SetSubscriptionOptions(r.Context(), &store.SubscriptionOptionsIn{
+					PubKeyStr:      p.Get("board_public_key"),
+					AutoConnectStr: p.Get("auto_connect"),
+				})
+			},
+		},
+
+		/*
+			<<< REGISTRY >>>
+		*/
+
+		{
+			Method:      http.MethodGet,
+			Path:        "/registry/boards",
+			OperationID: "DiscoverBoards",
+			Tag:         "registry",
+			Summary:     "Discovers boards announced to registry boards, most recently announced first.",
+			Params: []Param{
+				{Name: "query", Type: ParamString, Description: "Space separated terms that all need to be in an announcement."},
+				{Name: "category", Type: ParamString, Description: "Category of announced boards."},
+				{Name: "registry", Type: ParamString, Description: "Public key of registry board, all configured registries if not set."},
+				{Name: "start_index", Type: ParamInteger, Description: "Index of first board of page."},
+				{Name: "page_size", Type: ParamInteger, Description: "Maximum number of boards of page."},
+			},
+			Legacy: "/api/discover_boards",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.DiscoverBoards(r.Context(), &store.DiscoverBoardsIn{
+					Query:             p.Get("query"),
+					Category:          p.Get("category"),
+					RegistryPubKeyStr: p.Get("registry"),
+					StartIndexStr:     p.Get("start_index"),
+					PageSizeStr:       p.Get("page_size"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/registry/announcements",
+			OperationID: "AnnounceBoard",
+			Tag:         "registry",
+			Summary:     "Announces a master board, with it's name, description and metadata, to registry boards.",
+			Params: []Param{
+				board,
+				{Name: "registry", Type: ParamString, Description: "Public key of registry board, all configured registries if not set."},
+			},
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.AnnounceBoard(r.Context(), &store.AnnounceBoardIn{
+					BoardPubKeyStr:    p.Get("board_public_key"),
+					RegistryPubKeyStr: p.Get("registry"),
+				})
+			},
+		},
+	}
+}
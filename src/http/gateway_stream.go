@@ -0,0 +1,191 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/store"
+	"github.com/skycoin/bbs/src/store/state"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// limitParam is the parameter of syndication feeds.
+var limitParam = Param{Name: "limit", Type: ParamInteger, Description: "Maximum number of entries of the feed."}
+
+// streamRoutes obtains the routes that write their own responses, rather than
+// a JSON 'Response'.
+func streamRoutes(g *Gateway) []*Route {
+	routes := []*Route{
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/events",
+			OperationID: "SubscribeBoard",
+			Tag:         "boards",
+			Summary:     "Streams change events of a board as server-sent events, pushed after each compilation.",
+			Legacy:      "/api/subscribe_board",
+			Produces:    "text/event-stream",
+			Stream: func(w http.ResponseWriter, r *http.Request, p Params) {
+				flusher, ok := w.(http.Flusher)
+				if !ok {
+					sendErr(w, boo.New(boo.NotAllowed, "streaming is not supported"))
+					return
+				}
+				var started bool
+				e := g.Access.RangeBoardEvents(r.Context(), &store.BoardIn{
+					PubKeyStr: p.Get("board_public_key"),
+				}, func(event *state.BoardEvent) error {
+					if !started {
+						w.Header().Set("Content-Type", "text/event-stream")
+						w.Header().Set("Cache-Control", "no-cache")
+						w.WriteHeader(http.StatusOK)
+						started = true
+					}
+					data, e := json.Marshal(event)
+					if e != nil {
+						return e
+					}
+					if _, e := fmt.Fprintf(w, "data: %s\n\n", data); e != nil {
+						return e
+					}
+					flusher.Flush()
+					return nil
+				})
+				if e != nil && r.Context().Err() == nil {
+					if !started {
+						sendErr(w, e)
+						return
+					}
+					g.l.Println("board subscription interrupted:", e)
+				}
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/attachments/{hash}",
+			OperationID: "GetAttachment",
+			Tag:         "boards",
+			Summary:     "Gets the data of an attachment of a post, supporting range requests.",
+			Legacy:      "/api/boards/{board_public_key}/attachments/{hash}",
+			Produces:    "application/octet-stream",
+			Stream: func(w http.ResponseWriter, r *http.Request, p Params) {
+				serveAttachment(w, r, g, p.Get("board_public_key"), p.Get("hash"))
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/threads/{thread_ref}/transcript",
+			OperationID: "GetThreadTranscript",
+			Tag:         "threads",
+			Summary:     "Streams the thread and all its posts in reply-order, as newline-delimited JSON.",
+			Legacy:      "/api/get_thread_transcript",
+			Produces:    "application/x-ndjson",
+			Stream: func(w http.ResponseWriter, r *http.Request, p Params) {
+				var (
+					enc     = json.NewEncoder(w)
+					started bool
+				)
+				e := g.Access.RangeThreadTranscript(r.Context(), &store.ThreadIn{
+					BoardPubKeyStr: p.Get("board_public_key"),
+					ThreadRefStr:   p.Get("thread_ref"),
+				}, func(entry *state.TranscriptEntry) error {
+					if !started {
+						w.Header().Set("Content-Type", "application/x-ndjson")
+						w.WriteHeader(http.StatusOK)
+						started = true
+					}
+					return enc.Encode(entry)
+				})
+				if e != nil {
+					if !started {
+						sendErr(w, e)
+						return
+					}
+					g.l.Println("thread transcript interrupted:", e)
+				}
+			},
+		},
+	}
+
+	// Syndication feeds of boards (most recent threads) and threads (most recent posts).
+	formats := []struct {
+		name      string
+		format    string
+		mediaType string
+	}{
+		{"RSS", store.SyndicationRSS, "application/rss+xml"},
+		{"Atom", store.SyndicationAtom, "application/atom+xml"},
+	}
+	for _, f := range formats {
+		format := f.format
+		routes = append(routes,
+			&Route{
+				Method:      http.MethodGet,
+				Path:        "/boards/{board_public_key}/feed." + format,
+				OperationID: "GetBoard" + f.name + "Feed",
+				Tag:         "boards",
+				Summary:     "Gets a " + f.name + " feed of the most recent threads of a board.",
+				Params:      []Param{limitParam},
+				Legacy:      "/api/boards/{board_public_key}/feed." + format,
+				Produces:    f.mediaType,
+				Stream: func(w http.ResponseWriter, r *http.Request, p Params) {
+					serveSyndication(w, r, g, &store.SyndicationIn{
+						BoardPubKeyStr: p.Get("board_public_key"),
+						Format:         format,
+						LimitStr:       p.Get("limit"),
+					})
+				},
+			},
+			&Route{
+				Method:      http.MethodGet,
+				Path:        "/boards/{board_public_key}/threads/{thread_ref}/feed." + format,
+				OperationID: "GetThread" + f.name + "Feed",
+				Tag:         "threads",
+				Summary:     "Gets a " + f.name + " feed of the most recent posts of a thread.",
+				Params:      []Param{limitParam},
+				Legacy:      "/api/boards/{board_public_key}/threads/{thread_ref}/feed." + format,
+				Produces:    f.mediaType,
+				Stream: func(w http.ResponseWriter, r *http.Request, p Params) {
+					serveSyndication(w, r, g, &store.SyndicationIn{
+						BoardPubKeyStr: p.Get("board_public_key"),
+						ThreadRefStr:   p.Get("thread_ref"),
+						Format:         format,
+						LimitStr:       p.Get("limit"),
+					})
+				},
+			},
+		)
+	}
+	return routes
+}
+
+// serveSyndication writes a syndication feed.
+func serveSyndication(w http.ResponseWriter, r *http.Request, g *Gateway, in *store.SyndicationIn) {
+	feed, e := g.Access.GetSyndication(r.Context(), in)
+	if e != nil {
+		sendErr(w, e)
+		return
+	}
+	w.Header().Set("Content-Type", feed.ContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(feed.Data)
+}
+
+// serveAttachment writes the data of an attachment, supporting range requests.
+func serveAttachment(w http.ResponseWriter, r *http.Request, g *Gateway, bpk, hash string) {
+	attachment, e := g.Access.GetAttachment(r.Context(), &store.AttachmentIn{
+		BoardPubKeyStr: bpk,
+		HashStr:        hash,
+	})
+	if e != nil {
+		sendErr(w, e)
+		return
+	}
+	w.Header().Set("Content-Type", attachment.MediaType)
+	w.Header().Set("Content-Disposition",
+		mime.FormatMediaType("inline", map[string]string{"filename": attachment.Name}))
+	http.ServeContent(w, r, attachment.Name,
+		time.Unix(0, attachment.TS), bytes.NewReader(attachment.Data))
+}
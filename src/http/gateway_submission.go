@@ -1,73 +1,241 @@
 package http
 
 import (
+	"github.com/skycoin/bbs/src/misc/boo"
 	"github.com/skycoin/bbs/src/store"
+	"github.com/skycoin/bbs/src/store/state"
+	"io"
+	"io/ioutil"
 	"net/http"
 )
 
-func RegisterSubmissionHandlers(mux *http.ServeMux, g *Gateway) {
-
-	mux.HandleFunc("/api/submission/prepare_thread",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.PrepareThread(r.Context(), &store.PrepareThreadIn{
-				OfBoardStr: r.FormValue("of_board"),
-				Name:       r.FormValue("name"),
-				Body:       r.FormValue("body"),
-				CreatorStr: r.FormValue("creator"),
-			}))
-		})
-
-	mux.HandleFunc("/api/submission/prepare_post",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.PreparePost(r.Context(), &store.PreparePostIn{
-				OfBoardStr:  r.FormValue("of_board"),
-				OfThreadStr: r.FormValue("of_thread"),
-				OfPostStr:   r.FormValue("of_post"),
-				Name:        r.FormValue("name"),
-				Body:        r.FormValue("body"),
-				ImagesStr:   r.FormValue("images"),
-				CreatorStr:  r.FormValue("creator"),
-			}))
-		})
-
-	mux.HandleFunc("/api/submission/prepare_thread_vote",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.PrepareThreadVote(r.Context(), &store.PrepareThreadVoteIn{
-				OfBoardStr:  r.FormValue("of_board"),
-				OfThreadStr: r.FormValue("of_thread"),
-				ValueStr:    r.FormValue("value"),
-				TagsStr:     r.FormValue("tags"),
-				CreatorStr:  r.FormValue("creator"),
-			}))
-		})
-
-	mux.HandleFunc("/api/submission/prepare_post_vote",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.PreparePostVote(r.Context(), &store.PreparePostVoteIn{
-				OfBoardStr: r.FormValue("of_board"),
-				OfPostStr:  r.FormValue("of_post"),
-				ValueStr:   r.FormValue("value"),
-				TagsStr:    r.FormValue("tags"),
-				CreatorStr: r.FormValue("creator"),
-			}))
-		})
-
-	mux.HandleFunc("/api/submission/prepare_user_vote",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.PrepareUserVote(r.Context(), &store.PrepareUserVoteIn{
-				OfBoardStr: r.FormValue("of_board"),
-				OfUserStr:  r.FormValue("of_user"),
-				ValueStr:   r.FormValue("value"),
-				TagsStr:    r.FormValue("tags"),
-				CreatorStr: r.FormValue("creator"),
-			}))
-		})
-
-	mux.HandleFunc("/api/submission/finalize",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(g.Access.FinalizeSubmission(r.Context(), &store.FinalizeSubmissionIn{
-				HashStr: r.FormValue("hash"),
-				SigStr:  r.FormValue("sig"),
-			}))
-		})
+// Submissions are prepared from their fields, then finalized with the creator's
+// signature of the prepared hash.
+func submissionRoutes(g *Gateway) []*Route {
+	var (
+		ofBoard = Param{Name: "of_board", Type: ParamString, Required: true, Description: "Public key of board."}
+		creator = Param{Name: "creator", Type: ParamString, Required: true, Description: "Public key of creator."}
+		value   = Param{Name: "value", Type: ParamInteger, Required: true, Description: "Value of vote, one of -1, 0 or 1."}
+		tags    = Param{Name: "tags", Type: ParamString, Description: "Comma separated tags of vote."}
+	)
+	return []*Route{
+		{
+			Method:      http.MethodPost,
+			Path:        "/submissions/threads",
+			OperationID: "PrepareThread",
+			Tag:         "submissions",
+			Summary:     "Prepares a thread.",
+			Params: []Param{
+				ofBoard,
+				{Name: "name", Type: ParamString, Required: true, Description: "Name of thread."},
+				{Name: "body", Type: ParamString, Required: true, Description: "Body of thread."},
+				creator,
+			},
+			Legacy: "/api/submission/prepare_thread",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.PrepareThread(r.Context(), &store.PrepareThreadIn{
+					OfBoardStr: p.Get("of_board"),
+					Name:       p.Get("name"),
+					Body:       p.Get("body"),
+					CreatorStr: p.Get("creator"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/submissions/posts",
+			OperationID: "PreparePost",
+			Tag:         "submissions",
+			Summary:     "Prepares a post.",
+			Params: []Param{
+				ofBoard,
+				{Name: "of_thread", Type: ParamString, Required: true, Description: "Hash of thread."},
+				{Name: "of_post", Type: ParamString, Description: "Hash of post replied to."},
+				{Name: "name", Type: ParamString, Description: "Name of post."},
+				{Name: "body", Type: ParamString, Required: true, Description: "Body of post."},
+				{Name: "images", Type: ParamString, Description: "Images of post, as JSON."},
+				creator,
+			},
+			Legacy: "/api/submission/prepare_post",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.PreparePost(r.Context(), &store.PreparePostIn{
+					OfBoardStr:  p.Get("of_board"),
+					OfThreadStr: p.Get("of_thread"),
+					OfPostStr:   p.Get("of_post"),
+					Name:        p.Get("name"),
+					Body:        p.Get("body"),
+					ImagesStr:   p.Get("images"),
+					CreatorStr:  p.Get("creator"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/submissions/thread_votes",
+			OperationID: "PrepareThreadVote",
+			Tag:         "submissions",
+			Summary:     "Prepares a vote of a thread.",
+			Params: []Param{
+				ofBoard,
+				{Name: "of_thread", Type: ParamString, Required: true, Description: "Hash of thread."},
+				value, tags, creator,
+			},
+			Legacy: "/api/submission/prepare_thread_vote",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.PrepareThreadVote(r.Context(), &store.PrepareThreadVoteIn{
+					OfBoardStr:  p.Get("of_board"),
+					OfThreadStr: p.Get("of_thread"),
+					ValueStr:    p.Get("value"),
+					TagsStr:     p.Get("tags"),
+					CreatorStr:  p.Get("creator"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/submissions/post_votes",
+			OperationID: "PreparePostVote",
+			Tag:         "submissions",
+			Summary:     "Prepares a vote of a post.",
+			Params: []Param{
+				ofBoard,
+				{Name: "of_post", Type: ParamString, Required: true, Description: "Hash of post."},
+				value, tags, creator,
+			},
+			Legacy: "/api/submission/prepare_post_vote",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.PreparePostVote(r.Context(), &store.PreparePostVoteIn{
+					OfBoardStr: p.Get("of_board"),
+					OfPostStr:  p.Get("of_post"),
+					ValueStr:   p.Get("value"),
+					TagsStr:    p.Get("tags"),
+					CreatorStr: p.Get("creator"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/submissions/user_votes",
+			OperationID: "PrepareUserVote",
+			Tag:         "submissions",
+			Summary:     "Prepares a vote of a user.",
+			Params: []Param{
+				ofBoard,
+				{Name: "of_user", Type: ParamString, Required: true, Description: "Public key of user."},
+				value, tags, creator,
+			},
+			Legacy: "/api/submission/prepare_user_vote",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.PrepareUserVote(r.Context(), &store.PrepareUserVoteIn{
+					OfBoardStr: p.Get("of_board"),
+					OfUserStr:  p.Get("of_user"),
+					ValueStr:   p.Get("value"),
+					TagsStr:    p.Get("tags"),
+					CreatorStr: p.Get("creator"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/submissions/key_rotations",
+			OperationID: "PrepareKeyRotation",
+			Tag:         "submissions",
+			Summary: "Prepares a key rotation, binding the creator's public key to the identity of 'of_user'. " +
+				"The 'proof' is the signature of 'of_user' of the hash of \"5,key_rotation,<creator>\".",
+			Params: []Param{
+				ofBoard,
+				{Name: "of_user", Type: ParamString, Required: true, Description: "Public key rotated from."},
+				{Name: "proof", Type: ParamString, Required: true, Description: "Signature of public key rotated from."},
+				creator,
+			},
+			Legacy: "/api/submission/prepare_key_rotation",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.PrepareKeyRotation(r.Context(), &store.PrepareKeyRotationIn{
+					OfBoardStr: p.Get("of_board"),
+					OfUserStr:  p.Get("of_user"),
+					ProofStr:   p.Get("proof"),
+					CreatorStr: p.Get("creator"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/submissions/user_profiles",
+			OperationID: "PrepareUserProfile",
+			Tag:         "submissions",
+			Summary:     "Prepares a profile of the creator.",
+			Params: []Param{
+				ofBoard,
+				{Name: "display_name", Type: ParamString, Description: "Display name of user."},
+				{Name: "avatar_ref", Type: ParamString, Description: "Image hash or url of avatar."},
+				creator,
+			},
+			Legacy: "/api/submission/prepare_user_profile",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.PrepareUserProfile(r.Context(), &store.PrepareUserProfileIn{
+					OfBoardStr:     p.Get("of_board"),
+					DisplayNameStr: p.Get("display_name"),
+					AvatarRefStr:   p.Get("avatar_ref"),
+					CreatorStr:     p.Get("creator"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/submissions/attachments",
+			OperationID: "PrepareAttachment",
+			Tag:         "submissions",
+			Summary: "Prepares an attachment of a post from the multipart 'file' field. " +
+				"The media type is taken from the file's header, unless 'media_type' is set.",
+			Params: []Param{
+				ofBoard,
+				{Name: "of_post", Type: ParamString, Required: true, Description: "Hash of post."},
+				{Name: "file", Type: ParamFile, Required: true, Description: "File to attach."},
+				{Name: "media_type", Type: ParamString, Description: "Media type of file."},
+				creator,
+			},
+			Legacy: "/api/submission/prepare_attachment",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				file, header, e := r.FormFile("file")
+				if e != nil {
+					return nil, boo.WrapType(e, boo.InvalidInput, "failed to read attachment file")
+				}
+				defer file.Close()
+				data, e := ioutil.ReadAll(io.LimitReader(file, state.MaxAttachmentSize+1))
+				if e != nil {
+					return nil, boo.WrapType(e, boo.InvalidInput, "failed to read attachment file")
+				}
+				mediaType := p.Get("media_type")
+				if mediaType == "" {
+					mediaType = header.Header.Get("Content-Type")
+				}
+				return g.Access.PrepareAttachment(r.Context(), &store.PrepareAttachmentIn{
+					OfBoardStr: p.Get("of_board"),
+					OfPostStr:  p.Get("of_post"),
+					Name:       header.Filename,
+					MediaType:  mediaType,
+					File:       data,
+					CreatorStr: p.Get("creator"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/submissions/finalize",
+			OperationID: "FinalizeSubmission",
+			Tag:         "submissions",
+			Summary:     "Finalizes a prepared submission with the creator's signature of it's hash.",
+			Params: []Param{
+				{Name: "hash", Type: ParamString, Required: true, Description: "Hash of prepared submission."},
+				{Name: "sig", Type: ParamString, Required: true, Description: "Signature of hash by creator."},
+			},
+			Legacy: "/api/submission/finalize",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return g.Access.FinalizeSubmission(r.Context(), &store.FinalizeSubmissionIn{
+					HashStr: p.Get("hash"),
+					SigStr:  p.Get("sig"),
+				})
+			},
+		},
+	}
 }
@@ -5,35 +5,68 @@ import (
 	"net/http"
 )
 
-func RegisterToolsHandlers(mux *http.ServeMux, _ *Gateway) {
-
-	// Generates a seed.
-	mux.HandleFunc("/api/tools/new_seed",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(tag.GenerateSeed())
-		})
-
-	// Generates public/private key pair.
-	mux.HandleFunc("/api/tools/new_key_pair",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(tag.GenerateKeyPair(&tag.GenerateKeyPairIn{
-				Seed: r.FormValue("seed"),
-			}))
-		})
-
-	// Generates a hash of given string data.
-	mux.HandleFunc("/api/tools/hash_string",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(tag.SumSHA256(&tag.SumSHA256In{
-				Data: r.FormValue("data"),
-			}))
-		})
-
-	mux.HandleFunc("/api/tools/sign",
-		func(w http.ResponseWriter, r *http.Request) {
-			send(w)(tag.SignHash(&tag.SignHashIn{
-				Hash:   r.FormValue("hash"),
-				SecKey: r.FormValue("secret_key"),
-			}))
-		})
+func toolsRoutes(_ *Gateway) []*Route {
+	return []*Route{
+		{
+			Method:      http.MethodGet,
+			Path:        "/tools/seed",
+			OperationID: "NewSeed",
+			Tag:         "tools",
+			Summary:     "Generates a seed.",
+			Legacy:      "/api/tools/new_seed",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return tag.GenerateSeed()
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/tools/key_pair",
+			OperationID: "NewKeyPair",
+			Tag:         "tools",
+			Summary:     "Generates public/private key pair.",
+			Params: []Param{
+				{Name: "seed", Type: ParamString, Required: true, Description: "Seed to generate key pair from."},
+			},
+			Legacy: "/api/tools/new_key_pair",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return tag.GenerateKeyPair(&tag.GenerateKeyPairIn{
+					Seed: p.Get("seed"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/tools/hash",
+			OperationID: "HashString",
+			Tag:         "tools",
+			Summary:     "Generates a hash of given string data.",
+			Params: []Param{
+				{Name: "data", Type: ParamString, Required: true, Description: "Data to hash."},
+			},
+			Legacy: "/api/tools/hash_string",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return tag.SumSHA256(&tag.SumSHA256In{
+					Data: p.Get("data"),
+				})
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/tools/sign",
+			OperationID: "SignHash",
+			Tag:         "tools",
+			Summary:     "Signs a hash with a secret key.",
+			Params: []Param{
+				{Name: "hash", Type: ParamString, Required: true, Description: "Hash to sign."},
+				{Name: "secret_key", Type: ParamString, Required: true, Description: "Secret key to sign with."},
+			},
+			Legacy: "/api/tools/sign",
+			Handle: func(r *http.Request, p Params) (interface{}, error) {
+				return tag.SignHash(&tag.SignHashIn{
+					Hash:   p.Get("hash"),
+					SecKey: p.Get("secret_key"),
+				})
+			},
+		},
+	}
 }
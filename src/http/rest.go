@@ -0,0 +1,383 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/store"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// REST API versioning. Routes of the REST API are served under RESTPrefix, and are
+// described by the OpenAPI definition served at RESTPrefix + OpenAPIPath.
+const (
+	RESTPrefix     = "/api/v1"
+	RESTVersion    = "1.0.0"
+	OpenAPIPath    = "/openapi.json"
+	OpenAPIVersion = "3.0.3"
+)
+
+// Types of route parameters, as named by OpenAPI.
+const (
+	ParamString  = "string"
+	ParamInteger = "integer"
+	ParamNumber  = "number"
+	ParamBoolean = "boolean"
+	ParamFile    = "file" // A file of a multipart form.
+)
+
+// Param describes a parameter of a route. Parameters named in the route's path are
+// path parameters. Others are query parameters, or url-encoded form fields of the
+// request body for POST routes.
+type Param struct {
+	Name        string
+	Type        string // One of 'ParamString', 'ParamInteger', 'ParamNumber', 'ParamBoolean' or 'ParamFile'.
+	Required    bool
+	Description string
+}
+
+// Params obtains the parameters of a request.
+type Params struct {
+	r    *http.Request
+	path map[string]string
+}
+
+// Get obtains a parameter, from the path if it is a path parameter, or form values otherwise.
+func (p Params) Get(name string) string {
+	if v, ok := p.path[name]; ok {
+		return v
+	}
+	return p.r.FormValue(name)
+}
+
+// Bool determines whether a parameter is "true".
+func (p Params) Bool(name string) bool {
+	return p.Get(name) == "true"
+}
+
+// RouteHandler handles a request of a route, returning what is to be sent.
+type RouteHandler func(r *http.Request, p Params) (interface{}, error)
+
+// StreamHandler handles a request of a route by writing the response itself.
+type StreamHandler func(w http.ResponseWriter, r *http.Request, p Params)
+
+// Route describes a route of the REST API, along with what is needed to document it.
+type Route struct {
+	Method      string  // HTTP method.
+	Path        string  // Path relative to 'RESTPrefix', with path parameters as '{name}'.
+	OperationID string  // Unique name of the operation.
+	Tag         string  // Group of the operation.
+	Summary     string  // Description of the operation.
+	Params      []Param // Parameters, excluding path parameters.
+	Legacy      string  // Path of the equivalent un-versioned handler, empty for none. May have path parameters.
+	Handle      RouteHandler
+	Stream      StreamHandler // Used instead of 'Handle' for streams and content other than JSON.
+	Produces    string        // Media type of successful responses of 'Stream'.
+}
+
+// segments obtains the segments of the route's path.
+func (r *Route) segments() []string {
+	return pathSegments(r.Path)
+}
+
+// match determines whether the route's path matches the given segments, obtaining
+// the path parameters if so.
+func (r *Route) match(segments []string) (map[string]string, bool) {
+	return matchPath(r.segments(), segments)
+}
+
+// serve handles a request of the route.
+func (r *Route) serve(w http.ResponseWriter, req *http.Request, params map[string]string) {
+	p := Params{r: req, path: params}
+	if r.Stream != nil {
+		r.Stream(w, req, p)
+		return
+	}
+	send(w)(r.Handle(req, p))
+}
+
+// pathSegments splits a path into it's segments.
+func pathSegments(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// matchPath determines whether the segments of a path pattern match the given
+// segments, obtaining the path parameters if so.
+func matchPath(rSegments, segments []string) (map[string]string, bool) {
+	if len(rSegments) != len(segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, s := range rSegments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[s[1:len(s)-1]] = segments[i]
+		} else if s != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// pathParams obtains the names of the route's path parameters.
+func (r *Route) pathParams() []string {
+	var out []string
+	for _, s := range r.segments() {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			out = append(out, s[1:len(s)-1])
+		}
+	}
+	return out
+}
+
+// restRouter serves routes of the REST API.
+type restRouter struct {
+	routes []*Route
+	spec   []byte // OpenAPI definition of routes.
+}
+
+func newRESTRouter(routes []*Route) (*restRouter, error) {
+	spec, e := json.MarshalIndent(openAPI(routes), "", "  ")
+	if e != nil {
+		return nil, boo.WrapType(e, boo.Internal, "failed to generate OpenAPI definition")
+	}
+	return &restRouter{routes: routes, spec: spec}, nil
+}
+
+func (rr *restRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, RESTPrefix)
+	if p == OpenAPIPath {
+		sendRaw(w, rr.spec, http.StatusOK)
+		return
+	}
+	segments := pathSegments(p)
+
+	var allowed []string
+	for _, route := range rr.routes {
+		params, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+		if route.Method != r.Method {
+			allowed = append(allowed, route.Method)
+			continue
+		}
+		route.serve(w, r, params)
+		return
+	}
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		out := store.NewErrorOut(boo.Newf(boo.NotAllowed,
+			"method %s is not allowed, allowed methods are %s", r.Method, strings.Join(allowed, ", ")))
+		out.Status = http.StatusMethodNotAllowed
+		sendStatus(w, Response{Okay: false, Error: out}, out.Status)
+		return
+	}
+	sendErr(w, boo.Newf(boo.NotFound, "no route for %s", r.URL.Path))
+}
+
+// legacyRouter serves legacy handlers of routes. Legacy paths with path parameters
+// share a router registered at the part of their path before the first parameter.
+type legacyRouter struct {
+	routes []*Route
+}
+
+func (lr *legacyRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path)
+	for _, route := range lr.routes {
+		if params, ok := matchPath(pathSegments(route.Legacy), segments); ok {
+			route.serve(w, r, params)
+			return
+		}
+	}
+	sendErr(w, boo.Newf(boo.NotFound, "no route for %s", r.URL.Path))
+}
+
+// RegisterRESTHandlers registers the REST API, along with the legacy handlers of it's routes.
+func RegisterRESTHandlers(mux *http.ServeMux, routes []*Route) error {
+	rr, e := newRESTRouter(routes)
+	if e != nil {
+		return e
+	}
+	mux.Handle(RESTPrefix+"/", rr)
+
+	var (
+		patterns []string
+		legacy   = make(map[string]*legacyRouter)
+	)
+	for _, route := range routes {
+		if route.Legacy == "" {
+			continue
+		}
+		pattern := route.Legacy
+		if i := strings.Index(pattern, "{"); i >= 0 {
+			pattern = pattern[:i]
+		}
+		lr, ok := legacy[pattern]
+		if !ok {
+			lr = new(legacyRouter)
+			legacy[pattern] = lr
+			patterns = append(patterns, pattern)
+		}
+		lr.routes = append(lr.routes, route)
+	}
+	for _, pattern := range patterns {
+		mux.Handle(pattern, legacy[pattern])
+	}
+	return nil
+}
+
+/*
+	<<< OPENAPI >>>
+*/
+
+// openAPI generates the OpenAPI definition of routes.
+func openAPI(routes []*Route) map[string]interface{} {
+	var (
+		paths = make(map[string]map[string]interface{})
+		tags  = make(map[string]struct{})
+	)
+	for _, route := range routes {
+		item, ok := paths[route.Path]
+		if !ok {
+			item = make(map[string]interface{})
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = openAPIOperation(route)
+		tags[route.Tag] = struct{}{}
+	}
+	tagList := make([]map[string]string, 0, len(tags))
+	for tag := range tags {
+		tagList = append(tagList, map[string]string{"name": tag})
+	}
+	sort.Slice(tagList, func(i, j int) bool {
+		return tagList[i]["name"] < tagList[j]["name"]
+	})
+	return map[string]interface{}{
+		"openapi": OpenAPIVersion,
+		"info": map[string]string{
+			"title":   "Skycoin BBS",
+			"version": RESTVersion,
+		},
+		"servers": []map[string]string{{"url": RESTPrefix}},
+		"tags":    tagList,
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Response": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"okay":  map[string]string{"type": ParamBoolean},
+						"data":  map[string]string{"description": "Output of the operation."},
+						"error": map[string]string{"$ref": "#/components/schemas/Error"},
+					},
+					"required": []string{"okay"},
+				},
+				"Error": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"type":    map[string]string{"type": ParamInteger},
+						"code":    map[string]string{"type": ParamString},
+						"title":   map[string]string{"type": ParamString},
+						"details": map[string]string{"type": ParamString},
+					},
+				},
+			},
+		},
+	}
+}
+
+func openAPIOperation(route *Route) map[string]interface{} {
+	response := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"description": description,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]string{"$ref": "#/components/schemas/Response"},
+				},
+			},
+		}
+	}
+	success := response("Successful operation.")
+	if route.Produces != "" {
+		success = map[string]interface{}{
+			"description": "Successful operation.",
+			"content": map[string]interface{}{
+				route.Produces: map[string]interface{}{
+					"schema": map[string]string{"type": ParamString},
+				},
+			},
+		}
+	}
+	op := map[string]interface{}{
+		"operationId": route.OperationID,
+		"summary":     route.Summary,
+		"tags":        []string{route.Tag},
+		"responses": map[string]interface{}{
+			"200":     success,
+			"default": response("Failed operation, with the error."),
+		},
+	}
+
+	params := make([]map[string]interface{}, 0, len(route.Params))
+	for _, name := range route.pathParams() {
+		params = append(params, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]string{"type": ParamString},
+		})
+	}
+	if route.Method == http.MethodPost && len(route.Params) > 0 {
+		var (
+			properties = make(map[string]interface{}, len(route.Params))
+			required   []string
+			mediaType  = "application/x-www-form-urlencoded"
+		)
+		for _, p := range route.Params {
+			property := map[string]string{
+				"type":        p.Type,
+				"description": p.Description,
+			}
+			if p.Type == ParamFile {
+				property["type"], property["format"] = ParamString, "binary"
+				mediaType = "multipart/form-data"
+			}
+			properties[p.Name] = property
+			if p.Required {
+				required = append(required, p.Name)
+			}
+		}
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		op["requestBody"] = map[string]interface{}{
+			"content": map[string]interface{}{
+				mediaType: map[string]interface{}{
+					"schema": schema,
+				},
+			},
+		}
+	} else {
+		for _, p := range route.Params {
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"in":          "query",
+				"required":    p.Required,
+				"description": p.Description,
+				"schema":      map[string]string{"type": p.Type},
+			})
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	return op
+}
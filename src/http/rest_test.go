@@ -0,0 +1,301 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testRoutes obtains routes that respond with their operation and parameters.
+func testRoutes() []*Route {
+	echo := func(id string) RouteHandler {
+		return func(r *http.Request, p Params) (interface{}, error) {
+			return map[string]string{
+				"op":    id,
+				"board": p.Get("board_public_key"),
+				"ref":   p.Get("thread_ref"),
+				"limit": p.Get("limit"),
+			}, nil
+		}
+	}
+	return []*Route{
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards",
+			OperationID: "GetBoards",
+			Tag:         "boards",
+			Legacy:      "/api/get_boards",
+			Handle:      echo("GetBoards"),
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}",
+			OperationID: "GetBoard",
+			Tag:         "boards",
+			Legacy:      "/api/get_board",
+			Handle:      echo("GetBoard"),
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/boards/{board_public_key}",
+			OperationID: "DeleteBoard",
+			Tag:         "boards",
+			Handle:      echo("DeleteBoard"),
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/boards/{board_public_key}/threads",
+			OperationID: "NewThread",
+			Tag:         "threads",
+			Params: []Param{
+				{Name: "name", Type: ParamString, Required: true, Description: "Name of thread."},
+				{Name: "image", Type: ParamFile, Description: "Image of thread."},
+			},
+			Handle: echo("NewThread"),
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/boards/{board_public_key}/threads/{thread_ref}/feed.rss",
+			OperationID: "GetThreadRSSFeed",
+			Tag:         "threads",
+			Params:      []Param{limitParam},
+			Legacy:      "/api/boards/{board_public_key}/threads/{thread_ref}/feed.rss",
+			Produces:    "application/rss+xml",
+			Stream: func(w http.ResponseWriter, r *http.Request, p Params) {
+				w.Header().Set("Content-Type", "application/rss+xml")
+				fmt.Fprintf(w, "%s/%s/%s", p.Get("board_public_key"), p.Get("thread_ref"), p.Get("limit"))
+			},
+		},
+	}
+}
+
+func newTestMux(t *testing.T) *http.ServeMux {
+	mux := http.NewServeMux()
+	if e := RegisterRESTHandlers(mux, testRoutes()); e != nil {
+		t.Fatal("failed to register REST handlers:", e)
+	}
+	return mux
+}
+
+// decodeResponse decodes a JSON response, with it's data as strings.
+func decodeResponse(t *testing.T, rec *httptest.ResponseRecorder) (bool, map[string]string) {
+	var out struct {
+		Okay bool              `json:"okay"`
+		Data map[string]string `json:"data"`
+	}
+	if e := json.Unmarshal(rec.Body.Bytes(), &out); e != nil {
+		t.Fatalf("failed to decode response '%s': %v", rec.Body.String(), e)
+	}
+	return out.Okay, out.Data
+}
+
+func TestRESTRouter_match(t *testing.T) {
+	mux := newTestMux(t)
+
+	cases := []struct {
+		method, path string
+		op, board    string
+	}{
+		{http.MethodGet, RESTPrefix + "/boards", "GetBoards", ""},
+		{http.MethodGet, RESTPrefix + "/boards/", "GetBoards", ""},
+		{http.MethodGet, RESTPrefix + "/boards/abc", "GetBoard", "abc"},
+		{http.MethodDelete, RESTPrefix + "/boards/abc", "DeleteBoard", "abc"},
+		{http.MethodPost, RESTPrefix + "/boards/abc/threads", "NewThread", "abc"},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s %s: got status %d, expected %d", c.method, c.path, rec.Code, http.StatusOK)
+			continue
+		}
+		if _, data := decodeResponse(t, rec); data["op"] != c.op || data["board"] != c.board {
+			t.Errorf("%s %s: got %v, expected operation '%s' of board '%s'",
+				c.method, c.path, data, c.op, c.board)
+		}
+	}
+
+	for _, path := range []string{
+		RESTPrefix + "/unknown",
+		RESTPrefix + "/boards/abc/unknown",
+		RESTPrefix + "/boards/abc/threads/def",
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: got status %d, expected %d", path, rec.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestRESTRouter_methodNotAllowed(t *testing.T) {
+	mux := newTestMux(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, RESTPrefix+"/boards/abc", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("got status %d, expected %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "GET, DELETE" {
+		t.Errorf("got Allow '%s', expected '%s'", allow, "GET, DELETE")
+	}
+	if okay, _ := decodeResponse(t, rec); okay {
+		t.Error("response should not be okay")
+	}
+}
+
+func TestRESTRouter_stream(t *testing.T) {
+	mux := newTestMux(t)
+
+	for _, path := range []string{
+		RESTPrefix + "/boards/abc/threads/def/feed.rss?limit=5",
+		"/api/boards/abc/threads/def/feed.rss?limit=5",
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if ct := rec.Header().Get("Content-Type"); ct != "application/rss+xml" {
+			t.Errorf("GET %s: got content type '%s', expected '%s'", path, ct, "application/rss+xml")
+		}
+		if body := rec.Body.String(); body != "abc/def/5" {
+			t.Errorf("GET %s: got body '%s', expected '%s'", path, body, "abc/def/5")
+		}
+	}
+}
+
+func TestRegisterRESTHandlers_legacy(t *testing.T) {
+	mux := newTestMux(t)
+
+	cases := []struct {
+		method, path string
+		status       int
+		op, board    string
+	}{
+		{http.MethodGet, "/api/get_boards", http.StatusOK, "GetBoards", ""},
+		{http.MethodPost, "/api/get_board?board_public_key=abc", http.StatusOK, "GetBoard", "abc"},
+		{http.MethodGet, "/api/boards/abc/threads/def/feed.atom", http.StatusNotFound, "", ""},
+		{http.MethodGet, "/api/boards/abc", http.StatusNotFound, "", ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+		if rec.Code != c.status {
+			t.Errorf("%s %s: got status %d, expected %d", c.method, c.path, rec.Code, c.status)
+			continue
+		}
+		if c.status != http.StatusOK {
+			continue
+		}
+		if _, data := decodeResponse(t, rec); data["op"] != c.op || data["board"] != c.board {
+			t.Errorf("%s %s: got %v, expected operation '%s' of board '%s'",
+				c.method, c.path, data, c.op, c.board)
+		}
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Tags []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			Parameters  []struct {
+				Name     string `json:"name"`
+				In       string `json:"in"`
+				Required bool   `json:"required"`
+			} `json:"parameters"`
+			RequestBody struct {
+				Content map[string]struct {
+					Schema struct {
+						Properties map[string]map[string]string `json:"properties"`
+						Required   []string                     `json:"required"`
+					} `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]struct {
+				Content map[string]interface{} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+
+	rec := httptest.NewRecorder()
+	newTestMux(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, RESTPrefix+OpenAPIPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, expected %d", rec.Code, http.StatusOK)
+	}
+	if e := json.Unmarshal(rec.Body.Bytes(), &spec); e != nil {
+		t.Fatal("failed to decode OpenAPI definition:", e)
+	}
+
+	if spec.OpenAPI != OpenAPIVersion || len(spec.Servers) != 1 || spec.Servers[0].URL != RESTPrefix {
+		t.Errorf("got version '%s' and servers %v", spec.OpenAPI, spec.Servers)
+	}
+	var tags []string
+	for _, tag := range spec.Tags {
+		tags = append(tags, tag.Name)
+	}
+	if expected := []string{"boards", "threads"}; !reflect.DeepEqual(tags, expected) {
+		t.Errorf("got tags %v, expected %v", tags, expected)
+	}
+
+	board := spec.Paths["/boards/{board_public_key}"]
+	if board["get"].OperationID != "GetBoard" || board["delete"].OperationID != "DeleteBoard" {
+		t.Errorf("got operations %v of board path", board)
+	}
+	if params := board["get"].Parameters; len(params) != 1 ||
+		params[0].Name != "board_public_key" || params[0].In != "path" || !params[0].Required {
+		t.Errorf("got parameters %+v, expected required path parameter 'board_public_key'", params)
+	}
+
+	post := spec.Paths["/boards/{board_public_key}/threads"]["post"]
+	form, ok := post.RequestBody.Content["multipart/form-data"]
+	if !ok {
+		t.Fatalf("got request body %+v, expected multipart form", post.RequestBody)
+	}
+	if form.Schema.Properties["image"]["format"] != "binary" ||
+		!reflect.DeepEqual(form.Schema.Required, []string{"name"}) {
+		t.Errorf("got form schema %+v", form.Schema)
+	}
+
+	feed := spec.Paths["/boards/{board_public_key}/threads/{thread_ref}/feed.rss"]["get"]
+	if _, ok := feed.Responses["200"].Content["application/rss+xml"]; !ok {
+		t.Errorf("got successful response %+v, expected RSS content", feed.Responses["200"])
+	}
+	if _, ok := feed.Responses["default"].Content["application/json"]; !ok {
+		t.Errorf("got failed response %+v, expected JSON content", feed.Responses["default"])
+	}
+	var names []string
+	for _, p := range feed.Parameters {
+		names = append(names, p.In+":"+p.Name)
+	}
+	if expected := "path:board_public_key path:thread_ref query:limit"; strings.Join(names, " ") != expected {
+		t.Errorf("got parameters '%s', expected '%s'", strings.Join(names, " "), expected)
+	}
+}
+
+func TestGateway_routes(t *testing.T) {
+	seen := make(map[string]struct{})
+	for _, route := range new(Gateway).routes() {
+		if route.Handle == nil && route.Stream == nil {
+			t.Errorf("route %s has no handler", route.OperationID)
+		}
+		if route.Stream != nil && route.Produces == "" {
+			t.Errorf("stream route %s has no media type", route.OperationID)
+		}
+		if _, ok := seen[route.OperationID]; ok {
+			t.Errorf("operation %s is not unique", route.OperationID)
+		}
+		seen[route.OperationID] = struct{}{}
+	}
+	if e := RegisterRESTHandlers(http.NewServeMux(), new(Gateway).routes()); e != nil {
+		t.Error("failed to register REST handlers:", e)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 )
 
+// Types of errors. Values are exposed to clients, so existing types are never
+// reordered or removed.
 const (
 	Unknown = iota
 	Internal
@@ -14,6 +16,7 @@ const (
 	NotAllowed
 	NotFound
 	AlreadyExists
+	TooManyRequests
 )
 
 // Message returns the error message of type 't'.
@@ -35,11 +38,39 @@ func Message(t int) string {
 		return "Not Found"
 	case AlreadyExists:
 		return "Already Exists"
+	case TooManyRequests:
+		return "Too Many Requests"
 	default:
 		return "Unknown Error"
 	}
 }
 
+// Code returns the stable, machine-readable code of error type 't'.
+func Code(t int) string {
+	switch t {
+	case Internal:
+		return "INTERNAL"
+	case InvalidInput:
+		return "INVALID_INPUT"
+	case InvalidRead:
+		return "INVALID_READ"
+	case NotMaster:
+		return "NOT_MASTER"
+	case NotAuthorised:
+		return "NOT_AUTHORISED"
+	case NotAllowed:
+		return "NOT_ALLOWED"
+	case NotFound:
+		return "NOT_FOUND"
+	case AlreadyExists:
+		return "ALREADY_EXISTS"
+	case TooManyRequests:
+		return "TOO_MANY_REQUESTS"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 // This satisfies the 'error' interface.
 type elem struct {
 	e error
@@ -62,3 +62,17 @@ func TestType(t *testing.T) {
 		}
 	})
 }
+
+func TestCode(t *testing.T) {
+	seen := make(map[string]int)
+	for typ := Unknown; typ <= TooManyRequests; typ++ {
+		code := Code(typ)
+		if other, ok := seen[code]; ok {
+			t.Errorf("types %d and %d have the same code '%s'", other, typ, code)
+		}
+		seen[code] = typ
+	}
+	if got := Code(Type(WrapType(New(NotFound, "missing"), Unknown, "wrapped"))); got != "NOT_FOUND" {
+		t.Errorf("got code '%s', expected '%s'", got, "NOT_FOUND")
+	}
+}
@@ -0,0 +1,276 @@
+// Package gql executes GraphQL queries against a schema of objects with resolved fields.
+// It supports a subset of GraphQL: a single query operation with aliases, arguments
+// and variables, without fragments, directives or introspection (other than '__typename').
+package gql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"reflect"
+)
+
+// MaxDepth is the maximum nesting of selections of a query.
+const MaxDepth = 12
+
+// Object represents a type of the schema with fields.
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+// Field represents a field of an object.
+type Field struct {
+	Type    *Object // Type of field (or of elements if the field is a list), nil for scalars.
+	Resolve func(p *Params) (interface{}, error)
+}
+
+// Params represents what is provided to resolve a field.
+type Params struct {
+	Context context.Context
+	Source  interface{}            // Value of object the field is of.
+	Args    map[string]interface{} // Arguments, with variables substituted.
+}
+
+// String obtains a string argument, empty if not provided.
+func (p *Params) String(name string) (string, error) {
+	switch v := p.Args[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		return "", boo.Newf(boo.InvalidInput, "argument '%s' needs to be a string", name)
+	}
+}
+
+// Int obtains an integer argument, or 'def' if not provided.
+func (p *Params) Int(name string, def int) (int, error) {
+	switch v := p.Args[name].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return v, nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+	return 0, boo.Newf(boo.InvalidInput, "argument '%s' needs to be an integer", name)
+}
+
+// Bool obtains a boolean argument, false if not provided.
+func (p *Params) Bool(name string) (bool, error) {
+	switch v := p.Args[name].(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	default:
+		return false, boo.Newf(boo.InvalidInput, "argument '%s' needs to be a boolean", name)
+	}
+}
+
+// Error represents an error encountered in resolving a field.
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"` // Keys and indexes to field, from root.
+}
+
+// Result represents the result of a query.
+type Result struct {
+	Data   *Map     `json:"data"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Map represents resolved fields, in the order of selection.
+type Map struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newMap() *Map {
+	return &Map{values: make(map[string]interface{})}
+}
+
+func (m *Map) set(key string, v interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+// Get obtains the value of a resolved field.
+func (m *Map) Get(key string) interface{} {
+	if m == nil {
+		return nil
+	}
+	return m.values[key]
+}
+
+// MarshalJSON encodes fields in the order of selection.
+func (m *Map) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	buf := bytes.NewBufferString("{")
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, e := json.Marshal(m.values[key])
+		if e != nil {
+			return nil, e
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Execute parses and executes a query against the root object, with 'root' as it's
+// value. Errors of parsing and validating the query are returned, while errors of
+// resolving fields are recorded in the result, with the fields as null.
+func Execute(ctx context.Context, schema *Object, root interface{}, query string, variables map[string]interface{}) (*Result, error) {
+	q, e := Parse(query)
+	if e != nil {
+		return nil, e
+	}
+	vars := make(map[string]interface{}, len(q.Defaults)+len(variables))
+	for k, v := range q.Defaults {
+		vars[k] = v
+	}
+	for k, v := range variables {
+		vars[k] = v
+	}
+	if e := validate(schema, q.Selections, vars, 1); e != nil {
+		return nil, boo.WrapType(e, boo.InvalidInput, "invalid query")
+	}
+	ex := &executor{ctx: ctx, vars: vars}
+	out := &Result{Data: ex.object(schema, root, q.Selections, nil)}
+	out.Errors = ex.errors
+	return out, nil
+}
+
+// validate ensures that selections are of fields of the object, that scalar fields
+// have no sub-selections and object fields do, and that variables are provided.
+func validate(obj *Object, selections []*Selection, vars map[string]interface{}, depth int) error {
+	if depth > MaxDepth {
+		return fmt.Errorf("query exceeds maximum depth of %d", MaxDepth)
+	}
+	for _, s := range selections {
+		if s.Name == "__typename" {
+			if s.Selections != nil {
+				return fmt.Errorf("field '__typename' cannot have selections")
+			}
+			continue
+		}
+		f, ok := obj.Fields[s.Name]
+		if !ok {
+			return fmt.Errorf("cannot query field '%s' on type '%s'", s.Name, obj.Name)
+		}
+		for arg, v := range s.Args {
+			if name, ok := v.(Variable); ok {
+				if _, ok := vars[string(name)]; !ok {
+					return fmt.Errorf("variable '$%s' of argument '%s' is not provided", name, arg)
+				}
+			}
+		}
+		switch {
+		case f.Type == nil && s.Selections != nil:
+			return fmt.Errorf("field '%s' of type '%s' is a scalar and cannot have selections", s.Name, obj.Name)
+		case f.Type != nil && s.Selections == nil:
+			return fmt.Errorf("field '%s' of type '%s' needs selections", s.Name, obj.Name)
+		case f.Type != nil:
+			if e := validate(f.Type, s.Selections, vars, depth+1); e != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+type executor struct {
+	ctx    context.Context
+	vars   map[string]interface{}
+	errors []*Error
+}
+
+func (ex *executor) fail(path []interface{}, e error) {
+	ex.errors = append(ex.errors, &Error{
+		Message: e.Error(),
+		Path:    append([]interface{}(nil), path...),
+	})
+}
+
+// args substitutes variables of arguments.
+func (ex *executor) args(args map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		if name, ok := v.(Variable); ok {
+			v = ex.vars[string(name)]
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// object resolves the selected fields of an object, nil if 'src' is nil.
+func (ex *executor) object(obj *Object, src interface{}, selections []*Selection, path []interface{}) *Map {
+	if isNil(src) {
+		return nil
+	}
+	out := newMap()
+	for _, s := range selections {
+		fPath := append(path, s.Alias)
+		if s.Name == "__typename" {
+			out.set(s.Alias, obj.Name)
+			continue
+		}
+		f := obj.Fields[s.Name]
+		v, e := f.Resolve(&Params{Context: ex.ctx, Source: src, Args: ex.args(s.Args)})
+		if e != nil {
+			ex.fail(fPath, e)
+			out.set(s.Alias, nil)
+			continue
+		}
+		if f.Type == nil {
+			out.set(s.Alias, v)
+			continue
+		}
+		out.set(s.Alias, ex.value(f.Type, v, s.Selections, fPath))
+	}
+	return out
+}
+
+// value resolves an object, or each object of a list (empty if nil).
+func (ex *executor) value(obj *Object, v interface{}, selections []*Selection, path []interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return ex.object(obj, v, selections, path)
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = ex.object(obj, rv.Index(i).Interface(), selections, append(path, i))
+	}
+	return list
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
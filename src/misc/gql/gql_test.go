@@ -0,0 +1,163 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testItem struct {
+	Name     string
+	Children []*testItem
+}
+
+func testSchema() *Object {
+	item := &Object{Name: "Item"}
+	item.Fields = map[string]*Field{
+		"name": {Resolve: func(p *Params) (interface{}, error) {
+			return p.Source.(*testItem).Name, nil
+		}},
+		"children": {Type: item, Resolve: func(p *Params) (interface{}, error) {
+			first, e := p.Int("first", -1)
+			if e != nil {
+				return nil, e
+			}
+			children := p.Source.(*testItem).Children
+			if first >= 0 && first < len(children) {
+				children = children[:first]
+			}
+			return children, nil
+		}},
+		"broken": {Resolve: func(p *Params) (interface{}, error) {
+			return nil, errors.New("broken field")
+		}},
+	}
+	return &Object{
+		Name: "Query",
+		Fields: map[string]*Field{
+			"item": {Type: item, Resolve: func(p *Params) (interface{}, error) {
+				name, e := p.String("name")
+				if e != nil {
+					return nil, e
+				}
+				for _, v := range p.Source.([]*testItem) {
+					if v.Name == name {
+						return v, nil
+					}
+				}
+				return (*testItem)(nil), nil
+			}},
+		},
+	}
+}
+
+func testRoot() []*testItem {
+	return []*testItem{
+		{Name: "a", Children: []*testItem{
+			{Name: "a1", Children: []*testItem{{Name: "a1x"}}},
+			{Name: "a2"},
+			{Name: "a3"},
+		}},
+	}
+}
+
+func TestExecute(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		exp   string
+	}{
+		{
+			name:  "nested",
+			query: `{ item(name: "a") { name children(first: 2) { name children { name } } } }`,
+			exp:   `{"item":{"name":"a","children":[{"name":"a1","children":[{"name":"a1x"}]},{"name":"a2","children":[]}]}}`,
+		},
+		{
+			name:  "aliases_and_variables",
+			query: `query Q($n: String!, $first: Int = 1) { x: item(name: $n) { __typename kids: children(first: $first) { name } } }`,
+			vars:  map[string]interface{}{"n": "a"},
+			exp:   `{"x":{"__typename":"Item","kids":[{"name":"a1"}]}}`,
+		},
+		{
+			name:  "null_object",
+			query: `# comment
+				{ item(name: "none") { name } }`,
+			exp: `{"item":null}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := Execute(context.Background(), testSchema(), testRoot(), c.query, c.vars)
+			if e != nil {
+				t.Fatal("failed to execute:", e)
+			}
+			if len(out.Errors) > 0 {
+				t.Fatalf("unexpected errors: %v", out.Errors[0])
+			}
+			data, _ := json.Marshal(out.Data)
+			if string(data) != c.exp {
+				t.Errorf("expected %s, got %s", c.exp, data)
+			}
+		})
+	}
+
+	t.Run("field_errors", func(t *testing.T) {
+		out, e := Execute(context.Background(), testSchema(), testRoot(),
+			`{ item(name: "a") { name broken } }`, nil)
+		if e != nil {
+			t.Fatal("failed to execute:", e)
+		}
+		if len(out.Errors) != 1 || len(out.Errors[0].Path) != 2 || out.Errors[0].Path[1] != "broken" {
+			t.Fatalf("unexpected errors: %+v", out.Errors)
+		}
+		data, _ := json.Marshal(out.Data)
+		if exp := `{"item":{"name":"a","broken":null}}`; string(data) != exp {
+			t.Errorf("expected %s, got %s", exp, data)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, query := range []string{
+			`{ item(name: "a") { unknown } }`,
+			`{ item(name: "a") }`,
+			`{ item(name: "a") { name { x } } }`,
+			`{ item(name: $missing) { name } }`,
+			`mutation { item { name } }`,
+			`{ item(name: "a") { ...F } }`,
+			`{ item(name: "a" { name } }`,
+		} {
+			if _, e := Execute(context.Background(), testSchema(), testRoot(), query, nil); e == nil {
+				t.Errorf("expected error for query: %s", query)
+			}
+		}
+	})
+}
+
+func TestParse_depth(t *testing.T) {
+	nest := func(open, inner, close string, n int) string {
+		return strings.Repeat(open, n) + inner + strings.Repeat(close, n)
+	}
+	cases := []struct {
+		name  string
+		query string
+		valid bool
+	}{
+		{"selections", nest("{a", "", "}", MaxDepth), true},
+		{"deep_selections", nest("{a", "", "}", MaxDepth+1), false},
+		{"list", "{a(x: " + nest("[", "1", "]", MaxDepth-1) + ")}", true},
+		{"deep_list", "{a(x: " + nest("[", "1", "]", MaxDepth) + ")}", false},
+		{"deep_object", "{a(x: " + nest("{y:", "1", "}", MaxDepth) + ")}", false},
+		{"deep_type", "query($x: " + nest("[", "Int", "]", MaxDepth) + ") {a}", false},
+		{"overflow", nest("{a", "", "}", 1<<20), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, e := Parse(c.query); (e == nil) != c.valid {
+				t.Errorf("got error %v, expected valid %v", e, c.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package gql
+
+import (
+	"fmt"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Selection represents a field selected in a query.
+type Selection struct {
+	Alias      string                 // Key of field in result.
+	Name       string                 // Name of field.
+	Args       map[string]interface{} // Arguments, with variables as 'Variable'.
+	Selections []*Selection           // Sub-selections, nil for scalar fields.
+}
+
+// Variable represents a reference to a variable of a query.
+type Variable string
+
+// Query represents a parsed query operation.
+type Query struct {
+	Name       string
+	Defaults   map[string]interface{} // Default values of variables.
+	Selections []*Selection
+}
+
+// Parse parses a query document. Only a single query operation is supported,
+// without fragments or directives.
+func Parse(src string) (*Query, error) {
+	p := &parser{src: src}
+	q, e := p.parseQuery()
+	if e != nil {
+		return nil, boo.WrapType(e, boo.InvalidInput, "failed to parse query")
+	}
+	return q, nil
+}
+
+type parser struct {
+	src    string
+	pos    int
+	depth  int // Nesting of selections.
+	nested int // Nesting of values and types.
+}
+
+// enter increments a nesting counter, failing once it exceeds MaxDepth, so that
+// deeply nested input cannot exhaust the stack. The returned function leaves.
+func (p *parser) enter(counter *int) (func(), error) {
+	if *counter++; *counter > MaxDepth {
+		return nil, p.errorf("query exceeds maximum depth of %d", MaxDepth)
+	}
+	return func() { *counter-- }, nil
+}
+
+func (p *parser) errorf(f string, v ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(f, v...), p.pos)
+}
+
+// skip skips whitespace, commas and comments.
+func (p *parser) skip() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == ',' || unicode.IsSpace(rune(c)):
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek obtains the next character, or 0 at end of input.
+func (p *parser) peek() byte {
+	p.skip()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos++
+	return nil
+}
+
+func isNameChar(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+func (p *parser) name() (string, error) {
+	p.skip()
+	start := p.pos
+	for p.pos < len(p.src) && isNameChar(p.src[p.pos], p.pos == start) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected name")
+	}
+	return p.src[start:p.pos], nil
+}
+
+func (p *parser) parseQuery() (*Query, error) {
+	q := &Query{Defaults: make(map[string]interface{})}
+	if p.peek() != '{' {
+		keyword, e := p.name()
+		if e != nil {
+			return nil, e
+		}
+		if keyword != "query" {
+			return nil, p.errorf("operation '%s' is not supported", keyword)
+		}
+		if c := p.peek(); isNameChar(c, true) {
+			if q.Name, e = p.name(); e != nil {
+				return nil, e
+			}
+		}
+		if p.peek() == '(' {
+			if e := p.parseVariables(q); e != nil {
+				return nil, e
+			}
+		}
+	}
+	var e error
+	if q.Selections, e = p.parseSelections(); e != nil {
+		return nil, e
+	}
+	if p.peek() != 0 {
+		return nil, p.errorf("only a single query operation is supported")
+	}
+	return q, nil
+}
+
+// parseVariables parses variable definitions, keeping only default values.
+func (p *parser) parseVariables(q *Query) error {
+	p.pos++
+	for p.peek() != ')' {
+		if e := p.expect('$'); e != nil {
+			return e
+		}
+		name, e := p.name()
+		if e != nil {
+			return e
+		}
+		if e := p.expect(':'); e != nil {
+			return e
+		}
+		if e := p.skipType(); e != nil {
+			return e
+		}
+		if p.peek() == '=' {
+			p.pos++
+			v, e := p.parseValue()
+			if e != nil {
+				return e
+			}
+			q.Defaults[name] = v
+		}
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) skipType() error {
+	leave, e := p.enter(&p.nested)
+	if e != nil {
+		return e
+	}
+	defer leave()
+	if p.peek() == '[' {
+		p.pos++
+		if e := p.skipType(); e != nil {
+			return e
+		}
+		if e := p.expect(']'); e != nil {
+			return e
+		}
+	} else if _, e := p.name(); e != nil {
+		return e
+	}
+	if p.peek() == '!' {
+		p.pos++
+	}
+	return nil
+}
+
+func (p *parser) parseSelections() ([]*Selection, error) {
+	leave, e := p.enter(&p.depth)
+	if e != nil {
+		return nil, e
+	}
+	defer leave()
+	if e := p.expect('{'); e != nil {
+		return nil, e
+	}
+	var out []*Selection
+	for p.peek() != '}' {
+		switch p.peek() {
+		case 0:
+			return nil, p.errorf("unexpected end of query")
+		case '.':
+			return nil, p.errorf("fragments are not supported")
+		case '@':
+			return nil, p.errorf("directives are not supported")
+		}
+		s, e := p.parseSelection()
+		if e != nil {
+			return nil, e
+		}
+		out = append(out, s)
+	}
+	p.pos++
+	if len(out) == 0 {
+		return nil, p.errorf("empty selection")
+	}
+	return out, nil
+}
+
+func (p *parser) parseSelection() (*Selection, error) {
+	name, e := p.name()
+	if e != nil {
+		return nil, e
+	}
+	s := &Selection{Alias: name, Name: name}
+	if p.peek() == ':' {
+		p.pos++
+		if s.Name, e = p.name(); e != nil {
+			return nil, e
+		}
+	}
+	if p.peek() == '(' {
+		p.pos++
+		s.Args = make(map[string]interface{})
+		for p.peek() != ')' {
+			arg, e := p.name()
+			if e != nil {
+				return nil, e
+			}
+			if e := p.expect(':'); e != nil {
+				return nil, e
+			}
+			if s.Args[arg], e = p.parseValue(); e != nil {
+				return nil, e
+			}
+		}
+		p.pos++
+	}
+	if p.peek() == '{' {
+		if s.Selections, e = p.parseSelections(); e != nil {
+			return nil, e
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) parseValue() (interface{}, error) {
+	leave, e := p.enter(&p.nested)
+	if e != nil {
+		return nil, e
+	}
+	defer leave()
+	switch c := p.peek(); {
+	case c == '$':
+		p.pos++
+		name, e := p.name()
+		return Variable(name), e
+	case c == '"':
+		return p.parseString()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case c == '[':
+		p.pos++
+		list := make([]interface{}, 0)
+		for p.peek() != ']' {
+			if p.peek() == 0 {
+				return nil, p.errorf("unexpected end of list")
+			}
+			v, e := p.parseValue()
+			if e != nil {
+				return nil, e
+			}
+			list = append(list, v)
+		}
+		p.pos++
+		return list, nil
+	case c == '{':
+		p.pos++
+		obj := make(map[string]interface{})
+		for p.peek() != '}' {
+			key, e := p.name()
+			if e != nil {
+				return nil, e
+			}
+			if e := p.expect(':'); e != nil {
+				return nil, e
+			}
+			if obj[key], e = p.parseValue(); e != nil {
+				return nil, e
+			}
+		}
+		p.pos++
+		return obj, nil
+	default:
+		name, e := p.name()
+		if e != nil {
+			return nil, e
+		}
+		switch name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return name, nil // Enum values are obtained as strings.
+		}
+	}
+}
+
+func (p *parser) parseString() (interface{}, error) {
+	start := p.pos
+	for p.pos++; p.pos < len(p.src); p.pos++ {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos++
+		case '"':
+			p.pos++
+			v, e := strconv.Unquote(p.src[start:p.pos])
+			if e != nil {
+				return nil, p.errorf("invalid string")
+			}
+			return v, nil
+		case '\n':
+			return nil, p.errorf("unterminated string")
+		}
+	}
+	return nil, p.errorf("unterminated string")
+}
+
+func (p *parser) parseNumber() (interface{}, error) {
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte("+-.eE0123456789", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	raw := p.src[start:p.pos]
+	if i, e := strconv.Atoi(raw); e == nil {
+		return i, nil
+	}
+	f, e := strconv.ParseFloat(raw, 64)
+	if e != nil {
+		return nil, p.errorf("invalid number '%s'", raw)
+	}
+	return f, nil
+}
@@ -1,7 +1,11 @@
 package inform
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -25,3 +29,69 @@ func TestNewLogger(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestLogger_Levels(t *testing.T) {
+	const module = "TEST_LEVELS"
+	var buf bytes.Buffer
+	logger := New(true, &buf, module)
+
+	SetLevel(module, WarnLevel)
+	logger.Infof("dropped")
+	logger.Log(WarnLevel, "kept", "key", "value")
+	if got := Recent(0, module, DebugLevel); len(got) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(got))
+	}
+	if !strings.Contains(buf.String(), "WARN kept key=value") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+
+	SetLevel(module, DebugLevel)
+	logger.Debugf("debug %d", 1)
+	if got := Recent(0, module, DebugLevel); len(got) != 2 || got[1].Message != "debug 1" {
+		t.Errorf("expected debug entry to be kept, got %v", got)
+	}
+	if got := Recent(0, module, WarnLevel); len(got) != 1 {
+		t.Errorf("expected 1 entry of warn level, got %d", len(got))
+	}
+}
+
+func TestLogger_JSON(t *testing.T) {
+	const module = "TEST_JSON"
+	var buf bytes.Buffer
+	logger := New(true, &buf, module).With("board", "abc")
+
+	SetFormat(JSONFormat)
+	defer SetFormat(TextFormat)
+	logger.Log(ErrorLevel, "failed", "error", errors.New("oops"))
+
+	var entry struct {
+		Level   string            `json:"level"`
+		Module  string            `json:"module"`
+		Message string            `json:"message"`
+		Fields  map[string]string `json:"fields"`
+	}
+	if e := json.Unmarshal(buf.Bytes(), &entry); e != nil {
+		t.Fatal(e)
+	}
+	if entry.Level != "ERROR" || entry.Module != module || entry.Message != "failed" ||
+		entry.Fields["board"] != "abc" || entry.Fields["error"] != "oops" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+}
+
+func TestSetBufferSize(t *testing.T) {
+	const module = "TEST_BUFFER"
+	defer SetBufferSize(DefaultBufferSize)
+	SetBufferSize(3)
+	logger := New(false, nil, module)
+	for i := 0; i < 5; i++ {
+		logger.Printf("%d", i)
+	}
+	got := Recent(0, module, DebugLevel)
+	if len(got) != 3 || got[0].Message != "2" || got[2].Message != "4" {
+		t.Errorf("expected the 3 most recent entries, got %v", got)
+	}
+	if got := Recent(2, module, DebugLevel); len(got) != 2 || got[0].Message != "3" {
+		t.Errorf("expected the 2 most recent entries, got %v", got)
+	}
+}
@@ -0,0 +1,371 @@
+package inform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	<<< LEVELS >>>
+*/
+
+// Level is the severity of a log entry.
+type Level int
+
+// Log levels, from least to most severe.
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var levelNames = [...]string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// String returns the name of the level.
+func (l Level) String() string {
+	if l < DebugLevel || l > ErrorLevel {
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// MarshalJSON encodes the level as it's name.
+func (l Level) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
+
+// ParseLevel obtains a level from it's name (case insensitive).
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid log level '%s', expected one of %s",
+		s, strings.Join(levelNames[:], ", "))
+}
+
+/*
+	<<< FORMATS >>>
+*/
+
+// Format is how log entries are written to a logger's destination.
+type Format int
+
+// Log formats.
+const (
+	TextFormat Format = iota // Human readable, with fields as key=value pairs.
+	JSONFormat               // One JSON object per line.
+)
+
+// ParseFormat obtains a format from it's name ("text" or "json").
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "text", "":
+		return TextFormat, nil
+	case "json":
+		return JSONFormat, nil
+	default:
+		return 0, fmt.Errorf("invalid log format '%s', expected 'text' or 'json'", s)
+	}
+}
+
+/*
+	<<< ENTRIES >>>
+*/
+
+// Entry is a single log entry.
+type Entry struct {
+	Time    time.Time              `json:"time"`
+	Level   Level                  `json:"level"`
+	Module  string                 `json:"module"`
+	Caller  string                 `json:"caller,omitempty"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+func (e *Entry) encodeText() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s %s: %s %s",
+		e.Module, e.Time.Format("2006/01/02 15:04:05"), e.Caller, e.Level, e.Message)
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, e.Fields[k])
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+func (e *Entry) encodeJSON() string {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return e.encodeText()
+	}
+	return string(data) + "\n"
+}
+
+/*
+	<<< REGISTRY >>>
+*/
+
+// DefaultBufferSize is the number of recent entries kept in memory.
+const DefaultBufferSize = 1024
+
+// registry holds the log configuration shared by all loggers, and the buffer of
+// recent entries.
+type registry struct {
+	mux      sync.Mutex
+	format   Format
+	fallback Level            // Level of modules that have none set.
+	levels   map[string]Level // Levels of modules.
+	buf      []*Entry         // Ring buffer of recent entries.
+	next     int              // Index of buf to write next.
+	full     bool             // Whether buf has wrapped.
+}
+
+var reg = &registry{
+	fallback: InfoLevel,
+	levels:   make(map[string]Level),
+	buf:      make([]*Entry, DefaultBufferSize),
+}
+
+func (r *registry) enabled(module string, level Level) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	min, ok := r.levels[module]
+	if !ok {
+		min = r.fallback
+	}
+	return level >= min
+}
+
+func (r *registry) record(e *Entry) Format {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.buf[r.next] = e
+	if r.next = (r.next + 1) % len(r.buf); r.next == 0 {
+		r.full = true
+	}
+	return r.format
+}
+
+// SetFormat sets the format of all loggers.
+func SetFormat(format Format) {
+	reg.mux.Lock()
+	defer reg.mux.Unlock()
+	reg.format = format
+}
+
+// SetLevel sets the minimum level of entries logged by a module.
+// An empty module sets the level of modules that have none set.
+func SetLevel(module string, level Level) {
+	reg.mux.Lock()
+	defer reg.mux.Unlock()
+	if module == "" {
+		reg.fallback = level
+	} else {
+		reg.levels[module] = level
+	}
+}
+
+// Levels obtains the levels of modules that have one set.
+// The level of other modules is under the empty key.
+func Levels() map[string]Level {
+	reg.mux.Lock()
+	defer reg.mux.Unlock()
+	out := make(map[string]Level, len(reg.levels)+1)
+	for module, level := range reg.levels {
+		out[module] = level
+	}
+	out[""] = reg.fallback
+	return out
+}
+
+// SetBufferSize resizes the buffer of recent entries, keeping the most recent.
+func SetBufferSize(size int) {
+	if size < 1 {
+		size = 1
+	}
+	reg.mux.Lock()
+	defer reg.mux.Unlock()
+	kept := reg.ordered()
+	if len(kept) > size {
+		kept = kept[len(kept)-size:]
+	}
+	reg.buf = make([]*Entry, size)
+	reg.next = copy(reg.buf, kept) % size
+	reg.full = len(kept) == size
+}
+
+// ordered obtains the buffered entries, oldest first.
+func (r *registry) ordered() []*Entry {
+	if !r.full {
+		return append([]*Entry(nil), r.buf[:r.next]...)
+	}
+	return append(append([]*Entry(nil), r.buf[r.next:]...), r.buf[:r.next]...)
+}
+
+// Recent obtains up to 'n' most recent buffered entries of given module and
+// minimum level, oldest first. An empty module matches all modules, and n <= 0
+// obtains all matching entries.
+func Recent(n int, module string, min Level) []*Entry {
+	reg.mux.Lock()
+	all := reg.ordered()
+	reg.mux.Unlock()
+
+	out := make([]*Entry, 0, len(all))
+	for _, e := range all {
+		if e.Level >= min && (module == "" || e.Module == module) {
+			out = append(out, e)
+		}
+	}
+	if n > 0 && len(out) > n {
+		out = out[len(out)-n:]
+	}
+	return out
+}
+
+/*
+	<<< LOGGER >>>
+*/
+
+// Logger writes leveled, structured entries of a module.
+// Entries below the module's level (see SetLevel) are discarded, others are
+// written to the destination and kept in the buffer of recent entries.
+// Loggers are safe for concurrent use.
+type Logger struct {
+	mux    *sync.Mutex
+	dst    io.Writer
+	module string
+	fields map[string]interface{}
+}
+
+// New creates a new logger of a module.
+func New(show bool, dst io.Writer, module string) *Logger {
+	if !show {
+		dst = &empty{}
+	}
+	return &Logger{
+		mux:    new(sync.Mutex),
+		dst:    dst,
+		module: module,
+	}
+}
+
+// With obtains a logger of the same module that adds the given key/value pairs
+// to all it's entries.
+func (l *Logger) With(kv ...interface{}) *Logger {
+	fields := make(map[string]interface{}, len(l.fields)+len(kv)/2)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	addFields(fields, kv)
+	return &Logger{
+		mux:    l.mux,
+		dst:    l.dst,
+		module: l.module,
+		fields: fields,
+	}
+}
+
+// Module returns the module of the logger.
+func (l *Logger) Module() string { return l.module }
+
+// Log writes an entry of given level and message, with key/value pairs as fields.
+func (l *Logger) Log(level Level, msg string, kv ...interface{}) {
+	l.log(level, msg, kv)
+}
+
+// Debugf writes a formatted entry of debug level.
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	l.log(DebugLevel, fmt.Sprintf(format, v...), nil)
+}
+
+// Infof writes a formatted entry of info level.
+func (l *Logger) Infof(format string, v ...interface{}) {
+	l.log(InfoLevel, fmt.Sprintf(format, v...), nil)
+}
+
+// Warnf writes a formatted entry of warn level.
+func (l *Logger) Warnf(format string, v ...interface{}) {
+	l.log(WarnLevel, fmt.Sprintf(format, v...), nil)
+}
+
+// Errorf writes a formatted entry of error level.
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	l.log(ErrorLevel, fmt.Sprintf(format, v...), nil)
+}
+
+// Printf writes a formatted entry of info level, as of log.Logger.
+func (l *Logger) Printf(format string, v ...interface{}) {
+	l.log(InfoLevel, fmt.Sprintf(format, v...), nil)
+}
+
+// Println writes an entry of info level, as of log.Logger.
+func (l *Logger) Println(v ...interface{}) {
+	l.log(InfoLevel, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+func (l *Logger) log(level Level, msg string, kv []interface{}) {
+	if !reg.enabled(l.module, level) {
+		return
+	}
+	e := &Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Module:  l.module,
+		Message: msg,
+	}
+	if _, file, line, ok := runtime.Caller(2); ok {
+		e.Caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+	if len(l.fields)+len(kv) > 0 {
+		e.Fields = make(map[string]interface{}, len(l.fields)+len(kv)/2)
+		for k, v := range l.fields {
+			e.Fields[k] = v
+		}
+		addFields(e.Fields, kv)
+	}
+
+	var out string
+	switch reg.record(e) {
+	case JSONFormat:
+		out = e.encodeJSON()
+	default:
+		out = e.encodeText()
+	}
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if _, err := io.WriteString(l.dst, out); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write log entry:", err)
+	}
+}
+
+// addFields adds key/value pairs to fields. Values of errors are added as their
+// messages, and a key without a value is added under "!BADKEY".
+func addFields(fields map[string]interface{}, kv []interface{}) {
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fields["!BADKEY"] = kv[i]
+			break
+		}
+		v := kv[i+1]
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		fields[fmt.Sprint(kv[i])] = v
+	}
+}
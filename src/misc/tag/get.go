@@ -65,6 +65,17 @@ func GetSig(s string) (cipher.Sig, error) {
 	return sig, nil
 }
 
+// GetUnixTime obtains a unix time (in seconds) from string.
+func GetUnixTime(v string) (int64, error) {
+	ts, e := strconv.ParseInt(v, 10, 64)
+	if e != nil {
+		return 0, boo.WrapType(e, boo.InvalidInput, "invalid unix time")
+	} else if ts < 0 {
+		return 0, boo.New(boo.InvalidInput, "invalid unix time")
+	}
+	return ts, nil
+}
+
 func GetVoteValue(v string) (int8, error) {
 	value, e := strconv.Atoi(v)
 	if e != nil {
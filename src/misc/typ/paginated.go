@@ -1,6 +1,7 @@
 package typ
 
 import (
+	"encoding/base64"
 	"github.com/skycoin/bbs/src/misc/boo"
 )
 
@@ -8,6 +9,7 @@ type PaginatedCreator func() Paginated
 
 type Paginated interface {
 	Append(v string)
+	Delete(v string) bool
 	Has(v string) bool
 	Get(in *PaginatedInput) (*PaginatedOutput, error)
 	Len() int
@@ -18,6 +20,12 @@ type PaginatedInput struct {
 	StartIndex uint `json:"start_index"` // index to start with.
 	PageSize   uint `json:"page_size"`   // max number of elements in a page.
 	Reverse    bool `json:"reverse"`     // whether to get elements in the opposite direction.
+
+	// Cursored pages by cursor rather than by 'start_index'. Without a cursor, paging
+	// starts from the first element (or last element if reversed). Pages resume
+	// reliably when elements are appended between requests.
+	Cursored bool   `json:"cursored,omitempty"`
+	Cursor   string `json:"cursor,omitempty"` // opaque token, as obtained from 'next_cursor'.
 }
 
 type PaginatedOutput struct {
@@ -25,6 +33,7 @@ type PaginatedOutput struct {
 	StartIndex  uint     `json:"start_index"`
 	PageSize    uint     `json:"page_size"`
 	IsReversed  bool     `json:"is_reversed"`
+	NextCursor  string   `json:"next_cursor,omitempty"` // only when cursored and more elements remain.
 	Data        []string `json:"-"`
 }
 
@@ -62,3 +71,90 @@ func NewPaginatedOutput(in *PaginatedInput, dataCount uint) (*PaginatedOutput, e
 		Data:        make([]string, obtainedCount),
 	}, nil
 }
+
+// GetPage obtains a page of the given list of elements.
+func GetPage(in *PaginatedInput, list []string) (*PaginatedOutput, error) {
+	if in.Cursored {
+		return getCursoredPage(in, list)
+	}
+	out, e := NewPaginatedOutput(in, uint(len(list)))
+	if e != nil {
+		return nil, e
+	}
+	fillPage(out, list)
+	return out, nil
+}
+
+func getCursoredPage(in *PaginatedInput, list []string) (*PaginatedOutput, error) {
+	if in.PageSize <= 0 {
+		return nil, boo.New(boo.InvalidInput,
+			"invalid 'max_count' provided, valid values are in range '>= 0'")
+	}
+
+	// Obtain index of first element of page, or 'remaining' as 0 if there is none.
+	var start, remaining uint
+	if in.Cursor == "" {
+		if remaining = uint(len(list)); in.Reverse && remaining > 0 {
+			start = remaining - 1
+		}
+	} else {
+		last, e := cursorIndex(in.Cursor, list)
+		if e != nil {
+			return nil, e
+		}
+		if in.Reverse {
+			if remaining = last; remaining > 0 {
+				start = last - 1
+			}
+		} else {
+			start, remaining = last+1, uint(len(list))-last-1
+		}
+	}
+
+	out := &PaginatedOutput{
+		StartIndex: start,
+		PageSize:   in.PageSize,
+		IsReversed: in.Reverse,
+	}
+	if out.RecordCount = remaining; out.RecordCount > in.PageSize {
+		out.RecordCount = in.PageSize
+	}
+	out.Data = make([]string, out.RecordCount)
+	fillPage(out, list)
+	if out.RecordCount > 0 && remaining > out.RecordCount {
+		out.NextCursor = encodeCursor(out.Data[len(out.Data)-1])
+	}
+	return out, nil
+}
+
+func fillPage(out *PaginatedOutput, list []string) {
+	var action func(v uint) uint
+	if out.IsReversed {
+		action = func(v uint) uint { return v - 1 }
+	} else {
+		action = func(v uint) uint { return v + 1 }
+	}
+	for i, j := uint(0), out.StartIndex; i < uint(len(out.Data)); i, j = i+1, action(j) {
+		out.Data[i] = list[j]
+	}
+}
+
+func encodeCursor(v string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(v))
+}
+
+// cursorIndex obtains the index of the element the cursor points to.
+func cursorIndex(cursor string, list []string) (uint, error) {
+	raw, e := base64.RawURLEncoding.DecodeString(cursor)
+	if e != nil {
+		return 0, boo.WrapType(e, boo.InvalidInput, "invalid 'cursor' provided")
+	}
+	v := string(raw)
+	for i, elem := range list {
+		if elem == v {
+			return uint(i), nil
+		}
+	}
+	return 0, boo.New(boo.InvalidInput,
+		"invalid 'cursor' provided, element no longer exists")
+}
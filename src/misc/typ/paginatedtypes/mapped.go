@@ -21,28 +21,27 @@ func (p *Mapped) Append(v string) {
 	p.list = append(p.list, v)
 }
 
+func (p *Mapped) Delete(v string) bool {
+	if !p.Has(v) {
+		return false
+	}
+	delete(p.dict, v)
+	for i, elem := range p.list {
+		if elem == v {
+			p.list = append(p.list[:i], p.list[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
 func (p *Mapped) Has(v string) bool {
 	_, ok := p.dict[v]
 	return ok
 }
 
 func (p *Mapped) Get(in *typ.PaginatedInput) (*typ.PaginatedOutput, error) {
-	out, e := typ.NewPaginatedOutput(in, uint(len(p.list)))
-	if e != nil {
-		return nil, e
-	}
-
-	var action func(v uint) uint
-	if in.Reverse {
-		action = func(v uint) uint { return v - 1 }
-	} else {
-		action = func(v uint) uint { return v + 1 }
-	}
-	for i, j := uint(0), in.StartIndex; i < uint(len(out.Data)); i, j = i+1, action(j) {
-		out.Data[i] = p.list[j]
-	}
-
-	return out, nil
+	return typ.GetPage(in, p.list)
 }
 
 func (p *Mapped) Len() int {
@@ -14,6 +14,16 @@ func (p *Simple) Append(v string) {
 	p.list = append(p.list, v)
 }
 
+func (p *Simple) Delete(v string) bool {
+	for i, elem := range p.list {
+		if elem == v {
+			p.list = append(p.list[:i], p.list[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 func (p *Simple) Has(v string) bool {
 	for _, elem := range p.list {
 		if elem == v {
@@ -24,22 +34,7 @@ func (p *Simple) Has(v string) bool {
 }
 
 func (p *Simple) Get(in *typ.PaginatedInput) (*typ.PaginatedOutput, error) {
-	out, e := typ.NewPaginatedOutput(in, uint(len(p.list)))
-	if e != nil {
-		return nil, e
-	}
-
-	var action func(v uint) uint
-	if in.Reverse {
-		action = func(v uint) uint { return v - 1 }
-	} else {
-		action = func(v uint) uint { return v + 1 }
-	}
-	for i, j := uint(0), in.StartIndex; i < uint(len(out.Data)); i, j = i+1, action(j) {
-		out.Data[i] = p.list[j]
-	}
-
-	return out, nil
+	return typ.GetPage(in, p.list)
 }
 
 func (p *Simple) Len() int {
@@ -39,7 +39,56 @@ func TestSimple_Get(t *testing.T) {
 		if e != nil {
 			t.Error(e)
 		} else {
-			t.Log(out.Data, out.RemainingCount)
+			t.Log(out.Data, out.RecordCount)
+		}
+	})
+}
+
+func TestSimple_GetCursored(t *testing.T) {
+	p := NewSimple()
+	for i := 0; i < 5; i++ {
+		p.Append(fmt.Sprintf("%d", i))
+	}
+
+	cases := []struct {
+		name     string
+		reverse  bool
+		appended string // Appended after the first page.
+		expected [][]string
+	}{
+		{"forward", false, "5", [][]string{{"0", "1"}, {"2", "3"}, {"4", "5"}}},
+		{"reverse", true, "6", [][]string{{"5", "4"}, {"3", "2"}, {"1", "0"}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			in := &typ.PaginatedInput{PageSize: 2, Reverse: c.reverse, Cursored: true}
+			for i, expected := range c.expected {
+				out, e := p.Get(in)
+				if e != nil {
+					t.Fatal(e)
+				}
+				if fmt.Sprint(out.Data) != fmt.Sprint(expected) {
+					t.Fatalf("page %d: got %v, expected %v", i, out.Data, expected)
+				}
+				if last := i == len(c.expected)-1; last != (out.NextCursor == "") {
+					t.Fatalf("page %d: unexpected next cursor '%s'", i, out.NextCursor)
+				}
+				if i == 0 {
+					p.Append(c.appended)
+				}
+				in.Cursor = out.NextCursor
+			}
+		})
+	}
+
+	t.Run("expect error when cursor element is deleted", func(t *testing.T) {
+		out, e := p.Get(&typ.PaginatedInput{PageSize: 1, Cursored: true})
+		if e != nil {
+			t.Fatal(e)
+		}
+		p.Delete(out.Data[0])
+		if _, e := p.Get(&typ.PaginatedInput{PageSize: 1, Cursored: true, Cursor: out.NextCursor}); e == nil {
+			t.Error("expected error when cursor element is deleted")
 		}
 	})
 }
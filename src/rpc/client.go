@@ -9,13 +9,7 @@ import (
 
 func Send(address string) func(method string, in interface{}) string {
 	return func(method string, in interface{}) string {
-		client, e := rpc.Dial("tcp", address)
-		if e != nil {
-			return errString(e)
-		}
-		defer client.Close()
-		var out string
-		if e := client.Call(method, in, &out); e != nil {
+		if out, e := Call(address, method, in); e != nil {
 			return errString(e)
 		} else {
 			return okString(out)
@@ -23,6 +17,20 @@ func Send(address string) func(method string, in interface{}) string {
 	}
 }
 
+// Call calls a method of the node served at address, and obtains the JSON result.
+func Call(address, method string, in interface{}) (string, error) {
+	client, e := rpc.Dial("tcp", address)
+	if e != nil {
+		return "", e
+	}
+	defer client.Close()
+	var out string
+	if e := client.Call(method, in, &out); e != nil {
+		return "", e
+	}
+	return out, nil
+}
+
 func Do(out interface{}, e error) string {
 	if e != nil {
 		return errString(e)
@@ -69,6 +77,14 @@ func DeleteConnection(in *store.ConnectionIn) (string, interface{}) {
 	return method("DeleteConnection"), in
 }
 
+func EnableConnection(in *store.ConnectionIn) (string, interface{}) {
+	return method("EnableConnection"), in
+}
+
+func DisableConnection(in *store.ConnectionIn) (string, interface{}) {
+	return method("DisableConnection"), in
+}
+
 /*
 	<<< SUBSCRIPTIONS >>>
 */
@@ -85,6 +101,34 @@ func DeleteSubscription(in *store.BoardIn) (string, interface{}) {
 	return method("DeleteSubscription"), in
 }
 
+func SetSubscriptionOptions(in *store.SubscriptionOptionsIn) (string, interface{}) {
+	return method("SetSubscriptionOptions"), in
+}
+
+/*
+	<<< REGISTRY >>>
+*/
+
+func AnnounceBoard(in *store.AnnounceBoardIn) (string, interface{}) {
+	return method("AnnounceBoard"), in
+}
+
+func DiscoverBoards(in *store.DiscoverBoardsIn) (string, interface{}) {
+	return method("DiscoverBoards"), in
+}
+
+/*
+	<<< LOGS >>>
+*/
+
+func GetLogs(in *store.LogsIn) (string, interface{}) {
+	return method("GetLogs"), in
+}
+
+func SetLogLevel(in *store.LogLevelIn) (string, interface{}) {
+	return method("SetLogLevel"), in
+}
+
 /*
 	<<< CONTENT : ADMIN >>>
 */
@@ -107,6 +151,56 @@ func ImportBoard(in *store.ImportBoardIn) (string, interface{}) {
 	return method("ImportBoard"), in
 }
 
+func ExportArchive(in *store.ExportBoardIn) (string, interface{}) {
+	in.FilePath, _ = filepath.Abs(in.FilePath)
+	return method("ExportArchive"), in
+}
+
+func ImportArchive(in *store.ImportArchiveIn) (string, interface{}) {
+	in.FilePath, _ = filepath.Abs(in.FilePath)
+	return method("ImportArchive"), in
+}
+
+func DeleteUserContent(in *store.UserIn) (string, interface{}) {
+	return method("DeleteUserContent"), in
+}
+
+func SetPinOrder(in *store.PinOrderIn) (string, interface{}) {
+	return method("SetPinOrder"), in
+}
+
+func SetRetention(in *store.RetentionIn) (string, interface{}) {
+	return method("SetRetention"), in
+}
+
+func SetSubmissionPolicy(in *store.SubmissionPolicyIn) (string, interface{}) {
+	return method("SetSubmissionPolicy"), in
+}
+
+func SetArchived(in *store.ArchivedIn) (string, interface{}) {
+	return method("SetArchived"), in
+}
+
+func SetRegistry(in *store.RegistryIn) (string, interface{}) {
+	return method("SetRegistry"), in
+}
+
+func SetBoardMeta(in *store.SetBoardMetaIn) (string, interface{}) {
+	return method("SetBoardMeta"), in
+}
+
+func GetReports(in *store.BoardIn) (string, interface{}) {
+	return method("GetReports"), in
+}
+
+func GetModerationQueue(in *store.BoardIn) (string, interface{}) {
+	return method("GetModerationQueue"), in
+}
+
+func CompactVotes(in *store.ContentIn) (string, interface{}) {
+	return method("CompactVotes"), in
+}
+
 /*
 	<<< CONTENT >>>
 */
@@ -131,6 +225,10 @@ func GetFollowPage(in *store.UserIn) (string, interface{}) {
 	return method("GetFollowPage"), in
 }
 
+func Query(in *store.GraphQLIn) (string, interface{}) {
+	return method("Query"), in
+}
+
 /*
 	<<< CONTENT : SUBMISSION >>>
 */
@@ -61,6 +61,14 @@ func (g *Gateway) DeleteConnection(in *store.ConnectionIn, out *string) error {
 	return send(out)(g.Access.DeleteConnection(context.Background(), in))
 }
 
+func (g *Gateway) EnableConnection(in *store.ConnectionIn, out *string) error {
+	return send(out)(g.Access.EnableConnection(context.Background(), in))
+}
+
+func (g *Gateway) DisableConnection(in *store.ConnectionIn, out *string) error {
+	return send(out)(g.Access.DisableConnection(context.Background(), in))
+}
+
 /*
 	<<< SUBSCRIPTIONS >>>
 */
@@ -77,6 +85,34 @@ func (g *Gateway) DeleteSubscription(in *store.BoardIn, out *string) error {
 	return send(out)(g.Access.DeleteSubscription(context.Background(), in))
 }
 
+func (g *Gateway) SetSubscriptionOptions(in *store.SubscriptionOptionsIn, out *string) error {
+	return send(out)(g.Access.SetSubscriptionOptions(context.Background(), in))
+}
+
+/*
+	<<< REGISTRY >>>
+*/
+
+func (g *Gateway) AnnounceBoard(in *store.AnnounceBoardIn, out *string) error {
+	return send(out)(g.Access.AnnounceBoard(context.Background(), in))
+}
+
+func (g *Gateway) DiscoverBoards(in *store.DiscoverBoardsIn, out *string) error {
+	return send(out)(g.Access.DiscoverBoards(context.Background(), in))
+}
+
+/*
+	<<< LOGS >>>
+*/
+
+func (g *Gateway) GetLogs(in *store.LogsIn, out *string) error {
+	return send(out)(g.Access.GetLogs(context.Background(), in))
+}
+
+func (g *Gateway) SetLogLevel(in *store.LogLevelIn, out *string) error {
+	return send(out)(g.Access.SetLogLevel(context.Background(), in))
+}
+
 /*
 	<<< CONTENT : ADMIN >>>
 */
@@ -97,6 +133,54 @@ func (g *Gateway) ImportBoard(in *store.ImportBoardIn, out *string) error {
 	return send(out)(g.Access.ImportBoard(context.Background(), in))
 }
 
+func (g *Gateway) ExportArchive(in *store.ExportBoardIn, out *string) error {
+	return send(out)(g.Access.ExportArchive(context.Background(), in))
+}
+
+func (g *Gateway) ImportArchive(in *store.ImportArchiveIn, out *string) error {
+	return send(out)(g.Access.ImportArchive(context.Background(), in))
+}
+
+func (g *Gateway) DeleteUserContent(in *store.UserIn, out *string) error {
+	return send(out)(g.Access.DeleteUserContent(context.Background(), in))
+}
+
+func (g *Gateway) SetPinOrder(in *store.PinOrderIn, out *string) error {
+	return send(out)(g.Access.SetPinOrder(context.Background(), in))
+}
+
+func (g *Gateway) SetRetention(in *store.RetentionIn, out *string) error {
+	return send(out)(g.Access.SetRetention(context.Background(), in))
+}
+
+func (g *Gateway) SetSubmissionPolicy(in *store.SubmissionPolicyIn, out *string) error {
+	return send(out)(g.Access.SetSubmissionPolicy(context.Background(), in))
+}
+
+func (g *Gateway) SetArchived(in *store.ArchivedIn, out *string) error {
+	return send(out)(g.Access.SetArchived(context.Background(), in))
+}
+
+func (g *Gateway) SetRegistry(in *store.RegistryIn, out *string) error {
+	return send(out)(g.Access.SetRegistry(context.Background(), in))
+}
+
+func (g *Gateway) SetBoardMeta(in *store.SetBoardMetaIn, out *string) error {
+	return send(out)(g.Access.SetBoardMeta(context.Background(), in))
+}
+
+func (g *Gateway) GetReports(in *store.BoardIn, out *string) error {
+	return send(out)(g.Access.GetReports(context.Background(), in))
+}
+
+func (g *Gateway) GetModerationQueue(in *store.BoardIn, out *string) error {
+	return send(out)(g.Access.GetModerationQueue(context.Background(), in))
+}
+
+func (g *Gateway) CompactVotes(in *store.ContentIn, out *string) error {
+	return send(out)(g.Access.CompactVotes(context.Background(), in))
+}
+
 /*
 	<<< CONTENT >>>
 */
@@ -121,6 +205,10 @@ func (g *Gateway) GetFollowPage(in *store.UserIn, out *string) error {
 	return send(out)(g.Access.GetFollowPage(context.Background(), in))
 }
 
+func (g *Gateway) Query(in *store.GraphQLIn, out *string) error {
+	return send(out)(g.Access.Query(context.Background(), in))
+}
+
 /*
 	<<< CONTENT : SUBMISSION >>>
 */
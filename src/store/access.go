@@ -2,12 +2,16 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/gql"
+	"github.com/skycoin/bbs/src/misc/inform"
 	"github.com/skycoin/bbs/src/misc/typ"
 	"github.com/skycoin/bbs/src/store/cxo"
 	"github.com/skycoin/bbs/src/store/medial"
 	"github.com/skycoin/bbs/src/store/object"
 	"github.com/skycoin/bbs/src/store/state"
+	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/util/file"
 	"log"
 	"math"
@@ -90,6 +94,48 @@ func (a *Access) PrepareUserVote(ctx context.Context, in *PrepareUserVoteIn) (*P
 	}
 }
 
+func (a *Access) PrepareUserProfile(ctx context.Context, in *PrepareUserProfileIn) (*PrepareOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	if hash, raw, e := a.Medial.Add(in.CreatorPubKey, in.Data); e != nil {
+		return nil, e
+	} else {
+		return &PrepareOut{
+			Hash: hash.Hex(),
+			Raw:  string(raw),
+		}, nil
+	}
+}
+
+func (a *Access) PrepareKeyRotation(ctx context.Context, in *PrepareKeyRotationIn) (*PrepareOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	if hash, raw, e := a.Medial.Add(in.CreatorPubKey, in.Data); e != nil {
+		return nil, e
+	} else {
+		return &PrepareOut{
+			Hash: hash.Hex(),
+			Raw:  string(raw),
+		}, nil
+	}
+}
+
+func (a *Access) PrepareAttachment(ctx context.Context, in *PrepareAttachmentIn) (*PrepareOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	if hash, raw, e := a.Medial.Add(in.CreatorPubKey, in.Data); e != nil {
+		return nil, e
+	} else {
+		return &PrepareOut{
+			Hash: hash.Hex(),
+			Raw:  string(raw),
+		}, nil
+	}
+}
+
 func (a *Access) FinalizeSubmission(ctx context.Context, in *FinalizeSubmissionIn) (interface{}, error) {
 	if e := in.Process(); e != nil {
 		return nil, e
@@ -136,11 +182,14 @@ func (a *Access) FinalizeSubmission(ctx context.Context, in *FinalizeSubmissionI
 			ContentHash: transport.Body.OfPost,
 		})
 
-	case object.V5UserVoteType:
+	case object.V5UserVoteType, object.V5UserProfileType, object.V5KeyRotationType:
 		return bi.Viewer().GetUserProfile(&state.UserProfileIn{
 			UserPubKey: transport.Body.Creator,
 		})
 
+	case object.V5AttachmentType:
+		return bi.Viewer().GetAttachments(transport.Body.OfPost)
+
 	default:
 		return nil, boo.Newf(boo.InvalidInput,
 			"content submission of type '%s' is invalid", transport.Body.Type)
@@ -202,7 +251,8 @@ func (a *Access) GetAvailableBoards(ctx context.Context) (*AvailableBoardsOut, e
 */
 
 func (a *Access) GetConnections(ctx context.Context) (*ConnectionsOut, error) {
-	return getConnectionsOut(ctx, a.CXO.GetActiveConnections(), a.CXO.GetSavedConnections()), nil
+	return getConnectionsOut(ctx, a.CXO.GetActiveConnections(), a.CXO.GetSavedConnections(),
+		a.CXO.GetDiscoveredConnections()), nil
 }
 
 func (a *Access) NewConnection(ctx context.Context, in *ConnectionIn) (*ConnectionsOut, error) {
@@ -226,12 +276,42 @@ func (a *Access) DeleteConnection(ctx context.Context, in *ConnectionIn) (*Conne
 	return a.GetConnections(ctx)
 }
 
+func (a *Access) EnableConnection(ctx context.Context, in *ConnectionIn) (*ConnectionsOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	if e := a.CXO.EnableConnection(in.Address); e != nil {
+		return nil, e
+	}
+	return a.GetConnections(ctx)
+}
+
+func (a *Access) DisableConnection(ctx context.Context, in *ConnectionIn) (*ConnectionsOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	if e := a.CXO.DisableConnection(in.Address); e != nil {
+		return nil, e
+	}
+	return a.GetConnections(ctx)
+}
+
 /*
 	<<< SUBSCRIPTIONS >>>
 */
 
 func (a *Access) GetSubscriptions(ctx context.Context) (*SubscriptionsOut, error) {
-	return getSubscriptionsOut(ctx, a.CXO.GetSubscriptions()), nil
+	return getSubscriptionsOut(ctx, a.CXO.GetSubscriptions(), a.CXO.GetSubscriptionOptions), nil
+}
+
+func (a *Access) IsSubscribed(ctx context.Context, in *BoardIn) (*IsSubscribedOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	return &IsSubscribedOut{
+		PubKey:       in.PubKeyStr,
+		IsSubscribed: a.CXO.IsSubscribed(in.PubKey),
+	}, nil
 }
 
 func (a *Access) NewSubscription(ctx context.Context, in *BoardIn) (*SubscriptionsOut, error) {
@@ -254,6 +334,165 @@ func (a *Access) DeleteSubscription(ctx context.Context, in *BoardIn) (*Subscrip
 	return a.GetSubscriptions(ctx)
 }
 
+func (a *Access) SetSubscriptionOptions(ctx context.Context, in *SubscriptionOptionsIn) (*SubscriptionsOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	options, e := a.CXO.GetSubscriptionOptions(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	if e := a.CXO.SetSubscriptionOptions(in.PubKey, in.Apply(options)); e != nil {
+		return nil, e
+	}
+	return a.GetSubscriptions(ctx)
+}
+
+/*
+	<<< REGISTRY >>>
+*/
+
+// AnnounceBoard announces a master board, with it's name, description and metadata,
+// to a registry board (or all configured registries if none is specified).
+func (a *Access) AnnounceBoard(ctx context.Context, in *AnnounceBoardIn) (*AnnounceBoardOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	sk, e := a.CXO.GetMasterSecKey(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	board, e := bi.Viewer().GetBoard()
+	if e != nil {
+		return nil, e
+	}
+	if board == nil {
+		return nil, boo.New(boo.NotFound, "board is not yet compiled")
+	}
+	registries := a.getRegistries(in.RegistryPubKeyStr, in.RegistryPubKey)
+	if len(registries) == 0 {
+		return nil, boo.New(boo.InvalidInput, "no registries are configured")
+	}
+	out := &AnnounceBoardOut{Registries: make([]string, 0, len(registries))}
+	for _, rpk := range registries {
+		transport, e := newAnnouncement(board.Body.(*object.Body), in.BoardPubKey, sk, rpk)
+		if e != nil {
+			return nil, e
+		}
+		rbi, e := submitAndWait(ctx, a, transport)
+		if e != nil {
+			return nil, boo.WrapTypef(e, boo.Type(e), "failed to announce to registry '%s'", rpk.Hex())
+		}
+		if out.Board, e = rbi.Viewer().GetAnnouncement(in.BoardPubKeyStr); e != nil {
+			return nil, e
+		}
+		out.Registries = append(out.Registries, rpk.Hex())
+	}
+	return out, nil
+}
+
+// DiscoverBoards searches the boards announced to a registry board (or all configured
+// registries if none is specified), most recently announced first.
+func (a *Access) DiscoverBoards(ctx context.Context, in *DiscoverBoardsIn) (*DiscoverBoardsOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	var (
+		search   = &state.AnnouncementsIn{Query: in.Query, Category: in.Category}
+		newest   = make(map[string]*state.Announcement)
+		explicit = in.RegistryPubKeyStr != ""
+	)
+	for _, rpk := range a.getRegistries(in.RegistryPubKeyStr, in.RegistryPubKey) {
+		bi, e := a.CXO.GetBoardInstance(rpk)
+		if e != nil {
+			if explicit {
+				return nil, e
+			}
+			continue
+		}
+		list, e := bi.Viewer().GetAnnouncements(search)
+		if e != nil {
+			if explicit {
+				return nil, e
+			}
+			continue
+		}
+		for _, an := range list {
+			if got, ok := newest[an.Board]; !ok || an.TS > got.TS {
+				newest[an.Board] = an
+			}
+		}
+	}
+	list := make([]*state.Announcement, 0, len(newest))
+	for _, an := range newest {
+		list = append(list, an)
+	}
+	state.SortAnnouncements(list)
+
+	boards := make([]string, len(list))
+	for i, an := range list {
+		boards[i] = an.Board
+	}
+	meta, e := typ.GetPage(&typ.PaginatedInput{
+		StartIndex: in.StartIndex,
+		PageSize:   in.PageSize,
+	}, boards)
+	if e != nil {
+		return nil, e
+	}
+	return getDiscoverBoardsOut(meta, newest), nil
+}
+
+// getRegistries obtains the specified registry, or all configured registries if
+// none is specified.
+func (a *Access) getRegistries(rpkStr string, rpk cipher.PubKey) []cipher.PubKey {
+	if rpkStr != "" {
+		return []cipher.PubKey{rpk}
+	}
+	return a.CXO.GetRegistries()
+}
+
+// newAnnouncement creates an announcement of a board to a registry, signed by the board.
+func newAnnouncement(board *object.Body, bpk cipher.PubKey, bsk cipher.SecKey, rpk cipher.PubKey) (*object.Transport, error) {
+	raw, e := json.Marshal(&object.Body{
+		Type:    object.V5BoardAnnouncementType,
+		TS:      time.Now().UnixNano(),
+		OfBoard: rpk.Hex(),
+		Name:    board.Name,
+		Body:    board.Body,
+		Meta:    board.Meta,
+		Creator: bpk.Hex(),
+	})
+	if e != nil {
+		return nil, boo.WrapType(e, boo.Internal, "failed to encode announcement")
+	}
+	sig := cipher.SignHash(cipher.SumSHA256(raw), bsk)
+	return object.NewTransport(raw, sig)
+}
+
+/*
+	<<< LOGS >>>
+*/
+
+func (a *Access) GetLogs(ctx context.Context, in *LogsIn) (*LogsOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	return getLogsOut(inform.Recent(in.Count, in.Module, in.Level)), nil
+}
+
+func (a *Access) SetLogLevel(ctx context.Context, in *LogLevelIn) (*LogsOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	inform.SetLevel(in.Module, in.Level)
+	return getLogsOut([]*inform.Entry{}), nil
+}
+
 /*
 	<<< CONTENT : ADMIN >>>
 */
@@ -309,48 +548,68 @@ func (a *Access) ImportBoard(ctx context.Context, in *ImportBoardIn) (*ExportBoa
 	return getExportBoardOut(in.FilePath, pagesIn), nil
 }
 
-/*
-	<<< CONTENT >>>
-*/
-
-func (a *Access) GetBoards(ctx context.Context) (*BoardsOut, error) {
-	m, r, e := a.CXO.GetBoards(ctx)
+func (a *Access) ExportArchive(ctx context.Context, in *ExportBoardIn) (*ExportBoardOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.PubKey)
 	if e != nil {
 		return nil, e
 	}
-	return getBoardsOut(ctx, m, r), nil
+	board, e := bi.Viewer().GetBoard()
+	if e != nil {
+		return nil, e
+	}
+	f, e := os.OpenFile(in.FilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0600))
+	if e != nil {
+		return nil, boo.WrapType(e, boo.Internal, "failed to create archive file")
+	}
+	defer f.Close()
+	if e := a.CXO.ExportArchive(in.PubKey, f); e != nil {
+		return nil, e
+	}
+	return &ExportBoardOut{FilePath: in.FilePath, Board: board}, nil
 }
 
-func (a *Access) GetBoard(ctx context.Context, in *BoardIn) (*BoardOut, error) {
+func (a *Access) ImportArchive(ctx context.Context, in *ImportArchiveIn) (*ImportArchiveOut, error) {
 	if e := in.Process(); e != nil {
 		return nil, e
 	}
-	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	f, e := os.Open(in.FilePath)
+	if e != nil {
+		return nil, boo.WrapType(e, boo.NotFound, "failed to open archive file")
+	}
+	defer f.Close()
+	archive, e := state.ReadArchive(f)
 	if e != nil {
 		return nil, e
 	}
-	board, e := bi.Viewer().GetBoard()
+	result, e := a.CXO.ImportArchive(ctx, archive, in.SecKey)
 	if e != nil {
 		return nil, e
 	}
-	return getBoardOut(board), nil
+	return getImportArchiveOut(in.FilePath, archive, result), nil
 }
 
-func (a *Access) GetBoardPage(ctx context.Context, in *BoardIn) (interface{}, error) {
+func (a *Access) DeleteUserContent(ctx context.Context, in *UserIn) (*DeleteUserContentOut, error) {
 	if e := in.Process(); e != nil {
 		return nil, e
 	}
-	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
 	if e != nil {
 		return nil, e
 	}
-	return bi.Viewer().GetBoardPage(&state.BoardPageIn{
-		Perspective:    in.UserPubKeyStr,
-		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
-	})
+	count, goal, e := bi.DeleteByUser(in.UserPubKey)
+	if e != nil {
+		return nil, e
+	}
+	if e := bi.WaitSeq(ctx, goal); e != nil {
+		return nil, e
+	}
+	return getDeleteUserContentOut(in.UserPubKeyStr, count), nil
 }
 
-func (a *Access) NewThread(ctx context.Context, in *NewThreadIn) (interface{}, error) {
+func (a *Access) SetPinOrder(ctx context.Context, in *PinOrderIn) (interface{}, error) {
 	if e := in.Process(); e != nil {
 		return nil, e
 	}
@@ -358,28 +617,19 @@ func (a *Access) NewThread(ctx context.Context, in *NewThreadIn) (interface{}, e
 	if e != nil {
 		return nil, e
 	}
-	var goal uint64
-	if bi.IsMaster() {
-		if goal, e = bi.Submit(in.Transport); e != nil {
-			return nil, e
-		}
-	} else {
-		log.Println("NewThread: subs:", bi.GetSubmissionKeys())
-		goal, e = a.CXO.SubmitToRemote(ctx, bi.GetSubmissionKeys(), in.Transport)
-		if e != nil {
-			return nil, e
-		}
+	goal, e := bi.SetPinOrder(in.ThreadRefs)
+	if e != nil {
+		return nil, e
 	}
 	if e := bi.WaitSeq(ctx, goal); e != nil {
 		return nil, e
 	}
 	return bi.Viewer().GetBoardPage(&state.BoardPageIn{
-		Perspective:    in.CreatorPubKey.Hex(),
 		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
 	})
 }
 
-func (a *Access) GetThreadPage(ctx context.Context, in *ThreadIn) (interface{}, error) {
+func (a *Access) SetRetention(ctx context.Context, in *RetentionIn) (interface{}, error) {
 	if e := in.Process(); e != nil {
 		return nil, e
 	}
@@ -387,14 +637,17 @@ func (a *Access) GetThreadPage(ctx context.Context, in *ThreadIn) (interface{},
 	if e != nil {
 		return nil, e
 	}
-	return bi.Viewer().GetThreadPage(&state.ThreadPageIn{
-		Perspective:    in.UserPubKeyStr,
-		ThreadHash:     in.ThreadRefStr,
-		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
-	})
+	goal, e := bi.SetRetention(in.Days)
+	if e != nil {
+		return nil, e
+	}
+	if e := bi.WaitSeq(ctx, goal); e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetBoard()
 }
 
-func (a *Access) NewPost(ctx context.Context, in *NewPostIn) (interface{}, error) {
+func (a *Access) SetSubmissionPolicy(ctx context.Context, in *SubmissionPolicyIn) (interface{}, error) {
 	if e := in.Process(); e != nil {
 		return nil, e
 	}
@@ -402,43 +655,53 @@ func (a *Access) NewPost(ctx context.Context, in *NewPostIn) (interface{}, error
 	if e != nil {
 		return nil, e
 	}
-	var goal uint64
-	if bi.IsMaster() {
-		if goal, e = bi.Submit(in.Transport); e != nil {
-			return nil, e
-		}
-	} else {
-		goal, e = a.CXO.SubmitToRemote(ctx, bi.GetSubmissionKeys(), in.Transport)
-		if e != nil {
-			return nil, e
-		}
+	goal, e := bi.SetSubmissionPolicy(in.Policy)
+	if e != nil {
+		return nil, e
 	}
 	if e := bi.WaitSeq(ctx, goal); e != nil {
 		return nil, e
 	}
-	return bi.Viewer().GetThreadPage(&state.ThreadPageIn{
-		Perspective:    in.CreatorPubKey.Hex(),
-		ThreadHash:     in.ThreadRefStr,
-		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
-	})
+	return bi.Viewer().GetBoard()
 }
 
-func (a *Access) GetParticipants(ctx context.Context, in *BoardIn) (interface{}, error) {
+func (a *Access) SetArchived(ctx context.Context, in *ArchivedIn) (interface{}, error) {
 	if e := in.Process(); e != nil {
 		return nil, e
 	}
-	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
 	if e != nil {
 		return nil, e
 	}
-	return bi.Viewer().GetParticipants()
+	goal, e := bi.SetArchived(in.Archived)
+	if e != nil {
+		return nil, e
+	}
+	if e := bi.WaitSeq(ctx, goal); e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetBoard()
 }
 
-/*
-	<<< VOTES >>>
-*/
+func (a *Access) SetRegistry(ctx context.Context, in *RegistryIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	goal, e := bi.SetRegistry(in.Registry)
+	if e != nil {
+		return nil, e
+	}
+	if e := bi.WaitSeq(ctx, goal); e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetBoard()
+}
 
-func (a *Access) GetFollowPage(ctx context.Context, in *UserIn) (interface{}, error) {
+func (a *Access) SetBoardMeta(ctx context.Context, in *SetBoardMetaIn) (interface{}, error) {
 	if e := in.Process(); e != nil {
 		return nil, e
 	}
@@ -446,8 +709,636 @@ func (a *Access) GetFollowPage(ctx context.Context, in *UserIn) (interface{}, er
 	if e != nil {
 		return nil, e
 	}
-	return bi.Viewer().GetUserProfile(&state.UserProfileIn{
-		UserPubKey: in.UserPubKeyStr,
+	goal, e := bi.SetBoardMeta(in.Meta)
+	if e != nil {
+		return nil, e
+	}
+	if e := bi.WaitSeq(ctx, goal); e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetBoard()
+}
+
+func (a *Access) GetReports(ctx context.Context, in *BoardIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	if !bi.IsMaster() {
+		return nil, boo.Newf(boo.NotAllowed,
+			"reports of board '%s' are only visible to the node that owns it", in.PubKeyStr)
+	}
+	return bi.Viewer().GetReports(&state.ReportsIn{
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) GetModerationQueue(ctx context.Context, in *BoardIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	if !bi.IsMaster() {
+		return nil, boo.Newf(boo.NotAllowed,
+			"moderation queue of board '%s' is only visible to the node that owns it", in.PubKeyStr)
+	}
+	return bi.Viewer().GetModerationQueue(&state.ModerationQueueIn{
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) CompactVotes(ctx context.Context, in *ContentIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().CompactVotes(in.ContentRefStr)
+}
+
+/*
+	<<< CONTENT >>>
+*/
+
+func (a *Access) GetBoards(ctx context.Context) (*BoardsOut, error) {
+	m, r, e := a.CXO.GetBoards(ctx)
+	if e != nil {
+		return nil, e
+	}
+	return getBoardsOut(ctx, m, r), nil
+}
+
+func (a *Access) GetStats(ctx context.Context) (*state.CompilerStats, error) {
+	return a.CXO.GetStats(), nil
+}
+
+func (a *Access) GetBoardVersion(ctx context.Context, in *BoardIn) (*BoardVersionOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	version, e := a.CXO.GetBoardVersion(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	return &BoardVersionOut{PubKey: in.PubKeyStr, Version: version}, nil
+}
+
+func (a *Access) GetBoardSyncStatus(ctx context.Context, in *BoardIn) (*state.BoardSyncStatus, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	return a.CXO.GetBoardSyncStatus(in.PubKey)
+}
+
+func (a *Access) GetRateLimit(ctx context.Context, in *RateLimitIn) (*state.RateLimitStatus, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	return a.CXO.GetRateLimit(in.UserPubKey), nil
+}
+
+func (a *Access) GetUserWatchlist(ctx context.Context, in *WatchlistIn) (*WatchlistOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	threads, e := a.CXO.GetUserWatchlist(in.UserPubKeyStr, in.LastSeen)
+	if e != nil {
+		return nil, e
+	}
+	return &WatchlistOut{Threads: threads}, nil
+}
+
+func (a *Access) GetBoardsPage(ctx context.Context, in *BoardsPageIn) (*state.BoardsPageOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	return a.CXO.GetBoardsPage(&state.BoardsPageIn{
+		SortBy: in.SortBy,
+		PaginatedInput: typ.PaginatedInput{
+			StartIndex: in.StartIndex,
+			PageSize:   in.PageSize,
+		},
+	})
+}
+
+func (a *Access) GetAggregatedFeed(ctx context.Context, in *FeedIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	return a.CXO.GetAggregatedFeed(&state.AggregatedFeedIn{
+		Boards:         in.BoardPubKeys,
+		Dedup:          in.Dedup,
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) SearchAll(ctx context.Context, in *SearchAllIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	return a.CXO.SearchAll(&state.GlobalSearchIn{
+		Boards:         in.BoardPubKeys,
+		Query:          in.Query,
+		Perspective:    in.UserPubKeyStr,
+		HideBlocked:    in.HideBlocked,
+		PerBoardLimit:  in.PerBoardLimit,
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+// GetSyndication renders the most recent threads of a board, or the most recent
+// posts of a thread, as a RSS or Atom feed.
+func (a *Access) GetSyndication(ctx context.Context, in *SyndicationIn) (*Syndication, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	paginated := typ.PaginatedInput{PageSize: uint(in.Limit)}
+	src := &syndicationSource{
+		id:   in.BoardPubKeyStr,
+		link: "/api/boards/" + in.BoardPubKeyStr,
+	}
+	if in.ThreadRefStr == "" {
+		out, e := bi.Viewer().GetBoardPage(&state.BoardPageIn{
+			SortBy:         state.SortNewest,
+			PaginatedInput: paginated,
+		})
+		if e != nil {
+			return nil, e
+		}
+		board := toSyndicationEntry(out.Board)
+		src.title, src.desc = board.title, board.body
+		for _, thread := range out.Threads {
+			src.entries = append(src.entries, toSyndicationEntry(thread))
+		}
+	} else {
+		paginated.Reverse, paginated.Cursored = true, true
+		out, e := bi.Viewer().GetThreadPage(&state.ThreadPageIn{
+			ThreadHash:     in.ThreadRefStr,
+			PaginatedInput: paginated,
+		})
+		if e != nil {
+			return nil, e
+		}
+		thread := toSyndicationEntry(out.Thread)
+		src.id, src.title, src.desc = thread.id, thread.title, thread.body
+		src.link += "/threads/" + in.ThreadRefStr
+		for _, post := range out.Posts {
+			src.entries = append(src.entries, toSyndicationEntry(post))
+		}
+	}
+	return src.render(in.Format)
+}
+
+func (a *Access) GetBoard(ctx context.Context, in *BoardIn) (*BoardOut, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	board, e := bi.Viewer().GetBoard()
+	if e != nil {
+		return nil, e
+	}
+	return getBoardOut(board), nil
+}
+
+func (a *Access) GetBoardPage(ctx context.Context, in *BoardIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetBoardPage(&state.BoardPageIn{
+		Perspective:      in.UserPubKeyStr,
+		HideBlockedVotes: in.HideBlocked,
+		SinceUnix:        in.SinceUnix,
+		HashesOnly:       in.HashesOnly,
+		SortBy:           in.SortBy,
+		NonEmptyOnly:     in.NonEmptyOnly,
+		IncludeTopReply:  in.IncludeTopReply,
+		IncludeArchived:  in.IncludeArchived,
+		Muted:            in.Muted,
+		MinTrust:         in.MinTrust,
+		ShowSpam:         in.ShowSpam,
+		PaginatedInput:   typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) GetBoardPageDiff(ctx context.Context, in *BoardIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.GetBoardPageDiff(&state.BoardPageIn{
+		Perspective:      in.UserPubKeyStr,
+		HideBlockedVotes: in.HideBlocked,
+		SinceUnix:        in.SinceUnix,
+		SortBy:           in.SortBy,
+		NonEmptyOnly:     in.NonEmptyOnly,
+		IncludeTopReply:  in.IncludeTopReply,
+		IncludeArchived:  in.IncludeArchived,
+		Muted:            in.Muted,
+		MinTrust:         in.MinTrust,
+		ShowSpam:         in.ShowSpam,
+		PaginatedInput:   typ.PaginatedInput{PageSize: math.MaxUint64},
+	}, in.SinceVersion)
+}
+
+// RangeBoardEvents calls 'action' with change events of the board as they are compiled,
+// until the context is done, 'action' returns an error or the subscription is dropped.
+func (a *Access) RangeBoardEvents(ctx context.Context, in *BoardIn, action func(event *state.BoardEvent) error) error {
+	if e := in.Process(); e != nil {
+		return e
+	}
+	events, unsubscribe, e := a.CXO.SubscribeBoard(in.PubKey)
+	if e != nil {
+		return e
+	}
+	defer unsubscribe()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return boo.New(boo.NotAllowed,
+					"subscription dropped")
+			}
+			if e := action(event); e != nil {
+				return e
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (a *Access) NewThread(ctx context.Context, in *NewThreadIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	var goal uint64
+	if bi.IsMaster() {
+		if goal, e = bi.Submit(in.Transport); e != nil {
+			return nil, e
+		}
+	} else {
+		log.Println("NewThread: subs:", bi.GetSubmissionKeys())
+		goal, e = a.CXO.SubmitToRemote(ctx, bi.GetSubmissionKeys(), in.Transport)
+		if e != nil {
+			return nil, e
+		}
+	}
+	if e := bi.WaitSeq(ctx, goal); e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetBoardPage(&state.BoardPageIn{
+		Perspective:    in.CreatorPubKey.Hex(),
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) GetThreadPage(ctx context.Context, in *ThreadIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetThreadPage(&state.ThreadPageIn{
+		Perspective:      in.UserPubKeyStr,
+		HideBlockedVotes: in.HideBlocked,
+		ThreadHash:       in.ThreadRefStr,
+		SinceUnix:        in.SinceUnix,
+		HashesOnly:       in.HashesOnly,
+		CollapseBelow:    in.CollapseBelow,
+		Muted:            in.Muted,
+		MinTrust:         in.MinTrust,
+		ShowSpam:         in.ShowSpam,
+		PaginatedInput:   typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) RangeThreadTranscript(ctx context.Context, in *ThreadIn, action func(entry *state.TranscriptEntry) error) error {
+	if e := in.Process(); e != nil {
+		return e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return e
+	}
+	return bi.Viewer().RangeThreadTranscript(in.ThreadRefStr, func(entry *state.TranscriptEntry) error {
+		if e := ctx.Err(); e != nil {
+			return e
+		}
+		return action(entry)
+	})
+}
+
+func (a *Access) GetReplies(ctx context.Context, in *PostIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetReplies(&state.RepliesIn{
+		Perspective:    in.UserPubKeyStr,
+		PostHash:       in.PostRefStr,
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) GetContentPath(ctx context.Context, in *ContentIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetContentPath(in.ContentRefStr)
+}
+
+func (a *Access) ResolveVote(ctx context.Context, in *ContentIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().ResolveVote(in.ContentRefStr)
+}
+
+func (a *Access) NewPost(ctx context.Context, in *NewPostIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	var goal uint64
+	if bi.IsMaster() {
+		if goal, e = bi.Submit(in.Transport); e != nil {
+			return nil, e
+		}
+	} else {
+		goal, e = a.CXO.SubmitToRemote(ctx, bi.GetSubmissionKeys(), in.Transport)
+		if e != nil {
+			return nil, e
+		}
+	}
+	if e := bi.WaitSeq(ctx, goal); e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetThreadPage(&state.ThreadPageIn{
+		Perspective:    in.CreatorPubKey.Hex(),
+		ThreadHash:     in.ThreadRefStr,
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) GetTrustNetwork(ctx context.Context, in *UserIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetTrustNetwork(&state.TrustNetworkIn{
+		UserPubKey:     in.UserPubKeyStr,
+		Relation:       in.Relation,
+		MaxDepth:       in.MaxDepth,
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) GetTrustScore(ctx context.Context, in *UserIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetTrustScore(in.PerspectiveStr, in.UserPubKeyStr)
+}
+
+func (a *Access) GetIdentity(ctx context.Context, in *UserIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetIdentity(in.UserPubKeyStr)
+}
+
+func (a *Access) GetUserVoteCount(ctx context.Context, in *UserIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetUserVoteCount(in.UserPubKeyStr)
+}
+
+func (a *Access) GetParticipants(ctx context.Context, in *BoardIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetParticipants(&state.ParticipantsIn{
+		MinReputation:  in.MinReputation,
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) GetContentByVoteTag(ctx context.Context, in *BoardIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetContentByVoteTag(&state.VoteTagIn{
+		Tag:              in.VoteTag,
+		Perspective:      in.UserPubKeyStr,
+		HideBlockedVotes: in.HideBlocked,
+		PaginatedInput:   typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) GetActivityFeed(ctx context.Context, in *BoardIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetActivityFeed(&state.ActivityIn{
+		Perspective:      in.UserPubKeyStr,
+		HideBlockedVotes: in.HideBlocked,
+		MaxPerThread:     in.MaxPerThread,
+		PaginatedInput:   typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) GetAllThreadVotes(ctx context.Context, in *BoardIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetAllThreadVotes(&state.AllVotesIn{
+		Perspective:      in.UserPubKeyStr,
+		HideBlockedVotes: in.HideBlocked,
+		SortBy:           in.SortBy,
+		PaginatedInput:   typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) GetThreadParticipants(ctx context.Context, in *ThreadIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetThreadParticipants(&state.ThreadParticipantsIn{
+		ThreadHash:     in.ThreadRefStr,
+		WithProfiles:   in.WithProfiles,
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+func (a *Access) GetTrustGraph(ctx context.Context, in *BoardIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.PubKey)
+	if e != nil {
+		return nil, e
+	}
+	edges, e := bi.Viewer().ExportTrustGraph()
+	if e != nil {
+		return nil, e
+	}
+	return &TrustGraphOut{Edges: edges}, nil
+}
+
+/*
+	<<< QUERY >>>
+*/
+
+// Query executes a GraphQL query against the subscribed boards, so that clients can
+// obtain boards, threads, posts, votes and profiles in the nesting they need.
+func (a *Access) Query(ctx context.Context, in *GraphQLIn) (*gql.Result, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	return a.executeGraphQL(ctx, in.Query, in.Variables)
+}
+
+/*
+	<<< VOTES >>>
+*/
+
+func (a *Access) GetFollowPage(ctx context.Context, in *UserIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetUserProfile(&state.UserProfileIn{
+		UserPubKey:       in.UserPubKeyStr,
+		Fields:           in.Fields,
+		RecentContent:    in.Recent,
+		Perspective:      in.PerspectiveStr,
+		HideBlockedVotes: in.HideBlocked,
+	})
+}
+
+func (a *Access) GetUserActivity(ctx context.Context, in *UserIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetUserActivity(&state.UserActivityIn{
+		UserPubKey:       in.UserPubKeyStr,
+		Perspective:      in.PerspectiveStr,
+		HideBlockedVotes: in.HideBlocked,
+		PaginatedInput:   typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+}
+
+// GetAttachment obtains an attachment of a post, with it's data.
+func (a *Access) GetAttachment(ctx context.Context, in *AttachmentIn) (*state.Attachment, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetAttachment(in.HashStr)
+}
+
+func (a *Access) GetMentions(ctx context.Context, in *UserIn) (interface{}, error) {
+	if e := in.Process(); e != nil {
+		return nil, e
+	}
+	bi, e := a.CXO.GetBoardInstance(in.BoardPubKey)
+	if e != nil {
+		return nil, e
+	}
+	return bi.Viewer().GetMentions(&state.MentionsIn{
+		UserPubKey:       in.UserPubKeyStr,
+		Perspective:      in.PerspectiveStr,
+		HideBlockedVotes: in.HideBlocked,
+		PaginatedInput:   typ.PaginatedInput{PageSize: math.MaxUint64},
 	})
 }
 
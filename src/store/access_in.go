@@ -4,9 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/inform"
 	"github.com/skycoin/bbs/src/misc/tag"
 	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/bbs/src/store/state"
 	"github.com/skycoin/skycoin/src/cipher"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -43,6 +48,15 @@ type UserIn struct {
 	BoardPubKey    cipher.PubKey
 	UserPubKeyStr  string
 	UserPubKey     cipher.PubKey
+	FieldsStr      string
+	Fields         []string
+	Relation       string
+	MaxDepthStr    string
+	MaxDepth       int
+	RecentStr      string
+	Recent         int
+	PerspectiveStr string
+	HideBlocked    bool
 }
 
 func (a *UserIn) Process() error {
@@ -53,15 +67,49 @@ func (a *UserIn) Process() error {
 	if a.UserPubKey, e = tag.GetPubKey(a.UserPubKeyStr); e != nil {
 		return ErrProcess(e, "user's public key")
 	}
+	if a.FieldsStr != "" {
+		if a.Fields, e = tag.GetTags(a.FieldsStr); e != nil {
+			return ErrProcess(e, "profile fields")
+		}
+	}
+	if a.MaxDepthStr != "" {
+		if a.MaxDepth, e = strconv.Atoi(a.MaxDepthStr); e != nil {
+			return ErrProcess(e, "maximum depth")
+		}
+	}
+	if a.RecentStr != "" {
+		if a.Recent, e = strconv.Atoi(a.RecentStr); e != nil {
+			return ErrProcess(e, "recent content count")
+		}
+	}
 	return nil
 }
 
 // BoardIn represents a subscription input.
 type BoardIn struct {
-	PubKeyStr     string
-	PubKey        cipher.PubKey
-	UserPubKeyStr string
-	UserPubKey    cipher.PubKey
+	PubKeyStr        string
+	PubKey           cipher.PubKey
+	UserPubKeyStr    string
+	UserPubKey       cipher.PubKey
+	SinceUnixStr     string
+	SinceUnix        int64
+	HashesOnly       bool
+	HideBlocked      bool
+	SortBy           string
+	NonEmptyOnly     bool
+	IncludeTopReply  bool
+	IncludeArchived  bool
+	MinReputationStr string
+	MinReputation    *int
+	SinceVersionStr  string
+	SinceVersion     uint64
+	VoteTag          string
+	MaxPerThreadStr  string
+	MaxPerThread     int
+	Muted            string
+	MinTrustStr      string
+	MinTrust         float64
+	ShowSpam         bool
 }
 
 func (a *BoardIn) Process() error {
@@ -74,6 +122,424 @@ func (a *BoardIn) Process() error {
 			return ErrProcess(e, "user public key")
 		}
 	}
+	if a.SinceUnixStr != "" {
+		if a.SinceUnix, e = tag.GetUnixTime(a.SinceUnixStr); e != nil {
+			return ErrProcess(e, "since unix time")
+		}
+	}
+	if a.MinReputationStr != "" {
+		minRep, e := strconv.Atoi(a.MinReputationStr)
+		if e != nil {
+			return ErrProcess(e, "minimum reputation")
+		}
+		a.MinReputation = &minRep
+	}
+	if a.SinceVersionStr != "" {
+		if a.SinceVersion, e = strconv.ParseUint(a.SinceVersionStr, 10, 64); e != nil {
+			return ErrProcess(e, "since version")
+		}
+	}
+	if a.MaxPerThreadStr != "" {
+		if a.MaxPerThread, e = strconv.Atoi(a.MaxPerThreadStr); e != nil {
+			return ErrProcess(e, "maximum per thread")
+		}
+	}
+	if a.MinTrustStr != "" {
+		if a.MinTrust, e = strconv.ParseFloat(a.MinTrustStr, 64); e != nil {
+			return ErrProcess(e, "minimum trust")
+		}
+	}
+	return nil
+}
+
+type FeedIn struct {
+	BoardPubKeysStr string // Comma separated, empty for all boards.
+	BoardPubKeys    []cipher.PubKey
+	Dedup           bool
+}
+
+func (a *FeedIn) Process() error {
+	if a.BoardPubKeysStr == "" {
+		return nil
+	}
+	for _, pkStr := range strings.Split(a.BoardPubKeysStr, ",") {
+		pk, e := tag.GetPubKey(strings.TrimSpace(pkStr))
+		if e != nil {
+			return ErrProcess(e, "board public key")
+		}
+		a.BoardPubKeys = append(a.BoardPubKeys, pk)
+	}
+	return nil
+}
+
+type BoardsPageIn struct {
+	SortBy        string
+	StartIndexStr string
+	StartIndex    uint
+	PageSizeStr   string
+	PageSize      uint
+}
+
+func (a *BoardsPageIn) Process() error {
+	a.PageSize = math.MaxUint64
+	if a.StartIndexStr != "" {
+		i, e := strconv.ParseUint(a.StartIndexStr, 10, 32)
+		if e != nil {
+			return ErrProcess(e, "start index")
+		}
+		a.StartIndex = uint(i)
+	}
+	if a.PageSizeStr != "" {
+		n, e := strconv.ParseUint(a.PageSizeStr, 10, 32)
+		if e != nil {
+			return ErrProcess(e, "page size")
+		}
+		a.PageSize = uint(n)
+	}
+	return nil
+}
+
+type AttachmentIn struct {
+	BoardPubKeyStr string
+	BoardPubKey    cipher.PubKey
+	HashStr        string
+	Hash           cipher.SHA256
+}
+
+func (a *AttachmentIn) Process() error {
+	var e error
+	if a.BoardPubKey, e = tag.GetPubKey(a.BoardPubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	if a.Hash, e = tag.GetHash(a.HashStr); e != nil {
+		return ErrProcess(e, "attachment hash")
+	}
+	return nil
+}
+
+type SyndicationIn struct {
+	BoardPubKeyStr string
+	BoardPubKey    cipher.PubKey
+	ThreadRefStr   string // Optional, feed of thread's posts rather than board's threads.
+	ThreadRef      cipher.SHA256
+	Format         string
+	LimitStr       string
+	Limit          int
+}
+
+func (a *SyndicationIn) Process() error {
+	var e error
+	if a.BoardPubKey, e = tag.GetPubKey(a.BoardPubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	if a.ThreadRefStr != "" {
+		if a.ThreadRef, e = tag.GetHash(a.ThreadRefStr); e != nil {
+			return ErrProcess(e, "thread hash")
+		}
+	}
+	switch a.Format {
+	case "":
+		a.Format = SyndicationRSS
+	case SyndicationRSS, SyndicationAtom:
+	default:
+		return ErrProcess(nil, "feed format")
+	}
+	a.Limit = DefaultSyndicationLimit
+	if a.LimitStr != "" {
+		if a.Limit, e = strconv.Atoi(a.LimitStr); e != nil {
+			return ErrProcess(e, "feed limit")
+		}
+		if a.Limit < 1 || a.Limit > MaxSyndicationLimit {
+			return ErrProcess(nil, "feed limit")
+		}
+	}
+	return nil
+}
+
+type SearchAllIn struct {
+	BoardPubKeysStr  string // Comma separated, empty for all boards.
+	BoardPubKeys     []cipher.PubKey
+	Query            string
+	UserPubKeyStr    string
+	UserPubKey       cipher.PubKey
+	HideBlocked      bool
+	PerBoardLimitStr string
+	PerBoardLimit    int
+}
+
+func (a *SearchAllIn) Process() error {
+	var e error
+	if strings.TrimSpace(a.Query) == "" {
+		return ErrProcess(nil, "empty search query")
+	}
+	if a.BoardPubKeysStr != "" {
+		for _, pkStr := range strings.Split(a.BoardPubKeysStr, ",") {
+			pk, e := tag.GetPubKey(strings.TrimSpace(pkStr))
+			if e != nil {
+				return ErrProcess(e, "board public key")
+			}
+			a.BoardPubKeys = append(a.BoardPubKeys, pk)
+		}
+	}
+	if a.UserPubKeyStr != "" {
+		if a.UserPubKey, e = tag.GetPubKey(a.UserPubKeyStr); e != nil {
+			return ErrProcess(e, "user's public key")
+		}
+	}
+	if a.PerBoardLimitStr != "" {
+		if a.PerBoardLimit, e = strconv.Atoi(a.PerBoardLimitStr); e != nil {
+			return ErrProcess(e, "per board limit")
+		}
+	}
+	return nil
+}
+
+type WatchlistIn struct {
+	UserPubKeyStr string
+	UserPubKey    cipher.PubKey
+	LastSeenStr   string // JSON object of thread hash to unix time.
+	LastSeen      map[string]int64
+}
+
+func (a *WatchlistIn) Process() error {
+	var e error
+	if a.UserPubKey, e = tag.GetPubKey(a.UserPubKeyStr); e != nil {
+		return ErrProcess(e, "user's public key")
+	}
+	if a.LastSeenStr != "" {
+		if e = json.Unmarshal([]byte(a.LastSeenStr), &a.LastSeen); e != nil {
+			return ErrProcess(e, "last seen times")
+		}
+	}
+	return nil
+}
+
+type RateLimitIn struct {
+	UserPubKeyStr string
+	UserPubKey    cipher.PubKey
+}
+
+func (a *RateLimitIn) Process() error {
+	var e error
+	if a.UserPubKey, e = tag.GetPubKey(a.UserPubKeyStr); e != nil {
+		return ErrProcess(e, "user's public key")
+	}
+	return nil
+}
+
+type PinOrderIn struct {
+	BoardPubKeyStr string
+	BoardPubKey    cipher.PubKey
+	ThreadRefsStr  string // Comma separated and ordered, empty to unpin all.
+	ThreadRefs     []cipher.SHA256
+}
+
+func (a *PinOrderIn) Process() error {
+	var e error
+	if a.BoardPubKey, e = tag.GetPubKey(a.BoardPubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	if a.ThreadRefsStr == "" {
+		return nil
+	}
+	for _, refStr := range strings.Split(a.ThreadRefsStr, ",") {
+		ref, e := tag.GetHash(strings.TrimSpace(refStr))
+		if e != nil {
+			return ErrProcess(e, "thread reference")
+		}
+		a.ThreadRefs = append(a.ThreadRefs, ref)
+	}
+	return nil
+}
+
+type RetentionIn struct {
+	BoardPubKeyStr string
+	BoardPubKey    cipher.PubKey
+	DaysStr        string // 0 to never archive.
+	Days           int
+}
+
+func (a *RetentionIn) Process() error {
+	var e error
+	if a.BoardPubKey, e = tag.GetPubKey(a.BoardPubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	if a.Days, e = strconv.Atoi(a.DaysStr); e != nil {
+		return ErrProcess(e, "retention days")
+	}
+	return nil
+}
+
+type SubmissionPolicyIn struct {
+	BoardPubKeyStr   string
+	BoardPubKey      cipher.PubKey
+	MinReputationStr string // Empty for no minimum.
+	TrustedOnly      bool
+	Policy           *object.SubmissionPolicy
+}
+
+func (a *SubmissionPolicyIn) Process() error {
+	var e error
+	if a.BoardPubKey, e = tag.GetPubKey(a.BoardPubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	policy := &object.SubmissionPolicy{TrustedOnly: a.TrustedOnly}
+	if a.MinReputationStr != "" {
+		if policy.MinReputation, e = strconv.Atoi(a.MinReputationStr); e != nil {
+			return ErrProcess(e, "minimum reputation")
+		}
+	}
+	if *policy != (object.SubmissionPolicy{}) {
+		a.Policy = policy
+	}
+	return nil
+}
+
+type ArchivedIn struct {
+	BoardPubKeyStr string
+	BoardPubKey    cipher.PubKey
+	Archived       bool // False to unarchive.
+}
+
+func (a *ArchivedIn) Process() error {
+	var e error
+	if a.BoardPubKey, e = tag.GetPubKey(a.BoardPubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	return nil
+}
+
+type RegistryIn struct {
+	BoardPubKeyStr string
+	BoardPubKey    cipher.PubKey
+	Registry       bool // False to stop accepting board announcements.
+}
+
+func (a *RegistryIn) Process() error {
+	var e error
+	if a.BoardPubKey, e = tag.GetPubKey(a.BoardPubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	return nil
+}
+
+type LogsIn struct {
+	Module   string // Empty for all modules.
+	LevelStr string // Minimum level, empty for all levels.
+	Level    inform.Level
+	CountStr string // Maximum number of most recent entries, empty for all.
+	Count    int
+}
+
+func (a *LogsIn) Process() error {
+	var e error
+	if a.LevelStr != "" {
+		if a.Level, e = inform.ParseLevel(a.LevelStr); e != nil {
+			return ErrProcess(e, "log level")
+		}
+	}
+	if a.CountStr != "" {
+		if a.Count, e = strconv.Atoi(a.CountStr); e != nil {
+			return ErrProcess(e, "log entry count")
+		}
+	}
+	return nil
+}
+
+type LogLevelIn struct {
+	Module   string // Empty to set the level of modules that have none set.
+	LevelStr string
+	Level    inform.Level
+}
+
+func (a *LogLevelIn) Process() error {
+	var e error
+	if a.Level, e = inform.ParseLevel(a.LevelStr); e != nil {
+		return ErrProcess(e, "log level")
+	}
+	return nil
+}
+
+type SubscriptionOptionsIn struct {
+	PubKeyStr      string
+	PubKey         cipher.PubKey
+	AutoConnectStr string // Empty to keep as is.
+}
+
+func (a *SubscriptionOptionsIn) Process() error {
+	var e error
+	if a.PubKey, e = tag.GetPubKey(a.PubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	if a.AutoConnectStr != "" {
+		if _, e = strconv.ParseBool(a.AutoConnectStr); e != nil {
+			return ErrProcess(e, "auto connect")
+		}
+	}
+	return nil
+}
+
+// Apply applies the provided options to current options.
+func (a *SubscriptionOptionsIn) Apply(options object.SubscriptionOptions) object.SubscriptionOptions {
+	if a.AutoConnectStr != "" {
+		options.AutoConnect, _ = strconv.ParseBool(a.AutoConnectStr)
+	}
+	return options
+}
+
+type AnnounceBoardIn struct {
+	BoardPubKeyStr    string
+	BoardPubKey       cipher.PubKey
+	RegistryPubKeyStr string // Empty for all configured registries.
+	RegistryPubKey    cipher.PubKey
+}
+
+func (a *AnnounceBoardIn) Process() error {
+	var e error
+	if a.BoardPubKey, e = tag.GetPubKey(a.BoardPubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	if a.RegistryPubKeyStr != "" {
+		if a.RegistryPubKey, e = tag.GetPubKey(a.RegistryPubKeyStr); e != nil {
+			return ErrProcess(e, "registry public key")
+		}
+	}
+	return nil
+}
+
+type DiscoverBoardsIn struct {
+	Query             string
+	Category          string
+	RegistryPubKeyStr string // Empty for all configured registries.
+	RegistryPubKey    cipher.PubKey
+	StartIndexStr     string
+	StartIndex        uint
+	PageSizeStr       string
+	PageSize          uint
+}
+
+func (a *DiscoverBoardsIn) Process() error {
+	var e error
+	if a.RegistryPubKeyStr != "" {
+		if a.RegistryPubKey, e = tag.GetPubKey(a.RegistryPubKeyStr); e != nil {
+			return ErrProcess(e, "registry public key")
+		}
+	}
+	a.PageSize = math.MaxUint64
+	if a.StartIndexStr != "" {
+		i, e := strconv.ParseUint(a.StartIndexStr, 10, 32)
+		if e != nil {
+			return ErrProcess(e, "start index")
+		}
+		a.StartIndex = uint(i)
+	}
+	if a.PageSizeStr != "" {
+		n, e := strconv.ParseUint(a.PageSizeStr, 10, 32)
+		if e != nil {
+			return ErrProcess(e, "page size")
+		}
+		a.PageSize = uint(n)
+	}
 	return nil
 }
 
@@ -102,6 +568,23 @@ func (a *ImportBoardIn) Process() error {
 	return nil
 }
 
+type ImportArchiveIn struct {
+	FilePath  string
+	SecKeyStr string
+	SecKey    cipher.SecKey
+}
+
+func (a *ImportArchiveIn) Process() error {
+	var e error
+	if e = tag.CheckPath(a.FilePath); e != nil {
+		return ErrProcess(e, "file path")
+	}
+	if a.SecKey, e = tag.GetSecKey(a.SecKeyStr); e != nil {
+		return ErrProcess(e, "board secret key")
+	}
+	return nil
+}
+
 type NewBoardIn struct {
 	Name        string
 	Body        string
@@ -109,7 +592,8 @@ type NewBoardIn struct {
 	BoardPubKey cipher.PubKey
 	BoardSecKey cipher.SecKey
 	TS          int64
-	Content     *object.Content
+	BoardMetaIn
+	Content *object.Content
 }
 
 func (a *NewBoardIn) Process(subKeyTrans []*object.MessengerSubKeyTransport) error {
@@ -123,6 +607,10 @@ func (a *NewBoardIn) Process(subKeyTrans []*object.MessengerSubKeyTransport) err
 		a.TS = time.Now().UnixNano()
 	}
 
+	if e := a.BoardMetaIn.process(); e != nil {
+		return e
+	}
+
 	a.BoardPubKey, a.BoardSecKey = cipher.GenerateDeterministicKeyPair([]byte(a.Seed))
 
 	subKeys := make([]object.MessengerSubKey, len(subKeyTrans))
@@ -139,17 +627,81 @@ func (a *NewBoardIn) Process(subKeyTrans []*object.MessengerSubKeyTransport) err
 		Body:    a.Body,
 		SubKeys: subKeys,
 		Tags:    []string{},
+		Meta:    a.Meta,
 	})
 	return nil
 }
 
-type ThreadIn struct {
+type BoardMetaIn struct {
+	Description string
+	RulesStr    string // Newline separated.
+	TagsStr     string // Comma separated.
+	Category    string
+	IconStr     string // Hash of icon attachment.
+	Meta        *object.BoardMeta
+}
+
+// process obtains the board metadata, leaving it nil if nothing is set.
+func (a *BoardMetaIn) process() error {
+	var e error
+	meta := &object.BoardMeta{
+		Description: a.Description,
+		Category:    strings.TrimSpace(a.Category),
+		Icon:        a.IconStr,
+	}
+	for _, rule := range strings.Split(a.RulesStr, "\n") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			meta.Rules = append(meta.Rules, rule)
+		}
+	}
+	if a.TagsStr != "" {
+		if meta.Tags, e = tag.GetTags(a.TagsStr); e != nil {
+			return ErrProcess(e, "board tags")
+		}
+	}
+	if meta.Description == "" && meta.Category == "" && meta.Icon == "" &&
+		len(meta.Rules) == 0 && len(meta.Tags) == 0 {
+		return nil
+	}
+	if e := state.CheckBoardMeta(meta); e != nil {
+		return ErrProcess(e, "board metadata")
+	}
+	a.Meta = meta
+	return nil
+}
+
+type SetBoardMetaIn struct {
 	BoardPubKeyStr string
 	BoardPubKey    cipher.PubKey
-	ThreadRefStr   string
-	ThreadRef      cipher.SHA256
-	UserPubKeyStr  string
-	UserPubKey     cipher.PubKey
+	BoardMetaIn
+}
+
+func (a *SetBoardMetaIn) Process() error {
+	var e error
+	if a.BoardPubKey, e = tag.GetPubKey(a.BoardPubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	return a.BoardMetaIn.process()
+}
+
+type ThreadIn struct {
+	BoardPubKeyStr   string
+	BoardPubKey      cipher.PubKey
+	ThreadRefStr     string
+	ThreadRef        cipher.SHA256
+	UserPubKeyStr    string
+	UserPubKey       cipher.PubKey
+	SinceUnixStr     string
+	SinceUnix        int64
+	HashesOnly       bool
+	HideBlocked      bool
+	WithProfiles     bool
+	CollapseBelowStr string
+	CollapseBelow    *int
+	Muted            string
+	MinTrustStr      string
+	MinTrust         float64
+	ShowSpam         bool
 }
 
 func (a *ThreadIn) Process() error {
@@ -165,6 +717,66 @@ func (a *ThreadIn) Process() error {
 			return ErrProcess(e, "user's public key")
 		}
 	}
+	if a.SinceUnixStr != "" {
+		if a.SinceUnix, e = tag.GetUnixTime(a.SinceUnixStr); e != nil {
+			return ErrProcess(e, "since unix time")
+		}
+	}
+	if a.CollapseBelowStr != "" {
+		collapseBelow, e := strconv.Atoi(a.CollapseBelowStr)
+		if e != nil {
+			return ErrProcess(e, "collapse below score")
+		}
+		a.CollapseBelow = &collapseBelow
+	}
+	if a.MinTrustStr != "" {
+		if a.MinTrust, e = strconv.ParseFloat(a.MinTrustStr, 64); e != nil {
+			return ErrProcess(e, "minimum trust")
+		}
+	}
+	return nil
+}
+
+type PostIn struct {
+	BoardPubKeyStr string
+	BoardPubKey    cipher.PubKey
+	PostRefStr     string
+	PostRef        cipher.SHA256
+	UserPubKeyStr  string
+	UserPubKey     cipher.PubKey
+}
+
+func (a *PostIn) Process() error {
+	var e error
+	if a.BoardPubKey, e = tag.GetPubKey(a.BoardPubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	if a.PostRef, e = tag.GetHash(a.PostRefStr); e != nil {
+		return ErrProcess(e, "post hash")
+	}
+	if a.UserPubKeyStr != "" {
+		if a.UserPubKey, e = tag.GetPubKey(a.UserPubKeyStr); e != nil {
+			return ErrProcess(e, "user's public key")
+		}
+	}
+	return nil
+}
+
+type ContentIn struct {
+	BoardPubKeyStr string
+	BoardPubKey    cipher.PubKey
+	ContentRefStr  string
+	ContentRef     cipher.SHA256
+}
+
+func (a *ContentIn) Process() error {
+	var e error
+	if a.BoardPubKey, e = tag.GetPubKey(a.BoardPubKeyStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	if a.ContentRef, e = tag.GetHash(a.ContentRefStr); e != nil {
+		return ErrProcess(e, "content hash")
+	}
 	return nil
 }
 
@@ -458,6 +1070,24 @@ func (a *VotePostIn) Process() error {
 	return nil
 }
 
+type GraphQLIn struct {
+	Query        string
+	VariablesStr string // JSON object of variable name to value.
+	Variables    map[string]interface{}
+}
+
+func (a *GraphQLIn) Process() error {
+	if a.Query == "" {
+		return ErrProcess(nil, "query, as none is provided")
+	}
+	if a.VariablesStr != "" && a.VariablesStr != "null" {
+		if e := json.Unmarshal([]byte(a.VariablesStr), &a.Variables); e != nil {
+			return ErrProcess(e, "variables")
+		}
+	}
+	return nil
+}
+
 /*
 	<<< HELPER FUNCTIONS >>>
 */
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/skycoin/src/cipher"
+	"testing"
+)
+
+func TestSubscriptionOptionsIn(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	var (
+		enabled  = object.SubscriptionOptions{AutoConnect: true}
+		disabled = object.SubscriptionOptions{AutoConnect: false}
+	)
+
+	cases := []struct {
+		name     string
+		in       SubscriptionOptionsIn
+		current  object.SubscriptionOptions
+		expected object.SubscriptionOptions
+		errType  int
+	}{
+		{"keep", SubscriptionOptionsIn{PubKeyStr: pk.Hex()}, disabled, disabled, 0},
+		{"enable", SubscriptionOptionsIn{PubKeyStr: pk.Hex(), AutoConnectStr: "true"}, disabled, enabled, 0},
+		{"disable", SubscriptionOptionsIn{PubKeyStr: pk.Hex(), AutoConnectStr: "false"}, enabled, disabled, 0},
+		{"invalid_auto_connect", SubscriptionOptionsIn{PubKeyStr: pk.Hex(), AutoConnectStr: "maybe"}, enabled, enabled, boo.InvalidInput},
+		{"invalid_public_key", SubscriptionOptionsIn{PubKeyStr: "invalid"}, enabled, enabled, boo.InvalidInput},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := c.in.Process()
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to process input:", e)
+			}
+			if c.in.PubKey != pk {
+				t.Errorf("got public key '%s', expected '%s'", c.in.PubKey.Hex(), pk.Hex())
+			}
+			if got := c.in.Apply(c.current); got != c.expected {
+				t.Errorf("got options %+v, expected %+v", got, c.expected)
+			}
+		})
+	}
+}
@@ -2,16 +2,74 @@ package store
 
 import (
 	"context"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/inform"
+	"github.com/skycoin/bbs/src/misc/typ"
 	"github.com/skycoin/bbs/src/store/object"
 	"github.com/skycoin/bbs/src/store/state"
 	"github.com/skycoin/skycoin/src/cipher"
+	"net/http"
+	"strings"
 )
 
+// ErrorOut represents an error, as reported to clients.
+type ErrorOut struct {
+	Type    int    `json:"type"`    // Numeric error type (see boo).
+	Code    string `json:"code"`    // Machine-readable error code (see boo.Code).
+	Title   string `json:"title"`   // Human-readable error type.
+	Details string `json:"details"` // Error message.
+	Status  int    `json:"-"`       // HTTP status of the error.
+}
+
+// NewErrorOut obtains the output of an error, mapping it's type to a code and HTTP status.
+func NewErrorOut(e error) *ErrorOut {
+	t := boo.Type(e)
+	out := &ErrorOut{
+		Type:    t,
+		Code:    boo.Code(t),
+		Title:   boo.Message(t),
+		Details: e.Error(),
+	}
+	if d := out.Details; d != "" {
+		out.Details = strings.ToUpper(d[:1]) + d[1:] + "."
+	}
+	switch t {
+	case boo.Unknown, boo.Internal:
+		out.Status = http.StatusInternalServerError
+	case boo.NotAuthorised, boo.NotMaster:
+		out.Status = http.StatusUnauthorized
+	case boo.NotAllowed:
+		out.Status = http.StatusForbidden
+	case boo.NotFound:
+		out.Status = http.StatusNotFound
+	case boo.AlreadyExists:
+		out.Status = http.StatusConflict
+	case boo.TooManyRequests:
+		out.Status = http.StatusTooManyRequests
+	default:
+		out.Status = http.StatusBadRequest
+	}
+	return out
+}
+
 type SubmissionOut struct {
 	NewSubmission   *object.ContentRep `json:"new_submission"`
 	NewVotesSummary *state.VoteRepView `json:"new_votes_summary"`
 }
 
+type WatchlistOut struct {
+	Threads []*state.WatchlistItem `json:"threads"`
+}
+
+type BoardVersionOut struct {
+	PubKey  string `json:"public_key"`
+	Version uint64 `json:"version"`
+}
+
+type TrustGraphOut struct {
+	Edges []state.TrustEdge `json:"edges"`
+}
+
 type MessengersOut struct {
 	Connections []*object.MessengerConnection `json:"connections"`
 }
@@ -37,27 +95,39 @@ func getAvailableBoardsOut(pks []cipher.PubKey) *AvailableBoardsOut {
 }
 
 type ConnectionsOut struct {
-	ActiveConnections []object.Connection `json:"connections"`
-	SavedConnections  []object.Connection `json:"saved_connections"`
+	ActiveConnections     []object.Connection `json:"connections"`
+	SavedConnections      []object.Connection `json:"saved_connections"`
+	DiscoveredConnections []object.Connection `json:"discovered_connections"` // Discovered from peers of remote boards.
 }
 
-func getConnectionsOut(_ context.Context, active, saved []object.Connection) *ConnectionsOut {
+func getConnectionsOut(_ context.Context, active, saved, discovered []object.Connection) *ConnectionsOut {
 	return &ConnectionsOut{
-		ActiveConnections: active,
-		SavedConnections:  saved,
+		ActiveConnections:     active,
+		SavedConnections:      saved,
+		DiscoveredConnections: discovered,
 	}
 }
 
+type IsSubscribedOut struct {
+	PubKey       string `json:"public_key"`
+	IsSubscribed bool   `json:"is_subscribed"`
+}
+
 type SubscriptionsOut struct {
-	Subscriptions []string `json:"subscriptions"`
+	Subscriptions []string                              `json:"subscriptions"`
+	Options       map[string]object.SubscriptionOptions `json:"options"` // Of public key.
 }
 
-func getSubscriptionsOut(_ context.Context, ss []cipher.PubKey) *SubscriptionsOut {
+func getSubscriptionsOut(_ context.Context, ss []cipher.PubKey, options func(cipher.PubKey) (object.SubscriptionOptions, error)) *SubscriptionsOut {
 	out := &SubscriptionsOut{
 		Subscriptions: make([]string, len(ss)),
+		Options:       make(map[string]object.SubscriptionOptions, len(ss)),
 	}
 	for i, s := range ss {
 		out.Subscriptions[i] = s.Hex()
+		if opts, e := options(s); e == nil {
+			out.Options[s.Hex()] = opts
+		}
 	}
 	return out
 }
@@ -84,6 +154,27 @@ func getBoardOut(v interface{}) *BoardOut {
 	}
 }
 
+type AnnounceBoardOut struct {
+	Board      *state.Announcement `json:"board"`
+	Registries []string            `json:"registries"` // Registries announced to.
+}
+
+type DiscoverBoardsOut struct {
+	Meta   *typ.PaginatedOutput  `json:"meta"`
+	Boards []*state.Announcement `json:"boards"`
+}
+
+func getDiscoverBoardsOut(meta *typ.PaginatedOutput, announcements map[string]*state.Announcement) *DiscoverBoardsOut {
+	out := &DiscoverBoardsOut{
+		Meta:   meta,
+		Boards: make([]*state.Announcement, len(meta.Data)),
+	}
+	for i, bpk := range meta.Data {
+		out.Boards[i] = announcements[bpk]
+	}
+	return out
+}
+
 type ExportBoardOut struct {
 	FilePath string             `json:"file_path"`
 	Board    *object.ContentRep `json:"board"`
@@ -95,3 +186,49 @@ func getExportBoardOut(path string, pages *object.PagesJSON) *ExportBoardOut {
 		Board:    pages.BoardPage.Board.ToRep(),
 	}
 }
+
+type ImportArchiveOut struct {
+	FilePath string                     `json:"file_path"`
+	Board    *object.ContentRep         `json:"board"`
+	Result   *state.ImportArchiveResult `json:"result"`
+}
+
+func getImportArchiveOut(path string, archive *state.Archive, result *state.ImportArchiveResult) *ImportArchiveOut {
+	return &ImportArchiveOut{
+		FilePath: path,
+		Board: &object.ContentRep{
+			Header: archive.Content.Header,
+			Body:   archive.Content.Body,
+		},
+		Result: result,
+	}
+}
+
+type DeleteUserContentOut struct {
+	UserPubKey   string `json:"user_public_key"`
+	DeletedCount int    `json:"deleted_count"`
+}
+
+func getDeleteUserContentOut(upk string, count int) *DeleteUserContentOut {
+	return &DeleteUserContentOut{
+		UserPubKey:   upk,
+		DeletedCount: count,
+	}
+}
+
+type LogsOut struct {
+	Levels  map[string]string `json:"levels"` // Levels of modules, the level of others under the empty key.
+	Entries []*inform.Entry   `json:"entries"`
+}
+
+func getLogsOut(entries []*inform.Entry) *LogsOut {
+	levels := inform.Levels()
+	out := &LogsOut{
+		Levels:  make(map[string]string, len(levels)),
+		Entries: entries,
+	}
+	for module, level := range levels {
+		out.Levels[module] = level.String()
+	}
+	return out
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"github.com/skycoin/bbs/src/misc/tag"
 	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/bbs/src/store/state"
 	"github.com/skycoin/skycoin/src/cipher"
 	"time"
 )
@@ -241,3 +242,102 @@ func (a *FinalizeSubmissionIn) Process() error {
 	}
 	return nil
 }
+
+type PrepareUserProfileIn struct {
+	OfBoardStr     string
+	DisplayNameStr string
+	AvatarRefStr   string
+	CreatorStr     string
+	CreatorPubKey  cipher.PubKey
+	Data           *object.Body
+}
+
+func (a *PrepareUserProfileIn) Process() error {
+	var e error
+	if _, e = tag.GetPubKey(a.OfBoardStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	if a.CreatorPubKey, e = tag.GetPubKey(a.CreatorStr); e != nil {
+		return ErrProcess(e, "creator's public key")
+	}
+	a.Data = &object.Body{
+		Type:      object.V5UserProfileType,
+		TS:        time.Now().UnixNano(),
+		OfBoard:   a.OfBoardStr,
+		Name:      a.DisplayNameStr,
+		AvatarRef: a.AvatarRefStr,
+		Creator:   a.CreatorStr,
+	}
+	return nil
+}
+
+type PrepareKeyRotationIn struct {
+	OfBoardStr    string
+	OfBoard       cipher.PubKey
+	OfUserStr     string // Public key rotated from.
+	ProofStr      string // Signature of key rotated from, of object.KeyRotationHash(CreatorStr).
+	CreatorStr    string // Public key rotated to.
+	CreatorPubKey cipher.PubKey
+	Data          *object.Body
+}
+
+func (a *PrepareKeyRotationIn) Process() error {
+	var e error
+	if a.OfBoard, e = tag.GetPubKey(a.OfBoardStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	if a.CreatorPubKey, e = tag.GetPubKey(a.CreatorStr); e != nil {
+		return ErrProcess(e, "creator's public key")
+	}
+	a.Data = &object.Body{
+		Type:    object.V5KeyRotationType,
+		TS:      time.Now().UnixNano(),
+		OfBoard: a.OfBoardStr,
+		OfUser:  a.OfUserStr,
+		Proof:   a.ProofStr,
+		Creator: a.CreatorStr,
+	}
+	if e = state.ValidateSubmission(a.Data, a.OfBoard); e != nil {
+		return ErrProcess(e, "key rotation")
+	}
+	return nil
+}
+
+type PrepareAttachmentIn struct {
+	OfBoardStr    string
+	OfBoard       cipher.PubKey
+	OfPostStr     string
+	Name          string
+	MediaType     string
+	File          []byte
+	CreatorStr    string
+	CreatorPubKey cipher.PubKey
+	Data          *object.Body
+}
+
+func (a *PrepareAttachmentIn) Process() error {
+	var e error
+	if a.OfBoard, e = tag.GetPubKey(a.OfBoardStr); e != nil {
+		return ErrProcess(e, "board public key")
+	}
+	if _, e = tag.GetHash(a.OfPostStr); e != nil {
+		return ErrProcess(e, "post hash")
+	}
+	if a.CreatorPubKey, e = tag.GetPubKey(a.CreatorStr); e != nil {
+		return ErrProcess(e, "creator's public key")
+	}
+	a.Data = &object.Body{
+		Type:      object.V5AttachmentType,
+		TS:        time.Now().UnixNano(),
+		OfBoard:   a.OfBoardStr,
+		OfPost:    a.OfPostStr,
+		Name:      a.Name,
+		MediaType: a.MediaType,
+		Data:      a.File,
+		Creator:   a.CreatorStr,
+	}
+	if e = state.ValidateSubmission(a.Data, a.OfBoard); e != nil {
+		return ErrProcess(e, "attachment")
+	}
+	return nil
+}
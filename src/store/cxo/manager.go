@@ -14,6 +14,7 @@ import (
 	"github.com/skycoin/cxo/skyobject"
 	"github.com/skycoin/net/skycoin-messenger/factory"
 	"github.com/skycoin/skycoin/src/cipher"
+	"io"
 	"io/ioutil"
 	log2 "log"
 	"os"
@@ -23,7 +24,6 @@ import (
 	"strings"
 	"sync"
 	"time"
-	"github.com/skycoin/cxo/node/gnet"
 )
 
 const (
@@ -32,6 +32,7 @@ const (
 	FileName                 = "bbs.json"
 	ExportSubDir             = "exports"
 	ExportFileExt            = ".export"
+	SnapshotSubDir           = "snapshots"
 	BashAutoCompleteFileName = "bash_autocomplete"
 	RetryDuration            = time.Second * 5
 )
@@ -46,20 +47,28 @@ type ManagerConfig struct {
 	CXOPort                    *int     // CXO listening port.
 	CXORPCEnable               *bool    // Whether to enable CXO RPC.
 	CXORPCPort                 *int     // CXO RPC port.
+	Passphrase                 *string  // Passphrase to encrypt secret keys of file with, empty for none.
+	PEX                        *bool    // Whether to exchange peers of boards.
+	PEXAddress                 *string  // CXO address of this node advertised by master boards, empty for none.
+	Registries                 []string // Public keys of registry boards to announce and discover boards with.
 }
 
 // Manager manages interaction with CXO and storing/retrieving node configuration files.
 type Manager struct {
-	mux      sync.Mutex
-	c        *ManagerConfig
-	l        *log2.Logger
-	file     *object.CXOFileManager
-	node     *node.Node
-	compiler *state.Compiler
-	relay    *accord.Relay
-	wg       sync.WaitGroup
-	newRoots chan state.RootWrap
-	quit     chan struct{}
+	mux        sync.Mutex
+	c          *ManagerConfig
+	l          *log2.Logger
+	file       *object.CXOFileManager
+	node       *node.Node
+	compiler   *state.Compiler
+	relay      *accord.Relay
+	health     *healthTracker
+	reconnects *reconnector
+	pex        *pexTracker
+	adverts    *advertTracker
+	wg         sync.WaitGroup
+	newRoots   chan state.RootWrap
+	quit       chan struct{}
 }
 
 // NewManager creates a new CXO manager.
@@ -68,11 +77,16 @@ func NewManager(config *ManagerConfig, compilerConfig *state.CompilerConfig) *Ma
 		c: config,
 		l: inform.NewLogger(true, os.Stdout, LogPrefix),
 		file: object.NewCXOFileManager(&object.CXOFileManagerConfig{
-			Memory: config.Memory,
+			Memory:     config.Memory,
+			Passphrase: config.Passphrase,
 		}),
-		relay:    accord.NewRelay(),
-		newRoots: make(chan state.RootWrap, 10),
-		quit:     make(chan struct{}),
+		relay:      accord.NewRelay(),
+		health:     newHealthTracker(),
+		reconnects: newReconnector(),
+		pex:        newPEXTracker(),
+		adverts:    newAdvertTracker(),
+		newRoots:   make(chan state.RootWrap, 10),
+		quit:       make(chan struct{}),
 	}
 
 	// Prepare CXO node.
@@ -81,6 +95,10 @@ func NewManager(config *ManagerConfig, compilerConfig *state.CompilerConfig) *Ma
 	}
 
 	// Prepare CXO compiler.
+	if !*config.Memory && compilerConfig.SnapshotDir == nil {
+		snapshotDir := path.Join(*config.Config, SubDir, SnapshotSubDir)
+		compilerConfig.SnapshotDir = &snapshotDir
+	}
 	manager.compiler = state.NewCompiler(compilerConfig, manager.file, manager.newRoots, manager.node)
 
 	// Prepare messenger relay.
@@ -111,6 +129,9 @@ func NewManager(config *ManagerConfig, compilerConfig *state.CompilerConfig) *Ma
 
 	go manager.retryLoop()
 	go manager.relayLoop()
+	go manager.healthLoop()
+	go manager.reconnectLoop()
+	go manager.pexLoop()
 	return manager
 }
 
@@ -163,8 +184,12 @@ func (m *Manager) prepareNode() error {
 	c.RemoteClose = false
 	c.RPCAddress = "[::]:" + strconv.Itoa(*m.c.CXORPCPort)
 
+	// Dial once, as reconnections are scheduled by reconnectLoop with backoff.
+	c.Config.DialsLimit = 1
+
 	c.OnRootReceived = func(c *node.Conn, root *skyobject.Root) {
 		m.l.Printf("Receiving board '%s' (NOT FILLED)", root.Pub.Hex()[:5]+"...")
+		m.health.addRoot(c.Address(), root)
 		root.IsFull = false
 		m.newRoots <- state.RootWrap{Root: root}
 	}
@@ -181,34 +206,26 @@ func (m *Manager) prepareNode() error {
 		}
 
 		m.file.SetConnectionStatus(c.Address(), true)
+		m.health.setConnected(c.Address(), true)
+		m.reconnects.reset(c.Address())
 		m.l.Printf("Connected to '%s'", c.Address())
 	}
 
 	c.OnCloseConnection = func(c *node.Conn) {
 		m.file.SetConnectionStatus(c.Address(), false)
+		m.health.setConnected(c.Address(), false)
 		m.l.Printf("Disconnected from '%s'", c.Address())
-
-		go func() {
-			for {
-				time.Sleep(time.Second * 2)
-				switch c, e := m.node.Connect(c.Address()); e {
-				case nil, gnet.ErrAlreadyListen:
-					return
-				default:
-					if c == nil {
-						m.l.Printf("Reconnecting due to error: '%s'", e.Error())
-					} else {
-						m.l.Printf("Reconnecting to `%s` due to error: '%s'", c.Address(), e.Error())
-					}
-				}
-			}
-		}()
 	}
 
 	c.OnSubscribeRemote = func(c *node.Conn, feed cipher.PubKey) error {
+		m.health.addFeed(c.Address(), feed)
 		return nil
 	}
 
+	c.OnUnsubscribeRemote = func(c *node.Conn, feed cipher.PubKey) {
+		m.health.delFeed(c.Address(), feed)
+	}
+
 	var e error
 	if m.node, e = node.NewNode(c); e != nil {
 		return e
@@ -237,8 +254,16 @@ func (m *Manager) prepareFile() error {
 		}
 		m.SubscribeRemote(pk)
 	}
+	for _, pk := range m.GetRegistries() {
+		if !m.file.HasMasterSub(pk) {
+			m.SubscribeRemote(pk)
+		}
+	}
 
 	if e := m.file.RangeMasterSubs(func(pk cipher.PubKey, sk cipher.SecKey) {
+		if e := m.subscribeNode(pk); e != nil {
+			m.l.Println("prepareFile() subscribeNode() failed with error:", e)
+		}
 		if r, e := m.node.Container().LastRoot(pk); e != nil {
 			m.l.Println("prepareFile() LastRoot failed with error:", e)
 		} else {
@@ -262,6 +287,13 @@ func (m *Manager) prepareFile() error {
 	return nil
 }
 
+// saveFile saves the CXO file immediately, rather than on the next retry loop.
+func (m *Manager) saveFile() {
+	if e := m.file.Save(m.filePath()); e != nil {
+		m.l.Println("failed to save CXO file:", e)
+	}
+}
+
 func (m *Manager) filePath() string {
 	return path.Join(*m.c.Config, SubDir, FileName)
 }
@@ -314,11 +346,6 @@ func (m *Manager) relayLoop() {
 					m.node.ConnectToMessenger(address)
 				}
 			})
-			m.file.RangeConnections(func(address string, status bool) {
-				if status == false {
-					m.node.Pool().Dial(address)
-				}
-			})
 		case address := <-m.relay.Disconnections():
 			m.file.SetMessengerPK(address, cipher.PubKey{})
 			go m.compiler.EnsureSubmissionKeys(m.relay.SubmissionKeys())
@@ -401,7 +428,7 @@ func (m *Manager) SubmitToRemote(
 		// Attempt to submit.
 		goal, e := m.relay.SubmitToRemote(ctx, subKey.PubKey, submission)
 		if e != nil {
-			m.l.Println("\t\t\t- Failed to submit to remote, error: %v", e)
+			m.l.Printf("\t\t\t- Failed to submit to remote, error: %v", e)
 			m.l.Println("\t\t\t (SKIPPING)")
 			continue
 		}
@@ -447,40 +474,73 @@ func (m *Manager) GetAvailableBoards() []cipher.PubKey {
 	<<< CONNECTION >>>
 */
 
+// GetActiveConnections obtains the connections of the CXO node, with their live state.
 func (m *Manager) GetActiveConnections() []object.Connection {
 	connections := m.node.Connections()
+	feeds := m.node.Feeds()
 	out := make([]object.Connection, len(connections))
-	for i, conn := range m.node.Connections() {
+	for i, conn := range connections {
 		out[i] = object.Connection{
 			Address: conn.Address(),
 			State:   conn.Gnet().State().String(),
 		}
+		m.health.fill(&out[i], conn, feeds)
 	}
 	return out
 }
 
+// GetSavedConnections obtains the connections of the CXO file, with their live state.
 func (m *Manager) GetSavedConnections() []object.Connection {
 	out := make([]object.Connection, 0)
+	conns := make([]*node.Conn, 0)
 	m.file.RangeConnections(func(address string, status bool) {
 		if conn := m.node.Connection(address); conn != nil && status == true {
 			out = append(out, object.Connection{
 				Address: address,
 				State:   conn.Gnet().State().String(),
 			})
+			conns = append(conns, conn)
 		} else {
 			out = append(out, object.Connection{
 				Address: address,
 				State:   "CLOSED",
 			})
+			conns = append(conns, nil)
 		}
 	})
+	feeds := m.node.Feeds()
+	for i := range out {
+		out[i].Enabled = m.file.IsConnectionEnabled(out[i].Address)
+		m.health.fill(&out[i], conns[i], feeds)
+		m.reconnects.fill(&out[i])
+	}
 	return out
 }
 
 func (m *Manager) Connect(address string) error {
+	m.reconnects.reset(address)
 	return m.file.AddConnection(address)
 }
 
+// EnableConnection enables reconnecting to a saved connection, and attempts to
+// connect immediately.
+func (m *Manager) EnableConnection(address string) error {
+	if e := m.file.SetConnectionEnabled(address, true); e != nil {
+		return e
+	}
+	m.reconnects.reset(address)
+	return nil
+}
+
+// DisableConnection disables reconnecting to a saved connection, and closes it.
+func (m *Manager) DisableConnection(address string) error {
+	if e := m.file.SetConnectionEnabled(address, false); e != nil {
+		return e
+	}
+	m.reconnects.reset(address)
+	return m.disconnectNode(address)
+}
+
 //func (m *Manager) connectNode(address string) (*gnet.Conn, error) {
 //	if connection, e := m.node.Pool().Dial(address); e != nil {
 //		switch e {
@@ -498,6 +558,7 @@ func (m *Manager) Connect(address string) error {
 
 func (m *Manager) Disconnect(address string) error {
 	m.file.RemoveConnection(address)
+	m.reconnects.reset(address)
 	if e := m.disconnectNode(address); e != nil {
 		return e
 	}
@@ -528,6 +589,7 @@ func (m *Manager) SubscribeRemote(bpk cipher.PubKey) error {
 	if e := m.file.AddRemoteSub(bpk); e != nil {
 		return e
 	}
+	m.saveFile()
 	m.subscribeNode(bpk)
 	return nil
 }
@@ -536,10 +598,30 @@ func (m *Manager) SubscribeMaster(bpk cipher.PubKey, bsk cipher.SecKey) error {
 	if e := m.file.AddMasterSub(bpk, bsk); e != nil {
 		return e
 	}
+	m.saveFile()
 	m.subscribeNode(bpk)
 	return nil
 }
 
+// GetSubscriptionOptions obtains the options of a subscription.
+func (m *Manager) GetSubscriptionOptions(bpk cipher.PubKey) (object.SubscriptionOptions, error) {
+	return m.file.GetSubOptions(bpk)
+}
+
+// SetSubscriptionOptions sets the options of a subscription.
+func (m *Manager) SetSubscriptionOptions(bpk cipher.PubKey, options object.SubscriptionOptions) error {
+	if e := m.file.SetSubOptions(bpk, options); e != nil {
+		return e
+	}
+	m.saveFile()
+	return nil
+}
+
+func (m *Manager) IsSubscribed(bpk cipher.PubKey) bool {
+	return (m.file.HasMasterSub(bpk) || m.file.HasRemoteSub(bpk)) &&
+		m.compiler.IsSubscribed(bpk)
+}
+
 func (m *Manager) subscribeNode(bpk cipher.PubKey) error {
 	if e := m.node.AddFeed(bpk); e != nil {
 		return boo.WrapType(e, boo.Internal, "failed to add feed")
@@ -552,6 +634,7 @@ func (m *Manager) UnsubscribeRemote(bpk cipher.PubKey) error {
 		if e := m.file.RemoveSub(bpk); e != nil {
 			return e
 		}
+		m.saveFile()
 		m.unsubscribeNode(bpk)
 		return nil
 	}
@@ -564,6 +647,7 @@ func (m *Manager) UnsubscribeMaster(bpk cipher.PubKey) error {
 		if e := m.file.RemoveSub(bpk); e != nil {
 			return e
 		}
+		m.saveFile()
 		m.unsubscribeNode(bpk)
 		return nil
 	}
@@ -572,7 +656,9 @@ func (m *Manager) UnsubscribeMaster(bpk cipher.PubKey) error {
 }
 
 func (m *Manager) unsubscribeNode(bpk cipher.PubKey) {
-	m.compiler.DeleteBoard(bpk)
+	if e := m.compiler.RemoveBoard(bpk); e != nil {
+		m.l.Println(e)
+	}
 	m.node.DelFeed(bpk)
 }
 
@@ -584,6 +670,54 @@ func (m *Manager) GetBoardInstance(bpk cipher.PubKey) (*state.BoardInstance, err
 	return m.compiler.GetBoard(bpk)
 }
 
+func (m *Manager) GetUserWatchlist(upk string, lastSeen map[string]int64) ([]*state.WatchlistItem, error) {
+	return m.compiler.GetUserWatchlist(upk, lastSeen)
+}
+
+func (m *Manager) GetBoardVersion(bpk cipher.PubKey) (uint64, error) {
+	return m.compiler.BoardVersion(bpk)
+}
+
+func (m *Manager) GetBoardSyncStatus(bpk cipher.PubKey) (*state.BoardSyncStatus, error) {
+	return m.compiler.BoardSyncStatus(bpk)
+}
+
+func (m *Manager) GetRateLimit(upk cipher.PubKey) *state.RateLimitStatus {
+	return m.compiler.RateLimit(upk.Hex())
+}
+
+func (m *Manager) GetStats() *state.CompilerStats {
+	return m.compiler.Stats()
+}
+
+func (m *Manager) ListBoards() []*state.BoardInstanceStats {
+	return m.compiler.ListBoards()
+}
+
+func (m *Manager) GetBoardsPage(in *state.BoardsPageIn) (*state.BoardsPageOut, error) {
+	return m.compiler.GetBoardsPage(in)
+}
+
+func (m *Manager) GetAggregatedFeed(in *state.AggregatedFeedIn) (*state.AggregatedFeedOut, error) {
+	return m.compiler.GetAggregatedFeed(in)
+}
+
+func (m *Manager) SearchAll(in *state.GlobalSearchIn) (*state.GlobalSearchOut, error) {
+	return m.compiler.SearchAll(in)
+}
+
+func (m *Manager) OnBoardUpdated(hook state.BoardUpdatedHook) {
+	m.compiler.OnBoardUpdated(hook)
+}
+
+func (m *Manager) OnBoardError(hook state.BoardErrorHook) {
+	m.compiler.OnBoardError(hook)
+}
+
+func (m *Manager) SubscribeBoard(pk cipher.PubKey) (<-chan *state.BoardEvent, func(), error) {
+	return m.compiler.SubscribeBoard(pk)
+}
+
 func (m *Manager) GetBoards(ctx context.Context) ([]interface{}, []interface{}, error) {
 
 	var masterOut = []interface{}{}
@@ -620,6 +754,10 @@ func (m *Manager) GetBoards(ctx context.Context) ([]interface{}, []interface{},
 }
 
 func (m *Manager) NewBoard(content *object.Content, pk cipher.PubKey, sk cipher.SecKey) error {
+	if e := checkKeyPair(pk, sk); e != nil {
+		return e
+	}
+
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
@@ -636,6 +774,18 @@ func (m *Manager) NewBoard(content *object.Content, pk cipher.PubKey, sk cipher.
 	}
 }
 
+// checkKeyPair ensures that the secret key is valid and corresponds to the public key.
+func checkKeyPair(pk cipher.PubKey, sk cipher.SecKey) error {
+	if e := sk.Verify(); e != nil {
+		return boo.WrapType(e, boo.InvalidRead, "invalid board secret key")
+	}
+	if cipher.PubKeyFromSecKey(sk) != pk {
+		return boo.Newf(boo.InvalidRead,
+			"secret key does not correspond to board public key '%s'", pk.Hex())
+	}
+	return nil
+}
+
 /*
 	<<< ADMIN >>>
 */
@@ -662,9 +812,8 @@ func (m *Manager) ImportBoard(ctx context.Context, in *object.PagesJSON) error {
 		pk = in.GetPubKey()
 		sk = in.GetSecKey()
 	)
-	if cipher.PubKeyFromSecKey(sk) != pk {
-		return boo.New(boo.InvalidRead,
-			"public key does not match secret key in exported board file")
+	if e := checkKeyPair(pk, sk); e != nil {
+		return boo.WrapType(e, boo.InvalidRead, "exported board file")
 	}
 	if m.file.HasRemoteSub(pk) {
 		m.unsubscribeNode(pk)
@@ -687,3 +836,45 @@ func (m *Manager) ImportBoard(ctx context.Context, in *object.PagesJSON) error {
 	}
 	return bi.WaitSeq(ctx, goal)
 }
+
+// ExportArchive writes a portable archive of the board of public key 'pk'.
+func (m *Manager) ExportArchive(pk cipher.PubKey, w io.Writer) error {
+	bi, e := m.GetBoardInstance(pk)
+	if e != nil {
+		return e
+	}
+	return bi.Viewer().ExportBoard(w)
+}
+
+// ImportArchive seeds a master board from an archive. The board is created from
+// the archived board content if it is not already a master board of this node.
+func (m *Manager) ImportArchive(ctx context.Context, archive *state.Archive, sk cipher.SecKey) (*state.ImportArchiveResult, error) {
+	pk, e := archive.GetPubKey()
+	if e != nil {
+		return nil, boo.WrapType(e, boo.InvalidRead, "archive")
+	}
+	if e := checkKeyPair(pk, sk); e != nil {
+		return nil, e
+	}
+	if m.file.HasRemoteSub(pk) {
+		m.unsubscribeNode(pk)
+	}
+	if m.file.HasMasterSub(pk) == false {
+		content, e := archive.Content.ToContent()
+		if e != nil {
+			return nil, e
+		}
+		if e := m.NewBoard(content, pk, sk); e != nil {
+			return nil, e
+		}
+	}
+	bi, e := m.GetBoardInstance(pk)
+	if e != nil {
+		return nil, e
+	}
+	result, goal, e := bi.ImportArchive(archive)
+	if e != nil {
+		return nil, e
+	}
+	return result, bi.WaitSeq(ctx, goal)
+}
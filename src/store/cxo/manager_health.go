@@ -0,0 +1,156 @@
+package cxo
+
+import (
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/cxo/node"
+	"github.com/skycoin/cxo/skyobject"
+	"github.com/skycoin/skycoin/src/cipher"
+	"sync"
+	"time"
+)
+
+// HealthDuration is the interval between health checks of connections.
+const HealthDuration = time.Second * 10
+
+// connHealth records the live state of a connection, gathered from CXO callbacks
+// and health checks.
+type connHealth struct {
+	connected bool
+	lastSeen  time.Time
+	latency   time.Duration
+	bytes     uint64
+	feeds     map[cipher.PubKey]struct{} // Feeds known to be shared with peer.
+}
+
+// healthTracker records the live state of connections of the CXO node, by address.
+type healthTracker struct {
+	mux   sync.Mutex
+	conns map[string]*connHealth
+}
+
+func newHealthTracker() *healthTracker {
+	return &healthTracker{conns: make(map[string]*connHealth)}
+}
+
+// get obtains the health of a connection, creating it if needed.
+// Lock should be held by caller.
+func (t *healthTracker) get(address string) *connHealth {
+	h, ok := t.conns[address]
+	if !ok {
+		h = &connHealth{feeds: make(map[cipher.PubKey]struct{})}
+		t.conns[address] = h
+	}
+	return h
+}
+
+func (t *healthTracker) setConnected(address string, connected bool) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	h := t.get(address)
+	h.connected = connected
+	h.lastSeen = time.Now()
+	if !connected {
+		h.latency = 0
+	}
+}
+
+func (t *healthTracker) addRoot(address string, root *skyobject.Root) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	h := t.get(address)
+	h.bytes += uint64(len(root.Encode()))
+	h.feeds[root.Pub] = struct{}{}
+}
+
+func (t *healthTracker) addFeed(address string, pk cipher.PubKey) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.get(address).feeds[pk] = struct{}{}
+}
+
+func (t *healthTracker) delFeed(address string, pk cipher.PubKey) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	delete(t.get(address).feeds, pk)
+}
+
+// setFeeds replaces the feeds shared with a peer, as obtained from the peer itself.
+func (t *healthTracker) setFeeds(address string, pks []cipher.PubKey) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	h := t.get(address)
+	h.feeds = make(map[cipher.PubKey]struct{}, len(pks))
+	for _, pk := range pks {
+		h.feeds[pk] = struct{}{}
+	}
+}
+
+func (t *healthTracker) setLatency(address string, latency time.Duration) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.get(address).latency = latency
+}
+
+// fill fills a connection's live state. 'conn' is nil if the node is not connected,
+// and 'feeds' are the feeds of the node.
+func (t *healthTracker) fill(out *object.Connection, conn *node.Conn, feeds []cipher.PubKey) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	h, ok := t.conns[out.Address]
+	if !ok {
+		h = &connHealth{}
+	}
+	lastSeen := h.lastSeen
+	if conn != nil {
+		out.Connected = true
+		if lastRead := conn.Gnet().LastRead(); lastRead.After(lastSeen) {
+			lastSeen = lastRead
+		}
+		out.Latency = int64(h.latency)
+	}
+	if !lastSeen.IsZero() {
+		out.LastSeen = lastSeen.UnixNano()
+	}
+	out.BytesSynced = h.bytes
+	for _, pk := range feeds {
+		if _, ok := h.feeds[pk]; ok {
+			out.Subscriptions++
+		}
+	}
+}
+
+// checkHealth measures the round-trip latency of a connection by requesting the
+// peer's list of feeds. A non-public peer still responds (with a rejection), so
+// latency is measured regardless, while shared feeds are only updated from public peers.
+func (m *Manager) checkHealth(conn *node.Conn) {
+	start := time.Now()
+	pks, e := conn.ListOfFeeds()
+	switch e {
+	case nil:
+		m.health.setLatency(conn.Address(), time.Since(start))
+		m.health.setFeeds(conn.Address(), pks)
+	case node.ErrNonPublicPeer:
+		m.health.setLatency(conn.Address(), time.Since(start))
+	default:
+		m.l.Printf("Health check of '%s' failed: %v", conn.Address(), e)
+	}
+}
+
+func (m *Manager) healthLoop() {
+	m.wg.Add(1)
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(HealthDuration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.quit:
+			return
+		case <-ticker.C:
+			for _, conn := range m.node.Connections() {
+				go m.checkHealth(conn)
+			}
+		}
+	}
+}
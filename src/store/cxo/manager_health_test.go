@@ -0,0 +1,87 @@
+package cxo
+
+import (
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/cxo/skyobject"
+	"github.com/skycoin/skycoin/src/cipher"
+	"testing"
+	"time"
+)
+
+func TestHealthTracker_fill(t *testing.T) {
+	const (
+		address = "127.0.0.1:8998"
+		unknown = "127.0.0.1:8999"
+	)
+	var (
+		apk, _ = cipher.GenerateDeterministicKeyPair([]byte("a"))
+		bpk, _ = cipher.GenerateDeterministicKeyPair([]byte("b"))
+		cpk, _ = cipher.GenerateDeterministicKeyPair([]byte("c"))
+		root   = &skyobject.Root{Pub: cpk}
+		feeds  = []cipher.PubKey{apk, bpk, cpk}
+	)
+
+	cases := []struct {
+		name          string
+		address       string
+		change        func(h *healthTracker)
+		lastSeen      bool // Whether last seen is set.
+		bytesSynced   uint64
+		subscriptions int
+	}{
+		{"unknown", unknown, func(h *healthTracker) {}, false, 0, 0},
+		{"connected", address, func(h *healthTracker) {
+			h.setConnected(address, true)
+		}, true, 0, 0},
+		{"feeds", address, func(h *healthTracker) {
+			h.addFeed(address, apk)
+			h.addFeed(address, bpk)
+			h.delFeed(address, bpk)
+		}, false, 0, 1},
+		{"replaced_feeds", address, func(h *healthTracker) {
+			h.addFeed(address, apk)
+			h.setFeeds(address, []cipher.PubKey{bpk, cpk})
+		}, false, 0, 2},
+		{"root", address, func(h *healthTracker) {
+			h.addRoot(address, root)
+			h.addRoot(address, root)
+		}, false, 2 * uint64(len(root.Encode())), 1},
+		{"disconnected", address, func(h *healthTracker) {
+			h.setConnected(address, true)
+			h.setLatency(address, time.Millisecond)
+			h.setConnected(address, false)
+		}, true, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := newHealthTracker()
+			c.change(h)
+
+			out := object.Connection{Address: c.address}
+			h.fill(&out, nil, feeds)
+			if out.Connected || out.Latency != 0 {
+				t.Errorf("got connected %v with latency %d, expected neither without a connection",
+					out.Connected, out.Latency)
+			}
+			if (out.LastSeen != 0) != c.lastSeen {
+				t.Errorf("got last seen %d, expected set %v", out.LastSeen, c.lastSeen)
+			}
+			if out.BytesSynced != c.bytesSynced || out.Subscriptions != c.subscriptions {
+				t.Errorf("got %d bytes synced and %d subscriptions, expected %d and %d",
+					out.BytesSynced, out.Subscriptions, c.bytesSynced, c.subscriptions)
+			}
+		})
+	}
+
+	// Latency is reset on disconnection.
+	h := newHealthTracker()
+	h.setConnected(address, true)
+	h.setLatency(address, time.Millisecond)
+	if got := h.conns[address].latency; got != time.Millisecond {
+		t.Errorf("got latency %v, expected %v", got, time.Millisecond)
+	}
+	h.setConnected(address, false)
+	if got := h.conns[address].latency; got != 0 {
+		t.Errorf("got latency %v after disconnection, expected none", got)
+	}
+}
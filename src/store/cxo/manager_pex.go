@@ -0,0 +1,244 @@
+package cxo
+
+import (
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/bbs/src/store/state"
+	"github.com/skycoin/cxo/node"
+	"github.com/skycoin/skycoin/src/cipher"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Peer exchange (PEX). Master boards advertise the addresses of healthy peers that
+// share them (and of this node, if configured) in the board itself, so that they are
+// synced with the board. Nodes subscribed to remote boards dial the advertised peers.
+const (
+	PEXDuration       = time.Second * 30
+	PEXMaxAttempts    = 3                // Failed attempts after which a discovered peer is dropped.
+	PEXForgetDuration = time.Minute * 10 // Duration a dropped peer is not rediscovered.
+	PEXRetainDuration = time.Minute * 2  // Duration an unhealthy peer is still advertised.
+)
+
+// pexTracker records peers discovered from remote boards, by address.
+type pexTracker struct {
+	mux     sync.Mutex
+	peers   map[string]struct{}
+	dropped map[string]time.Time // Time at which dropped peers can be rediscovered.
+}
+
+func newPEXTracker() *pexTracker {
+	return &pexTracker{
+		peers:   make(map[string]struct{}),
+		dropped: make(map[string]time.Time),
+	}
+}
+
+// discover adds a peer, unless it was dropped recently or the maximum is reached.
+func (t *pexTracker) discover(address string, now time.Time) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if _, ok := t.peers[address]; ok {
+		return true
+	}
+	if until, ok := t.dropped[address]; ok {
+		if now.Before(until) {
+			return false
+		}
+		delete(t.dropped, address)
+	}
+	if len(t.peers) >= state.MaxPeers {
+		return false
+	}
+	t.peers[address] = struct{}{}
+	return true
+}
+
+func (t *pexTracker) drop(address string, now time.Time) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	delete(t.peers, address)
+	t.dropped[address] = now.Add(PEXForgetDuration)
+}
+
+func (t *pexTracker) list() []string {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	out := make([]string, 0, len(t.peers))
+	for address := range t.peers {
+		out = append(out, address)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// advertTracker records when advertised peers of master boards were last healthy,
+// so that peers are only withdrawn after staying unhealthy for PEXRetainDuration.
+// This avoids republishing boards each time the health of a peer flaps.
+type advertTracker struct {
+	mux  sync.Mutex
+	seen map[cipher.PubKey]map[string]time.Time // Key: board, Value: last healthy time by address.
+}
+
+func newAdvertTracker() *advertTracker {
+	return &advertTracker{
+		seen: make(map[cipher.PubKey]map[string]time.Time),
+	}
+}
+
+// peers records the currently healthy peers of a board, and obtains the peers to
+// advertise: those that were healthy within PEXRetainDuration.
+func (t *advertTracker) peers(pk cipher.PubKey, healthy []string, now time.Time) []string {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	seen, ok := t.seen[pk]
+	if !ok {
+		seen = make(map[string]time.Time)
+		t.seen[pk] = seen
+	}
+	for _, address := range healthy {
+		seen[address] = now
+	}
+	out := make([]string, 0, len(seen))
+	for address, last := range seen {
+		if now.Sub(last) > PEXRetainDuration {
+			delete(seen, address)
+			continue
+		}
+		out = append(out, address)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// healthy determines whether a connection shares a feed, and responded to it's
+// last health check.
+func (t *healthTracker) healthy(address string, pk cipher.PubKey) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	h, ok := t.conns[address]
+	if !ok || !h.connected || h.latency == 0 {
+		return false
+	}
+	_, ok = h.feeds[pk]
+	return ok
+}
+
+// advertisePeers ensures master boards advertise healthy peers that share them.
+// Only outgoing connections are advertised, as addresses of incoming connections
+// are not listening addresses. Peers that become unhealthy are kept advertised for
+// PEXRetainDuration.
+func (m *Manager) advertisePeers() {
+	var pks []cipher.PubKey
+	m.file.RangeMasterSubs(func(pk cipher.PubKey, _ cipher.SecKey) {
+		pks = append(pks, pk)
+	})
+	conns := m.node.Connections()
+	now := time.Now()
+	for _, pk := range pks {
+		var peers []string
+		if *m.c.PEXAddress != "" {
+			peers = append(peers, *m.c.PEXAddress)
+		}
+		for _, conn := range conns {
+			if !conn.Gnet().IsIncoming() && m.health.healthy(conn.Address(), pk) {
+				peers = append(peers, conn.Address())
+			}
+		}
+		peers = m.adverts.peers(pk, peers, now)
+		bi, e := m.compiler.GetBoard(pk)
+		if e != nil {
+			continue
+		}
+		if _, e := bi.EnsurePeers(peers); e != nil {
+			m.l.Printf("Failed to advertise peers of board '%s': %v", pk.Hex()[:5]+"...", e)
+		}
+	}
+}
+
+// discoverPeers dials peers advertised by remote boards with auto-connect enabled,
+// that are not already connected or saved.
+func (m *Manager) discoverPeers() {
+	var pks []cipher.PubKey
+	m.file.RangeRemoteSubs(func(pk cipher.PubKey) {
+		pks = append(pks, pk)
+	})
+	saved := make(map[string]struct{})
+	m.file.RangeConnections(func(address string, _ bool) {
+		saved[address] = struct{}{}
+	})
+	now := time.Now()
+	for _, pk := range pks {
+		if options, e := m.file.GetSubOptions(pk); e != nil || !options.AutoConnect {
+			continue
+		}
+		bi, e := m.compiler.GetBoard(pk)
+		if e != nil {
+			continue
+		}
+		for _, address := range bi.GetPeers() {
+			if _, ok := saved[address]; ok || address == *m.c.PEXAddress {
+				continue
+			}
+			m.pex.discover(address, now)
+		}
+	}
+	for _, address := range m.pex.list() {
+		if m.node.Connection(address) == nil && m.reconnects.begin(address, now) {
+			go m.connectDiscovered(address)
+		}
+	}
+}
+
+// connectDiscovered attempts to connect to a discovered peer, dropping it after
+// PEXMaxAttempts failed attempts.
+func (m *Manager) connectDiscovered(address string) {
+	m.reconnect(address)
+	if m.reconnects.attempts(address) >= PEXMaxAttempts {
+		m.l.Printf("Dropping discovered peer '%s'", address)
+		m.reconnects.reset(address)
+		m.pex.drop(address, time.Now())
+	}
+}
+
+// GetDiscoveredConnections obtains the peers discovered from remote boards, with
+// their live state.
+func (m *Manager) GetDiscoveredConnections() []object.Connection {
+	feeds := m.node.Feeds()
+	addresses := m.pex.list()
+	out := make([]object.Connection, len(addresses))
+	for i, address := range addresses {
+		out[i] = object.Connection{
+			Address: address,
+			State:   "CLOSED",
+			Enabled: true,
+		}
+		var conn *node.Conn
+		if conn = m.node.Connection(address); conn != nil {
+			out[i].State = conn.Gnet().State().String()
+		}
+		m.health.fill(&out[i], conn, feeds)
+		m.reconnects.fill(&out[i])
+	}
+	return out
+}
+
+func (m *Manager) pexLoop() {
+	m.wg.Add(1)
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(PEXDuration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.quit:
+			return
+		case <-ticker.C:
+			if *m.c.PEX {
+				m.advertisePeers()
+				m.discoverPeers()
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package cxo
+
+import (
+	"fmt"
+	"github.com/skycoin/bbs/src/store/state"
+	"github.com/skycoin/skycoin/src/cipher"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPEXTracker_dropAndForget(t *testing.T) {
+	const address = "127.0.0.1:8998"
+	var (
+		p   = newPEXTracker()
+		now = time.Unix(0, 0)
+	)
+
+	if !p.discover(address, now) {
+		t.Fatal("peer should be discovered")
+	}
+	if got := p.list(); !reflect.DeepEqual(got, []string{address}) {
+		t.Errorf("got peers %v, expected %v", got, []string{address})
+	}
+
+	p.drop(address, now)
+	if got := p.list(); len(got) != 0 {
+		t.Errorf("got peers %v after drop, expected none", got)
+	}
+	if p.discover(address, now.Add(PEXForgetDuration-time.Second)) {
+		t.Error("dropped peer should not be rediscovered before it is forgotten")
+	}
+	if !p.discover(address, now.Add(PEXForgetDuration)) {
+		t.Error("dropped peer should be rediscovered once forgotten")
+	}
+}
+
+func TestPEXTracker_discover_max(t *testing.T) {
+	var (
+		p   = newPEXTracker()
+		now = time.Unix(0, 0)
+	)
+	for i := 0; i < state.MaxPeers; i++ {
+		if !p.discover(fmt.Sprintf("127.0.0.1:%d", 9000+i), now) {
+			t.Fatalf("peer %d should be discovered", i)
+		}
+	}
+	if p.discover("127.0.0.2:9000", now) {
+		t.Error("peer should not be discovered past the maximum")
+	}
+	if !p.discover("127.0.0.1:9000", now) {
+		t.Error("known peer should still be reported as discovered")
+	}
+	if got := len(p.list()); got != state.MaxPeers {
+		t.Errorf("got %d peers, expected %d", got, state.MaxPeers)
+	}
+}
+
+func TestAdvertTracker_peers(t *testing.T) {
+	const (
+		a = "127.0.0.1:8998"
+		b = "127.0.0.1:8999"
+	)
+	var (
+		adverts  = newAdvertTracker()
+		pk, _    = cipher.GenerateDeterministicKeyPair([]byte("a"))
+		other, _ = cipher.GenerateDeterministicKeyPair([]byte("b"))
+		now      = time.Unix(0, 0)
+	)
+
+	if got := adverts.peers(pk, []string{b, a}, now); !reflect.DeepEqual(got, []string{a, b}) {
+		t.Errorf("got peers %v, expected %v", got, []string{a, b})
+	}
+
+	// Peers that flap are still advertised.
+	now = now.Add(PEXDuration)
+	if got := adverts.peers(pk, []string{a}, now); !reflect.DeepEqual(got, []string{a, b}) {
+		t.Errorf("got peers %v while b is unhealthy, expected %v", got, []string{a, b})
+	}
+	now = now.Add(PEXDuration)
+	if got := adverts.peers(pk, []string{a, b}, now); !reflect.DeepEqual(got, []string{a, b}) {
+		t.Errorf("got peers %v, expected %v", got, []string{a, b})
+	}
+
+	// Peers that stay unhealthy are withdrawn.
+	now = now.Add(PEXRetainDuration)
+	if got := adverts.peers(pk, []string{a}, now); !reflect.DeepEqual(got, []string{a, b}) {
+		t.Errorf("got peers %v at the end of retention, expected %v", got, []string{a, b})
+	}
+	now = now.Add(time.Second)
+	if got := adverts.peers(pk, []string{a}, now); !reflect.DeepEqual(got, []string{a}) {
+		t.Errorf("got peers %v after retention, expected %v", got, []string{a})
+	}
+
+	// Boards are tracked separately.
+	if got := adverts.peers(other, nil, now); len(got) != 0 {
+		t.Errorf("got peers %v for other board, expected none", got)
+	}
+}
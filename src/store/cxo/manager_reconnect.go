@@ -0,0 +1,156 @@
+package cxo
+
+import (
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/cxo/node/gnet"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Reconnection backoff. The delay before a reconnection attempt doubles with each
+// failed attempt, from ReconnectBaseDelay up to ReconnectMaxDelay, and is randomised
+// by up to ReconnectJitter (as a fraction of the delay) either way.
+const (
+	ReconnectTick      = time.Second
+	ReconnectBaseDelay = time.Second * 2
+	ReconnectMaxDelay  = time.Minute * 5
+	ReconnectJitter    = 0.2
+)
+
+// backoff records the reconnection attempts of a peer.
+type backoff struct {
+	attempts int       // Failed attempts since last connected.
+	next     time.Time // Time of next attempt.
+	dialing  bool      // Whether an attempt is in progress.
+}
+
+// reconnector schedules reconnection attempts of peers, by address.
+type reconnector struct {
+	mux   sync.Mutex
+	peers map[string]*backoff
+	rand  *rand.Rand
+}
+
+func newReconnector() *reconnector {
+	return &reconnector{
+		peers: make(map[string]*backoff),
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// delay obtains the delay before the next attempt, after given failed attempts.
+// Lock should be held by caller.
+func (r *reconnector) delay(attempts int) time.Duration {
+	d := ReconnectBaseDelay
+	for i := 1; i < attempts && d < ReconnectMaxDelay; i++ {
+		d *= 2
+	}
+	if d > ReconnectMaxDelay {
+		d = ReconnectMaxDelay
+	}
+	jitter := (r.rand.Float64()*2 - 1) * ReconnectJitter
+	return d + time.Duration(float64(d)*jitter)
+}
+
+// begin marks an attempt to reconnect to a peer as in progress.
+// Returns false if an attempt is in progress, or not yet due.
+func (r *reconnector) begin(address string, now time.Time) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	b, ok := r.peers[address]
+	if !ok {
+		b = new(backoff)
+		r.peers[address] = b
+	}
+	if b.dialing || now.Before(b.next) {
+		return false
+	}
+	b.dialing = true
+	return true
+}
+
+// failed records a failed attempt, and schedules the next.
+func (r *reconnector) failed(address string, now time.Time) time.Duration {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	b, ok := r.peers[address]
+	if !ok {
+		b = new(backoff)
+		r.peers[address] = b
+	}
+	b.dialing = false
+	b.attempts++
+	d := r.delay(b.attempts)
+	b.next = now.Add(d)
+	return d
+}
+
+// attempts obtains the failed attempts of a peer since last connected.
+func (r *reconnector) attempts(address string) int {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if b, ok := r.peers[address]; ok {
+		return b.attempts
+	}
+	return 0
+}
+
+// reset forgets the attempts of a peer, so that the next attempt is immediate.
+func (r *reconnector) reset(address string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	delete(r.peers, address)
+}
+
+// fill fills the reconnection state of a connection.
+func (r *reconnector) fill(out *object.Connection) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if b, ok := r.peers[out.Address]; ok && out.Enabled && !out.Connected {
+		out.Attempts = b.attempts
+		if !b.next.IsZero() {
+			out.NextAttempt = b.next.UnixNano()
+		}
+	}
+}
+
+// reconnect attempts to connect to a peer.
+func (m *Manager) reconnect(address string) {
+	switch _, e := m.node.Connect(address); e {
+	case nil, gnet.ErrAlreadyListen:
+		m.reconnects.reset(address)
+	default:
+		d := m.reconnects.failed(address, time.Now())
+		m.l.Printf("Reconnecting to '%s' failed with error: '%v', retrying in %v",
+			address, e, d.Round(time.Second))
+	}
+}
+
+// reconnectLoop reconnects to enabled peers of the CXO file that are disconnected.
+func (m *Manager) reconnectLoop() {
+	m.wg.Add(1)
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(ReconnectTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.quit:
+			return
+		case now := <-ticker.C:
+			var disconnected []string
+			m.file.RangeConnections(func(address string, status bool) {
+				if status == false {
+					disconnected = append(disconnected, address)
+				}
+			})
+			for _, address := range disconnected {
+				if m.file.IsConnectionEnabled(address) && m.reconnects.begin(address, now) {
+					go m.reconnect(address)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package cxo
+
+import (
+	"github.com/skycoin/bbs/src/store/object"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func newTestReconnector(seed int64) *reconnector {
+	r := newReconnector()
+	r.rand = rand.New(rand.NewSource(seed))
+	return r
+}
+
+// withinJitter determines whether d is within the jitter of expected.
+func withinJitter(d, expected time.Duration) bool {
+	spread := time.Duration(float64(expected) * ReconnectJitter)
+	return d >= expected-spread && d <= expected+spread
+}
+
+func TestReconnector_delay(t *testing.T) {
+	r := newTestReconnector(1)
+
+	cases := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, ReconnectBaseDelay},
+		{2, ReconnectBaseDelay * 2},
+		{3, ReconnectBaseDelay * 4},
+		{8, ReconnectBaseDelay * 128},
+		{9, ReconnectMaxDelay},
+		{100, ReconnectMaxDelay},
+	}
+	for _, c := range cases {
+		for i := 0; i < 50; i++ {
+			if d := r.delay(c.attempts); !withinJitter(d, c.expected) {
+				t.Fatalf("%d attempts: got delay %v, expected %v ±%v%%",
+					c.attempts, d, c.expected, ReconnectJitter*100)
+			}
+		}
+	}
+
+	// The same source results in the same delays.
+	a, b := newTestReconnector(2), newTestReconnector(2)
+	for i := 1; i < 10; i++ {
+		if da, db := a.delay(i), b.delay(i); da != db {
+			t.Fatalf("%d attempts: got delays %v and %v from the same source", i, da, db)
+		}
+	}
+}
+
+func TestReconnector_beginAndFailed(t *testing.T) {
+	const address = "127.0.0.1:8998"
+	var (
+		r   = newTestReconnector(1)
+		now = time.Unix(0, 0)
+	)
+
+	if !r.begin(address, now) {
+		t.Fatal("first attempt should begin immediately")
+	}
+	if r.begin(address, now) {
+		t.Fatal("attempt should not begin while another is in progress")
+	}
+
+	d := r.failed(address, now)
+	if !withinJitter(d, ReconnectBaseDelay) {
+		t.Errorf("got delay %v, expected %v", d, ReconnectBaseDelay)
+	}
+	if got := r.attempts(address); got != 1 {
+		t.Errorf("got %d attempts, expected %d", got, 1)
+	}
+	if r.begin(address, now.Add(d-time.Millisecond)) {
+		t.Error("attempt should not begin before it is due")
+	}
+	if !r.begin(address, now.Add(d)) {
+		t.Fatal("attempt should begin once due")
+	}
+
+	now = now.Add(d)
+	if d = r.failed(address, now); !withinJitter(d, ReconnectBaseDelay*2) {
+		t.Errorf("got delay %v, expected %v", d, ReconnectBaseDelay*2)
+	}
+
+	// Connection state reflects the backoff, only when enabled and disconnected.
+	conn := &object.Connection{Address: address, Enabled: true}
+	r.fill(conn)
+	if conn.Attempts != 2 || conn.NextAttempt != now.Add(d).UnixNano() {
+		t.Errorf("got %d attempts and next attempt at %d, expected %d and %d",
+			conn.Attempts, conn.NextAttempt, 2, now.Add(d).UnixNano())
+	}
+	disabled := &object.Connection{Address: address, Enabled: false}
+	if r.fill(disabled); disabled.Attempts != 0 || disabled.NextAttempt != 0 {
+		t.Errorf("disabled connection: got %d attempts and next attempt at %d, expected none",
+			disabled.Attempts, disabled.NextAttempt)
+	}
+
+	r.reset(address)
+	if got := r.attempts(address); got != 0 {
+		t.Errorf("got %d attempts after reset, expected %d", got, 0)
+	}
+	if !r.begin(address, now) {
+		t.Error("attempt should begin immediately after reset")
+	}
+}
@@ -0,0 +1,32 @@
+package cxo
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/tag"
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// GetRegistries obtains the public keys of the configured registry boards.
+// Invalid public keys are ignored.
+func (m *Manager) GetRegistries() []cipher.PubKey {
+	out := make([]cipher.PubKey, 0, len(m.c.Registries))
+	for _, pkStr := range m.c.Registries {
+		pk, e := tag.GetPubKey(pkStr)
+		if e != nil {
+			m.l.Printf("Ignoring invalid registry '%s': %v", pkStr, e)
+			continue
+		}
+		out = append(out, pk)
+	}
+	return out
+}
+
+// GetMasterSecKey obtains the secret key of a master board.
+func (m *Manager) GetMasterSecKey(bpk cipher.PubKey) (cipher.SecKey, error) {
+	sk, ok := m.file.GetMasterSubSecKey(bpk)
+	if !ok {
+		return cipher.SecKey{}, boo.Newf(boo.NotFound,
+			"not master of board '%s'", bpk.Hex())
+	}
+	return sk, nil
+}
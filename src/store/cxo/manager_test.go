@@ -0,0 +1,184 @@
+package cxo
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/store/cxo/setup"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/bbs/src/store/state"
+	"github.com/skycoin/cxo/node"
+	"github.com/skycoin/cxo/skyobject"
+	"github.com/skycoin/skycoin/src/cipher"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckKeyPair(t *testing.T) {
+	pk, sk := cipher.GenerateDeterministicKeyPair([]byte("board"))
+	otherPK, otherSK := cipher.GenerateDeterministicKeyPair([]byte("other"))
+
+	cases := []struct {
+		name    string
+		pk      cipher.PubKey
+		sk      cipher.SecKey
+		errType int
+	}{
+		{"valid", pk, sk, 0},
+		{"other_valid", otherPK, otherSK, 0},
+		{"mismatched", pk, otherSK, boo.InvalidRead},
+		{"empty_secret", pk, cipher.SecKey{}, boo.InvalidRead},
+		{"empty_public", cipher.PubKey{}, sk, boo.InvalidRead},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := checkKeyPair(c.pk, c.sk)
+			if c.errType == 0 {
+				if e != nil {
+					t.Errorf("got error %v, expected none", e)
+				}
+				return
+			}
+			if boo.Type(e) != c.errType {
+				t.Errorf("got error %v, expected type %d", e, c.errType)
+			}
+		})
+	}
+}
+
+// prepareManager prepares a manager of given configuration, with a node that
+// does not listen.
+func prepareManager(t *testing.T, config *ManagerConfig) (*Manager, func()) {
+	updateInterval := 1
+
+	c := node.NewConfig()
+	c.Skyobject.Registry = skyobject.NewRegistry(setup.PrepareRegistry)
+	c.InMemoryDB = true
+	c.EnableListener = false
+	c.EnableRPC = false
+
+	n, e := node.NewNode(c)
+	if e != nil {
+		t.Fatal("failed to create cxo node:", e)
+	}
+	m := &Manager{
+		c:    config,
+		file: object.NewCXOFileManager(&object.CXOFileManagerConfig{Memory: config.Memory}),
+		node: n,
+	}
+	m.compiler = state.NewCompiler(
+		&state.CompilerConfig{UpdateInterval: &updateInterval},
+		m.file, make(chan state.RootWrap), n)
+	return m, func() {
+		m.compiler.Close()
+		n.Close()
+	}
+}
+
+func TestManager_IsSubscribed(t *testing.T) {
+	memMode := true
+	m, quit := prepareManager(t, &ManagerConfig{Memory: &memMode})
+	defer quit()
+
+	var (
+		masterPK, masterSK = cipher.GenerateDeterministicKeyPair([]byte("master"))
+		remotePK, _        = cipher.GenerateDeterministicKeyPair([]byte("remote"))
+		fileOnlyPK, _      = cipher.GenerateDeterministicKeyPair([]byte("file only"))
+		feedOnlyPK, _      = cipher.GenerateDeterministicKeyPair([]byte("feed only"))
+	)
+	if e := m.file.AddMasterSub(masterPK, masterSK); e != nil {
+		t.Fatal("failed to add master subscription:", e)
+	}
+	for _, pk := range []cipher.PubKey{remotePK, fileOnlyPK} {
+		if e := m.file.AddRemoteSub(pk); e != nil {
+			t.Fatal("failed to add remote subscription:", e)
+		}
+	}
+	for _, pk := range []cipher.PubKey{masterPK, remotePK, feedOnlyPK} {
+		if e := m.node.AddFeed(pk); e != nil {
+			t.Fatal("failed to add feed:", e)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		pk       cipher.PubKey
+		expected bool
+	}{
+		{"master", masterPK, true},
+		{"remote", remotePK, true},
+		{"file_only", fileOnlyPK, false},
+		{"feed_only", feedOnlyPK, false},
+		{"unknown", cipher.PubKey{}, false},
+	}
+	for _, c := range cases {
+		if got := m.IsSubscribed(c.pk); got != c.expected {
+			t.Errorf("%s: got subscribed %v, expected %v", c.name, got, c.expected)
+		}
+	}
+}
+
+func TestManager_SetSubscriptionOptions(t *testing.T) {
+	dir, e := ioutil.TempDir("", "bbs_cxo_manager")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	if e := os.MkdirAll(filepath.Join(dir, SubDir), 0700); e != nil {
+		t.Fatal(e)
+	}
+
+	memMode := false
+	m, quit := prepareManager(t, &ManagerConfig{Memory: &memMode, Config: &dir})
+	defer quit()
+
+	var (
+		pk, _        = cipher.GenerateDeterministicKeyPair([]byte("remote"))
+		unknownPK, _ = cipher.GenerateDeterministicKeyPair([]byte("unknown"))
+		manual       = object.SubscriptionOptions{AutoConnect: false}
+	)
+	if e := m.SubscribeRemote(pk); e != nil {
+		t.Fatal("failed to subscribe:", e)
+	}
+	if got, e := m.GetSubscriptionOptions(pk); e != nil || got != object.DefaultSubscriptionOptions() {
+		t.Errorf("new subscription: got options %+v (%v), expected defaults", got, e)
+	}
+
+	cases := []struct {
+		name    string
+		pk      cipher.PubKey
+		errType int
+	}{
+		{"subscribed", pk, 0},
+		{"unknown", unknownPK, boo.NotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := m.SetSubscriptionOptions(c.pk, manual)
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				if _, e := m.GetSubscriptionOptions(c.pk); boo.Type(e) != c.errType {
+					t.Errorf("getting options: got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to set subscription options:", e)
+			}
+			if got, e := m.GetSubscriptionOptions(c.pk); e != nil || got != manual {
+				t.Errorf("got options %+v (%v), expected %+v", got, e, manual)
+			}
+		})
+	}
+
+	// Options are saved to file immediately.
+	saved := object.NewCXOFileManager(&object.CXOFileManagerConfig{Memory: &memMode})
+	if e := saved.Load(m.filePath()); e != nil {
+		t.Fatal("failed to load saved CXO file:", e)
+	}
+	if got, e := saved.GetSubOptions(pk); e != nil || got != manual {
+		t.Errorf("saved options: got %+v (%v), expected %+v", got, e, manual)
+	}
+}
@@ -0,0 +1,299 @@
+package store
+
+import (
+	"context"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/gql"
+	"github.com/skycoin/bbs/src/misc/tag"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/bbs/src/store/state"
+	"github.com/skycoin/skycoin/src/cipher"
+	"math"
+)
+
+// The GraphQL schema nests boards, threads, posts, votes and profiles as:
+//
+//	board(public_key, perspective) / boards(perspective) {
+//		public_key name body ts
+//		threads(first, sort_by) { ...Thread }
+//		thread(hash) { ...Thread }
+//		user(public_key) { ...Profile }
+//	}
+//	Thread { hash name body creator ts pinned archived post_count votes author posts(first) }
+//	Post { hash name body creator ts reply_to votes author }
+//	Votes { up down voted_up voted_down }
+//	Profile { public_key display_name avatar_ref thread_count post_count reputation trusted_count trusted_by_count }
+//
+// Views are obtained from the perspective given to the board, as with the board and thread pages.
+
+// graphBoard is a board, as resolved by the GraphQL schema.
+type graphBoard struct {
+	pk          string
+	v           state.ContentReader
+	rep         *object.ContentRep
+	perspective string
+}
+
+// graphContent is a thread or post, as resolved by the GraphQL schema.
+type graphContent struct {
+	board *graphBoard
+	rep   *object.ContentRep
+}
+
+func (c *graphContent) body() *object.Body {
+	if body, ok := c.rep.Body.(*object.Body); ok {
+		return body
+	}
+	return new(object.Body)
+}
+
+// graphVotes is the vote tally of a thread or post, as resolved by the GraphQL schema.
+type graphVotes struct {
+	Up, Down           int
+	VotedUp, VotedDown bool
+}
+
+func newGraphVotes(rep *object.ContentRep) *graphVotes {
+	out := new(graphVotes)
+	if votes, ok := rep.Votes.(*state.VoteRepView); ok && votes != nil {
+		out.Up, out.VotedUp = votes.Up.Count, votes.Up.Voted
+		out.Down, out.VotedDown = votes.Down.Count, votes.Down.Voted
+	} else if rep.Score != nil {
+		out.Up, out.Down = rep.Score.Up, rep.Score.Down
+	}
+	return out
+}
+
+// pageOf obtains the paginated input of the first elements, all if 'first' is not positive.
+func pageOf(first int) typ.PaginatedInput {
+	if first <= 0 {
+		return typ.PaginatedInput{PageSize: math.MaxUint64}
+	}
+	return typ.PaginatedInput{PageSize: uint(first)}
+}
+
+// newGraphSchema creates the GraphQL schema, resolving boards with the access.
+func newGraphSchema(a *Access) *gql.Object {
+	var (
+		board   = &gql.Object{Name: "Board"}
+		thread  = &gql.Object{Name: "Thread"}
+		post    = &gql.Object{Name: "Post"}
+		votes   = &gql.Object{Name: "Votes"}
+		profile = &gql.Object{Name: "Profile"}
+	)
+
+	getBoard := func(pk cipher.PubKey, perspective string) (*graphBoard, error) {
+		bi, e := a.CXO.GetBoardInstance(pk)
+		if e != nil {
+			return nil, e
+		}
+		rep, e := bi.Viewer().GetBoard()
+		if e != nil {
+			return nil, e
+		}
+		if rep == nil {
+			return nil, boo.New(boo.NotFound, "board is not yet compiled")
+		}
+		return &graphBoard{pk: pk.Hex(), v: bi.Viewer(), rep: rep, perspective: perspective}, nil
+	}
+
+	getProfile := func(b *graphBoard, upk string) (interface{}, error) {
+		if !b.v.HasUser(upk) {
+			return (*state.UserProfileOut)(nil), nil
+		}
+		return b.v.GetUserProfile(&state.UserProfileIn{UserPubKey: upk})
+	}
+
+	content := func(get func(c *graphContent) interface{}) *gql.Field {
+		return &gql.Field{Resolve: func(p *gql.Params) (interface{}, error) {
+			return get(p.Source.(*graphContent)), nil
+		}}
+	}
+
+	contentFields := func() map[string]*gql.Field {
+		return map[string]*gql.Field{
+			"hash":    content(func(c *graphContent) interface{} { return c.rep.Header.Hash }),
+			"name":    content(func(c *graphContent) interface{} { return c.body().Name }),
+			"body":    content(func(c *graphContent) interface{} { return c.body().Body }),
+			"creator": content(func(c *graphContent) interface{} { return c.body().Creator }),
+			"ts":      content(func(c *graphContent) interface{} { return c.body().TS }),
+			"votes": {Type: votes, Resolve: func(p *gql.Params) (interface{}, error) {
+				return newGraphVotes(p.Source.(*graphContent).rep), nil
+			}},
+			"author": {Type: profile, Resolve: func(p *gql.Params) (interface{}, error) {
+				c := p.Source.(*graphContent)
+				return getProfile(c.board, c.body().Creator)
+			}},
+		}
+	}
+
+	board.Fields = map[string]*gql.Field{
+		"public_key": {Resolve: func(p *gql.Params) (interface{}, error) {
+			return p.Source.(*graphBoard).pk, nil
+		}},
+		"name": {Resolve: func(p *gql.Params) (interface{}, error) {
+			return (&graphContent{rep: p.Source.(*graphBoard).rep}).body().Name, nil
+		}},
+		"body": {Resolve: func(p *gql.Params) (interface{}, error) {
+			return (&graphContent{rep: p.Source.(*graphBoard).rep}).body().Body, nil
+		}},
+		"ts": {Resolve: func(p *gql.Params) (interface{}, error) {
+			return (&graphContent{rep: p.Source.(*graphBoard).rep}).body().TS, nil
+		}},
+		"threads": {Type: thread, Resolve: func(p *gql.Params) (interface{}, error) {
+			b := p.Source.(*graphBoard)
+			first, e := p.Int("first", -1)
+			if e != nil || first == 0 {
+				return []*graphContent{}, e
+			}
+			sortBy, e := p.String("sort_by")
+			if e != nil {
+				return nil, e
+			}
+			page, e := b.v.GetBoardPage(&state.BoardPageIn{
+				Perspective:    b.perspective,
+				SortBy:         sortBy,
+				PaginatedInput: pageOf(first),
+			})
+			if e != nil {
+				return nil, e
+			}
+			reps := append(page.Pinned, page.Threads...)
+			if first >= 0 && first < len(reps) {
+				reps = reps[:first]
+			}
+			out := make([]*graphContent, len(reps))
+			for i, rep := range reps {
+				out[i] = &graphContent{board: b, rep: rep}
+			}
+			return out, nil
+		}},
+		"thread": {Type: thread, Resolve: func(p *gql.Params) (interface{}, error) {
+			b := p.Source.(*graphBoard)
+			hash, e := p.String("hash")
+			if e != nil {
+				return nil, e
+			}
+			page, e := b.v.GetThreadPage(&state.ThreadPageIn{
+				Perspective:    b.perspective,
+				ThreadHash:     hash,
+				PaginatedInput: pageOf(1),
+			})
+			if e != nil {
+				return nil, e
+			}
+			return &graphContent{board: b, rep: page.Thread}, nil
+		}},
+		"user": {Type: profile, Resolve: func(p *gql.Params) (interface{}, error) {
+			upk, e := p.String("public_key")
+			if e != nil {
+				return nil, e
+			}
+			return getProfile(p.Source.(*graphBoard), upk)
+		}},
+	}
+
+	thread.Fields = contentFields()
+	thread.Fields["pinned"] = content(func(c *graphContent) interface{} { return c.rep.Pinned })
+	thread.Fields["archived"] = content(func(c *graphContent) interface{} { return c.rep.Archived })
+	thread.Fields["post_count"] = content(func(c *graphContent) interface{} {
+		if c.rep.Stats == nil {
+			return 0
+		}
+		return c.rep.Stats.PostCount
+	})
+	thread.Fields["posts"] = &gql.Field{Type: post, Resolve: func(p *gql.Params) (interface{}, error) {
+		c := p.Source.(*graphContent)
+		first, e := p.Int("first", -1)
+		if e != nil || first == 0 {
+			return []*graphContent{}, e
+		}
+		page, e := c.board.v.GetThreadPage(&state.ThreadPageIn{
+			Perspective:    c.board.perspective,
+			ThreadHash:     c.rep.Header.Hash,
+			PaginatedInput: pageOf(first),
+		})
+		if e != nil {
+			return nil, e
+		}
+		out := make([]*graphContent, len(page.Posts))
+		for i, rep := range page.Posts {
+			out[i] = &graphContent{board: c.board, rep: rep}
+		}
+		return out, nil
+	}}
+
+	post.Fields = contentFields()
+	post.Fields["reply_to"] = content(func(c *graphContent) interface{} { return c.body().OfPost })
+
+	votes.Fields = map[string]*gql.Field{
+		"up": {Resolve: func(p *gql.Params) (interface{}, error) {
+			return p.Source.(*graphVotes).Up, nil
+		}},
+		"down": {Resolve: func(p *gql.Params) (interface{}, error) {
+			return p.Source.(*graphVotes).Down, nil
+		}},
+		"voted_up": {Resolve: func(p *gql.Params) (interface{}, error) {
+			return p.Source.(*graphVotes).VotedUp, nil
+		}},
+		"voted_down": {Resolve: func(p *gql.Params) (interface{}, error) {
+			return p.Source.(*graphVotes).VotedDown, nil
+		}},
+	}
+
+	profileField := func(get func(out *state.UserProfileOut) interface{}) *gql.Field {
+		return &gql.Field{Resolve: func(p *gql.Params) (interface{}, error) {
+			return get(p.Source.(*state.UserProfileOut)), nil
+		}}
+	}
+	profile.Fields = map[string]*gql.Field{
+		"public_key":       profileField(func(out *state.UserProfileOut) interface{} { return out.UserPubKey }),
+		"display_name":     profileField(func(out *state.UserProfileOut) interface{} { return out.Profile.DisplayName }),
+		"avatar_ref":       profileField(func(out *state.UserProfileOut) interface{} { return out.Profile.AvatarRef }),
+		"thread_count":     profileField(func(out *state.UserProfileOut) interface{} { return out.Profile.ThreadCount }),
+		"post_count":       profileField(func(out *state.UserProfileOut) interface{} { return out.Profile.PostCount }),
+		"reputation":       profileField(func(out *state.UserProfileOut) interface{} { return out.Profile.Reputation }),
+		"trusted_count":    profileField(func(out *state.UserProfileOut) interface{} { return out.Profile.TrustedCount }),
+		"trusted_by_count": profileField(func(out *state.UserProfileOut) interface{} { return out.Profile.TrustedByCount }),
+	}
+
+	return &gql.Object{
+		Name: "Query",
+		Fields: map[string]*gql.Field{
+			"board": {Type: board, Resolve: func(p *gql.Params) (interface{}, error) {
+				pkStr, e := p.String("public_key")
+				if e != nil {
+					return nil, e
+				}
+				pk, e := tag.GetPubKey(pkStr)
+				if e != nil {
+					return nil, boo.WrapType(e, boo.InvalidInput, "invalid board public key")
+				}
+				perspective, e := p.String("perspective")
+				if e != nil {
+					return nil, e
+				}
+				return getBoard(pk, perspective)
+			}},
+			"boards": {Type: board, Resolve: func(p *gql.Params) (interface{}, error) {
+				perspective, e := p.String("perspective")
+				if e != nil {
+					return nil, e
+				}
+				var out []*graphBoard
+				for _, pk := range a.CXO.GetSubscriptions() {
+					if b, e := getBoard(pk, perspective); e == nil {
+						out = append(out, b)
+					}
+				}
+				return out, nil
+			}},
+		},
+	}
+}
+
+// executeGraphQL executes a GraphQL query against the boards of the node.
+func (a *Access) executeGraphQL(ctx context.Context, query string, variables map[string]interface{}) (*gql.Result, error) {
+	return gql.Execute(ctx, newGraphSchema(a), struct{}{}, query, variables)
+}
@@ -4,32 +4,60 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 )
 
+// SubscriptionOptions represents per-board options of a subscription.
+type SubscriptionOptions struct {
+	AutoConnect bool `json:"auto_connect"` // Whether to dial peers discovered from the board.
+}
+
+// DefaultSubscriptionOptions returns the options of new subscriptions, and of
+// subscriptions saved without options.
+func DefaultSubscriptionOptions() SubscriptionOptions {
+	return SubscriptionOptions{
+		AutoConnect: true,
+	}
+}
+
 type SubscriptionView struct {
-	PK string `json:"public_key"`
-	SK string `json:"secret_key,omitempty"`
+	PK      string               `json:"public_key"`
+	SK      string               `json:"secret_key,omitempty"`
+	Options *SubscriptionOptions `json:"options,omitempty"`
 }
 
 type Subscription struct {
-	PK cipher.PubKey
-	SK cipher.SecKey
+	PK      cipher.PubKey
+	SK      cipher.SecKey
+	Options SubscriptionOptions
 }
 
 func (s *Subscription) View() SubscriptionView {
+	options := s.Options
 	if s.SK == (cipher.SecKey{}) {
 		return SubscriptionView{
-			PK: s.PK.Hex(),
+			PK:      s.PK.Hex(),
+			Options: &options,
 		}
 	} else {
 		return SubscriptionView{
-			PK: s.PK.Hex(),
-			SK: s.SK.Hex(),
+			PK:      s.PK.Hex(),
+			SK:      s.SK.Hex(),
+			Options: &options,
 		}
 	}
 }
 
+// options obtains the options of a subscription view, or the defaults if it has none.
+func (v SubscriptionView) options() SubscriptionOptions {
+	if v.Options == nil {
+		return DefaultSubscriptionOptions()
+	}
+	return *v.Options
+}
+
 type CXOFile struct {
 	MasterSubs         []SubscriptionView `json:"master_subscriptions"`
 	RemoteSubs         []SubscriptionView `json:"remote_subscriptions"`
 	MessengerAddresses []string           `json:"messenger_addresses"`
 	Connections        []string           `json:"connections"`
+	DisabledConns      []string           `json:"disabled_connections,omitempty"` // Connections not to reconnect to.
+	SealedKeys         *SealedKeys        `json:"sealed_secret_keys,omitempty"`   // Secret keys of master subscriptions, if encrypted.
 }
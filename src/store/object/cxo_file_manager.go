@@ -9,6 +9,7 @@ import (
 	"github.com/skycoin/skycoin/src/util/file"
 	"log"
 	"os"
+	"sort"
 	"sync"
 )
 
@@ -18,7 +19,8 @@ const (
 
 // CXOFileManagerConfig configures the CXOFileManager.
 type CXOFileManagerConfig struct {
-	Memory *bool // Whether to run in memory mode.
+	Memory     *bool   // Whether to run in memory mode.
+	Passphrase *string // Passphrase to encrypt secret keys with, empty to save them as plain text.
 }
 
 // CXOFileManager manages the CXOFile.
@@ -32,6 +34,7 @@ type CXOFileManager struct {
 	remotes     *typ.List
 	messengers  *typ.List
 	connections *typ.List
+	disabled    map[string]struct{} // Connections not to reconnect to.
 }
 
 // NewCXOFileManager creates a new file manager with provided configuration.
@@ -43,6 +46,7 @@ func NewCXOFileManager(config *CXOFileManagerConfig) *CXOFileManager {
 		remotes:     typ.NewList(),
 		messengers:  typ.NewList(),
 		connections: typ.NewList(),
+		disabled:    make(map[string]struct{}),
 	}
 }
 
@@ -97,7 +101,7 @@ func (m *CXOFileManager) AddMasterSub(pk cipher.PubKey, sk cipher.SecKey) error
 	m.remotes.DelOfKey(pk)
 
 	// Append to masters.
-	if m.masters.Append(pk, &Subscription{PK: pk, SK: sk}) == false {
+	if m.masters.Append(pk, &Subscription{PK: pk, SK: sk, Options: DefaultSubscriptionOptions()}) == false {
 		return boo.Newf(boo.AlreadyExists,
 			"board of public key '%s' already exists in master subscriptions",
 			pk.Hex())
@@ -117,7 +121,7 @@ func (m *CXOFileManager) AddRemoteSub(pk cipher.PubKey) error {
 			"file already has master subscription to '%s'", pk.Hex())
 	}
 
-	if m.remotes.Append(pk, &Subscription{PK: pk}) == false {
+	if m.remotes.Append(pk, &Subscription{PK: pk, Options: DefaultSubscriptionOptions()}) == false {
 		return boo.Newf(boo.AlreadyExists,
 			"file already has remote subscription to '%s'", pk.Hex())
 	}
@@ -126,6 +130,40 @@ func (m *CXOFileManager) AddRemoteSub(pk cipher.PubKey) error {
 	return nil
 }
 
+// GetSubOptions obtains the options of a subscription, whether master or remote.
+func (m *CXOFileManager) GetSubOptions(pk cipher.PubKey) (SubscriptionOptions, error) {
+	defer m.lock()()
+	sub, e := m.getSub(pk)
+	if e != nil {
+		return SubscriptionOptions{}, e
+	}
+	return sub.Options, nil
+}
+
+// SetSubOptions sets the options of a subscription, whether master or remote.
+func (m *CXOFileManager) SetSubOptions(pk cipher.PubKey, options SubscriptionOptions) error {
+	defer m.lock()()
+	sub, e := m.getSub(pk)
+	if e != nil {
+		return e
+	}
+	if sub.Options != options {
+		sub.Options = options
+		m.tagChanges()
+	}
+	return nil
+}
+
+func (m *CXOFileManager) getSub(pk cipher.PubKey) (*Subscription, error) {
+	for _, list := range []*typ.List{m.masters, m.remotes} {
+		if v, ok := list.GetOfKey(pk); ok {
+			return v.(*Subscription), nil
+		}
+	}
+	return nil, boo.Newf(boo.NotFound,
+		"subscription to '%s' not found", pk.Hex())
+}
+
 // RemoveSub removes a subscription from file, whether master or remote.
 func (m *CXOFileManager) RemoveSub(pk cipher.PubKey) error {
 	defer m.lock()()
@@ -341,6 +379,7 @@ func (m *CXOFileManager) RemoveConnection(address string) error {
 	defer m.lock()()
 
 	m.connections.DelOfKey(address)
+	delete(m.disabled, address)
 	m.tagChanges()
 	return nil
 }
@@ -368,6 +407,32 @@ func (m *CXOFileManager) SetConnectionStatus(address string, status bool) error
 	return nil
 }
 
+// SetConnectionEnabled sets whether a connection is to be reconnected to.
+func (m *CXOFileManager) SetConnectionEnabled(address string, enabled bool) error {
+	defer m.lock()()
+	if _, ok := m.connections.GetOfKey(address); !ok {
+		return boo.Newf(boo.NotFound,
+			"connection address '%s' is not found", address)
+	}
+	if _, disabled := m.disabled[address]; disabled != enabled {
+		return nil
+	}
+	if enabled {
+		delete(m.disabled, address)
+	} else {
+		m.disabled[address] = struct{}{}
+	}
+	m.tagChanges()
+	return nil
+}
+
+// IsConnectionEnabled obtains whether a connection is to be reconnected to.
+func (m *CXOFileManager) IsConnectionEnabled(address string) bool {
+	defer m.lock()()
+	_, disabled := m.disabled[address]
+	return !disabled
+}
+
 // GetConnectionStatus obtains a connection status of specified connection.
 func (m *CXOFileManager) GetConnectionStatus(address string) bool {
 	defer m.lock()()
@@ -393,6 +458,12 @@ func (m *CXOFileManager) load(path string) error {
 		}
 	}
 
+	// Decrypt secret keys of master subscriptions.
+	sealedKeys, e := m.openKeys(fileData.SealedKeys)
+	if e != nil {
+		return e
+	}
+
 	// LOAD TO MEMORY //
 
 	// Range master subscriptions.
@@ -406,6 +477,11 @@ func (m *CXOFileManager) load(path string) error {
 		}
 
 		// Get private key of master subscription.
+		if sealedKeys != nil {
+			sub.SK = sealedKeys[sub.PK]
+		} else if sub.SK != "" && m.passphrase() != "" {
+			m.tagChanges() // Encrypt plain text secret keys on next save.
+		}
 		sk, e := tag.GetSecKey(sub.SK)
 		if e != nil {
 			return boo.WrapTypef(e, boo.InvalidRead,
@@ -413,7 +489,7 @@ func (m *CXOFileManager) load(path string) error {
 		}
 
 		// Append.
-		m.masters.Append(pk, &Subscription{PK: pk, SK: sk})
+		m.masters.Append(pk, &Subscription{PK: pk, SK: sk, Options: sub.options()})
 	}
 
 	// Range remote subscriptions.
@@ -427,13 +503,13 @@ func (m *CXOFileManager) load(path string) error {
 		}
 
 		// Append.
-		m.remotes.Append(pk, &Subscription{PK: pk})
+		m.remotes.Append(pk, &Subscription{PK: pk, Options: sub.options()})
 	}
 
 	// Range messenger addresses.
 	for i, address := range fileData.MessengerAddresses {
 		if e := tag.CheckAddress(address); e != nil {
-			return boo.WrapType(e, boo.InvalidRead,
+			return boo.WrapTypef(e, boo.InvalidRead,
 				"invalid address in file at messenger_addresses[%d]", i)
 		}
 		m.messengers.Append(address, cipher.PubKey{})
@@ -442,11 +518,16 @@ func (m *CXOFileManager) load(path string) error {
 	// Range connections.
 	for i, address := range fileData.Connections {
 		if e := tag.CheckAddress(address); e != nil {
-			return boo.WrapType(e, boo.InvalidRead,
+			return boo.WrapTypef(e, boo.InvalidRead,
 				"invalid address in file at connections[%d]", i)
 		}
 		m.connections.Append(address, false)
 	}
+	for _, address := range fileData.DisabledConns {
+		if _, ok := m.connections.GetOfKey(address); ok {
+			m.disabled[address] = struct{}{}
+		}
+	}
 
 	return nil
 }
@@ -477,6 +558,14 @@ func (m *CXOFileManager) save(path string) error {
 		fileData.Connections[i] = k.(string)
 		return false, nil
 	})
+	for address := range m.disabled {
+		fileData.DisabledConns = append(fileData.DisabledConns, address)
+	}
+	sort.Strings(fileData.DisabledConns)
+
+	if e := m.sealKeys(&fileData); e != nil {
+		return e
+	}
 
 	if e := file.SaveJSON(path, fileData, os.FileMode(0600)); e != nil {
 		return boo.WrapTypef(e, boo.Internal,
@@ -485,6 +574,35 @@ func (m *CXOFileManager) save(path string) error {
 	return nil
 }
 
+// openKeys decrypts sealed secret keys of master subscriptions.
+// Returns nil if the secret keys are not sealed.
+func (m *CXOFileManager) openKeys(sealed *SealedKeys) (map[string]string, error) {
+	if sealed == nil {
+		return nil, nil
+	}
+	if m.passphrase() == "" {
+		return nil, boo.New(boo.NotAuthorised,
+			"secret keys of CXO file are encrypted, a passphrase is required to unlock them")
+	}
+	return sealed.Open(m.passphrase())
+}
+
+// sealKeys moves secret keys of master subscriptions from the file data to it's sealed
+// secret keys, if a passphrase is set. Keys are sealed with a new salt and nonce on each save.
+func (m *CXOFileManager) sealKeys(fileData *CXOFile) error {
+	if m.passphrase() == "" {
+		return nil
+	}
+	keys := make(map[string]string, len(fileData.MasterSubs))
+	for i, sub := range fileData.MasterSubs {
+		keys[sub.PK] = sub.SK
+		fileData.MasterSubs[i].SK = ""
+	}
+	var e error
+	fileData.SealedKeys, e = SealKeys(m.passphrase(), keys)
+	return e
+}
+
 func (m *CXOFileManager) tagChanges() {
 	m.hasChanges = true
 }
@@ -501,3 +619,10 @@ func (m *CXOFileManager) lock() func() {
 func (m *CXOFileManager) memMode() bool {
 	return *m.c.Memory
 }
+
+func (m *CXOFileManager) passphrase() string {
+	if m.c.Passphrase == nil {
+		return ""
+	}
+	return *m.c.Passphrase
+}
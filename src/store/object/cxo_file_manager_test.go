@@ -0,0 +1,216 @@
+package object
+
+import (
+	"encoding/json"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/skycoin/src/cipher"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newFileManager(passphrase string) *CXOFileManager {
+	memory := false
+	return NewCXOFileManager(&CXOFileManagerConfig{
+		Memory:     &memory,
+		Passphrase: &passphrase,
+	})
+}
+
+func readCXOFile(t *testing.T, path string) *CXOFile {
+	raw, e := ioutil.ReadFile(path)
+	if e != nil {
+		t.Fatal("failed to read CXO file:", e)
+	}
+	out := new(CXOFile)
+	if e := json.Unmarshal(raw, out); e != nil {
+		t.Fatal("failed to decode CXO file:", e)
+	}
+	return out
+}
+
+func TestCXOFileManager_sealedKeys(t *testing.T) {
+	dir, e := ioutil.TempDir("", "bbs_cxo_file")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cxo.json")
+
+	pk, sk := cipher.GenerateKeyPair()
+	plain, _ := json.Marshal(&CXOFile{
+		MasterSubs: []SubscriptionView{{PK: pk.Hex(), SK: sk.Hex()}},
+	})
+	if e := ioutil.WriteFile(path, plain, 0600); e != nil {
+		t.Fatal("failed to write CXO file:", e)
+	}
+
+	t.Run("migrates_plain_text", func(t *testing.T) {
+		m := newFileManager("passphrase")
+		if e := m.Load(path); e != nil {
+			t.Fatal("failed to load CXO file:", e)
+		}
+		if got, ok := m.GetMasterSubSecKey(pk); !ok || got != sk {
+			t.Fatal("secret key of plain text file is not loaded")
+		}
+		if e := m.Save(path); e != nil {
+			t.Fatal("failed to save CXO file:", e)
+		}
+		saved := readCXOFile(t, path)
+		if saved.SealedKeys == nil {
+			t.Fatal("secret keys are not sealed on save")
+		}
+		if len(saved.MasterSubs) != 1 || saved.MasterSubs[0].SK != "" {
+			t.Errorf("secret keys are saved as plain text: %+v", saved.MasterSubs)
+		}
+	})
+
+	t.Run("opens_sealed", func(t *testing.T) {
+		m := newFileManager("passphrase")
+		if e := m.Load(path); e != nil {
+			t.Fatal("failed to load CXO file:", e)
+		}
+		if got, ok := m.GetMasterSubSecKey(pk); !ok || got != sk {
+			t.Error("secret key of sealed file is not loaded")
+		}
+	})
+
+	t.Run("rejects_wrong_passphrase", func(t *testing.T) {
+		if e := newFileManager("wrong").Load(path); boo.Type(e) != boo.NotAuthorised {
+			t.Errorf("got error %v, expected not authorised", e)
+		}
+	})
+
+	t.Run("requires_passphrase", func(t *testing.T) {
+		if e := newFileManager("").Load(path); boo.Type(e) != boo.NotAuthorised {
+			t.Errorf("got error %v, expected not authorised", e)
+		}
+	})
+}
+
+func TestCXOFileManager_SetConnectionEnabled(t *testing.T) {
+	const address = "127.0.0.1:8998"
+	m := newFileManager("")
+
+	if e := m.SetConnectionEnabled(address, false); boo.Type(e) != boo.NotFound {
+		t.Errorf("disabling unknown connection: got error %v, expected not found", e)
+	}
+	if e := m.AddConnection(address); e != nil {
+		t.Fatal("failed to add connection:", e)
+	}
+	if !m.IsConnectionEnabled(address) {
+		t.Error("new connection should be enabled")
+	}
+	m.untagChanges()
+
+	if e := m.SetConnectionEnabled(address, false); e != nil {
+		t.Fatal("failed to disable connection:", e)
+	}
+	if m.IsConnectionEnabled(address) || !m.hasChanges {
+		t.Error("connection should be disabled, with changes to save")
+	}
+	m.untagChanges()
+
+	if e := m.SetConnectionEnabled(address, false); e != nil || m.hasChanges {
+		t.Errorf("disabling again: got error %v and changes %v, expected neither", e, m.hasChanges)
+	}
+	if e := m.SetConnectionEnabled(address, true); e != nil {
+		t.Fatal("failed to enable connection:", e)
+	}
+	if !m.IsConnectionEnabled(address) {
+		t.Error("connection should be enabled")
+	}
+}
+
+func TestCXOFileManager_SubOptions(t *testing.T) {
+	dir, e := ioutil.TempDir("", "bbs_cxo_file")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cxo.json")
+
+	var (
+		legacyPK, _  = cipher.GenerateKeyPair()
+		manualPK, _  = cipher.GenerateKeyPair()
+		masterPK, sk = cipher.GenerateKeyPair()
+		unknownPK, _ = cipher.GenerateKeyPair()
+		manual       = SubscriptionOptions{AutoConnect: false}
+		defaults     = DefaultSubscriptionOptions()
+	)
+	raw, _ := json.Marshal(&CXOFile{
+		RemoteSubs: []SubscriptionView{
+			{PK: legacyPK.Hex()},
+			{PK: manualPK.Hex(), Options: &manual},
+		},
+	})
+	if e := ioutil.WriteFile(path, raw, 0600); e != nil {
+		t.Fatal("failed to write CXO file:", e)
+	}
+	m := newFileManager("")
+	if e := m.Load(path); e != nil {
+		t.Fatal("failed to load CXO file:", e)
+	}
+	if e := m.AddMasterSub(masterPK, sk); e != nil {
+		t.Fatal("failed to add master subscription:", e)
+	}
+
+	cases := []struct {
+		name     string
+		pk       cipher.PubKey
+		expected SubscriptionOptions
+		errType  int
+	}{
+		{"saved_without_options", legacyPK, defaults, 0},
+		{"saved_with_options", manualPK, manual, 0},
+		{"new", masterPK, defaults, 0},
+		{"unknown", unknownPK, SubscriptionOptions{}, boo.NotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, e := m.GetSubOptions(c.pk)
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get subscription options:", e)
+			}
+			if got != c.expected {
+				t.Errorf("got options %+v, expected %+v", got, c.expected)
+			}
+		})
+	}
+
+	if e := m.SetSubOptions(unknownPK, manual); boo.Type(e) != boo.NotFound {
+		t.Errorf("setting options of unknown subscription: got error %v, expected not found", e)
+	}
+	m.untagChanges()
+	if e := m.SetSubOptions(manualPK, manual); e != nil || m.hasChanges {
+		t.Errorf("setting same options: got error %v and changes %v, expected neither", e, m.hasChanges)
+	}
+	if e := m.SetSubOptions(legacyPK, manual); e != nil || !m.hasChanges {
+		t.Errorf("setting options: got error %v and changes %v, expected changes to save", e, m.hasChanges)
+	}
+
+	// Options are persisted.
+	if e := m.Save(path); e != nil {
+		t.Fatal("failed to save CXO file:", e)
+	}
+	reloaded := newFileManager("")
+	if e := reloaded.Load(path); e != nil {
+		t.Fatal("failed to reload CXO file:", e)
+	}
+	for pk, expected := range map[cipher.PubKey]SubscriptionOptions{
+		legacyPK: manual,
+		manualPK: manual,
+		masterPK: defaults,
+	} {
+		if got, e := reloaded.GetSubOptions(pk); e != nil || got != expected {
+			t.Errorf("reloaded options of '%s': got %+v (%v), expected %+v", pk.Hex(), got, e, expected)
+		}
+	}
+}
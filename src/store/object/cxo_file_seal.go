@@ -0,0 +1,124 @@
+package object
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"golang.org/x/crypto/scrypt"
+	"io"
+)
+
+// Parameters of scrypt, used to derive keys of sealed secret keys.
+const (
+	SealKDF     = "scrypt"
+	SealScryptN = 1 << 15
+	SealScryptR = 8
+	SealScryptP = 1
+	sealKeyLen  = 32
+	sealSaltLen = 32
+)
+
+// SealedKeys holds the secret keys of master subscriptions, encrypted with a key derived
+// from a passphrase. The plain text is a JSON object of public keys to secret keys.
+type SealedKeys struct {
+	KDF   string `json:"kdf"`
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	Salt  string `json:"salt"`
+	Nonce string `json:"nonce"`
+	Data  string `json:"data"` // AES-256-GCM cipher text.
+}
+
+// SealKeys encrypts secret keys (of public key hex to secret key hex) with the passphrase,
+// using a new salt and nonce.
+func SealKeys(passphrase string, keys map[string]string) (*SealedKeys, error) {
+	plain, e := json.Marshal(keys)
+	if e != nil {
+		return nil, boo.WrapType(e, boo.Internal, "failed to encode secret keys")
+	}
+	salt := make([]byte, sealSaltLen)
+	if _, e := io.ReadFull(rand.Reader, salt); e != nil {
+		return nil, boo.WrapType(e, boo.Internal, "failed to generate salt")
+	}
+	out := &SealedKeys{
+		KDF:  SealKDF,
+		N:    SealScryptN,
+		R:    SealScryptR,
+		P:    SealScryptP,
+		Salt: hex.EncodeToString(salt),
+	}
+	aead, e := out.aead(passphrase, salt)
+	if e != nil {
+		return nil, e
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, e := io.ReadFull(rand.Reader, nonce); e != nil {
+		return nil, boo.WrapType(e, boo.Internal, "failed to generate nonce")
+	}
+	out.Nonce = hex.EncodeToString(nonce)
+	out.Data = hex.EncodeToString(aead.Seal(nil, nonce, plain, nil))
+	return out, nil
+}
+
+// Open decrypts the secret keys with the passphrase.
+// Only keys sealed with the Seal* parameters are accepted, so that a tampered file
+// cannot make key derivation consume excessive memory or time.
+func (s *SealedKeys) Open(passphrase string) (map[string]string, error) {
+	if s.KDF != SealKDF {
+		return nil, boo.Newf(boo.InvalidRead,
+			"unsupported key derivation function '%s' of sealed secret keys", s.KDF)
+	}
+	if s.N != SealScryptN || s.R != SealScryptR || s.P != SealScryptP {
+		return nil, boo.Newf(boo.InvalidRead,
+			"unsupported scrypt parameters (n=%d, r=%d, p=%d) of sealed secret keys", s.N, s.R, s.P)
+	}
+	salt, e := hex.DecodeString(s.Salt)
+	if e != nil {
+		return nil, boo.WrapType(e, boo.InvalidRead, "invalid salt of sealed secret keys")
+	}
+	nonce, e := hex.DecodeString(s.Nonce)
+	if e != nil {
+		return nil, boo.WrapType(e, boo.InvalidRead, "invalid nonce of sealed secret keys")
+	}
+	data, e := hex.DecodeString(s.Data)
+	if e != nil {
+		return nil, boo.WrapType(e, boo.InvalidRead, "invalid data of sealed secret keys")
+	}
+	aead, e := s.aead(passphrase, salt)
+	if e != nil {
+		return nil, e
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, boo.New(boo.InvalidRead, "invalid nonce size of sealed secret keys")
+	}
+	plain, e := aead.Open(nil, nonce, data, nil)
+	if e != nil {
+		return nil, boo.New(boo.NotAuthorised,
+			"failed to decrypt secret keys: incorrect passphrase or corrupted file")
+	}
+	var keys map[string]string
+	if e := json.Unmarshal(plain, &keys); e != nil {
+		return nil, boo.WrapType(e, boo.InvalidRead, "failed to decode sealed secret keys")
+	}
+	return keys, nil
+}
+
+func (s *SealedKeys) aead(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, e := scrypt.Key([]byte(passphrase), salt, s.N, s.R, s.P, sealKeyLen)
+	if e != nil {
+		return nil, boo.WrapType(e, boo.InvalidRead, "failed to derive key of sealed secret keys")
+	}
+	block, e := aes.NewCipher(key)
+	if e != nil {
+		return nil, boo.WrapType(e, boo.Internal, "failed to create cipher")
+	}
+	aead, e := cipher.NewGCM(block)
+	if e != nil {
+		return nil, boo.WrapType(e, boo.Internal, "failed to create cipher")
+	}
+	return aead, nil
+}
@@ -0,0 +1,73 @@
+package object
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"reflect"
+	"testing"
+)
+
+func TestSealKeys(t *testing.T) {
+	keys := map[string]string{"pk1": "sk1", "pk2": "sk2"}
+
+	sealed, e := SealKeys("passphrase", keys)
+	if e != nil {
+		t.Fatal("failed to seal keys:", e)
+	}
+
+	t.Run("opens", func(t *testing.T) {
+		got, e := sealed.Open("passphrase")
+		if e != nil {
+			t.Fatal("failed to open sealed keys:", e)
+		}
+		if !reflect.DeepEqual(got, keys) {
+			t.Errorf("got %v, expected %v", got, keys)
+		}
+	})
+
+	t.Run("rejects_wrong_passphrase", func(t *testing.T) {
+		if _, e := sealed.Open("wrong"); boo.Type(e) != boo.NotAuthorised {
+			t.Errorf("got error %v, expected not authorised", e)
+		}
+	})
+
+	t.Run("rejects_tampered_parameters", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			tamper  func(s *SealedKeys)
+			errType int
+		}{
+			{"n", func(s *SealedKeys) { s.N = 1 << 30 }, boo.InvalidRead},
+			{"r", func(s *SealedKeys) { s.R = 1 << 20 }, boo.InvalidRead},
+			{"p", func(s *SealedKeys) { s.P = 1 << 20 }, boo.InvalidRead},
+			{"kdf", func(s *SealedKeys) { s.KDF = "none" }, boo.InvalidRead},
+			{"data", func(s *SealedKeys) { s.Data = flipHexDigit(s.Data) }, boo.NotAuthorised},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				tampered := *sealed
+				c.tamper(&tampered)
+				if _, e := tampered.Open("passphrase"); boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+			})
+		}
+	})
+
+	t.Run("uses_new_salt_and_nonce", func(t *testing.T) {
+		other, e := SealKeys("passphrase", keys)
+		if e != nil {
+			t.Fatal("failed to seal keys:", e)
+		}
+		if other.Salt == sealed.Salt || other.Nonce == sealed.Nonce {
+			t.Error("expected a new salt and nonce on each seal")
+		}
+	})
+}
+
+// flipHexDigit changes the first digit of a hex string.
+func flipHexDigit(s string) string {
+	if s[0] == '0' {
+		return "1" + s[1:]
+	}
+	return "0" + s[1:]
+}
@@ -711,9 +711,18 @@ type UserView struct {
 	<<< CONNECTION >>>
 */
 
+// Connection represents a CXO connection, and it's live state as seen by the node.
 type Connection struct {
-	Address string `json:"address"`
-	State   string `json:"state"`
+	Address       string `json:"address"`
+	State         string `json:"state"`
+	Connected     bool   `json:"connected"`
+	LastSeen      int64  `json:"last_seen,omitempty"`          // Unix time (ns) of last message read, or of disconnection.
+	Latency       int64  `json:"latency,omitempty"`            // Round-trip latency (ns) of last health check.
+	BytesSynced   uint64 `json:"bytes_synced"`                 // Encoded size of roots received.
+	Subscriptions int    `json:"shared_subscriptions"`         // Number of subscriptions shared with peer.
+	Enabled       bool   `json:"enabled"`                      // Whether to reconnect to peer.
+	Attempts      int    `json:"reconnect_attempts,omitempty"` // Failed reconnection attempts since last connected.
+	NextAttempt   int64  `json:"next_attempt,omitempty"`       // Unix time (ns) of next reconnection attempt.
 }
 
 type MessengerConnection struct {
@@ -807,7 +816,7 @@ func valueErr(e error, ref *skyobject.Ref) error {
 }
 
 func elemValueErr(e error, elem *skyobject.RefsElem) error {
-	return boo.WrapType(e, boo.InvalidRead,
+	return boo.WrapTypef(e, boo.InvalidRead,
 		"failed to obtain value from elem object of ref '%s'",
 		elem.String())
 }
@@ -16,9 +16,10 @@ func errGetFromBody(e error, what string) error {
 }
 
 const (
-	TrustTag = "trust"
-	SpamTag  = "spam"
-	BlockTag = "block"
+	TrustTag  = "trust"
+	SpamTag   = "spam"
+	BlockTag  = "block"
+	ReportTag = "report" // Thread/post vote reporting content to the board owner (not counted as a vote).
 )
 
 type ImageData struct {
@@ -32,19 +33,46 @@ type ImageData struct {
 }
 
 type Body struct {
-	Type     ContentType       `json:"type"`                      // ALL
-	TS       int64             `json:"ts"`                        // ALL
-	OfBoard  string            `json:"of_board,omitempty"`        // thread, post, thread_vote, post_vote, user_vote
-	OfThread string            `json:"of_thread,omitempty"`       // post, thread_vote
-	OfPost   string            `json:"of_post,omitempty"`         // post (optional), post_vote
-	OfUser   string            `json:"of_user,omitempty"`         // vote
-	Name     string            `json:"name,omitempty"`            // board, thread, post
-	Body     string            `json:"body,omitempty"`            // board, thread, post
-	Images   []*ImageData      `json:"images,omitempty"`          // post (optional)
-	Value    int               `json:"value,omitempty"`           // thread_vote, post_vote, user_vote
-	Tags     []string          `json:"tags,omitempty"`            // board, thread_vote, post_vote, user_vote
-	SubKeys  []MessengerSubKey `json:"submission_keys,omitempty"` // board
-	Creator  string            `json:"creator,omitempty"`         // thread, post, thread_vote, post_vote, user_vote
+	Type      ContentType       `json:"type"`                      // ALL
+	TS        int64             `json:"ts"`                        // ALL
+	OfBoard   string            `json:"of_board,omitempty"`        // thread, post, thread_vote, post_vote, user_vote
+	OfThread  string            `json:"of_thread,omitempty"`       // post, thread_vote
+	OfPost    string            `json:"of_post,omitempty"`         // post (optional), post_vote, attachment
+	OfUser    string            `json:"of_user,omitempty"`         // vote, key_rotation (rotated from)
+	Name      string            `json:"name,omitempty"`            // board, thread, post, user_profile (display name), attachment (file name), board_announcement
+	Body      string            `json:"body,omitempty"`            // board, thread, post, board_announcement
+	Images    []*ImageData      `json:"images,omitempty"`          // post (optional)
+	Value     int               `json:"value,omitempty"`           // thread_vote, post_vote, user_vote
+	Tags      []string          `json:"tags,omitempty"`            // board, thread_vote, post_vote, user_vote
+	SubKeys   []MessengerSubKey `json:"submission_keys,omitempty"` // board
+	Pins      []string          `json:"pins,omitempty"`            // board (optional, ordered thread hashes)
+	Retention int               `json:"retention,omitempty"`       // board (optional, days after which threads are archived)
+	Policy    *SubmissionPolicy `json:"policy,omitempty"`          // board (optional, who may submit threads and posts)
+	Meta      *BoardMeta        `json:"meta,omitempty"`            // board (optional, structured metadata), board_announcement (optional)
+	Archived  bool              `json:"archived,omitempty"`        // board (optional, whether read-only)
+	Registry  bool              `json:"registry,omitempty"`        // board (optional, whether it accepts board announcements)
+	Peers     []string          `json:"peers,omitempty"`           // board (optional, addresses of CXO peers hosting the board)
+	AvatarRef string            `json:"avatar_ref,omitempty"`      // user_profile (optional, image hash or url)
+	MediaType string            `json:"media_type,omitempty"`      // attachment
+	Data      []byte            `json:"data,omitempty"`            // attachment
+	Proof     string            `json:"proof,omitempty"`           // key_rotation (signature of rotated from key, see KeyRotationHash)
+	Creator   string            `json:"creator,omitempty"`         // thread, post, thread_vote, post_vote, user_vote, user_profile, attachment, key_rotation (rotated to), board_announcement (announced board)
+}
+
+// SubmissionPolicy restricts who may submit threads and posts to a board.
+// The board owner is not restricted.
+type SubmissionPolicy struct {
+	MinReputation int  `json:"min_reputation,omitempty"` // Minimum reputation of creator.
+	TrustedOnly   bool `json:"trusted_only,omitempty"`   // Whether creator needs to be trusted by the board owner.
+}
+
+// BoardMeta represents structured metadata of a board, for clients to present.
+type BoardMeta struct {
+	Description string   `json:"description,omitempty"`
+	Rules       []string `json:"rules,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Icon        string   `json:"icon,omitempty"` // Hash of icon attachment.
 }
 
 func NewBody(raw []byte) (*Body, error) {
@@ -118,6 +146,33 @@ func (c *Body) GetCreator() (cipher.PubKey, error) {
 	}
 }
 
+// KeyRotationHash obtains the hash that the key rotated from signs as proof of a key
+// rotation to the public key 'to'.
+func KeyRotationHash(to string) cipher.SHA256 {
+	return cipher.SumSHA256([]byte(string(V5KeyRotationType) + "," + to))
+}
+
+// VerifyKeyRotation ensures that a key rotation is to a different key, and that the
+// proof is signed by the key rotated from.
+func (c *Body) VerifyKeyRotation() error {
+	from, e := c.GetOfUser()
+	if e != nil {
+		return e
+	}
+	if c.OfUser == c.Creator {
+		return boo.New(boo.InvalidInput, "cannot rotate key to itself")
+	}
+	proof, e := tag.GetSig(c.Proof)
+	if e != nil {
+		return errGetFromBody(e, "proof")
+	}
+	if e := cipher.VerifySignature(from, proof, KeyRotationHash(c.Creator)); e != nil {
+		return boo.WrapType(e, boo.NotAuthorised,
+			"key rotation proof is not signed by the key rotated from")
+	}
+	return nil
+}
+
 func (c *Body) HasTag(tag string) bool {
 	for _, v := range c.Tags {
 		if v == tag {
@@ -131,6 +186,57 @@ func (c *Body) HasValue(v int) bool {
 	return c.Value == v
 }
 
+// Copy obtains a deep copy of the body.
+func (c *Body) Copy() *Body {
+	if c == nil {
+		return nil
+	}
+	out := *c
+	out.Images = copyImages(c.Images)
+	out.Tags = copyStrings(c.Tags)
+	if c.SubKeys != nil {
+		out.SubKeys = append([]MessengerSubKey{}, c.SubKeys...)
+	}
+	out.Pins = copyStrings(c.Pins)
+	if c.Policy != nil {
+		policy := *c.Policy
+		out.Policy = &policy
+	}
+	if c.Meta != nil {
+		meta := *c.Meta
+		meta.Rules = copyStrings(c.Meta.Rules)
+		meta.Tags = copyStrings(c.Meta.Tags)
+		out.Meta = &meta
+	}
+	out.Peers = copyStrings(c.Peers)
+	if c.Data != nil {
+		out.Data = append([]byte{}, c.Data...)
+	}
+	return &out
+}
+
+func copyImages(images []*ImageData) []*ImageData {
+	if images == nil {
+		return nil
+	}
+	out := make([]*ImageData, len(images))
+	for i, img := range images {
+		if img != nil {
+			cp := *img
+			cp.Thumbs = copyImages(img.Thumbs)
+			out[i] = &cp
+		}
+	}
+	return out
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
 type Content struct {
 	Header []byte `json:"header"` // Contains type, creator public key and signature.
 	Body   []byte `json:"body"`   // Contains actual content.
@@ -223,16 +329,52 @@ func (c *Content) SetBodyRaw(raw []byte) {
 
 func (c *Content) ToRep() *ContentRep {
 	return &ContentRep{
-		Header: c.GetHeader(),
-		Body:   c.GetBody(),
+		Header:  c.GetHeader(),
+		Body:    c.GetBody(),
+		RawBody: c.Body,
 	}
 }
 
+// ThreadStats represents the activity of a thread.
+type ThreadStats struct {
+	PostCount    int   `json:"post_count"`
+	LastActivity int64 `json:"last_activity"` // Timestamp of thread or latest post.
+	Participants int   `json:"participants"`  // Number of distinct creators of thread and posts.
+}
+
+// ContentScore represents the vote tally of a thread or post, regardless of perspective.
+type ContentScore struct {
+	Up   int `json:"up"`
+	Down int `json:"down"`
+	Net  int `json:"net"` // Up votes - down votes.
+}
+
+// AttachmentRef represents a file attached to a post, without it's data.
+type AttachmentRef struct {
+	Hash      string `json:"hash"` // Hash of attachment content.
+	Name      string `json:"name"`
+	MediaType string `json:"media_type"`
+	Size      int    `json:"size"` // Number of bytes of data.
+}
+
 type ContentRep struct {
-	PubKey string             `json:"public_key,omitempty"`
-	Header *ContentHeaderData `json:"header,omitempty"`
-	Body   interface{}        `json:"body,omitempty"`
-	Votes  interface{}        `json:"votes,omitempty"`
+	PubKey      string             `json:"public_key,omitempty"`
+	Header      *ContentHeaderData `json:"header,omitempty"`
+	Body        interface{}        `json:"body,omitempty"`
+	Votes       interface{}        `json:"votes,omitempty"`
+	UnreadCount int                `json:"unread_count,omitempty"`
+	ByOwner     bool               `json:"by_owner,omitempty"`    // Whether created by the board owner.
+	Identity    string             `json:"identity,omitempty"`    // Canonical identity of creator, if they rotated keys.
+	LastPost    *ContentRep        `json:"last_post,omitempty"`   // Preview of latest post (threads of board page).
+	TopReply    *ContentRep        `json:"top_reply,omitempty"`   // Highest scored post (threads of board page).
+	Collapsed   bool               `json:"collapsed,omitempty"`   // Whether post should be shown collapsed.
+	Archived    bool               `json:"archived,omitempty"`    // Whether thread is older than the board's retention.
+	Pinned      bool               `json:"pinned,omitempty"`      // Whether thread is pinned by the board owner.
+	Stats       *ThreadStats       `json:"stats,omitempty"`       // Activity of thread (threads of board and thread page).
+	Score       *ContentScore      `json:"score,omitempty"`       // Vote tally of thread or post.
+	Attachments []*AttachmentRef   `json:"attachments,omitempty"` // Files attached to post (posts of thread page).
+	SpamScore   float64            `json:"spam_score,omitempty"`  // Likelihood of thread or post being spam, from 0 to 1.
+	RawBody     []byte             `json:"-"`                     // Body as signed by it's creator, for export.
 }
 
 type ContentType string
@@ -245,19 +387,28 @@ func (t *ContentType) IsValid() bool {
 		V5PostType,
 		V5ThreadVoteType,
 		V5PostVoteType,
-		V5UserVoteType:
+		V5UserVoteType,
+		V5UserProfileType,
+		V5AttachmentType,
+		V5KeyRotationType,
+		V5BoardAnnouncementType:
 		return true
 	}
 	return false
 }
 
 const (
-	V5BoardType      = ContentType("5,board")
-	V5ThreadType     = ContentType("5,thread")
-	V5PostType       = ContentType("5,post")
-	V5ThreadVoteType = ContentType("5,thread_vote")
-	V5PostVoteType   = ContentType("5,post_vote")
-	V5UserVoteType   = ContentType("5,user_vote")
+	V5BoardType       = ContentType("5,board")
+	V5ThreadType      = ContentType("5,thread")
+	V5PostType        = ContentType("5,post")
+	V5ThreadVoteType  = ContentType("5,thread_vote")
+	V5PostVoteType    = ContentType("5,post_vote")
+	V5UserVoteType    = ContentType("5,user_vote")
+	V5UserProfileType = ContentType("5,user_profile") // User's display name and avatar, about themselves.
+	V5AttachmentType  = ContentType("5,attachment")   // File attached to a post, with it's data.
+	V5KeyRotationType = ContentType("5,key_rotation") // Binds a user's new public key to their old one.
+
+	V5BoardAnnouncementType = ContentType("5,board_announcement") // Lists a board (as creator) in a registry board.
 )
 
 type ContentHeaderData struct {
@@ -10,9 +10,9 @@ import (
 	"github.com/skycoin/cxo/node"
 	"github.com/skycoin/cxo/skyobject"
 	"github.com/skycoin/skycoin/src/cipher"
-	"log"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -22,10 +22,16 @@ var (
 
 	// ErrNotEditable occurs when pack has flag 'ViewOnly' set.
 	ErrNotEditable = boo.New(boo.NotAllowed, "cannot edit root")
+
+	// ErrInstanceClosed occurs when instance is closed.
+	ErrInstanceClosed = boo.New(boo.NotAllowed, "instance closed")
 )
 
 type BoardInstance struct {
-	l *log.Logger
+	version   uint64 // Views version, only access atomically (first for 64-bit alignment).
+	latestSeq uint64 // Latest known root sequence, only access atomically.
+
+	l *inform.Logger
 
 	//adders []views.Adder // generates views.
 
@@ -35,36 +41,104 @@ type BoardInstance struct {
 	h   *Headers
 	v   *Viewer
 
+	cMux       sync.Mutex // Held by the compiler while compiling, so updates of the board do not overlap.
+	publishing uint32     // Non-zero while a periodic publish is dispatched, only access atomically.
+
+	uMux       sync.Mutex
+	updated    chan struct{}  // Closed and replaced on every successful update.
+	updatedAt  time.Time      // Time of last successful update that changed views.
+	compiledAt time.Time      // Time of last successful compile of a root.
+	diffs      []*versionDiff // Diffs of recent versions, oldest first.
+	loadedAt   time.Time      // Time the instance was initiated.
+
 	needPublish typ.Bool // Whether there are changes that need to be published.
 	needReset   typ.Bool // Whether a reset is needed.
 	isReceived  typ.Bool // Whether we have received this root.
 	isReady     typ.Bool // Whether we have received a full root.
+	isClosed    typ.Bool // Whether the instance is closed.
+
+	sortBy  string // Name of default sorter for views.
+	debug   bool   // Whether views record update diagnostics.
+	maxPins int    // Maximum number of pinned threads.
+
+	snapshotDir string // Directory of views snapshots, empty to disable.
+
+	limiter *RateLimiter // Limits submissions per user, nil for no limit.
+
+	ctx    context.Context    // Done when the instance closes, cancelling updates.
+	cancel context.CancelFunc // Cancels 'ctx'.
+
+	repTransform RepTransform // Optional transform for reps of views.
+
+	reader ContentReader // Replaces the viewer for reads, nil to read the viewer.
+
+	spamScorer    SpamScorer // Scorer of threads and posts, nil for default.
+	spamThreshold float64    // Spam score at or above which content is hidden, <= 0 to never hide.
+
+	gcRetention time.Duration        // Time dereferenced content is kept before removal, <= 0 to disable.
+	gcAt        time.Time            // Time of last garbage collection.
+	gcMarks     map[string]time.Time // Dereferenced content, and when it was first observed as such.
 }
 
 // Init initiates the  the board instance.
 func (bi *BoardInstance) Init(n *node.Node, pk cipher.PubKey) *BoardInstance {
-	bi.l = inform.NewLogger(true, os.Stdout, "INSTANCE:"+pk.Hex()[:5]+"...")
+	bi.l = inform.New(true, os.Stdout, "INSTANCE").With("board", pk.Hex()[:5]+"...")
 	bi.n = n
+	bi.updated = make(chan struct{})
+	bi.loadedAt = time.Now()
+	bi.spamThreshold = DefaultSpamThreshold
+	bi.ctx, bi.cancel = context.WithCancel(context.Background())
 
 	return bi
 }
 
-// Close closes the board instance.
+// Close closes the board instance, releasing the pack, headers and views.
+// Updates in progress are cancelled.
+// Those waiting for an update are released with ErrInstanceClosed.
+// Closing an already closed instance does nothing.
 func (bi *BoardInstance) Close() {
+	if bi.cancel != nil {
+		bi.cancel()
+	}
 	bi.mux.Lock()
 	defer bi.mux.Unlock()
 
+	if bi.isClosed.Value() {
+		return
+	}
+	bi.isClosed.Set()
+	bi.isReady.Clear()
+	bi.isReceived.Clear()
+
 	if bi.p != nil {
 		bi.p.Close()
 	}
+	bi.p, bi.h, bi.v = nil, nil, nil
+
+	bi.uMux.Lock()
+	defer bi.uMux.Unlock()
+	bi.diffs = nil
+	close(bi.updated)
+}
+
+// IsClosed determines whether the instance is closed.
+func (bi *BoardInstance) IsClosed() bool {
+	return bi.isClosed.Value()
 }
 
 // UpdateWithReceived updates pack header and views to reflect latest sequence of received root.
-func (bi *BoardInstance) UpdateWithReceived(r *skyobject.Root, sk cipher.SecKey) error {
+// If the context is cancelled before the views are updated, the pack and headers are left as they were.
+// Views are reset on the next update if the context is cancelled mid-update.
+func (bi *BoardInstance) UpdateWithReceived(ctx context.Context, r *skyobject.Root, sk cipher.SecKey) error {
 	bi.mux.Lock()
 	defer bi.mux.Unlock()
 
-	bi.l.Printf("TRIGGERED: UpdateWithReceived()")
+	bi.l.Log(inform.DebugLevel, "updating with received root", "seq", r.Seq)
+
+	if bi.isClosed.Value() {
+		return ErrInstanceClosed
+	}
+	bi.ObserveSeq(r.Seq)
 
 	bi.isReceived.Set()
 	bi.isReady.Set()
@@ -81,10 +155,12 @@ func (bi *BoardInstance) UpdateWithReceived(r *skyobject.Root, sk cipher.SecKey)
 		if bi.p.Root().Seq >= r.Seq {
 			return nil
 		}
-		bi.p.Close()
 	} else {
 		firstRun = true
 	}
+	if e := ctx.Err(); e != nil {
+		return e
+	}
 
 	// Update pack, headers and views.
 
@@ -93,45 +169,61 @@ func (bi *BoardInstance) UpdateWithReceived(r *skyobject.Root, sk cipher.SecKey)
 	}
 
 	newPack, e := ct.Unpack(r, pFlags, ct.CoreRegistry().Types(), sk)
+	fixed := false
 	if e != nil {
-		bi.l.Println(" - root unpack failed with error:", e)
-		if newPack, e = bi.fixRoot(firstRun, pFlags, r.Seq, r.Pub, sk); e != nil {
-			bi.l.Println("\t- FAILED:", e)
+		bi.l.Log(inform.WarnLevel, "root unpack failed, fixing", "seq", r.Seq, "error", e)
+		if newPack, e = bi.fixRoot(ctx, firstRun, pFlags, r.Seq, r.Pub, sk); e != nil {
+			bi.l.Log(inform.ErrorLevel, "failed to fix root", "error", e)
 			return e
-		} else {
-			bi.l.Println("\t- SUCCESS!", newPack.Root().Seq)
-			bi.needPublish.Set()
 		}
+		bi.l.Log(inform.InfoLevel, "fixed root", "seq", newPack.Root().Seq)
+		fixed = true
 	}
 
-	bi.l.Println(" - root unpack succeeded.")
-	bi.p = newPack
+	bi.l.Debugf("root unpack succeeded")
 
-	newHeaders, e := NewHeaders(bi.h, bi.p)
+	newHeaders, e := NewHeaders(ctx, bi.h, newPack)
 	if e != nil {
-		bi.l.Println(" - failed to generate new headers:", e)
+		newPack.Close()
+		bi.l.Errorf("failed to generate new headers: %v", e)
 		return e
 	}
 
-	bi.l.Println(" - new headers successfully generated.")
+	bi.l.Debugf("new headers successfully generated")
+	if bi.p != nil {
+		bi.p.Close()
+	}
+	bi.p = newPack
 	bi.h = newHeaders
+	if fixed {
+		bi.needPublish.Set()
+	}
 
-	if firstRun {
-		if bi.v, e = NewViewer(bi.p); e != nil {
+	var diff *BoardDiff
+	if firstRun || bi.needReset.Value() {
+		if bi.v, e = bi.newViewer(); e != nil {
 			return e
 		}
+		bi.needReset.Clear()
+		bi.markCompiled()
 	} else {
-		if e := bi.v.Update(bi.p, bi.h); e != nil {
+		result, e := bi.v.Update(ctx, bi.p, bi.h)
+		if e != nil {
+			bi.needReset.Set()
 			return e
 		}
+		bi.markCompiled()
+		if !result.Changed() {
+			return nil
+		}
+		diff = &result.Diff
 	}
 
-	// TODO: Broadcast changes.
-
+	bi.broadcastUpdate(diff)
 	return nil
 }
 
-func (bi *BoardInstance) fixRoot(firstRun bool, flags skyobject.Flag, goal uint64, pk cipher.PubKey, sk cipher.SecKey) (*skyobject.Pack, error) {
+func (bi *BoardInstance) fixRoot(ctx context.Context, firstRun bool, flags skyobject.Flag, goal uint64, pk cipher.PubKey, sk cipher.SecKey) (*skyobject.Pack, error) {
 	var (
 		ct        = bi.n.Container()
 		isMaster  = sk != (cipher.SecKey{})
@@ -141,6 +233,9 @@ func (bi *BoardInstance) fixRoot(firstRun bool, flags skyobject.Flag, goal uint6
 	// If we don't have old, find it.
 	if firstRun == false {
 		for i := goal; i >= 0; i-- {
+			if e := ctx.Err(); e != nil {
+				return nil, e
+			}
 			if tempRoot, e := ct.Root(pk, i); e != nil || len(tempRoot.Refs) != object.RootChildrenCount {
 				continue
 			} else if tempPack, e := ct.Unpack(tempRoot, flags, ct.CoreRegistry().Types(), sk); e != nil {
@@ -165,6 +260,10 @@ func (bi *BoardInstance) fixRoot(firstRun bool, flags skyobject.Flag, goal uint6
 	// Surpass sequence.
 	oldSeq := validPack.Root().Seq
 	for i := oldSeq; i < goal; i++ {
+		if e := ctx.Err(); e != nil {
+			validPack.Close()
+			return nil, e
+		}
 		if e := validPack.Save(); e != nil {
 			return nil, boo.WrapTypef(e, boo.Internal, "failed to surpass seq(%d)", oldSeq)
 		}
@@ -177,66 +276,193 @@ func (bi *BoardInstance) fixRoot(firstRun bool, flags skyobject.Flag, goal uint6
 // Only use if instance is initialised, changes were made, and the node owns the board.
 // Should be triggered by compiler based on an interval.
 func (bi *BoardInstance) PublishChanges() error {
+	return bi.PublishChangesWithContext(context.Background())
+}
 
-	if bi.needPublish.Value() == false {
+// PublishChangesWithContext publishes changes to CXO, and updates the views to reflect them.
+// It also resets the views if a reset is pending, even when there is nothing to publish.
+// If the context is cancelled, changes that are not yet saved remain pending,
+// and views that are not yet updated are reset on the next publish.
+func (bi *BoardInstance) PublishChangesWithContext(ctx context.Context) error {
+
+	if bi.needPublish.Value() == false && bi.needReset.Value() == false {
 		return nil
 	}
-	defer bi.needPublish.Clear()
 
 	bi.mux.Lock()
 	defer bi.mux.Unlock()
 
 	if bi.p == nil || bi.p.Flags()&skyobject.ViewOnly > 0 {
+		bi.needPublish.Clear()
 		return nil
 	}
+	if e := ctx.Err(); e != nil {
+		return e
+	}
 
 	// Update CXO.
-	if e := bi.p.Save(); e != nil {
-		return boo.WrapType(e, boo.Internal, "failed to save in cxo db")
+	if bi.needPublish.Value() {
+		if e := bi.p.Save(); e != nil {
+			return boo.WrapType(e, boo.Internal, "failed to save in cxo db")
+		}
+		bi.needPublish.Clear()
+		bi.n.Publish(bi.p.Root())
+		bi.ObserveSeq(bi.p.Root().Seq)
 	}
-	bi.n.Publish(bi.p.Root())
 
 	// Reset header and views if needed.
+	var diff *BoardDiff
 	if bi.needReset.Value() {
 
 		// Reset headers.
-		var e error
-		if bi.h, e = NewHeaders(nil, bi.p); e != nil {
+		newHeaders, e := NewHeaders(ctx, nil, bi.p)
+		if e != nil {
 			return boo.WrapType(e, boo.Internal, "failed to reset headers")
 		}
+		bi.h = newHeaders
 
 		// Reset views.
-		if bi.v, e = NewViewer(bi.p); e != nil {
+		if bi.v, e = bi.newViewer(); e != nil {
 			return boo.WrapType(e, boo.Internal, "failed to reset view")
 		}
 
 		// End the need to reset.
 		bi.needReset.Clear()
+		bi.markCompiled()
 
 	} else {
 
 		// Update headers.
-		var e error
-		if bi.h, e = NewHeaders(bi.h, bi.p); e != nil {
+		newHeaders, e := NewHeaders(ctx, bi.h, bi.p)
+		if e != nil {
+			bi.needReset.Set()
 			return boo.WrapType(e, boo.Internal, "failed to generate new headers")
 		}
+		bi.h = newHeaders
 
 		// Update views.
-		if e := bi.v.Update(bi.p, bi.h); e != nil {
+		result, e := bi.v.Update(ctx, bi.p, bi.h)
+		if e != nil {
+			bi.needReset.Set()
 			return boo.WrapType(e, boo.Internal, "failed to update view")
 		}
+		bi.markCompiled()
+		if !result.Changed() {
+			return nil
+		}
+		diff = &result.Diff
 	}
 
-	// TODO: Broadcast changes.
-
+	bi.broadcastUpdate(diff)
 	return nil
 }
 
-// Viewer obtains the viewer.
-func (bi *BoardInstance) Viewer() *Viewer {
+// WaitForUpdate blocks until the next successful update of the views, or until context is done.
+func (bi *BoardInstance) WaitForUpdate(ctx context.Context) error {
+	bi.uMux.Lock()
+	updated := bi.updated
+	bi.uMux.Unlock()
+
+	select {
+	case <-updated:
+		if bi.isClosed.Value() {
+			return ErrInstanceClosed
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// broadcastUpdate releases all that are waiting for an update.
+// The diff of the update is retained for GetBoardPageDiff; a nil diff
+// (views were reset) discards retained diffs.
+func (bi *BoardInstance) broadcastUpdate(diff *BoardDiff) {
+	bi.uMux.Lock()
+	defer bi.uMux.Unlock()
+
+	version := atomic.AddUint64(&bi.version, 1)
+	bi.retainDiff(version, diff)
+	close(bi.updated)
+	bi.updated = make(chan struct{})
+	bi.updatedAt = time.Now()
+}
+
+// GetChanges obtains the content changes of the last compiled root.
+func (bi *BoardInstance) GetChanges() *object.Changes {
+	bi.mux.RLock()
+	defer bi.mux.RUnlock()
+	if bi.h == nil {
+		return nil
+	}
+	return bi.h.GetChanges()
+}
+
+// LoadedAt obtains the time the board instance was initiated.
+func (bi *BoardInstance) LoadedAt() time.Time {
+	return bi.loadedAt
+}
+
+// UpdatedAt obtains the time of the last update that changed the views.
+// Zero if views have not changed since the instance was initiated.
+func (bi *BoardInstance) UpdatedAt() time.Time {
+	bi.uMux.Lock()
+	defer bi.uMux.Unlock()
+	return bi.updatedAt
+}
+
+// ObserveSeq records the sequence of a root known to exist, which may not yet be
+// compiled. Sequences lower than the latest known are ignored.
+func (bi *BoardInstance) ObserveSeq(seq uint64) {
+	for {
+		latest := atomic.LoadUint64(&bi.latestSeq)
+		if seq <= latest || atomic.CompareAndSwapUint64(&bi.latestSeq, latest, seq) {
+			return
+		}
+	}
+}
+
+// LatestSeq obtains the latest known root sequence.
+func (bi *BoardInstance) LatestSeq() uint64 {
+	return atomic.LoadUint64(&bi.latestSeq)
+}
+
+// markCompiled records the time of a successful compile.
+func (bi *BoardInstance) markCompiled() {
+	bi.uMux.Lock()
+	defer bi.uMux.Unlock()
+	bi.compiledAt = time.Now()
+}
+
+// CompiledAt obtains the time of the last successful compile of a root.
+// Zero if no root has been compiled.
+func (bi *BoardInstance) CompiledAt() time.Time {
+	bi.uMux.Lock()
+	defer bi.uMux.Unlock()
+	return bi.compiledAt
+}
+
+// Version obtains the version of the views, which is incremented on every
+// update that changes them. It does not wait on board locks.
+func (bi *BoardInstance) Version() uint64 {
+	return atomic.LoadUint64(&bi.version)
+}
+
+// Viewer obtains the viewer as a ContentReader, or the reader set with SetContentReader.
+func (bi *BoardInstance) Viewer() ContentReader {
+	if bi.reader != nil {
+		return bi.reader
+	}
 	return bi.v
 }
 
+// SetContentReader replaces the viewer for reads of the instance, including the
+// checks of submissions. This is to be set before the instance is used, and is
+// intended for injecting fakes. Setting nil reads the viewer again.
+func (bi *BoardInstance) SetContentReader(reader ContentReader) {
+	bi.reader = reader
+}
+
 // IsMaster determines if we are master.
 func (bi *BoardInstance) IsMaster() bool {
 	bi.mux.RLock()
@@ -377,3 +603,58 @@ func (bi *BoardInstance) Import(in *object.PagesJSON) (uint64, error) {
 	bi.needReset.Set()
 	return goal, e
 }
+
+func (bi *BoardInstance) newViewer() (*Viewer, error) {
+	v, e := bi.loadViewer()
+	if e != nil {
+		if v, e = NewViewer(bi.p, bi.sortBy); e != nil {
+			return nil, e
+		}
+		bi.saveSnapshot(v)
+	}
+	v.SetDebug(bi.debug)
+	v.SetRepTransform(bi.repTransform)
+	v.SetSpamScorer(bi.spamScorer, bi.spamThreshold)
+	return v, nil
+}
+
+// loadViewer creates a viewer from the snapshot of the current root, if any.
+func (bi *BoardInstance) loadViewer() (*Viewer, error) {
+	if bi.snapshotDir == "" {
+		return nil, boo.New(boo.NotFound, "snapshots are disabled")
+	}
+	snap, e := LoadSnapshot(bi.snapshotDir, bi.p.Root().Pub.Hex())
+	if e != nil {
+		return nil, e
+	}
+	v, e := NewViewerFromSnapshot(snap, bi.p, bi.sortBy)
+	if e != nil {
+		bi.l.Warnf("snapshot discarded: %v", e)
+		return nil, e
+	}
+	bi.l.Infof("views loaded from snapshot of seq %d", snap.RootSeq)
+	return v, nil
+}
+
+// saveSnapshot saves a snapshot of the views, if snapshots are enabled.
+func (bi *BoardInstance) saveSnapshot(v *Viewer) {
+	if bi.snapshotDir == "" {
+		return
+	}
+	if e := v.SaveSnapshot(bi.snapshotDir, bi.p.Root()); e != nil {
+		bi.l.Errorf("failed to save snapshot: %v", e)
+	}
+}
+
+// SaveSnapshot saves a snapshot of the current views, so that they can be loaded
+// rather than compiled on the next start. Does nothing if snapshots are disabled,
+// or the views are yet to be compiled or are pending a reset.
+func (bi *BoardInstance) SaveSnapshot() {
+	bi.mux.RLock()
+	defer bi.mux.RUnlock()
+
+	if bi.p == nil || bi.v == nil || bi.needReset.Value() {
+		return
+	}
+	bi.saveSnapshot(bi.v)
+}
@@ -2,41 +2,117 @@ package state
 
 import (
 	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/tag"
 	"github.com/skycoin/bbs/src/store/object"
 	"github.com/skycoin/cxo/skyobject"
+	"github.com/skycoin/skycoin/src/cipher"
+	"mime"
+	"sort"
 )
 
 func (bi *BoardInstance) Submit(transport *object.Transport) (uint64, error) {
 
 	var goal uint64
 
+	e := bi.ViewPack(func(p *skyobject.Pack, _ *Headers) error {
+		return ValidateSubmission(transport.Body, p.Root().Pub)
+	})
+	if e != nil {
+		return 0, e
+	}
+	if e := bi.Viewer().CheckNotArchived(); e != nil {
+		return 0, e
+	}
 	switch transport.Body.Type {
-	case object.V5ThreadType:
-		if e := submitThread(bi, &goal, transport.Content); e != nil {
+	case object.V5ThreadType, object.V5PostType:
+		if e := bi.Viewer().CheckSubmissionPolicy(transport.Body.Creator); e != nil {
 			return 0, e
 		}
+	}
+	// Submissions rejected by the checks of their type are not counted.
+	if e := bi.limiter.Take(transport.Body.Creator); e != nil {
+		return 0, e
+	}
+	if e := submitContent(bi, &goal, transport); e != nil {
+		bi.limiter.Refund(transport.Body.Creator)
+		return 0, e
+	}
+
+	return goal, nil
+}
+
+// submitContent checks and submits content according to it's type.
+func submitContent(bi *BoardInstance, goal *uint64, transport *object.Transport) error {
+	switch transport.Body.Type {
+	case object.V5ThreadType:
+		return submitThread(bi, goal, transport.Content)
 	case object.V5PostType:
-		if e := submitPost(bi, &goal, transport.Content); e != nil {
-			return 0, e
-		}
+		return submitPost(bi, goal, transport.Content)
 	case object.V5ThreadVoteType:
-		if e := submitThreadVote(bi, &goal, transport.Content); e != nil {
-			return 0, e
-		}
+		return submitThreadVote(bi, goal, transport.Content)
 	case object.V5PostVoteType:
-		if e := submitPostVote(bi, &goal, transport.Content); e != nil {
-			return 0, e
-		}
+		return submitPostVote(bi, goal, transport.Content)
 	case object.V5UserVoteType:
-		if e := submitUserVote(bi, &goal, transport.Content); e != nil {
-			return 0, e
-		}
+		return submitUserVote(bi, goal, transport.Content)
+	case object.V5UserProfileType:
+		return submitUserProfile(bi, goal, transport.Content)
+	case object.V5AttachmentType:
+		return submitAttachment(bi, goal, transport.Content)
+	case object.V5KeyRotationType:
+		return submitKeyRotation(bi, goal, transport.Content)
+	case object.V5BoardAnnouncementType:
+		return submitAnnouncement(bi, goal, transport.Content)
 	default:
-		return 0, boo.Newf(boo.InvalidInput,
+		return boo.Newf(boo.InvalidInput,
 			"content has invalid type '%s'", transport.Body.Type)
 	}
+}
 
-	return goal, nil
+// ValidateSubmission ensures that the references of a submitted content body
+// are well formed and that it belongs to the board of public key 'pk'.
+func ValidateSubmission(body *object.Body, pk cipher.PubKey) error {
+	what := string(body.Type)
+	if _, e := body.GetCreator(); e != nil {
+		return boo.WrapTypef(e, boo.InvalidInput, "invalid %s", what)
+	}
+	if e := checkBoardRef(pk, body, what); e != nil {
+		return boo.WrapTypef(e, boo.InvalidInput, "invalid %s", what)
+	}
+
+	var e error
+	switch body.Type {
+	case object.V5ThreadType:
+	case object.V5PostType:
+		if _, e = body.GetOfThread(); e == nil && body.OfPost != "" {
+			_, e = body.GetOfPost()
+		}
+	case object.V5ThreadVoteType:
+		_, e = body.GetOfThread()
+	case object.V5PostVoteType:
+		_, e = body.GetOfPost()
+	case object.V5UserVoteType:
+		_, e = body.GetOfUser()
+	case object.V5UserProfileType:
+		if len([]rune(body.Name)) > MaxDisplayNameLength {
+			e = boo.Newf(boo.InvalidInput,
+				"display name exceeds %d characters", MaxDisplayNameLength)
+		}
+	case object.V5AttachmentType:
+		if _, e = body.GetOfPost(); e == nil {
+			e = checkAttachment(body)
+		}
+	case object.V5KeyRotationType:
+		e = body.VerifyKeyRotation()
+	case object.V5BoardAnnouncementType:
+		e = checkAnnouncement(body)
+	default:
+		return boo.Newf(boo.InvalidInput,
+			"content has invalid type '%s'", body.Type)
+	}
+	if e != nil {
+		return boo.WrapTypef(e, boo.InvalidInput, "invalid %s", what)
+	}
+	return nil
 }
 
 func submitThread(bi *BoardInstance, goal *uint64, thread *object.Content) error {
@@ -172,6 +248,82 @@ func submitUserVote(bi *BoardInstance, goal *uint64, uVote *object.Content) erro
 	})
 }
 
+// MaxDisplayNameLength is the maximum number of characters of a user's display name.
+const MaxDisplayNameLength = 64
+
+func submitUserProfile(bi *BoardInstance, goal *uint64, profile *object.Content) error {
+	body := profile.GetBody()
+
+	return bi.EditPack(func(p *skyobject.Pack, h *Headers) error {
+		*goal = p.Root().Seq + 1
+		return addVoteToDiffAndProfile(p, h, profile, body.Creator)
+	})
+}
+
+// Limits of attachments.
+const (
+	MaxAttachmentSize       = 1 << 20 // Maximum number of bytes of an attachment.
+	MaxAttachmentsOfPost    = 4       // Maximum number of attachments of a post.
+	MaxAttachmentNameLength = 255     // Maximum number of characters of an attachment's file name.
+)
+
+// checkAttachment ensures that the attachment's data is within limits and is described.
+func checkAttachment(body *object.Body) error {
+	switch {
+	case len(body.Data) == 0:
+		return boo.New(boo.InvalidInput, "attachment has no data")
+	case len(body.Data) > MaxAttachmentSize:
+		return boo.Newf(boo.InvalidInput,
+			"attachment of %d bytes exceeds %d bytes", len(body.Data), MaxAttachmentSize)
+	case body.Name == "" || len([]rune(body.Name)) > MaxAttachmentNameLength:
+		return boo.Newf(boo.InvalidInput,
+			"attachment name needs to be between 1 and %d characters", MaxAttachmentNameLength)
+	}
+	if _, _, e := mime.ParseMediaType(body.MediaType); e != nil {
+		return boo.WrapType(e, boo.InvalidInput, "invalid attachment media type")
+	}
+	return nil
+}
+
+func submitAttachment(bi *BoardInstance, goal *uint64, attachment *object.Content) error {
+	body := attachment.GetBody()
+
+	if e := bi.Viewer().CheckAttachment(body); e != nil {
+		return e
+	}
+
+	return bi.EditPack(func(p *skyobject.Pack, h *Headers) error {
+		*goal = p.Root().Seq + 1
+		return addVoteToDiffAndProfile(p, h, attachment, body.Creator)
+	})
+}
+
+func submitKeyRotation(bi *BoardInstance, goal *uint64, rotation *object.Content) error {
+	body := rotation.GetBody()
+
+	if e := bi.Viewer().CheckKeyRotation(body); e != nil {
+		return e
+	}
+
+	return bi.EditPack(func(p *skyobject.Pack, h *Headers) error {
+		*goal = p.Root().Seq + 1
+		return addVoteToDiffAndProfile(p, h, rotation, body.Creator)
+	})
+}
+
+func submitAnnouncement(bi *BoardInstance, goal *uint64, announcement *object.Content) error {
+	body := announcement.GetBody()
+
+	if e := bi.Viewer().CheckAnnouncement(body); e != nil {
+		return e
+	}
+
+	return bi.EditPack(func(p *skyobject.Pack, h *Headers) error {
+		*goal = p.Root().Seq + 1
+		return addVoteToDiffAndProfile(p, h, announcement, body.Creator)
+	})
+}
+
 func addContentToDiffAndProfile(p *skyobject.Pack, h *Headers,
 	pages *object.Pages, content *object.Content, creator string,
 ) error {
@@ -238,6 +390,211 @@ func (bi *BoardInstance) GetSubmissionKeys() []*object.MessengerSubKeyTransport
 	return subKeys
 }
 
+// MaxPeers is the maximum number of peer addresses advertised by a board.
+const MaxPeers = 16
+
+// EnsurePeers sets the addresses of CXO peers advertised by the board, for peer
+// exchange. The board is only edited if the addresses changed.
+func (bi *BoardInstance) EnsurePeers(peers []string) (uint64, error) {
+	peers = append([]string(nil), peers...)
+	sort.Strings(peers)
+	if len(peers) > MaxPeers {
+		peers = peers[:MaxPeers]
+	}
+	if current := bi.GetPeers(); len(current) == len(peers) {
+		same := true
+		for i := range peers {
+			same = same && current[i] == peers[i]
+		}
+		if same {
+			return 0, nil
+		}
+	}
+	bi.l.Println("ensuring peers as:", peers)
+	return bi.EditBoard(func(board *object.Content) (bool, error) {
+		body := board.GetBody()
+		body.Peers = peers
+		board.SetBody(body)
+		return true, nil
+	})
+}
+
+// GetPeers obtains the valid addresses of CXO peers advertised by the board.
+func (bi *BoardInstance) GetPeers() []string {
+	var peers []string
+	if e := bi.ViewBoard(func(board *object.Content) (bool, error) {
+		for _, address := range board.GetBody().Peers {
+			if len(peers) == MaxPeers {
+				break
+			}
+			if tag.CheckAddress(address) == nil {
+				peers = append(peers, address)
+			}
+		}
+		return false, nil
+	}); e != nil {
+		bi.l.Println("error obtaining peers:", e)
+		return nil
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// DefaultMaxPins is the default maximum number of pinned threads of a board.
+const DefaultMaxPins = 5
+
+// SetPinOrder sets the ordered list of pinned threads of the board.
+// An empty list unpins all threads.
+func (bi *BoardInstance) SetPinOrder(tHashes []cipher.SHA256) (uint64, error) {
+	maxPins := bi.maxPins
+	if maxPins <= 0 {
+		maxPins = DefaultMaxPins
+	}
+	if len(tHashes) > maxPins {
+		return 0, boo.Newf(boo.InvalidInput,
+			"%d pins exceeds the maximum of %d", len(tHashes), maxPins)
+	}
+	pins := make([]string, len(tHashes))
+	seen := make(map[cipher.SHA256]struct{}, len(tHashes))
+	for i, tHash := range tHashes {
+		if _, ok := seen[tHash]; ok {
+			return 0, boo.Newf(boo.InvalidInput,
+				"thread '%s' is pinned more than once", tHash.Hex())
+		}
+		seen[tHash] = struct{}{}
+		if !bi.Viewer().HasThread(tHash.Hex()) {
+			return 0, boo.Newf(boo.NotFound,
+				"thread '%s' does not exist", tHash.Hex())
+		}
+		pins[i] = tHash.Hex()
+	}
+	bi.l.Println("setting pin order as:", pins)
+	return bi.EditBoard(func(board *object.Content) (bool, error) {
+		body := board.GetBody()
+		body.Pins = pins
+		board.SetBody(body)
+		return true, nil
+	})
+}
+
+// MaxRetention is the maximum number of days a board can retain threads before archiving.
+const MaxRetention = 36500
+
+// SetRetention sets the number of days after which threads of the board are archived.
+// Archived threads are excluded from the board page unless requested. 0 disables archiving.
+func (bi *BoardInstance) SetRetention(days int) (uint64, error) {
+	if days < 0 || days > MaxRetention {
+		return 0, boo.Newf(boo.InvalidInput,
+			"retention of %d days is not between %d and %d inclusive", days, 0, MaxRetention)
+	}
+	bi.l.Printf("setting retention as: %d days", days)
+	return bi.EditBoard(func(board *object.Content) (bool, error) {
+		body := board.GetBody()
+		body.Retention = days
+		board.SetBody(body)
+		return true, nil
+	})
+}
+
+// SetSubmissionPolicy sets the restrictions on who may submit threads and posts to the board.
+// Nil removes all restrictions.
+func (bi *BoardInstance) SetSubmissionPolicy(policy *object.SubmissionPolicy) (uint64, error) {
+	bi.l.Printf("setting submission policy as: %+v", policy)
+	return bi.EditBoard(func(board *object.Content) (bool, error) {
+		body := board.GetBody()
+		body.Policy = policy
+		board.SetBody(body)
+		return true, nil
+	})
+}
+
+// SetArchived sets whether the board is archived. Archived boards do not accept
+// submissions, while their existing content continues to be served.
+func (bi *BoardInstance) SetArchived(archived bool) (uint64, error) {
+	bi.l.Printf("setting archived as: %v", archived)
+	return bi.EditBoard(func(board *object.Content) (bool, error) {
+		body := board.GetBody()
+		body.Archived = archived
+		board.SetBody(body)
+		return true, nil
+	})
+}
+
+// SetRegistry sets whether the board is a registry. Registry boards accept
+// announcements of other boards, and list them for board discovery.
+func (bi *BoardInstance) SetRegistry(registry bool) (uint64, error) {
+	bi.l.Printf("setting registry as: %v", registry)
+	return bi.EditBoard(func(board *object.Content) (bool, error) {
+		body := board.GetBody()
+		body.Registry = registry
+		board.SetBody(body)
+		return true, nil
+	})
+}
+
+// Limits of board metadata.
+const (
+	MaxBoardDescriptionLength = 1024 // Maximum number of characters of a board's description.
+	MaxBoardRules             = 20   // Maximum number of rules of a board.
+	MaxBoardRuleLength        = 280  // Maximum number of characters of a rule.
+	MaxBoardTags              = 10   // Maximum number of tags of a board.
+	MaxBoardTagLength         = 32   // Maximum number of characters of a tag or category.
+)
+
+// CheckBoardMeta ensures that board metadata is within limits and that the icon, if set,
+// is a valid hash. Nil metadata is valid.
+func CheckBoardMeta(meta *object.BoardMeta) error {
+	if meta == nil {
+		return nil
+	}
+	switch {
+	case len([]rune(meta.Description)) > MaxBoardDescriptionLength:
+		return boo.Newf(boo.InvalidInput,
+			"board description exceeds %d characters", MaxBoardDescriptionLength)
+	case len(meta.Rules) > MaxBoardRules:
+		return boo.Newf(boo.InvalidInput,
+			"board has %d rules, maximum is %d", len(meta.Rules), MaxBoardRules)
+	case len(meta.Tags) > MaxBoardTags:
+		return boo.Newf(boo.InvalidInput,
+			"board has %d tags, maximum is %d", len(meta.Tags), MaxBoardTags)
+	case len([]rune(meta.Category)) > MaxBoardTagLength:
+		return boo.Newf(boo.InvalidInput,
+			"board category exceeds %d characters", MaxBoardTagLength)
+	}
+	for i, rule := range meta.Rules {
+		if rule == "" || len([]rune(rule)) > MaxBoardRuleLength {
+			return boo.Newf(boo.InvalidInput,
+				"board rule %d is empty or exceeds %d characters", i, MaxBoardRuleLength)
+		}
+	}
+	for _, t := range meta.Tags {
+		if t == "" || len([]rune(t)) > MaxBoardTagLength {
+			return boo.Newf(boo.InvalidInput,
+				"board tag '%s' is empty or exceeds %d characters", t, MaxBoardTagLength)
+		}
+	}
+	if meta.Icon != "" {
+		if _, e := tag.GetHash(meta.Icon); e != nil {
+			return boo.WrapType(e, boo.InvalidInput, "invalid board icon hash")
+		}
+	}
+	return nil
+}
+
+// SetBoardMeta sets the structured metadata of the board. Nil removes the metadata.
+func (bi *BoardInstance) SetBoardMeta(meta *object.BoardMeta) (uint64, error) {
+	if e := CheckBoardMeta(meta); e != nil {
+		return 0, e
+	}
+	bi.l.Printf("setting board metadata as: %+v", meta)
+	return bi.EditBoard(func(board *object.Content) (bool, error) {
+		body := board.GetBody()
+		body.Meta = meta
+		board.SetBody(body)
+		return true, nil
+	})
+}
+
 // BoardAction is a function in which board modification/viewing takes place.
 // Returns a boolean that represents whether changes have been made and
 // an error on failure.
@@ -1,6 +1,9 @@
 package state
 
 import (
+	"encoding/json"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
 	"github.com/skycoin/bbs/src/store/object"
 	"github.com/skycoin/skycoin/src/cipher"
 	"math/rand"
@@ -129,8 +132,9 @@ func TestBoardInstance_NewThread(t *testing.T) {
 			for {
 				select {
 				case <-ticker.C:
+					// Errors do not stop the loop, as the content goroutine waits on it to quit.
 					if e := bi.PublishChanges(); e != nil {
-						t.Fatal("failed to publish changes:", e)
+						t.Error("failed to publish changes:", e)
 					}
 				case <-quitChan:
 					return
@@ -146,7 +150,7 @@ func TestBoardInstance_NewThread(t *testing.T) {
 				time.Sleep(time.Millisecond * 50)
 			}
 			if e := bi.PublishChanges(); e != nil {
-				t.Fatal("failed to publish changes:", e)
+				t.Error("failed to publish changes:", e)
 			}
 			publishLoopBegin <- struct{}{}
 			for i := 0; i < postCount; i++ {
@@ -181,7 +185,7 @@ func TestBoardInstance_NewThread(t *testing.T) {
 
 					}
 					if _, e := bi.EnsureSubmissionKeys(subKeys); e != nil {
-						t.Fatal("failed to change board submission keys:", e)
+						t.Error("failed to change board submission keys:", e)
 					}
 				}
 			}
@@ -190,3 +194,504 @@ func TestBoardInstance_NewThread(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+func TestBoardInstance_Submit_rateLimit(t *testing.T) {
+	const userSeed = "user"
+
+	bi, quit := initInstance(t, "a")
+	defer quit()
+	bi.limiter = NewRateLimiter(1, 1)
+
+	cpk, csk := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+	unknown := cipher.SumSHA256([]byte("unknown thread"))
+	raw, _ := json.Marshal(&object.Body{
+		Type:     object.V5PostType,
+		TS:       time.Now().UnixNano(),
+		OfBoard:  obtainBoardPubKey(t, bi).Hex(),
+		OfThread: unknown.Hex(),
+		Body:     "A post of a thread that does not exist.",
+		Creator:  cpk.Hex(),
+	})
+	transport, e := object.NewTransport(raw, cipher.SignHash(cipher.SumSHA256(raw), csk))
+	if e != nil {
+		t.Fatal("failed to generate transport:", e)
+	}
+
+	// Rejected submissions do not use up the allowance.
+	for i := 0; i < 3; i++ {
+		if _, e := bi.Submit(transport); e == nil || boo.Type(e) == boo.TooManyRequests {
+			t.Fatalf("rejected submission %d: got %v, expected rejection of the post", i, e)
+		}
+	}
+	if status := bi.limiter.Status(cpk.Hex()); status.Remaining != 1 {
+		t.Errorf("after rejections: got remaining %d, expected %d", status.Remaining, 1)
+	}
+	addThread(t, bi, 0, []byte(userSeed))
+	if _, e := bi.Submit(transport); boo.Type(e) != boo.TooManyRequests {
+		t.Errorf("submission beyond allowance: got %v, expected too many requests", e)
+	}
+}
+
+func TestValidateSubmission(t *testing.T) {
+	var (
+		bpk, _     = cipher.GenerateDeterministicKeyPair([]byte("board"))
+		wrongPK, _ = cipher.GenerateDeterministicKeyPair([]byte("other"))
+		upk, _     = cipher.GenerateDeterministicKeyPair([]byte("user"))
+		hash       = cipher.SumSHA256([]byte("content")).Hex()
+	)
+	cases := []struct {
+		name  string
+		body  *object.Body
+		valid bool
+	}{
+		{"thread", &object.Body{Type: object.V5ThreadType, OfBoard: bpk.Hex(), Creator: upk.Hex()}, true},
+		{"thread of other board", &object.Body{Type: object.V5ThreadType, OfBoard: wrongPK.Hex(), Creator: upk.Hex()}, false},
+		{"thread without creator", &object.Body{Type: object.V5ThreadType, OfBoard: bpk.Hex()}, false},
+		{"post", &object.Body{Type: object.V5PostType, OfBoard: bpk.Hex(), OfThread: hash, Creator: upk.Hex()}, true},
+		{"post of post", &object.Body{Type: object.V5PostType, OfBoard: bpk.Hex(), OfThread: hash, OfPost: hash, Creator: upk.Hex()}, true},
+		{"post without thread", &object.Body{Type: object.V5PostType, OfBoard: bpk.Hex(), Creator: upk.Hex()}, false},
+		{"post of corrupt post", &object.Body{Type: object.V5PostType, OfBoard: bpk.Hex(), OfThread: hash, OfPost: "bad", Creator: upk.Hex()}, false},
+		{"thread vote", &object.Body{Type: object.V5ThreadVoteType, OfBoard: bpk.Hex(), OfThread: hash, Creator: upk.Hex()}, true},
+		{"thread vote without thread", &object.Body{Type: object.V5ThreadVoteType, OfBoard: bpk.Hex(), Creator: upk.Hex()}, false},
+		{"post vote", &object.Body{Type: object.V5PostVoteType, OfBoard: bpk.Hex(), OfPost: hash, Creator: upk.Hex()}, true},
+		{"post vote without post", &object.Body{Type: object.V5PostVoteType, OfBoard: bpk.Hex(), Creator: upk.Hex()}, false},
+		{"user vote", &object.Body{Type: object.V5UserVoteType, OfBoard: bpk.Hex(), OfUser: upk.Hex(), Creator: upk.Hex()}, true},
+		{"user vote without user", &object.Body{Type: object.V5UserVoteType, OfBoard: bpk.Hex(), Creator: upk.Hex()}, false},
+		{"unknown type", &object.Body{Type: "5,unknown", OfBoard: bpk.Hex(), Creator: upk.Hex()}, false},
+	}
+	for _, c := range cases {
+		e := ValidateSubmission(c.body, bpk)
+		if c.valid && e != nil {
+			t.Errorf("%s: expected valid, got error: %v", c.name, e)
+		} else if !c.valid && e == nil {
+			t.Errorf("%s: expected error", c.name)
+		}
+	}
+}
+
+func TestBoardInstance_SetSubmissionPolicy(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	if _, e := bi.SetSubmissionPolicy(&object.SubmissionPolicy{TrustedOnly: true}); e != nil {
+		t.Fatal("failed to set submission policy:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	upk, _ := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+	if e := bi.Viewer().CheckSubmissionPolicy(upk.Hex()); e == nil {
+		t.Error("expected untrusted user to be rejected")
+	}
+	if e := bi.Viewer().CheckSubmissionPolicy(obtainBoardPubKey(t, bi).Hex()); e != nil {
+		t.Error("expected board owner to be accepted:", e)
+	}
+
+	page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+		Perspective:    upk.Hex(),
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get board page:", e)
+	}
+	if page.Policy.Eligible || page.Policy.Reason == "" {
+		t.Errorf("expected ineligible perspective with reason, got %+v", page.Policy)
+	}
+}
+
+func TestBoardInstance_SetBoardMeta(t *testing.T) {
+	bi, quit := initInstance(t, "a")
+	defer quit()
+
+	if _, e := bi.SetBoardMeta(&object.BoardMeta{Icon: "not a hash"}); e == nil {
+		t.Error("expected error for invalid icon hash")
+	}
+	if _, e := bi.SetBoardMeta(&object.BoardMeta{Tags: make([]string, MaxBoardTags+1)}); e == nil {
+		t.Error("expected error for too many tags")
+	}
+
+	meta := &object.BoardMeta{
+		Description: "A board for testing.",
+		Rules:       []string{"Be nice."},
+		Tags:        []string{"test"},
+		Category:    "misc",
+		Icon:        cipher.SumSHA256([]byte("icon")).Hex(),
+	}
+	if _, e := bi.SetBoardMeta(meta); e != nil {
+		t.Fatal("failed to set board metadata:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get board page:", e)
+	}
+	got := page.Board.Body.(*object.Body).Meta
+	if got == nil || got.Description != meta.Description || got.Category != meta.Category ||
+		got.Icon != meta.Icon || len(got.Rules) != 1 || len(got.Tags) != 1 {
+		t.Errorf("board metadata: got %+v, expected %+v", got, meta)
+	}
+}
+
+func TestBoardInstance_SetArchived(t *testing.T) {
+	const userSeed = "user"
+
+	bi, quit := initInstance(t, "a")
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	if _, e := bi.SetArchived(true); e != nil {
+		t.Fatal("failed to archive board:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cpk, csk := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+	body := &object.Body{
+		Type:     object.V5ThreadVoteType,
+		TS:       time.Now().UnixNano(),
+		OfBoard:  bi.v.pk.Hex(),
+		OfThread: tHash.Hex(),
+		Value:    +1,
+		Creator:  cpk.Hex(),
+	}
+	raw, _ := json.Marshal(body)
+	transport, e := object.NewTransport(raw, cipher.SignHash(cipher.SumSHA256(raw), csk))
+	if e != nil {
+		t.Fatal("failed to generate transport:", e)
+	}
+	if _, e := bi.Submit(transport); e != ErrBoardArchived {
+		t.Errorf("submit to archived board: got error %v, expected %v", e, ErrBoardArchived)
+	}
+
+	page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get board page:", e)
+	}
+	if len(page.Threads) != 1 || page.Policy.Eligible {
+		t.Errorf("expected existing thread and ineligible policy, got %d threads and %+v",
+			len(page.Threads), page.Policy)
+	}
+
+	if _, e := bi.SetArchived(false); e != nil {
+		t.Fatal("failed to unarchive board:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	if _, e := bi.Submit(transport); e != nil {
+		t.Error("failed to submit to unarchived board:", e)
+	}
+}
+
+func addAttachment(bi *BoardInstance, pHash string, data []byte, userSeed []byte) (uint64, error) {
+	cpk, csk := cipher.GenerateDeterministicKeyPair(userSeed)
+	body := &object.Body{
+		Type:      object.V5AttachmentType,
+		TS:        time.Now().UnixNano(),
+		OfBoard:   bi.v.pk.Hex(),
+		OfPost:    pHash,
+		Name:      "file.txt",
+		MediaType: "text/plain",
+		Data:      data,
+		Creator:   cpk.Hex(),
+	}
+	raw, _ := json.Marshal(body)
+	transport, e := object.NewTransport(raw, cipher.SignHash(cipher.SumSHA256(raw), csk))
+	if e != nil {
+		return 0, e
+	}
+	return bi.Submit(transport)
+}
+
+func TestBoardInstance_Submit_attachment(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+		otherSeed = "other"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	page, e := bi.Viewer().GetThreadPage(&ThreadPageIn{
+		ThreadHash:     tHash.Hex(),
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil || len(page.Posts) != 1 {
+		t.Fatal("failed to get post of thread:", e)
+	}
+	pHash := page.Posts[0].Header.Hash
+
+	if _, e := addAttachment(bi, pHash, []byte("hello"), []byte(otherSeed)); e == nil {
+		t.Error("expected attachment of another user's post to be rejected")
+	}
+	if _, e := addAttachment(bi, pHash, make([]byte, MaxAttachmentSize+1), []byte(userSeed)); e == nil {
+		t.Error("expected oversized attachment to be rejected")
+	}
+	if _, e := addAttachment(bi, pHash, []byte("hello"), []byte(userSeed)); e != nil {
+		t.Fatal("failed to submit attachment:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	refs, e := bi.Viewer().GetAttachments(pHash)
+	if e != nil {
+		t.Fatal("failed to get attachments:", e)
+	}
+	if len(refs) != 1 || refs[0].Size != len("hello") {
+		t.Fatalf("expected one attachment of %d bytes, got %v", len("hello"), refs)
+	}
+	attachment, e := bi.Viewer().GetAttachment(refs[0].Hash)
+	if e != nil {
+		t.Fatal("failed to get attachment:", e)
+	}
+	if string(attachment.Data) != "hello" || attachment.OfPost != pHash {
+		t.Errorf("unexpected attachment: %+v", attachment)
+	}
+	page, e = bi.Viewer().GetThreadPage(&ThreadPageIn{
+		ThreadHash:     tHash.Hex(),
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get thread page:", e)
+	}
+	if len(page.Posts[0].Attachments) != 1 {
+		t.Errorf("expected post to list it's attachment, got %v", page.Posts[0].Attachments)
+	}
+}
+
+func rotateKey(bi *BoardInstance, fromSeed, toSeed string) error {
+	fpk, fsk := cipher.GenerateDeterministicKeyPair([]byte(fromSeed))
+	tpk, tsk := cipher.GenerateDeterministicKeyPair([]byte(toSeed))
+	body := &object.Body{
+		Type:    object.V5KeyRotationType,
+		TS:      time.Now().UnixNano(),
+		OfBoard: bi.v.pk.Hex(),
+		OfUser:  fpk.Hex(),
+		Proof:   cipher.SignHash(object.KeyRotationHash(tpk.Hex()), fsk).Hex(),
+		Creator: tpk.Hex(),
+	}
+	raw, _ := json.Marshal(body)
+	transport, e := object.NewTransport(raw, cipher.SignHash(cipher.SumSHA256(raw), tsk))
+	if e != nil {
+		return e
+	}
+	_, e = bi.Submit(transport)
+	return e
+}
+
+func TestBoardInstance_KeyRotation(t *testing.T) {
+	const (
+		oldSeed = "old"
+		newSeed = "new"
+	)
+	oldPK, _ := cipher.GenerateDeterministicKeyPair([]byte(oldSeed))
+	newPK, _ := cipher.GenerateDeterministicKeyPair([]byte(newSeed))
+
+	bi, quit := initInstance(t, "a")
+	defer quit()
+
+	addThread(t, bi, 0, []byte(oldSeed))
+	addUserProfile(t, bi, "Old", 1, []byte(oldSeed))
+	if e := rotateKey(bi, oldSeed, newSeed); e != nil {
+		t.Fatal("failed to submit key rotation:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	tHash, _ := addThread(t, bi, 1, []byte(newSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	t.Run("rejects_invalid", func(t *testing.T) {
+		if e := rotateKey(bi, oldSeed, "other"); boo.Type(e) != boo.AlreadyExists {
+			t.Errorf("rotating key twice: got error %v", e)
+		}
+		if e := rotateKey(bi, newSeed, oldSeed); boo.Type(e) != boo.NotAllowed {
+			t.Errorf("rotating key in a cycle: got error %v", e)
+		}
+	})
+
+	t.Run("merges_identity", func(t *testing.T) {
+		out, e := bi.Viewer().GetUserProfile(&UserProfileIn{
+			UserPubKey:    newPK.Hex(),
+			RecentContent: 10,
+		})
+		if e != nil {
+			t.Fatal("failed to get user profile:", e)
+		}
+		if out.Identity == nil || out.Identity.Identity != oldPK.Hex() || out.Identity.Current != newPK.Hex() {
+			t.Fatalf("unexpected identity: %+v", out.Identity)
+		}
+		if out.Profile.ThreadCount != 2 || out.Profile.DisplayName != "Old" {
+			t.Errorf("expected merged profile, got %+v", out.Profile)
+		}
+		if len(out.RecentContent) != 2 {
+			t.Errorf("expected 2 recent threads, got %d", len(out.RecentContent))
+		}
+	})
+
+	t.Run("displays_identity", func(t *testing.T) {
+		page, e := bi.Viewer().GetThreadPage(&ThreadPageIn{
+			ThreadHash:     tHash.Hex(),
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get thread page:", e)
+		}
+		if page.Thread.Identity != oldPK.Hex() {
+			t.Errorf("expected thread of identity %s, got '%s'", oldPK.Hex(), page.Thread.Identity)
+		}
+	})
+}
+
+func TestBoardInstance_EnsurePeers(t *testing.T) {
+	bi, quit := initInstance(t, "a")
+	defer quit()
+
+	peers := []string{"127.0.0.1:8999", "127.0.0.1:8998"}
+	if goal, e := bi.EnsurePeers(peers); e != nil {
+		t.Fatal("failed to ensure peers:", e)
+	} else if goal == 0 {
+		t.Error("expected board to be edited")
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	got := bi.GetPeers()
+	if len(got) != 2 || got[0] != "127.0.0.1:8998" || got[1] != "127.0.0.1:8999" {
+		t.Errorf("unexpected peers: %v", got)
+	}
+	if peers[0] != "127.0.0.1:8999" {
+		t.Error("input peers should not be modified")
+	}
+	if goal, e := bi.EnsurePeers([]string{"127.0.0.1:8999", "127.0.0.1:8998"}); e != nil {
+		t.Fatal("failed to ensure peers:", e)
+	} else if goal != 0 {
+		t.Error("expected unchanged peers to not edit board")
+	}
+}
+
+func announceBoard(bi *BoardInstance, seed, name, category string, ts int64) error {
+	pk, sk := cipher.GenerateDeterministicKeyPair([]byte(seed))
+	body := &object.Body{
+		Type:    object.V5BoardAnnouncementType,
+		TS:      ts,
+		OfBoard: bi.v.pk.Hex(),
+		Name:    name,
+		Meta:    &object.BoardMeta{Category: category},
+		Creator: pk.Hex(),
+	}
+	raw, _ := json.Marshal(body)
+	transport, e := object.NewTransport(raw, cipher.SignHash(cipher.SumSHA256(raw), sk))
+	if e != nil {
+		return e
+	}
+	_, e = bi.Submit(transport)
+	return e
+}
+
+func TestBoardInstance_Announcement(t *testing.T) {
+	bi, quit := initInstance(t, "registry")
+	defer quit()
+
+	if e := announceBoard(bi, "a", "Golang Talk", "Programming", 1); e != ErrNotRegistry {
+		t.Fatalf("announcing to board that is not a registry: got error %v", e)
+	}
+	if _, e := bi.Viewer().GetAnnouncements(&AnnouncementsIn{}); e != ErrNotRegistry {
+		t.Fatalf("discovering boards of board that is not a registry: got error %v", e)
+	}
+	if _, e := bi.SetRegistry(true); e != nil {
+		t.Fatal("failed to set registry:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	if e := announceBoard(bi, "a", "Golang Talk", "Programming", 1); e != nil {
+		t.Fatal("failed to announce board:", e)
+	}
+	if e := announceBoard(bi, "b", "Cooking", "Food", 2); e != nil {
+		t.Fatal("failed to announce board:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	t.Run("rejects_outdated", func(t *testing.T) {
+		if e := announceBoard(bi, "a", "Golang", "Programming", 1); boo.Type(e) != boo.AlreadyExists {
+			t.Errorf("announcing outdated: got error %v", e)
+		}
+		if e := announceBoard(bi, "c", "", "", 3); boo.Type(e) != boo.InvalidInput {
+			t.Errorf("announcing unnamed: got error %v", e)
+		}
+	})
+
+	t.Run("searches", func(t *testing.T) {
+		all, e := bi.Viewer().GetAnnouncements(&AnnouncementsIn{})
+		if e != nil {
+			t.Fatal("failed to get announcements:", e)
+		}
+		if len(all) != 2 || all[0].Name != "Cooking" || all[1].Name != "Golang Talk" {
+			t.Errorf("expected newest first, got %+v", all)
+		}
+		found, e := bi.Viewer().GetAnnouncements(&AnnouncementsIn{Query: "golang programming"})
+		if e != nil {
+			t.Fatal("failed to get announcements:", e)
+		}
+		if len(found) != 1 || found[0].Name != "Golang Talk" {
+			t.Errorf("unexpected search result: %+v", found)
+		}
+		found, e = bi.Viewer().GetAnnouncements(&AnnouncementsIn{Category: "food"})
+		if e != nil {
+			t.Fatal("failed to get announcements:", e)
+		}
+		if len(found) != 1 || found[0].Name != "Cooking" {
+			t.Errorf("unexpected category result: %+v", found)
+		}
+	})
+
+	t.Run("replaces_with_newer", func(t *testing.T) {
+		if e := announceBoard(bi, "a", "Go", "Programming", 3); e != nil {
+			t.Fatal("failed to announce board:", e)
+		}
+		if e := bi.PublishChanges(); e != nil {
+			t.Fatal("failed to publish changes:", e)
+		}
+		pk, _ := cipher.GenerateDeterministicKeyPair([]byte("a"))
+		an, e := bi.Viewer().GetAnnouncement(pk.Hex())
+		if e != nil {
+			t.Fatal("failed to get announcement:", e)
+		}
+		if an.Name != "Go" || an.TS != 3 {
+			t.Errorf("expected newer announcement, got %+v", an)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/cxo/skyobject"
+)
+
+// ImportArchiveResult describes what was imported from an archive.
+type ImportArchiveResult struct {
+	Threads     int `json:"threads"`
+	Posts       int `json:"posts"`
+	Submissions int `json:"submissions"` // Votes, profiles, attachments, key rotations and announcements.
+	Skipped     int `json:"skipped"`     // Content that already exists or failed verification.
+}
+
+// ImportArchive seeds the board with the content of an archive, in a single edit.
+// The board's content is replaced by the archived board content. Threads that
+// already exist are skipped along with their posts, as is content that fails
+// verification against it's header.
+func (bi *BoardInstance) ImportArchive(archive *Archive) (*ImportArchiveResult, uint64, error) {
+	var (
+		goal   uint64
+		result = new(ImportArchiveResult)
+	)
+	board, e := archive.Content.ToContent()
+	if e != nil {
+		return nil, 0, e
+	}
+	e = bi.EditPack(func(p *skyobject.Pack, h *Headers) error {
+		goal = p.Root().Seq + 1
+
+		pages, e := object.GetPages(p, &object.GetPagesIn{
+			RootPage:  false,
+			BoardPage: true,
+			DiffPage:  true,
+			UsersPage: true,
+		})
+		if e != nil {
+			return e
+		}
+		if archive.Board != p.Root().Pub.Hex() {
+			return boo.Newf(boo.InvalidInput,
+				"archive is of board '%s', not '%s'", archive.Board, p.Root().Pub.Hex())
+		}
+		if e := pages.BoardPage.Board.SetValue(board); e != nil {
+			return e
+		}
+
+		for _, at := range archive.Threads {
+			thread, e := at.Thread.ToContent()
+			if e != nil || checkBoardRef(p.Root().Pub, at.Thread.Body, "thread") != nil {
+				result.Skipped += 1 + len(at.Posts)
+				continue
+			}
+			if _, has := h.GetThreadPageHash(at.Thread.Header.Hash); has {
+				result.Skipped += 1 + len(at.Posts)
+				continue
+			}
+			tp := &object.ThreadPageJSON{Thread: thread}
+			for _, ap := range at.Posts {
+				post, e := ap.ToContent()
+				if e != nil || ap.Body.OfThread != at.Thread.Header.Hash {
+					result.Skipped++
+					continue
+				}
+				tp.Posts = append(tp.Posts, post)
+			}
+			tPage, e := object.NewThreadPage(p, tp)
+			if e != nil {
+				return e
+			}
+			if e := pages.BoardPage.Threads.Append(*tPage); e != nil {
+				return e
+			}
+			for _, c := range append([]*object.Content{thread}, tp.Posts...) {
+				if e := addContentToDiffAndProfile(p, h, pages, c, c.GetBody().Creator); e != nil {
+					return e
+				}
+			}
+			result.Threads++
+			result.Posts += len(tp.Posts)
+		}
+
+		var submissions []*ArchiveContent
+		submissions = append(submissions, archive.Profiles...)
+		submissions = append(submissions, archive.Votes...)
+		submissions = append(submissions, archive.Attachments...)
+		submissions = append(submissions, archive.Rotations...)
+		submissions = append(submissions, archive.Listings...)
+		for _, as := range submissions {
+			c, e := as.ToContent()
+			if e != nil || ValidateSubmission(as.Body, p.Root().Pub) != nil {
+				result.Skipped++
+				continue
+			}
+			if e := addContentToDiffAndProfile(p, h, pages, c, as.Body.Creator); e != nil {
+				return e
+			}
+			result.Submissions++
+		}
+		return pages.Save(p)
+	})
+	bi.needReset.Set()
+	if e != nil {
+		return nil, 0, e
+	}
+	return result, goal, nil
+}
@@ -0,0 +1,174 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/skycoin/src/cipher"
+	"math"
+	"testing"
+	"time"
+)
+
+// addRawPost submits a post whose body is not encoded as object.Body would encode
+// it: keys are reordered, spaced, and include an unknown key.
+func addRawPost(t *testing.T, bi *BoardInstance, threadHash cipher.SHA256, userSeed []byte) {
+	cpk, csk := cipher.GenerateDeterministicKeyPair(userSeed)
+	raw := []byte(fmt.Sprintf(`{ "creator": "%s", "name": "Raw post", "body": "A post <not> re-encoded.",
+		"of_thread": "%s", "of_board": "%s", "type": "%s", "ts": %d, "unknown": true }`,
+		cpk.Hex(), threadHash.Hex(), obtainBoardPubKey(t, bi).Hex(), object.V5PostType, time.Now().UnixNano()))
+	transport, e := object.NewTransport(raw, cipher.SignHash(cipher.SumSHA256(raw), csk))
+	if e != nil {
+		t.Fatal("failed to generate transport:", e)
+	}
+	if _, e := bi.Submit(transport); e != nil {
+		t.Fatal("failed to create new post:", e)
+	}
+}
+
+func TestBoardInstance_ImportArchive(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+		voterSeed = "voter"
+	)
+
+	var buf bytes.Buffer
+	func() {
+		bi, quit := initInstance(t, boardSeed)
+		defer quit()
+
+		tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+		if e := bi.PublishChanges(); e != nil {
+			t.Fatal("failed to publish changes:", e)
+		}
+		upk, _ := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+		addPost(t, bi, tHash, 0, []byte(voterSeed))
+		addPost(t, bi, tHash, 1, []byte(userSeed))
+		addRawPost(t, bi, tHash, []byte(userSeed))
+		addThreadVote(t, bi, tHash, 1, []byte(voterSeed))
+		addUserVote(t, bi, upk, 1, "", []byte(voterSeed))
+		addUserProfile(t, bi, "user", time.Now().UnixNano(), []byte(userSeed))
+		if e := bi.PublishChanges(); e != nil {
+			t.Fatal("failed to publish changes:", e)
+		}
+		if e := bi.Viewer().ExportBoard(&buf); e != nil {
+			t.Fatal("failed to export board:", e)
+		}
+	}()
+
+	archive, e := ReadArchive(bytes.NewReader(buf.Bytes()))
+	if e != nil {
+		t.Fatal("failed to read archive:", e)
+	}
+	if len(archive.Threads) != 1 || len(archive.Threads[0].Posts) != 3 {
+		t.Fatalf("archived threads: got %d, expected %d with %d posts",
+			len(archive.Threads), 1, 3)
+	}
+	if len(archive.Votes) != 2 {
+		t.Errorf("archived votes: got %d, expected %d", len(archive.Votes), 2)
+	}
+	if len(archive.Profiles) != 1 {
+		t.Errorf("archived profiles: got %d, expected %d", len(archive.Profiles), 1)
+	}
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	result, _, e := bi.ImportArchive(archive)
+	if e != nil {
+		t.Fatal("failed to import archive:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	expected := ImportArchiveResult{Threads: 1, Posts: 3, Submissions: 3}
+	if *result != expected {
+		t.Errorf("import result: got %+v, expected %+v", *result, expected)
+	}
+
+	board, e := bi.Viewer().GetBoard()
+	if e != nil {
+		t.Fatal("failed to get board:", e)
+	}
+	if board.Header.Hash != archive.Content.Header.Hash {
+		t.Errorf("board content: got '%s', expected '%s'",
+			board.Header.Hash, archive.Content.Header.Hash)
+	}
+	page, e := bi.Viewer().GetThreadPage(&ThreadPageIn{
+		ThreadHash:     archive.Threads[0].Thread.Header.Hash,
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+	if e != nil {
+		t.Fatal("failed to get thread page:", e)
+	}
+	if len(page.Posts) != 3 {
+		t.Errorf("post count: got %d, expected %d", len(page.Posts), 3)
+	}
+
+	var reexport bytes.Buffer
+	if e := bi.Viewer().ExportBoard(&reexport); e != nil {
+		t.Fatal("failed to export imported board:", e)
+	}
+	again, e := ReadArchive(&reexport)
+	if e != nil {
+		t.Fatal("failed to read archive of imported board:", e)
+	}
+	if len(again.Votes) != len(archive.Votes) || len(again.Profiles) != len(archive.Profiles) {
+		t.Errorf("re-exported archive: got %d votes and %d profiles, expected %d and %d",
+			len(again.Votes), len(again.Profiles), len(archive.Votes), len(archive.Profiles))
+	}
+}
+
+func TestArchiveContent_ToContent(t *testing.T) {
+	pk, sk := cipher.GenerateDeterministicKeyPair([]byte("user"))
+	raw := []byte(fmt.Sprintf(`{"type": "%s", "creator": "%s", "extra": 1}`, object.V5UserProfileType, pk.Hex()))
+	hash := cipher.SumSHA256(raw)
+	valid := func() *ArchiveContent {
+		return &ArchiveContent{
+			Header:  &object.ContentHeaderData{Hash: hash.Hex(), Sig: cipher.SignHash(hash, sk).Hex()},
+			RawBody: append([]byte(nil), raw...),
+		}
+	}
+
+	cases := []struct {
+		name    string
+		tamper  func(ac *ArchiveContent)
+		errType int // Zero for no error.
+	}{
+		{"valid", func(ac *ArchiveContent) {}, 0},
+		{"decoded_body_ignored", func(ac *ArchiveContent) { ac.Body = &object.Body{Name: "other"} }, 0},
+		{"no_header", func(ac *ArchiveContent) { ac.Header = nil }, boo.InvalidRead},
+		{"no_raw_body", func(ac *ArchiveContent) { ac.RawBody = nil }, boo.InvalidRead},
+		{"malformed_raw_body", func(ac *ArchiveContent) { ac.RawBody = []byte("{") }, boo.InvalidRead},
+		{"tampered_raw_body", func(ac *ArchiveContent) { ac.RawBody[1] = ' ' }, boo.InvalidRead},
+		{"wrong_signature", func(ac *ArchiveContent) {
+			_, other := cipher.GenerateDeterministicKeyPair([]byte("other"))
+			ac.Header.Sig = cipher.SignHash(hash, other).Hex()
+		}, boo.NotAuthorised},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ac := valid()
+			c.tamper(ac)
+			content, e := ac.ToContent()
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to obtain content:", e)
+			}
+			if !bytes.Equal(content.Body, raw) {
+				t.Errorf("got body %s, expected %s", content.Body, raw)
+			}
+			if ac.Body == nil || ac.Body.Creator != pk.Hex() || ac.Body.Name != "" {
+				t.Errorf("decoded body is not of raw body: %+v", ac.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/cxo/skyobject"
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// DeleteByUser deletes all threads and posts created by the user of public key 'upk'.
+// Votes of deleted content are dropped when the views are reset on publish.
+// Only the master of the board can do this.
+// Returns the number of threads and posts deleted, and the goal sequence.
+func (bi *BoardInstance) DeleteByUser(upk cipher.PubKey) (int, uint64, error) {
+	if bi.v == nil {
+		return 0, 0, ErrViewerNotInitialized
+	}
+
+	var (
+		goal           uint64
+		count          int
+		threads, posts = bi.v.contentOfUser(upk.Hex())
+	)
+
+	e := bi.EditPack(func(p *skyobject.Pack, h *Headers) error {
+
+		// Set goal sequence.
+		goal = p.Root().Seq + 1
+
+		// Get root children pages.
+		pages, e := object.GetPages(p, &object.GetPagesIn{
+			RootPage:  false,
+			BoardPage: true,
+			DiffPage:  false,
+			UsersPage: false,
+		})
+		if e != nil {
+			return e
+		}
+
+		// Collect everything to delete before modifying anything.
+		var (
+			tpElems = make(map[string]*skyobject.RefsElem)
+			pDels   = make(map[string]*postsDeletion)
+		)
+		for tHash := range threads {
+			tpElem, _, e := getThreadPageOfHeaders(h, pages, tHash)
+			if e != nil {
+				return e
+			}
+			tpElems[tHash] = tpElem
+		}
+		for tHash, pHashes := range posts {
+			if _, ok := threads[tHash]; ok {
+				continue
+			}
+			tpElem, tPage, e := getThreadPageOfHeaders(h, pages, tHash)
+			if e != nil {
+				return e
+			}
+			d := &postsDeletion{tpElem: tpElem, tPage: tPage}
+			e = tPage.Posts.Ascend(func(_ int, pElem *skyobject.RefsElem) error {
+				post, e := object.GetContentFromElem(pElem)
+				if e != nil {
+					return e
+				}
+				if _, ok := pHashes[post.GetHeader().Hash]; ok {
+					d.pElems = append(d.pElems, pElem)
+				}
+				return nil
+			})
+			if e != nil {
+				return e
+			}
+			pDels[tHash] = d
+		}
+
+		// Delete posts.
+		for tHash, d := range pDels {
+			for _, pElem := range d.pElems {
+				if e := pElem.Delete(); e != nil {
+					return boo.WrapType(e, boo.Internal, "failed to delete post")
+				}
+				count++
+			}
+			if e := d.tPage.Save(d.tpElem); e != nil {
+				return e
+			}
+			h.SetThread(tHash, d.tpElem.Hash)
+		}
+
+		// Delete threads.
+		for tHash, tpElem := range tpElems {
+			if e := tpElem.Delete(); e != nil {
+				return boo.WrapType(e, boo.Internal, "failed to delete thread")
+			}
+			h.DelThread(tHash)
+			count++
+		}
+
+		return pages.Save(p)
+	})
+	if e != nil {
+		return 0, 0, e
+	}
+
+	bi.needReset.Set()
+	return count, goal, nil
+}
+
+// postsDeletion holds the posts to be deleted from a thread page.
+type postsDeletion struct {
+	tpElem *skyobject.RefsElem
+	tPage  *object.ThreadPage
+	pElems []*skyobject.RefsElem
+}
+
+func getThreadPageOfHeaders(h *Headers, pages *object.Pages, tHash string) (*skyobject.RefsElem, *object.ThreadPage, error) {
+	tpHash, ok := h.GetThreadPageHash(tHash)
+	if !ok {
+		return nil, nil, boo.Newf(boo.NotFound,
+			"thread of hash '%s' not found", tHash)
+	}
+	return pages.BoardPage.GetThreadPage(tpHash)
+}
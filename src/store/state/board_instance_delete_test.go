@@ -0,0 +1,101 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/skycoin/src/cipher"
+	"math"
+	"testing"
+)
+
+func TestBoardInstance_DeleteByUser(t *testing.T) {
+	const (
+		boardSeed = "a"
+		spamSeed  = "spammer"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	spamThread, _ := addThread(t, bi, 0, []byte(spamSeed))
+	userThread, _ := addThread(t, bi, 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, spamThread, 0, []byte(userSeed))
+	addPost(t, bi, userThread, 1, []byte(spamSeed))
+	addPost(t, bi, userThread, 2, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	spamPK, _ := cipher.GenerateDeterministicKeyPair([]byte(spamSeed))
+	count, _, e := bi.DeleteByUser(spamPK)
+	if e != nil {
+		t.Fatal("failed to delete by user:", e)
+	}
+	if count != 2 {
+		t.Errorf("deleted count: got %d, expected %d", count, 2)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	if threads := obtainThreadList(t, bi); len(threads) != 1 || threads[0] != userThread {
+		t.Fatalf("expected only thread '%s' to remain, got %v", userThread.Hex(), threads)
+	}
+	page, e := bi.Viewer().GetThreadPage(&ThreadPageIn{
+		ThreadHash:     userThread.Hex(),
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+	if e != nil {
+		t.Fatal("failed to get thread page:", e)
+	}
+	if len(page.Posts) != 1 {
+		t.Errorf("post count: got %d, expected %d", len(page.Posts), 1)
+	}
+}
+
+func TestBoardInstance_DeleteByUser_compactsUsers(t *testing.T) {
+	const (
+		boardSeed = "a"
+		spamSeed  = "spammer"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	spamThread, _ := addThread(t, bi, 0, []byte(spamSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addThreadVote(t, bi, spamThread, -1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	spamPK, _ := cipher.GenerateDeterministicKeyPair([]byte(spamSeed))
+	if _, _, e := bi.DeleteByUser(spamPK); e != nil {
+		t.Fatal("failed to delete by user:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	out, e := bi.Viewer().GetParticipants(&ParticipantsIn{
+		PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+	})
+	if e != nil {
+		t.Fatal("failed to get participants:", e)
+	}
+	// The voter only voted on the deleted thread, so neither user remains.
+	for _, upk := range out.Participants {
+		if upk != obtainBoardPubKey(t, bi).Hex() {
+			t.Errorf("expected user '%s' to be compacted", upk)
+		}
+	}
+	if len(bi.v.i.UserRefs) != 0 {
+		t.Errorf("expected no user references, got %v", bi.v.i.UserRefs)
+	}
+}
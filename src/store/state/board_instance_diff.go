@@ -0,0 +1,147 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"math"
+	"sort"
+	"sync/atomic"
+)
+
+// RetainedDiffs is the number of most recent version diffs a board instance keeps.
+// Clients further behind obtain a full board page.
+const RetainedDiffs = 32
+
+type versionDiff struct {
+	version uint64
+	diff    *BoardDiff
+}
+
+// retainDiff records the diff of a version, discarding the oldest beyond RetainedDiffs.
+// A nil diff discards all retained diffs. Should be called with 'uMux' locked.
+func (bi *BoardInstance) retainDiff(version uint64, diff *BoardDiff) {
+	if diff == nil {
+		bi.diffs = nil
+		return
+	}
+	bi.diffs = append(bi.diffs, &versionDiff{version: version, diff: diff})
+	if len(bi.diffs) > RetainedDiffs {
+		bi.diffs = bi.diffs[len(bi.diffs)-RetainedDiffs:]
+	}
+}
+
+// diffSince merges retained diffs of versions after 'since', up to the current version.
+// Returns false if the diff is not fully retained.
+func (bi *BoardInstance) diffSince(since uint64) (*BoardDiff, uint64, bool) {
+	bi.uMux.Lock()
+	defer bi.uMux.Unlock()
+
+	version := atomic.LoadUint64(&bi.version)
+	if since > version {
+		return nil, version, false
+	}
+	if since == version {
+		return new(BoardDiff), version, true
+	}
+	if len(bi.diffs) == 0 || bi.diffs[0].version > since+1 {
+		return nil, version, false
+	}
+
+	const (
+		added = iota + 1
+		removed
+		changed
+	)
+	state := make(map[string]int)
+	for _, vd := range bi.diffs {
+		if vd.version <= since {
+			continue
+		}
+		for _, tHash := range vd.diff.Added {
+			state[tHash] = added
+		}
+		for _, tHash := range vd.diff.Changed {
+			if state[tHash] == 0 {
+				state[tHash] = changed
+			}
+		}
+		for _, tHash := range vd.diff.Removed {
+			if state[tHash] == added {
+				delete(state, tHash)
+			} else {
+				state[tHash] = removed
+			}
+		}
+	}
+	out := new(BoardDiff)
+	for tHash, s := range state {
+		switch s {
+		case added:
+			out.Added = append(out.Added, tHash)
+		case removed:
+			out.Removed = append(out.Removed, tHash)
+		case changed:
+			out.Changed = append(out.Changed, tHash)
+		}
+	}
+	sort.Strings(out.Added)
+	sort.Strings(out.Removed)
+	sort.Strings(out.Changed)
+	return out, version, true
+}
+
+// BoardPageDiffOut represents the output for a board page diff.
+type BoardPageDiffOut struct {
+	Version uint64               `json:"version"`
+	Full    bool                 `json:"full"`            // Whether the full page is given as the diff is not retained.
+	Page    *BoardPageOut        `json:"page,omitempty"`  // Only set when full.
+	Board   *object.ContentRep   `json:"board,omitempty"` // Only set when not full.
+	Added   []*object.ContentRep `json:"added,omitempty"`
+	Changed []*object.ContentRep `json:"changed,omitempty"`
+	Removed []string             `json:"removed,omitempty"`
+}
+
+// GetBoardPageDiff obtains the threads that were added, removed or changed since
+// the given version of the views. If the diff is not retained, the full board page
+// is obtained instead. Pagination of 'in' only applies to the full page.
+func (bi *BoardInstance) GetBoardPageDiff(in *BoardPageIn, since uint64) (*BoardPageDiffOut, error) {
+	diff, version, ok := bi.diffSince(since)
+	if !ok {
+		page, e := bi.Viewer().GetBoardPage(in)
+		if e != nil {
+			return nil, e
+		}
+		return &BoardPageDiffOut{Version: version, Full: true, Page: page}, nil
+	}
+
+	board, e := bi.Viewer().GetBoard()
+	if e != nil {
+		return nil, e
+	}
+	out := &BoardPageDiffOut{Version: version, Board: board, Removed: diff.Removed}
+	if len(diff.Added) == 0 && len(diff.Changed) == 0 {
+		return out, nil
+	}
+	pageIn := *in
+	pageIn.HashesOnly = false
+	pageIn.PaginatedInput = typ.PaginatedInput{PageSize: math.MaxUint64}
+	page, e := bi.Viewer().GetBoardPage(&pageIn)
+	if e != nil {
+		return nil, e
+	}
+	threads := make(map[string]*object.ContentRep, len(page.Threads)+len(page.Pinned))
+	for _, rep := range append(page.Pinned, page.Threads...) {
+		threads[rep.Header.Hash] = rep
+	}
+	for _, tHash := range diff.Added {
+		if rep, ok := threads[tHash]; ok {
+			out.Added = append(out.Added, rep)
+		}
+	}
+	for _, tHash := range diff.Changed {
+		if rep, ok := threads[tHash]; ok {
+			out.Changed = append(out.Changed, rep)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBoardInstance_diffSince(t *testing.T) {
+	bi := new(BoardInstance)
+	bi.retainDiff(1, nil)
+	updates := []*BoardDiff{
+		{Added: []string{"a", "b"}},
+		{Changed: []string{"a", "c"}},
+		{Removed: []string{"b", "c"}, Added: []string{"d"}},
+	}
+	for _, diff := range updates {
+		bi.version++
+		bi.retainDiff(bi.version, diff)
+	}
+
+	cases := []struct {
+		name     string
+		since    uint64
+		ok       bool
+		expected *BoardDiff
+	}{
+		{"all", 0, true, &BoardDiff{Added: []string{"a", "d"}, Removed: []string{"c"}}},
+		{"last two", 1, true, &BoardDiff{Added: []string{"d"}, Removed: []string{"b", "c"}, Changed: []string{"a"}}},
+		{"current", 3, true, &BoardDiff{}},
+		{"future", 4, false, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			diff, version, ok := bi.diffSince(c.since)
+			if ok != c.ok {
+				t.Fatalf("ok: got %v, expected %v", ok, c.ok)
+			}
+			if version != 3 {
+				t.Errorf("version: got %d, expected %d", version, 3)
+			}
+			if ok && !reflect.DeepEqual(diff, c.expected) {
+				t.Errorf("diff: got %+v, expected %+v", diff, c.expected)
+			}
+		})
+	}
+
+	t.Run("not retained", func(t *testing.T) {
+		for i := 0; i < RetainedDiffs; i++ {
+			bi.version++
+			bi.retainDiff(bi.version, &BoardDiff{})
+		}
+		if _, _, ok := bi.diffSince(1); ok {
+			t.Error("expected diff to not be retained")
+		}
+		bi.version++
+		bi.retainDiff(bi.version, nil)
+		if _, _, ok := bi.diffSince(bi.version - 1); ok {
+			t.Error("expected diffs to be discarded on reset")
+		}
+	})
+}
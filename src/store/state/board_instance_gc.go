@@ -0,0 +1,213 @@
+package state
+
+import (
+	"context"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/cxo/skyobject"
+	"time"
+)
+
+// GCInterval is the minimum interval between garbage collections of a board.
+const GCInterval = time.Hour
+
+// GCResult describes a garbage collection of a board.
+type GCResult struct {
+	Dereferenced int `json:"dereferenced"` // Threads, posts and attachments no longer in the board.
+	Removed      int `json:"removed"`      // Of those, the ones removed as they exceeded the retention window.
+}
+
+// CollectGarbage removes threads and posts that are no longer in the board, along with
+// attachments of removed posts, from the board's diff page and user profiles. Once nothing
+// refers to them, CXO drops their objects.
+// Content is only removed once it has been dereferenced for longer than 'retention', as
+// first observed by this instance.
+// Only the master of the board can do this.
+// Returns the result, and the goal sequence.
+func (bi *BoardInstance) CollectGarbage(retention time.Duration) (*GCResult, uint64, error) {
+	var (
+		goal   uint64
+		result = new(GCResult)
+		now    = time.Now()
+	)
+
+	// Mark dereferenced content, and decide what is to be removed.
+	remove := make(map[string]struct{})
+	e := bi.ViewPack(func(p *skyobject.Pack, h *Headers) error {
+		if p.Flags()&skyobject.ViewOnly > 0 {
+			return ErrNotEditable
+		}
+		pages, e := object.GetPages(p, &object.GetPagesIn{
+			RootPage:  false,
+			BoardPage: true,
+			DiffPage:  true,
+			UsersPage: false,
+		})
+		if e != nil {
+			return e
+		}
+		referenced, e := referencedContent(pages.BoardPage)
+		if e != nil {
+			return e
+		}
+		marks := make(map[string]time.Time)
+		e = pages.DiffPage.Submissions.Ascend(func(_ int, cElem *skyobject.RefsElem) error {
+			c, e := object.GetContentFromElem(cElem)
+			if e != nil {
+				return e
+			}
+			hash := c.GetHeader().Hash
+			if !isDereferenced(referenced, hash, c.GetBody()) {
+				return nil
+			}
+			result.Dereferenced++
+			since, ok := bi.gcMarks[hash]
+			if !ok {
+				since = now
+			}
+			if now.Sub(since) >= retention {
+				remove[hash] = struct{}{}
+			} else {
+				marks[hash] = since
+			}
+			return nil
+		})
+		if e != nil {
+			return e
+		}
+		bi.gcMarks = marks
+		return nil
+	})
+	if e != nil || len(remove) == 0 {
+		return result, 0, e
+	}
+
+	e = bi.EditPack(func(p *skyobject.Pack, h *Headers) error {
+
+		// Set goal sequence.
+		goal = p.Root().Seq + 1
+
+		// Get root children pages.
+		pages, e := object.GetPages(p, &object.GetPagesIn{
+			RootPage:  false,
+			BoardPage: false,
+			DiffPage:  true,
+			UsersPage: true,
+		})
+		if e != nil {
+			return e
+		}
+
+		// Remove from diff page.
+		if result.Removed, e = removeSubmissions(&pages.DiffPage.Submissions, remove); e != nil {
+			return e
+		}
+
+		// Remove from user profiles.
+		e = pages.UsersPage.Users.Ascend(func(_ int, uapElem *skyobject.RefsElem) error {
+			uap, e := object.GetUserProfile(uapElem)
+			if e != nil {
+				return e
+			}
+			n, e := removeSubmissions(&uap.Submissions, remove)
+			if e != nil || n == 0 {
+				return e
+			}
+			if e := uapElem.SetValue(uap); e != nil {
+				return boo.WrapType(e, boo.Internal, "failed to save 'UserProfile'")
+			}
+			h.SetUser(uap.PubKey, uapElem.Hash)
+			return nil
+		})
+		if e != nil {
+			return e
+		}
+		return pages.Save(p)
+	})
+	if e != nil {
+		return nil, 0, e
+	}
+
+	bi.needReset.Set()
+	return result, goal, nil
+}
+
+// referencedContent obtains the hashes of threads and posts in the board page.
+func referencedContent(bp *object.BoardPage) (map[string]struct{}, error) {
+	referenced := make(map[string]struct{})
+	e := bp.RangeThreadPages(func(_ int, tp *object.ThreadPage) error {
+		thread, e := tp.GetThread()
+		if e != nil {
+			return e
+		}
+		referenced[thread.GetHeader().Hash] = struct{}{}
+		return tp.RangePosts(func(_ int, post *object.Content) error {
+			referenced[post.GetHeader().Hash] = struct{}{}
+			return nil
+		})
+	})
+	return referenced, e
+}
+
+// collectGarbageIfDue publishes pending changes, then collects garbage of the board and
+// publishes the removals, if garbage collection is enabled and was not run within GCInterval.
+// Pending changes are published first so that the removals are published in a root of their own.
+func (bi *BoardInstance) collectGarbageIfDue(ctx context.Context) error {
+	if bi.gcRetention <= 0 || time.Since(bi.gcAt) < GCInterval {
+		return nil
+	}
+	if e := bi.PublishChangesWithContext(ctx); e != nil {
+		return e
+	}
+	bi.gcAt = time.Now()
+	result, _, e := bi.CollectGarbage(bi.gcRetention)
+	if e != nil {
+		return e
+	}
+	if result.Removed == 0 {
+		return nil
+	}
+	bi.l.Printf("garbage collected %d of %d dereferenced submissions",
+		result.Removed, result.Dereferenced)
+	return bi.PublishChangesWithContext(ctx)
+}
+
+// isDereferenced determines whether the submission is a thread or post that is no longer
+// in the board, or an attachment of such a post.
+func isDereferenced(referenced map[string]struct{}, hash string, body *object.Body) bool {
+	switch body.Type {
+	case object.V5ThreadType, object.V5PostType:
+		_, ok := referenced[hash]
+		return !ok
+	case object.V5AttachmentType:
+		_, ok := referenced[body.OfPost]
+		return !ok
+	default:
+		return false
+	}
+}
+
+// removeSubmissions removes submissions of the given hashes from the refs.
+// Returns the number of submissions removed.
+func removeSubmissions(refs *skyobject.Refs, remove map[string]struct{}) (int, error) {
+	var elems []*skyobject.RefsElem
+	e := refs.Ascend(func(_ int, cElem *skyobject.RefsElem) error {
+		c, e := object.GetContentFromElem(cElem)
+		if e != nil {
+			return e
+		}
+		if _, ok := remove[c.GetHeader().Hash]; ok {
+			elems = append(elems, cElem)
+		}
+		return nil
+	})
+	if e != nil {
+		return 0, e
+	}
+	for _, cElem := range elems {
+		if e := cElem.Delete(); e != nil {
+			return 0, boo.WrapType(e, boo.Internal, "failed to delete submission")
+		}
+	}
+	return len(elems), nil
+}
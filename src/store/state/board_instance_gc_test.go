@@ -0,0 +1,106 @@
+package state
+
+import (
+	"context"
+	"github.com/skycoin/cxo/skyobject"
+	"github.com/skycoin/skycoin/src/cipher"
+	"testing"
+	"time"
+)
+
+func TestBoardInstance_CollectGarbage(t *testing.T) {
+	const (
+		boardSeed = "a"
+		spamSeed  = "spammer"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	spamThread, _ := addThread(t, bi, 0, []byte(spamSeed))
+	userThread, _ := addThread(t, bi, 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, spamThread, 0, []byte(userSeed))
+	addPost(t, bi, userThread, 1, []byte(spamSeed))
+	addPost(t, bi, userThread, 2, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	// Views of a remote node, updated without resets.
+	var (
+		remote  *Viewer
+		headers *Headers
+	)
+	e := bi.ViewPack(func(p *skyobject.Pack, h *Headers) (e error) {
+		headers = h
+		remote, e = NewViewer(p, "")
+		return
+	})
+	if e != nil {
+		t.Fatal("failed to create remote views:", e)
+	}
+
+	spamPK, _ := cipher.GenerateDeterministicKeyPair([]byte(spamSeed))
+	if _, _, e := bi.DeleteByUser(spamPK); e != nil {
+		t.Fatal("failed to delete by user:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	// Dereferenced content is kept within the retention window.
+	result, _, e := bi.CollectGarbage(time.Hour)
+	if e != nil {
+		t.Fatal("failed to collect garbage:", e)
+	}
+	if result.Dereferenced != 3 || result.Removed != 0 {
+		t.Fatalf("expected 3 dereferenced and 0 removed, got %+v", result)
+	}
+	if total := bi.GetChanges().Total; total != 5 {
+		t.Fatalf("diff page total: got %d, expected %d", total, 5)
+	}
+
+	result, _, e = bi.CollectGarbage(0)
+	if e != nil {
+		t.Fatal("failed to collect garbage:", e)
+	}
+	if result.Dereferenced != 3 || result.Removed != 3 {
+		t.Fatalf("expected 3 dereferenced and 3 removed, got %+v", result)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	if total := bi.GetChanges().Total; total != 2 {
+		t.Fatalf("diff page total: got %d, expected %d", total, 2)
+	}
+	if threads := obtainThreadList(t, bi); len(threads) != 1 || threads[0] != userThread {
+		t.Fatalf("expected only thread '%s' to remain, got %v", userThread.Hex(), threads)
+	}
+
+	// Remote views evict the collected posts.
+	var update *UpdateResult
+	e = bi.ViewPack(func(p *skyobject.Pack, _ *Headers) error {
+		h, e := NewHeaders(context.Background(), headers, p)
+		if e != nil {
+			return e
+		}
+		update, e = remote.Update(context.Background(), p, h)
+		return e
+	})
+	if e != nil {
+		t.Fatal("failed to update remote views:", e)
+	}
+	if update.Deleted != 1 || update.Evicted != 1 {
+		t.Errorf("expected 1 deleted and 1 evicted, got %d and %d", update.Deleted, update.Evicted)
+	}
+	if _, ok := remote.c.content[spamThread.Hex()]; ok {
+		t.Error("rep of deleted thread was not evicted")
+	}
+	if n := len(remote.c.content); n != 3 {
+		t.Errorf("remote reps: got %d, expected %d", n, 3)
+	}
+}
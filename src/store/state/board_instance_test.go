@@ -1,8 +1,10 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/skycoin/bbs/src/misc/boo"
 	"github.com/skycoin/bbs/src/misc/tag"
 	"github.com/skycoin/bbs/src/store/cxo/setup"
 	"github.com/skycoin/bbs/src/store/object"
@@ -75,7 +77,7 @@ func initInstance(t *testing.T, seed string) (*BoardInstance, func()) {
 	pk, sk, r := prepareBoard(t, n, seed)
 	bi := prepareInstance(t, n, pk)
 
-	if e := bi.UpdateWithReceived(r, sk); e != nil {
+	if e := bi.UpdateWithReceived(context.Background(), r, sk); e != nil {
 		t.Fatal("failed to update board instance:", e)
 	}
 
@@ -158,6 +160,53 @@ func addThread(t *testing.T, bi *BoardInstance, threadIndex int, userSeed []byte
 	return hash, goal
 }
 
+func addThreadVote(t *testing.T, bi *BoardInstance, threadHash cipher.SHA256, value int, userSeed []byte) uint64 {
+	cpk, csk := cipher.GenerateDeterministicKeyPair(userSeed)
+	body := &object.Body{
+		Type:     object.V5ThreadVoteType,
+		TS:       time.Now().UnixNano(),
+		OfBoard:  obtainBoardPubKey(t, bi).Hex(),
+		OfThread: threadHash.Hex(),
+		Value:    value,
+		Creator:  cpk.Hex(),
+	}
+	raw, _ := json.Marshal(body)
+	sig := cipher.SignHash(cipher.SumSHA256(raw), csk)
+	transport, e := object.NewTransport(raw, sig)
+	if e != nil {
+		t.Fatal("failed to generate transport:", e)
+	}
+	goal, e := bi.Submit(transport)
+	if e != nil {
+		t.Fatal("failed to vote on thread:", e)
+	}
+	return goal
+}
+
+func addUserVote(t *testing.T, bi *BoardInstance, ofUser cipher.PubKey, value int, tag string, userSeed []byte) uint64 {
+	cpk, csk := cipher.GenerateDeterministicKeyPair(userSeed)
+	body := &object.Body{
+		Type:    object.V5UserVoteType,
+		TS:      time.Now().UnixNano(),
+		OfBoard: obtainBoardPubKey(t, bi).Hex(),
+		OfUser:  ofUser.Hex(),
+		Value:   value,
+		Tags:    []string{tag},
+		Creator: cpk.Hex(),
+	}
+	raw, _ := json.Marshal(body)
+	sig := cipher.SignHash(cipher.SumSHA256(raw), csk)
+	transport, e := object.NewTransport(raw, sig)
+	if e != nil {
+		t.Fatal("failed to generate transport:", e)
+	}
+	goal, e := bi.Submit(transport)
+	if e != nil {
+		t.Fatal("failed to vote on user:", e)
+	}
+	return goal
+}
+
 func addPost(t *testing.T, bi *BoardInstance, threadHash cipher.SHA256, postIndex int, userSeed []byte) uint64 {
 	cpk, csk := cipher.GenerateDeterministicKeyPair(userSeed)
 	body := &object.Body{
@@ -182,6 +231,29 @@ func addPost(t *testing.T, bi *BoardInstance, threadHash cipher.SHA256, postInde
 	return goal
 }
 
+func addUserProfile(t *testing.T, bi *BoardInstance, name string, ts int64, userSeed []byte) uint64 {
+	cpk, csk := cipher.GenerateDeterministicKeyPair(userSeed)
+	body := &object.Body{
+		Type:      object.V5UserProfileType,
+		TS:        ts,
+		OfBoard:   obtainBoardPubKey(t, bi).Hex(),
+		Name:      name,
+		AvatarRef: name + ".png",
+		Creator:   cpk.Hex(),
+	}
+	raw, _ := json.Marshal(body)
+	sig := cipher.SignHash(cipher.SumSHA256(raw), csk)
+	transport, e := object.NewTransport(raw, sig)
+	if e != nil {
+		t.Fatal("failed to generate transport:", e)
+	}
+	goal, e := bi.Submit(transport)
+	if e != nil {
+		t.Fatal("failed to submit user profile:", e)
+	}
+	return goal
+}
+
 func TestBoardInstance_Init(t *testing.T) {
 	const (
 		bSeed = "a"
@@ -287,3 +359,226 @@ func TestBoardInstance_UpdateWithReceived(t *testing.T) {
 		}
 	})
 }
+
+func TestBoardInstance_WaitForUpdate(t *testing.T) {
+	const (
+		boardSeed   = "a"
+		userSeed    = "b"
+		waiterCount = 5
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	var (
+		ready = make(chan struct{}, waiterCount)
+		done  = make(chan error, waiterCount)
+	)
+	for i := 0; i < waiterCount; i++ {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+			defer cancel()
+			ready <- struct{}{}
+			done <- bi.WaitForUpdate(ctx)
+		}()
+	}
+	for i := 0; i < waiterCount; i++ {
+		<-ready
+	}
+	time.Sleep(time.Millisecond * 100)
+
+	addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	for i := 0; i < waiterCount; i++ {
+		if e := <-done; e != nil {
+			t.Fatalf("waiter %d was not released: %v", i, e)
+		}
+	}
+}
+
+func TestBoardInstance_PublishChangesWithContext_cancelled(t *testing.T) {
+	bi, quit := initInstance(t, "a")
+	defer quit()
+
+	addThread(t, bi, 0, []byte("user"))
+	seq := bi.GetSeq()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if e := bi.PublishChangesWithContext(ctx); e == nil {
+		t.Fatal("expected error when context is cancelled")
+	}
+	if !bi.needPublish.Value() {
+		t.Fatal("changes are no longer pending after cancelled publish")
+	}
+	if got := bi.GetSeq(); got != seq {
+		t.Errorf("seq: got %d, expected %d", got, seq)
+	}
+
+	// Views left behind by a cancelled update are reset on the next publish,
+	// even when there is nothing to publish.
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	bi.needReset.Set()
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	if bi.needPublish.Value() || bi.needReset.Value() {
+		t.Error("publish and reset should no longer be pending")
+	}
+	if got := len(obtainThreadList(t, bi)); got != 1 {
+		t.Errorf("thread count: got %d, expected %d", got, 1)
+	}
+}
+
+func TestBoardInstance_Close(t *testing.T) {
+	bi, quit := initInstance(t, "a")
+	defer quit()
+
+	waitErr := make(chan error)
+	go func() {
+		waitErr <- bi.WaitForUpdate(context.Background())
+	}()
+
+	bi.Close()
+	bi.Close() // Should do nothing.
+
+	select {
+	case e := <-waitErr:
+		if e != ErrInstanceClosed {
+			t.Errorf("waiting for update: got %v, expected %v", e, ErrInstanceClosed)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("waiting for update was not released on close")
+	}
+	if !bi.IsClosed() || bi.IsReady() {
+		t.Error("instance should be closed and not ready")
+	}
+	if _, e := bi.Viewer().GetBoard(); e != ErrViewerNotInitialized {
+		t.Errorf("viewing closed instance: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
+
+// fakeReader is a ContentReader that refuses submissions and has a fixed board,
+// delegating all else to the embedded reader.
+type fakeReader struct {
+	ContentReader
+	board *object.ContentRep
+}
+
+func (r *fakeReader) CheckNotArchived() error {
+	return boo.New(boo.NotAllowed, "fake reader refuses submissions")
+}
+
+func (r *fakeReader) GetBoard() (*object.ContentRep, error) {
+	return r.board, nil
+}
+
+func TestBoardInstance_SetContentReader(t *testing.T) {
+	const userSeed = "user"
+
+	bi, quit := initInstance(t, "a")
+	defer quit()
+
+	fake := &fakeReader{
+		ContentReader: bi.Viewer(),
+		board:         &object.ContentRep{Body: &object.Body{Name: "Fake Board"}},
+	}
+	bi.SetContentReader(fake)
+
+	if board, e := bi.Viewer().GetBoard(); e != nil || board != fake.board {
+		t.Errorf("got board %v and error %v, expected the fake board", board, e)
+	}
+	cpk, csk := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+	raw, _ := json.Marshal(&object.Body{
+		Type:    object.V5ThreadType,
+		TS:      time.Now().UnixNano(),
+		OfBoard: obtainBoardPubKey(t, bi).Hex(),
+		Name:    "Thread",
+		Creator: cpk.Hex(),
+	})
+	transport, e := object.NewTransport(raw, cipher.SignHash(cipher.SumSHA256(raw), csk))
+	if e != nil {
+		t.Fatal("failed to generate transport:", e)
+	}
+	if _, e := bi.Submit(transport); boo.Type(e) != boo.NotAllowed {
+		t.Errorf("submitting through fake reader: got %v, expected not allowed", e)
+	}
+	if bi.Viewer().HasThread(transport.Header.Hash) {
+		t.Error("refused thread is seen through the delegated reader")
+	}
+
+	bi.SetContentReader(nil)
+	if board, e := bi.Viewer().GetBoard(); e != nil || board == fake.board {
+		t.Errorf("got board %v and error %v, expected the board of the viewer", board, e)
+	}
+	addThread(t, bi, 0, []byte(userSeed))
+}
+
+func TestBoardInstance_syncStatus(t *testing.T) {
+	bi, quit := initInstance(t, "a")
+	defer quit()
+
+	pk := obtainBoardPubKey(t, bi)
+	status := bi.syncStatus(pk)
+	if status.Lag != 0 || status.LatestSeq != status.CompiledSeq {
+		t.Errorf("after init: got lag %d (latest %d, compiled %d), expected no lag",
+			status.Lag, status.LatestSeq, status.CompiledSeq)
+	}
+	if status.CompiledAt.IsZero() {
+		t.Error("after init: compile time not recorded")
+	}
+
+	bi.ObserveSeq(status.CompiledSeq + 3)
+	bi.ObserveSeq(status.CompiledSeq + 1) // Should be ignored.
+	if status = bi.syncStatus(pk); status.Lag != 3 {
+		t.Errorf("after observing newer root: got lag %d, expected %d", status.Lag, 3)
+	}
+}
+
+func TestBoardInstance_PublishChanges_unchanged(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "b"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		name    string
+		change  func()
+		updated bool
+	}{
+		{"nothing", func() {}, false},
+		{"thread", func() { addThread(t, bi, 1, []byte(userSeed)) }, true},
+		{"post", func() { addPost(t, bi, tHash, 0, []byte(userSeed)) }, true},
+		{"vote", func() { addThreadVote(t, bi, tHash, +1, []byte(userSeed)) }, true},
+		{"nothing_again", func() {}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			version := bi.Version()
+			c.change()
+			if e := bi.PublishChanges(); e != nil {
+				t.Fatal("failed to publish changes:", e)
+			}
+			if updated := bi.Version() != version; updated != c.updated {
+				t.Errorf("got broadcast %v, expected %v", updated, c.updated)
+			}
+		})
+	}
+
+	if _, e := (*Viewer)(nil).Update(context.Background(), nil, nil); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
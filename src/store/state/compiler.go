@@ -8,14 +8,17 @@ import (
 	"github.com/skycoin/cxo/node"
 	"github.com/skycoin/cxo/skyobject"
 	"github.com/skycoin/skycoin/src/cipher"
-	"log"
 	"os"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	LogPrefix = "COMPILER"
+	LogPrefix          = "COMPILER"
+	DefaultMaxCompiles = 4 // Default maximum number of boards compiled concurrently.
+	StatsCacheDuration = time.Second * 5
 )
 
 // RootWrap transports a cxo root.
@@ -26,19 +29,43 @@ type RootWrap struct {
 
 // CompilerConfig configure the Compiler.
 type CompilerConfig struct {
-	UpdateInterval *int // In seconds.
+	UpdateInterval *int     // In seconds.
+	UpdateTimeout  *int     // In seconds, per board update. Nil or <= 0 for no timeout.
+	DefaultSortBy  *string  // Name of registered sorter for board views. Nil or empty for chronological.
+	DebugUpdates   *bool    // Whether to record and log diagnostics of malformed content on update.
+	MaxPins        *int     // Maximum number of pinned threads per board. Nil or <= 0 for default.
+	MaxCompiles    *int     // Maximum number of boards compiled concurrently. Nil or <= 0 for default.
+	SnapshotDir    *string  // Directory to save and load views snapshots. Nil or empty to disable.
+	SubmitRate     *int     // Maximum submissions per user per minute, to master boards. Nil or <= 0 for no limit.
+	SubmitBurst    *int     // Maximum submissions per user at once. Nil or <= 0 for one.
+	SpamThreshold  *float64 // Spam score at or above which threads and posts are hidden. Nil for default, <= 0 to never hide.
+	GCRetention    *int     // In hours, before deleted content of master boards is removed. Nil or <= 0 to disable.
+
+	RepTransform RepTransform // Optional, applied to content reps of page outputs. Nil for none.
+	SpamScorer   SpamScorer   // Optional, scores threads and posts as spam. Nil for DefaultSpamScorer.
 }
 
 // Compiler compiles views for boards.
 type Compiler struct {
 	c *CompilerConfig
-	l *log.Logger
+	l *inform.Logger
 
 	node *node.Node
 	file *object.CXOFileManager
 
 	mux    sync.Mutex
 	boards map[cipher.PubKey]*BoardInstance
+	hub    *hub
+	hooks  hooks
+
+	statsMux  sync.Mutex
+	stats     *CompilerStats
+	statsTime time.Time
+
+	limiter *RateLimiter // Limits submissions per user, shared by all boards.
+
+	slots chan struct{}  // Worker pool slots, bounds concurrent compiles.
+	jobs  sync.WaitGroup // Dispatched compiles.
 
 	newRoots chan RootWrap
 	quit     chan struct{}
@@ -54,13 +81,16 @@ func NewCompiler(
 ) *Compiler {
 	compiler := &Compiler{
 		c:        config,
-		l:        inform.NewLogger(true, os.Stdout, LogPrefix),
+		l:        inform.New(true, os.Stdout, LogPrefix),
 		node:     node,
 		file:     file,
 		boards:   make(map[cipher.PubKey]*BoardInstance),
+		hub:      newHub(),
 		newRoots: newRoots,
 		quit:     make(chan struct{}),
 	}
+	compiler.slots = make(chan struct{}, compiler.maxCompiles())
+	compiler.limiter = compiler.newRateLimiter()
 	go compiler.updateLoop()
 	return compiler
 }
@@ -69,6 +99,7 @@ func NewCompiler(
 func (c *Compiler) Close() {
 	close(c.quit)
 	c.wg.Wait()
+	c.hub.close()
 }
 
 // Only for master boards.
@@ -85,53 +116,168 @@ func (c *Compiler) updateLoop() {
 			c.publishAllMasters()
 
 		case rootWrap := <-c.newRoots:
-			c.updateSingle(rootWrap.Root)
-			select {
-			case rootWrap.Done <- struct{}{}:
-			default:
-			}
+			c.updateSingle(rootWrap)
 
 		case <-c.quit:
+			c.jobs.Wait()
+			c.mux.Lock()
 			for _, bi := range c.boards {
+				bi.SaveSnapshot()
 				bi.Close()
 			}
+			c.mux.Unlock()
 			return
 		}
 	}
 }
 
+// dispatch runs a board update on the worker pool.
+// Updates of the same board are compiled one at a time, while updates of
+// different boards are compiled concurrently, up to the configured maximum.
+// 'done' is called after the update, if not nil.
+func (c *Compiler) dispatch(pk cipher.PubKey, bi *BoardInstance, what string, action func(ctx context.Context) error, done func()) {
+	c.jobs.Add(1)
+	go func() {
+		defer c.jobs.Done()
+		if done != nil {
+			defer done()
+		}
+
+		bi.cMux.Lock()
+		defer bi.cMux.Unlock()
+
+		c.slots <- struct{}{}
+		defer func() { <-c.slots }()
+
+		// Board may have been removed while waiting.
+		if bi.IsClosed() {
+			return
+		}
+		c.doUpdate(pk, bi, what, action)
+	}()
+}
+
 func (c *Compiler) publishAllMasters() {
 	c.file.RangeMasterSubs(func(pk cipher.PubKey, sk cipher.SecKey) {
-		bi := c.ensureBoard(pk)
+		c.dispatchPublish(pk, c.ensureBoard(pk))
+	})
+}
 
-		if e := bi.PublishChanges(); e != nil {
-			c.l.Printf(" - [%s] Publish failed with error: %v", pk.Hex()[:5]+"...", e)
+// dispatchPublish dispatches a publish of a master board, unless the previous
+// publish of the board is still queued or running. This way a stalled board
+// does not accumulate a publish per tick.
+func (c *Compiler) dispatchPublish(pk cipher.PubKey, bi *BoardInstance) bool {
+	if !atomic.CompareAndSwapUint32(&bi.publishing, 0, 1) {
+		c.l.Log(inform.DebugLevel, "previous publish is pending, skipping",
+			"board", pk.Hex()[:5]+"...")
+		return false
+	}
+	c.dispatch(pk, bi, "Publish", func(ctx context.Context) error {
+		if e := bi.PublishChangesWithContext(ctx); e != nil {
+			return e
 		}
+		return bi.collectGarbageIfDue(ctx)
+	}, func() {
+		atomic.StoreUint32(&bi.publishing, 0)
 	})
+	return true
 }
 
-func (c *Compiler) updateSingle(root *skyobject.Root) {
+func (c *Compiler) updateSingle(rootWrap RootWrap) {
+	var (
+		root   = rootWrap.Root
+		signal = func() {
+			select {
+			case rootWrap.Done <- struct{}{}:
+			default:
+			}
+		}
+	)
 
 	isRemote := c.file.HasRemoteSub(root.Pub)
 	sk, isMaster := c.file.GetMasterSubSecKey(root.Pub)
 
 	if !isRemote && !isMaster {
+		signal()
 		return
 	}
 
 	bi := c.ensureBoard(root.Pub)
+	bi.ObserveSeq(root.Seq)
 
 	if root.IsFull == false {
-		c.l.Printf("received root '%s' is not full, returning.", root.Pub.Hex()[:5]+"...")
+		c.l.Log(inform.DebugLevel, "received root is not full, returning",
+			"board", root.Pub.Hex()[:5]+"...", "seq", root.Seq)
+		signal()
 		return
 	}
 
 	if isMaster && bi.needPublish.Value() == true {
+		signal()
 		return
 	}
 
-	c.l.Printf("compiling '%s' : remote(%v) master(%v)", root.Pub.Hex()[:5]+"...", isRemote, isMaster)
-	bi.UpdateWithReceived(root, sk)
+	c.l.Log(inform.DebugLevel, "compiling board",
+		"board", root.Pub.Hex()[:5]+"...", "seq", root.Seq, "remote", isRemote, "master", isMaster)
+	c.dispatch(root.Pub, bi, "Update", func(ctx context.Context) error {
+		return bi.UpdateWithReceived(ctx, root, sk)
+	}, signal)
+}
+
+// doUpdate runs a board update, cancelling it if it exceeds the update timeout.
+// A cancelled update keeps it's worker slot until it observes the cancellation and returns,
+// so that a stuck board remains counted against the worker pool.
+func (c *Compiler) doUpdate(pk cipher.PubKey, bi *BoardInstance, what string, action func(ctx context.Context) error) {
+	ctx, cancel := c.updateContext(bi)
+	defer cancel()
+
+	version := bi.Version()
+	done := make(chan error, 1)
+	go func() { done <- action(ctx) }()
+
+	var e error
+	select {
+	case e = <-done:
+	case <-ctx.Done():
+		if !bi.IsClosed() {
+			c.l.Log(inform.WarnLevel, "board update timed out, waiting for it to stop",
+				"board", pk.Hex()[:5]+"...", "update", what)
+		}
+		e = <-done
+	}
+
+	switch {
+	case e == nil:
+		if bi.Version() != version {
+			c.boardUpdated(pk, bi)
+		}
+		c.hub.push(pk, bi)
+	case ctx.Err() != nil && bi.IsClosed():
+		c.l.Log(inform.InfoLevel, "board update cancelled as board is removed",
+			"board", pk.Hex()[:5]+"...", "update", what)
+	case ctx.Err() != nil:
+		c.l.Log(inform.WarnLevel, "board update timed out",
+			"board", pk.Hex()[:5]+"...", "update", what, "error", ctx.Err())
+		c.boardErrored(pk, boo.WrapTypef(ctx.Err(), boo.Internal, "%s timed out", what))
+	case e != nil:
+		c.l.Log(inform.ErrorLevel, "board update failed",
+			"board", pk.Hex()[:5]+"...", "update", what, "error", e)
+		c.boardErrored(pk, e)
+	}
+}
+
+// updateContext obtains the context of a board update, which is cancelled
+// when the update exceeds the update timeout or the board is removed.
+func (c *Compiler) updateContext(bi *BoardInstance) (context.Context, context.CancelFunc) {
+	parent := bi.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	if c.c.UpdateTimeout == nil || *c.c.UpdateTimeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent,
+		time.Second*time.Duration(*c.c.UpdateTimeout))
 }
 
 // EnsureSubmissionKeys ranges through masters and ensures that their specified
@@ -139,26 +285,60 @@ func (c *Compiler) updateSingle(root *skyobject.Root) {
 func (c *Compiler) EnsureSubmissionKeys(subKeys []*object.MessengerSubKeyTransport) error {
 	return c.file.RangeMasterSubs(func(pk cipher.PubKey, sk cipher.SecKey) {
 		if bi, e := c.GetBoard(pk); e != nil {
-			c.l.Println(e)
+			c.l.Errorf("%v", e)
 		} else {
 			if _, e := bi.EnsureSubmissionKeys(subKeys); e != nil {
-				c.l.Println(e)
+				c.l.Errorf("%v", e)
 			}
 		}
 	})
 }
 
-func (c *Compiler) DeleteBoard(bpk cipher.PubKey) {
+// RemoveBoard stops tracking a board. It's pending and in-progress updates are
+// cancelled, it's subscribers are dropped and it's views and snapshot are released.
+func (c *Compiler) RemoveBoard(pk cipher.PubKey) error {
 	c.mux.Lock()
-	defer c.mux.Unlock()
+	bi, has := c.boards[pk]
+	delete(c.boards, pk)
+	c.mux.Unlock()
 
-	bi, has := c.boards[bpk]
 	if !has {
-		return
+		return boo.Newf(boo.NotFound,
+			"board '%s' not found", pk.Hex()[:5]+"...")
 	}
 
 	bi.Close()
-	delete(c.boards, bpk)
+	c.hub.removeBoard(pk)
+	if bi.snapshotDir != "" {
+		if e := os.Remove(SnapshotPath(bi.snapshotDir, pk.Hex())); e != nil && !os.IsNotExist(e) {
+			c.l.Warnf("failed to remove snapshot: %v", e)
+		}
+	}
+
+	c.statsMux.Lock()
+	c.stats = nil
+	c.statsMux.Unlock()
+	return nil
+}
+
+// ListBoards obtains the status of all tracked boards, ordered by public key.
+func (c *Compiler) ListBoards() []*BoardInstanceStats {
+	c.mux.Lock()
+	boards := make(map[cipher.PubKey]*BoardInstance, len(c.boards))
+	for pk, bi := range c.boards {
+		boards[pk] = bi
+	}
+	c.mux.Unlock()
+
+	out := make([]*BoardInstanceStats, 0, len(boards))
+	for pk, bi := range boards {
+		out = append(out, bi.status(pk))
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].PubKey < out[j].PubKey
+	})
+	return out
 }
 
 func (c *Compiler) GetBoard(pk cipher.PubKey) (*BoardInstance, error) {
@@ -179,6 +359,35 @@ func (c *Compiler) GetBoard(pk cipher.PubKey) (*BoardInstance, error) {
 	return bi, nil
 }
 
+// BoardVersion obtains the views version of a board, for cache invalidation.
+func (c *Compiler) BoardVersion(pk cipher.PubKey) (uint64, error) {
+	bi, e := c.GetBoard(pk)
+	if e != nil {
+		return 0, e
+	}
+	return bi.Version(), nil
+}
+
+// BoardSyncStatus obtains how far the compiled state of a board is behind the
+// latest root known of it. Unlike GetBoard, boards not yet received are included.
+func (c *Compiler) BoardSyncStatus(pk cipher.PubKey) (*BoardSyncStatus, error) {
+	c.mux.Lock()
+	bi, ok := c.boards[pk]
+	c.mux.Unlock()
+
+	if !ok {
+		return nil, boo.Newf(boo.NotFound,
+			"board '%s' not found", pk.Hex()[:5]+"...")
+	}
+	return bi.syncStatus(pk), nil
+}
+
+// IsSubscribed determines whether the node is subscribed to the board.
+// Unlike GetBoard, this checks the node's feeds rather than cached board instances.
+func (c *Compiler) IsSubscribed(pk cipher.PubKey) bool {
+	return c.node.HasFeed(pk)
+}
+
 func (c *Compiler) UpdateBoard(root *skyobject.Root) {
 	c.newRoots <- RootWrap{Root: root}
 }
@@ -198,11 +407,11 @@ func (c *Compiler) GetMasterSummaries() []*object.BoardSummaryWrap {
 	var out []*object.BoardSummaryWrap
 	c.file.RangeMasterSubs(func(pk cipher.PubKey, sk cipher.SecKey) {
 		if bi, e := c.GetBoard(pk); e != nil {
-			c.l.Println(e)
+			c.l.Errorf("%v", e)
 		} else {
 			summary, e := bi.GetSummary(pk, sk)
 			if e != nil {
-				c.l.Println(e)
+				c.l.Errorf("%v", e)
 				return
 			}
 			out = append(out, summary)
@@ -215,6 +424,126 @@ func (c *Compiler) RangeMasterSubs(action object.MasterSubAction) error {
 	return c.file.RangeMasterSubs(action)
 }
 
+// CompilerStats represents the totals across all boards tracked by the compiler.
+type CompilerStats struct {
+	MasterBoardCount int `json:"master_board_count"`
+	RemoteBoardCount int `json:"remote_board_count"`
+	ThreadCount      int `json:"thread_count"`
+	PostCount        int `json:"post_count"`
+	ParticipantCount int `json:"participant_count"`
+	VoteCount        int `json:"vote_count"`
+
+	Boards []*BoardInstanceStats `json:"boards"`
+}
+
+// BoardInstanceStats represents the tracking state of a board.
+type BoardInstanceStats struct {
+	PubKey     string    `json:"public_key"`
+	IsMaster   bool      `json:"is_master"`
+	IsReceived bool      `json:"is_received"`
+	IsReady    bool      `json:"is_ready"`
+	Version    uint64    `json:"version"`
+	LoadedAt   time.Time `json:"loaded_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (bi *BoardInstance) status(pk cipher.PubKey) *BoardInstanceStats {
+	return &BoardInstanceStats{
+		PubKey:     pk.Hex(),
+		IsMaster:   bi.IsMaster(),
+		IsReceived: bi.IsReceived(),
+		IsReady:    bi.IsReady(),
+		Version:    bi.Version(),
+		LoadedAt:   bi.LoadedAt(),
+		UpdatedAt:  bi.UpdatedAt(),
+	}
+}
+
+// BoardSyncStatus represents how far the compiled state of a board is behind the
+// latest root known of it. For boards that are not master, this is the lag of
+// the node as a read-only follower.
+type BoardSyncStatus struct {
+	PubKey      string    `json:"public_key"`
+	IsMaster    bool      `json:"is_master"`
+	IsReceived  bool      `json:"is_received"`
+	LatestSeq   uint64    `json:"latest_seq"`   // Latest known root sequence.
+	CompiledSeq uint64    `json:"compiled_seq"` // Root sequence of compiled views.
+	Lag         uint64    `json:"lag"`          // Number of sequences not yet compiled.
+	CompiledAt  time.Time `json:"compiled_at"`  // Time of last compile.
+}
+
+func (bi *BoardInstance) syncStatus(pk cipher.PubKey) *BoardSyncStatus {
+	out := &BoardSyncStatus{
+		PubKey:      pk.Hex(),
+		IsMaster:    bi.IsMaster(),
+		IsReceived:  bi.IsReceived(),
+		LatestSeq:   bi.LatestSeq(),
+		CompiledSeq: bi.GetSeq(),
+		CompiledAt:  bi.CompiledAt(),
+	}
+	if out.LatestSeq > out.CompiledSeq {
+		out.Lag = out.LatestSeq - out.CompiledSeq
+	}
+	return out
+}
+
+// copy obtains a copy of the stats, which shares nothing with the original.
+func (s *CompilerStats) copy() *CompilerStats {
+	out := *s
+	out.Boards = make([]*BoardInstanceStats, len(s.Boards))
+	for i, bs := range s.Boards {
+		cp := *bs
+		out.Boards[i] = &cp
+	}
+	return &out
+}
+
+// Stats obtains the totals across all tracked boards.
+// Results are cached for 'StatsCacheDuration', and each call obtains it's own copy.
+func (c *Compiler) Stats() *CompilerStats {
+	c.statsMux.Lock()
+	defer c.statsMux.Unlock()
+
+	if c.stats != nil && time.Since(c.statsTime) < StatsCacheDuration {
+		return c.stats.copy()
+	}
+
+	c.mux.Lock()
+	boards := make(map[cipher.PubKey]*BoardInstance, len(c.boards))
+	for pk, bi := range c.boards {
+		boards[pk] = bi
+	}
+	c.mux.Unlock()
+
+	out := new(CompilerStats)
+	for pk, bi := range boards {
+		out.Boards = append(out.Boards, bi.status(pk))
+		if bi.IsReady() == false {
+			continue
+		}
+		stats, e := bi.Viewer().GetBoardStats()
+		if e != nil {
+			c.l.Errorf("%v", e)
+			continue
+		}
+		if bi.IsMaster() {
+			out.MasterBoardCount++
+		} else {
+			out.RemoteBoardCount++
+		}
+		out.ThreadCount += stats.ThreadCount
+		out.PostCount += stats.PostCount
+		out.ParticipantCount += stats.ParticipantCount
+		out.VoteCount += stats.VoteCount
+	}
+
+	sort.Slice(out.Boards, func(i, j int) bool {
+		return out.Boards[i].PubKey < out.Boards[j].PubKey
+	})
+	c.stats, c.statsTime = out, time.Now()
+	return out.copy()
+}
+
 /*
 	<<< HELPER FUNCTIONS >>>
 */
@@ -226,8 +555,74 @@ func (c *Compiler) ensureBoard(pk cipher.PubKey) *BoardInstance {
 	bi, has := c.boards[pk]
 	if !has {
 		bi = new(BoardInstance).Init(c.node, pk)
+		bi.sortBy = c.defaultSortBy()
+		bi.debug = c.c.DebugUpdates != nil && *c.c.DebugUpdates
+		bi.maxPins = c.maxPins()
+		bi.repTransform = c.c.RepTransform
+		bi.limiter = c.limiter
+		bi.spamScorer = c.c.SpamScorer
+		bi.spamThreshold = c.spamThreshold()
+		bi.gcRetention = c.gcRetention()
+		if c.c.SnapshotDir != nil {
+			bi.snapshotDir = *c.c.SnapshotDir
+		}
 		c.boards[pk] = bi
 	}
 	bi.SetReceived()
 	return bi
 }
+
+// RateLimit obtains the submission allowance of a user.
+func (c *Compiler) RateLimit(upk string) *RateLimitStatus {
+	return c.limiter.Status(upk)
+}
+
+func (c *Compiler) maxCompiles() int {
+	if c.c.MaxCompiles == nil || *c.c.MaxCompiles <= 0 {
+		return DefaultMaxCompiles
+	}
+	return *c.c.MaxCompiles
+}
+
+func (c *Compiler) maxPins() int {
+	if c.c.MaxPins == nil || *c.c.MaxPins <= 0 {
+		return DefaultMaxPins
+	}
+	return *c.c.MaxPins
+}
+
+func (c *Compiler) spamThreshold() float64 {
+	if c.c.SpamThreshold == nil {
+		return DefaultSpamThreshold
+	}
+	return *c.c.SpamThreshold
+}
+
+func (c *Compiler) gcRetention() time.Duration {
+	if c.c.GCRetention == nil || *c.c.GCRetention <= 0 {
+		return 0
+	}
+	return time.Hour * time.Duration(*c.c.GCRetention)
+}
+
+func (c *Compiler) defaultSortBy() string {
+	if c.c.DefaultSortBy == nil {
+		return ""
+	}
+	if _, e := GetSorter(*c.c.DefaultSortBy); e != nil {
+		c.l.Warnf("%v - falling back to chronological order", e)
+		return ""
+	}
+	return *c.c.DefaultSortBy
+}
+
+func (c *Compiler) newRateLimiter() *RateLimiter {
+	if c.c.SubmitRate == nil {
+		return nil
+	}
+	burst := 0
+	if c.c.SubmitBurst != nil {
+		burst = *c.c.SubmitBurst
+	}
+	return NewRateLimiter(*c.c.SubmitRate, burst)
+}
@@ -0,0 +1,318 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/skycoin/src/cipher"
+	"math"
+	"sort"
+)
+
+// AggregatedFeedIn represents the input required to obtain the aggregated feed.
+type AggregatedFeedIn struct {
+	Boards         []cipher.PubKey // Boards to include, empty for all ready boards.
+	Dedup          bool            // Whether to merge threads of same name and body cross-posted to multiple boards.
+	PaginatedInput typ.PaginatedInput
+}
+
+// FeedItem represents a thread in the aggregated feed.
+type FeedItem struct {
+	Board  string             `json:"board"`
+	Thread *object.ContentRep `json:"thread"`
+	AlsoIn []string           `json:"also_in,omitempty"` // Other boards of cross-posted thread (only with dedup).
+}
+
+// AggregatedFeedOut represents the output for the aggregated feed.
+type AggregatedFeedOut struct {
+	FeedMeta *typ.PaginatedOutput `json:"feed_meta"`
+	Feed     []*FeedItem          `json:"feed"`
+}
+
+// GetAggregatedFeed obtains threads of multiple boards, newest first.
+// With dedup, only the earliest occurrence of a cross-posted thread is kept.
+func (c *Compiler) GetAggregatedFeed(in *AggregatedFeedIn) (*AggregatedFeedOut, error) {
+	var items []*FeedItem
+	for _, bi := range c.feedBoards(in.Boards) {
+		page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+			PaginatedInput: typ.PaginatedInput{PageSize: math.MaxUint64},
+		})
+		if e != nil {
+			c.l.Warnf("%v", e)
+			continue
+		}
+		for _, thread := range page.Threads {
+			items = append(items, &FeedItem{
+				Board:  page.Board.PubKey,
+				Thread: thread,
+			})
+		}
+	}
+
+	if in.Dedup {
+		items = dedupFeed(items)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return feedTS(items[i]) > feedTS(items[j])
+	})
+
+	meta, e := typ.NewPaginatedOutput(&in.PaginatedInput, uint(len(items)))
+	if e != nil {
+		return nil, e
+	}
+	out := &AggregatedFeedOut{
+		FeedMeta: meta,
+		Feed:     make([]*FeedItem, meta.RecordCount),
+	}
+	for i := range out.Feed {
+		out.Feed[i] = items[pageIndex(&in.PaginatedInput, i)]
+	}
+	return out, nil
+}
+
+// feedBoards obtains the ready board instances of given public keys,
+// or all ready board instances if none are given.
+func (c *Compiler) feedBoards(pks []cipher.PubKey) []*BoardInstance {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	var out []*BoardInstance
+	if len(pks) == 0 {
+		for _, bi := range c.boards {
+			if bi.IsReady() {
+				out = append(out, bi)
+			}
+		}
+		return out
+	}
+	for _, pk := range pks {
+		if bi, ok := c.boards[pk]; ok && bi.IsReady() {
+			out = append(out, bi)
+		}
+	}
+	return out
+}
+
+// dedupFeed merges feed items of same thread name and body,
+// keeping the earliest and recording the boards of the others.
+func dedupFeed(items []*FeedItem) []*FeedItem {
+	sort.SliceStable(items, func(i, j int) bool {
+		return feedTS(items[i]) < feedTS(items[j])
+	})
+	var (
+		out   = make([]*FeedItem, 0, len(items))
+		first = make(map[cipher.SHA256]*FeedItem)
+	)
+	for _, item := range items {
+		body := item.Thread.Body.(*object.Body)
+		key := cipher.SumSHA256([]byte(body.Name + "\n" + body.Body))
+		if orig, ok := first[key]; ok {
+			orig.AlsoIn = append(orig.AlsoIn, item.Board)
+			continue
+		}
+		first[key] = item
+		out = append(out, item)
+	}
+	return out
+}
+
+func feedTS(item *FeedItem) int64 {
+	return item.Thread.Body.(*object.Body).TS
+}
+
+// WatchlistItem represents a thread watched by a user, of a board.
+type WatchlistItem struct {
+	Board string `json:"board"`
+	*WatchedThread
+}
+
+// GetUserWatchlist obtains threads watched by the user across all ready boards,
+// ordered by most recent activity. See Viewer.GetWatchedThreads.
+func (c *Compiler) GetUserWatchlist(upk string, lastSeen map[string]int64) ([]*WatchlistItem, error) {
+	out := []*WatchlistItem{}
+	for _, bi := range c.feedBoards(nil) {
+		board, e := bi.Viewer().GetBoard()
+		if e != nil {
+			c.l.Warnf("%v", e)
+			continue
+		}
+		watched, e := bi.Viewer().GetWatchedThreads(&WatchedThreadsIn{
+			UserPubKey: upk,
+			LastSeen:   lastSeen,
+		})
+		if e != nil {
+			c.l.Warnf("%v", e)
+			continue
+		}
+		for _, wt := range watched.Threads {
+			out = append(out, &WatchlistItem{Board: board.PubKey, WatchedThread: wt})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].LastActivity > out[j].LastActivity
+	})
+	return out, nil
+}
+
+// DefaultSearchPerBoardLimit is the default maximum number of results taken from
+// each board before merging, so that no single board dominates global search.
+const DefaultSearchPerBoardLimit = 50
+
+// GlobalSearchIn represents the input required to search content across boards.
+type GlobalSearchIn struct {
+	Boards         []cipher.PubKey // Boards to search, empty for all ready boards.
+	Query          string
+	Perspective    string
+	HideBlocked    bool // Whether to exclude content created by users blocked by perspective (per board).
+	PerBoardLimit  int  // Maximum results per board before merging, <= 0 for default.
+	PaginatedInput typ.PaginatedInput
+}
+
+// GlobalSearchResult represents a search result of a board.
+type GlobalSearchResult struct {
+	Board string `json:"board"`
+	*SearchResult
+}
+
+// GlobalSearchOut represents the output for searching content across boards.
+type GlobalSearchOut struct {
+	ResultsMeta *typ.PaginatedOutput  `json:"results_meta"`
+	Results     []*GlobalSearchResult `json:"results"`
+}
+
+// SearchAll searches content of multiple boards, merging the top results of
+// each board by score. See Viewer.SearchContent.
+func (c *Compiler) SearchAll(in *GlobalSearchIn) (*GlobalSearchOut, error) {
+	terms := searchTerms(in.Query)
+	if len(terms) == 0 {
+		return nil, boo.New(boo.InvalidInput, "empty search query")
+	}
+	limit := in.PerBoardLimit
+	if limit <= 0 {
+		limit = DefaultSearchPerBoardLimit
+	}
+
+	var results []*GlobalSearchResult
+	for _, bi := range c.feedBoards(in.Boards) {
+		board, e := bi.Viewer().GetBoard()
+		if e != nil {
+			c.l.Warnf("%v", e)
+			continue
+		}
+		found, e := bi.Viewer().SearchContent(&SearchIn{
+			Query:          in.Query,
+			Perspective:    in.Perspective,
+			HideBlocked:    in.HideBlocked,
+			PaginatedInput: typ.PaginatedInput{PageSize: uint(limit)},
+		})
+		if e != nil {
+			c.l.Warnf("%v", e)
+			continue
+		}
+		for _, result := range found.Results {
+			results = append(results, &GlobalSearchResult{
+				Board:        board.PubKey,
+				SearchResult: result,
+			})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return searchResultLess(results[i].SearchResult, results[j].SearchResult)
+	})
+
+	meta, e := typ.NewPaginatedOutput(&in.PaginatedInput, uint(len(results)))
+	if e != nil {
+		return nil, e
+	}
+	out := &GlobalSearchOut{
+		ResultsMeta: meta,
+		Results:     make([]*GlobalSearchResult, meta.RecordCount),
+	}
+	for i := range out.Results {
+		out.Results[i] = results[pageIndex(&in.PaginatedInput, i)]
+	}
+	return out, nil
+}
+
+// Orders of the boards list (see GetBoardsPage).
+const (
+	BoardsBySubscription = "subscription" // Master boards, then remote boards, in order of subscription.
+	BoardsByActivity     = "activity"     // Most recent activity first.
+)
+
+// BoardsPageIn represents the input required to obtain a page of compiled boards.
+type BoardsPageIn struct {
+	SortBy         string // BoardsBySubscription or BoardsByActivity, empty for BoardsBySubscription.
+	PaginatedInput typ.PaginatedInput
+}
+
+// BoardsPageItem represents the summary of a compiled board.
+type BoardsPageItem struct {
+	PubKey   string             `json:"public_key"`
+	IsMaster bool               `json:"is_master"`
+	Board    *object.ContentRep `json:"board"`
+	*BoardStatsOut
+}
+
+// BoardsPageOut represents the output for a page of compiled boards.
+type BoardsPageOut struct {
+	BoardsMeta *typ.PaginatedOutput `json:"boards_meta"`
+	Boards     []*BoardsPageItem    `json:"boards"`
+}
+
+// GetBoardsPage obtains a page of summaries of subscribed boards that are compiled.
+func (c *Compiler) GetBoardsPage(in *BoardsPageIn) (*BoardsPageOut, error) {
+	switch in.SortBy {
+	case "", BoardsBySubscription, BoardsByActivity:
+	default:
+		return nil, boo.Newf(boo.InvalidInput,
+			"invalid boards order '%s', expected '%s' or '%s'",
+			in.SortBy, BoardsBySubscription, BoardsByActivity)
+	}
+
+	var pks []cipher.PubKey
+	c.file.RangeMasterSubs(func(pk cipher.PubKey, _ cipher.SecKey) {
+		pks = append(pks, pk)
+	})
+	c.file.RangeRemoteSubs(func(pk cipher.PubKey) {
+		pks = append(pks, pk)
+	})
+
+	items := make([]*BoardsPageItem, 0, len(pks))
+	for _, bi := range c.feedBoards(pks) {
+		board, e := bi.Viewer().GetBoard()
+		if e != nil {
+			c.l.Warnf("%v", e)
+			continue
+		}
+		stats, e := bi.Viewer().GetBoardStats()
+		if e != nil {
+			c.l.Warnf("%v", e)
+			continue
+		}
+		items = append(items, &BoardsPageItem{
+			PubKey:        board.PubKey,
+			IsMaster:      bi.IsMaster(),
+			Board:         board,
+			BoardStatsOut: stats,
+		})
+	}
+	if in.SortBy == BoardsByActivity {
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].LastActivity > items[j].LastActivity
+		})
+	}
+
+	meta, e := typ.NewPaginatedOutput(&in.PaginatedInput, uint(len(items)))
+	if e != nil {
+		return nil, e
+	}
+	out := &BoardsPageOut{
+		BoardsMeta: meta,
+		Boards:     make([]*BoardsPageItem, meta.RecordCount),
+	}
+	for i := range out.Boards {
+		out.Boards[i] = items[pageIndex(&in.PaginatedInput, i)]
+	}
+	return out, nil
+}
@@ -0,0 +1,259 @@
+package state
+
+import (
+	"context"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/inform"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/skycoin/src/cipher"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// initFeedCompiler prepares a compiler of master boards of given seeds, sharing a node.
+func initFeedCompiler(t *testing.T, seeds ...string) (*Compiler, []*BoardInstance, func()) {
+	var (
+		memMode = true
+		n       = prepareNode(t)
+		c       = &Compiler{
+			c:      &CompilerConfig{},
+			l:      inform.New(true, os.Stdout, LogPrefix),
+			node:   n,
+			file:   object.NewCXOFileManager(&object.CXOFileManagerConfig{Memory: &memMode}),
+			boards: make(map[cipher.PubKey]*BoardInstance),
+			hub:    newHub(),
+		}
+		instances []*BoardInstance
+	)
+	for _, seed := range seeds {
+		pk, sk, r := prepareBoard(t, n, seed)
+		bi := prepareInstance(t, n, pk)
+		if e := bi.UpdateWithReceived(context.Background(), r, sk); e != nil {
+			t.Fatal("failed to update board instance:", e)
+		}
+		if e := c.file.AddMasterSub(pk, sk); e != nil {
+			t.Fatal("failed to add master subscription:", e)
+		}
+		c.boards[pk] = bi
+		instances = append(instances, bi)
+	}
+	return c, instances, func() {
+		for _, bi := range instances {
+			bi.Close()
+		}
+		n.Close()
+	}
+}
+
+// feedThreads obtains the "<board seed>/<thread name>" of each item of the feed.
+func feedThreads(out *AggregatedFeedOut, boards map[string]string) []string {
+	threads := make([]string, len(out.Feed))
+	for i, item := range out.Feed {
+		threads[i] = boards[item.Board] + "/" + item.Thread.Body.(*object.Body).Name
+	}
+	return threads
+}
+
+func TestCompiler_GetAggregatedFeed(t *testing.T) {
+	comp, instances, quit := initFeedCompiler(t, "a", "b")
+	defer quit()
+
+	var (
+		apk     = obtainBoardPubKey(t, instances[0])
+		bpk     = obtainBoardPubKey(t, instances[1])
+		boards  = map[string]string{apk.Hex(): "a", bpk.Hex(): "b"}
+		page    = typ.PaginatedInput{PageSize: 10}
+		other   = cipher.PubKey{}
+		threadA = "Thread 0"
+		threadB = "Thread 1"
+	)
+	// Thread 0 is cross-posted to board 'b' after it is created in board 'a'.
+	addThread(t, instances[0], 0, []byte("user"))
+	addThread(t, instances[0], 1, []byte("user"))
+	addThread(t, instances[1], 0, []byte("user"))
+	for _, bi := range instances {
+		if e := bi.PublishChanges(); e != nil {
+			t.Fatal("failed to publish changes:", e)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		in       *AggregatedFeedIn
+		expected []string
+		alsoIn   []string // Of the cross-posted thread of board 'a'.
+		errType  int
+	}{
+		{"all", &AggregatedFeedIn{PaginatedInput: page},
+			[]string{"b/" + threadA, "a/" + threadB, "a/" + threadA}, nil, 0},
+		{"dedup", &AggregatedFeedIn{Dedup: true, PaginatedInput: page},
+			[]string{"a/" + threadB, "a/" + threadA}, []string{bpk.Hex()}, 0},
+		{"of_board", &AggregatedFeedIn{Boards: []cipher.PubKey{bpk}, PaginatedInput: page},
+			[]string{"b/" + threadA}, nil, 0},
+		{"of_unknown_board", &AggregatedFeedIn{Boards: []cipher.PubKey{other}, PaginatedInput: page},
+			[]string{}, nil, 0},
+		{"paginated", &AggregatedFeedIn{PaginatedInput: typ.PaginatedInput{StartIndex: 2, PageSize: 2}},
+			[]string{"a/" + threadA}, nil, 0},
+		{"invalid_page", &AggregatedFeedIn{}, nil, nil, boo.InvalidInput},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := comp.GetAggregatedFeed(c.in)
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get aggregated feed:", e)
+			}
+			if got := feedThreads(out, boards); !reflect.DeepEqual(got, c.expected) {
+				t.Fatalf("got feed %v, expected %v", got, c.expected)
+			}
+			for _, item := range out.Feed {
+				if boards[item.Board] == "a" && item.Thread.Body.(*object.Body).Name == threadA &&
+					!reflect.DeepEqual(item.AlsoIn, c.alsoIn) {
+					t.Errorf("got cross-posted in %v, expected %v", item.AlsoIn, c.alsoIn)
+				}
+			}
+		})
+	}
+}
+
+func TestCompiler_GetUserWatchlist(t *testing.T) {
+	comp, instances, quit := initFeedCompiler(t, "a", "b")
+	defer quit()
+
+	var (
+		apk    = obtainBoardPubKey(t, instances[0])
+		bpk    = obtainBoardPubKey(t, instances[1])
+		boards = map[string]string{apk.Hex(): "a", bpk.Hex(): "b"}
+		upk, _ = cipher.GenerateDeterministicKeyPair([]byte("user"))
+	)
+	ownThread, _ := addThread(t, instances[0], 0, []byte("user"))
+	postedThread, _ := addThread(t, instances[0], 1, []byte("other"))
+	otherThread, _ := addThread(t, instances[1], 0, []byte("other"))
+	addThread(t, instances[1], 1, []byte("other"))
+	for _, bi := range instances {
+		if e := bi.PublishChanges(); e != nil {
+			t.Fatal("failed to publish changes:", e)
+		}
+	}
+	// Order of activity is reverse to order of creation.
+	addPost(t, instances[0], postedThread, 0, []byte("user"))
+	addPost(t, instances[0], ownThread, 1, []byte("other"))
+	addPost(t, instances[1], otherThread, 2, []byte("user"))
+	for _, bi := range instances {
+		if e := bi.PublishChanges(); e != nil {
+			t.Fatal("failed to publish changes:", e)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		upk      string
+		lastSeen map[string]int64
+		expected []string // Of "<board seed>/<thread name>".
+		unread   []int
+	}{
+		{"unseen", upk.Hex(), nil,
+			[]string{"b/Thread 0", "a/Thread 0", "a/Thread 1"}, []int{1, 1, 1}},
+		{"seen", upk.Hex(), map[string]int64{ownThread.Hex(): time.Now().Unix() + 60},
+			[]string{"b/Thread 0", "a/Thread 0", "a/Thread 1"}, []int{1, 0, 1}},
+		{"unknown_user", cipher.PubKey{}.Hex(), nil,
+			[]string{}, []int{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			items, e := comp.GetUserWatchlist(c.upk, c.lastSeen)
+			if e != nil {
+				t.Fatal("failed to get user watchlist:", e)
+			}
+			var (
+				got    = make([]string, len(items))
+				unread = make([]int, len(items))
+			)
+			for i, item := range items {
+				got[i] = boards[item.Board] + "/" + item.Thread.Body.(*object.Body).Name
+				unread[i] = item.UnreadCount
+			}
+			if !reflect.DeepEqual(got, c.expected) || !reflect.DeepEqual(unread, c.unread) {
+				t.Errorf("got watchlist %v with unread %v, expected %v with unread %v",
+					got, unread, c.expected, c.unread)
+			}
+		})
+	}
+
+	if _, e := (*Viewer)(nil).GetWatchedThreads(&WatchedThreadsIn{}); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
+
+func TestCompiler_GetBoardsPage(t *testing.T) {
+	comp, instances, quit := initFeedCompiler(t, "a", "b")
+	defer quit()
+
+	var (
+		apk        = obtainBoardPubKey(t, instances[0])
+		bpk        = obtainBoardPubKey(t, instances[1])
+		boards     = map[string]string{apk.Hex(): "a", bpk.Hex(): "b"}
+		pending, _ = cipher.GenerateKeyPair()
+		page       = typ.PaginatedInput{PageSize: 10}
+	)
+	// Subscribed boards that are not compiled are excluded.
+	if e := comp.file.AddRemoteSub(pending); e != nil {
+		t.Fatal("failed to add remote subscription:", e)
+	}
+	comp.boards[pending] = new(BoardInstance).Init(nil, pending)
+
+	// Board 'b' has the most recent activity.
+	for _, bi := range instances {
+		addThread(t, bi, 0, []byte("user"))
+		if e := bi.PublishChanges(); e != nil {
+			t.Fatal("failed to publish changes:", e)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		in       BoardsPageIn
+		expected []string // Board seeds.
+		errType  int
+	}{
+		{"default", BoardsPageIn{PaginatedInput: page}, []string{"a", "b"}, 0},
+		{"by_subscription", BoardsPageIn{SortBy: BoardsBySubscription, PaginatedInput: page}, []string{"a", "b"}, 0},
+		{"by_activity", BoardsPageIn{SortBy: BoardsByActivity, PaginatedInput: page}, []string{"b", "a"}, 0},
+		{"paginated", BoardsPageIn{PaginatedInput: typ.PaginatedInput{StartIndex: 1, PageSize: 1}}, []string{"b"}, 0},
+		{"invalid_order", BoardsPageIn{SortBy: "unknown", PaginatedInput: page}, nil, boo.InvalidInput},
+		{"invalid_page", BoardsPageIn{}, nil, boo.InvalidInput},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := comp.GetBoardsPage(&c.in)
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get boards page:", e)
+			}
+			got := make([]string, len(out.Boards))
+			for i, board := range out.Boards {
+				got[i] = boards[board.PubKey]
+				if !board.IsMaster || board.Board == nil || board.ThreadCount != 1 {
+					t.Errorf("board '%s': got master %v, board %v and %d threads, expected a master board of 1 thread",
+						got[i], board.IsMaster, board.Board, board.ThreadCount)
+				}
+			}
+			if !reflect.DeepEqual(got, c.expected) {
+				t.Errorf("got boards %v, expected %v", got, c.expected)
+			}
+		})
+	}
+}
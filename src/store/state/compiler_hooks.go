@@ -0,0 +1,57 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/skycoin/src/cipher"
+	"sync"
+)
+
+// BoardUpdatedHook is called after a compile cycle of a board that changed it's views,
+// with the content changes of the compiled root.
+type BoardUpdatedHook func(pk cipher.PubKey, changes *object.Changes)
+
+// BoardErrorHook is called after a compile cycle of a board that failed or timed out.
+type BoardErrorHook func(pk cipher.PubKey, e error)
+
+// hooks holds the registered compile cycle hooks.
+type hooks struct {
+	mux     sync.RWMutex
+	updated []BoardUpdatedHook
+	errored []BoardErrorHook
+}
+
+// OnBoardUpdated registers a hook that is called after a compile cycle changes the views of a board.
+// Hooks are called in order of registration from the compiler's update loop, so should not block.
+func (c *Compiler) OnBoardUpdated(hook BoardUpdatedHook) {
+	c.hooks.mux.Lock()
+	defer c.hooks.mux.Unlock()
+	c.hooks.updated = append(c.hooks.updated, hook)
+}
+
+// OnBoardError registers a hook that is called after a compile cycle of a board fails.
+// Hooks are called in order of registration from the compiler's update loop, so should not block.
+func (c *Compiler) OnBoardError(hook BoardErrorHook) {
+	c.hooks.mux.Lock()
+	defer c.hooks.mux.Unlock()
+	c.hooks.errored = append(c.hooks.errored, hook)
+}
+
+func (c *Compiler) boardUpdated(pk cipher.PubKey, bi *BoardInstance) {
+	c.hooks.mux.RLock()
+	defer c.hooks.mux.RUnlock()
+	if len(c.hooks.updated) == 0 {
+		return
+	}
+	changes := bi.GetChanges()
+	for _, hook := range c.hooks.updated {
+		hook(pk, changes)
+	}
+}
+
+func (c *Compiler) boardErrored(pk cipher.PubKey, e error) {
+	c.hooks.mux.RLock()
+	defer c.hooks.mux.RUnlock()
+	for _, hook := range c.hooks.errored {
+		hook(pk, e)
+	}
+}
@@ -0,0 +1,390 @@
+package state
+
+import (
+	"context"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/inform"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/skycoin/src/cipher"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompiler_Hooks(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	c := &Compiler{
+		c:   &CompilerConfig{},
+		l:   inform.New(true, os.Stdout, LogPrefix),
+		hub: newHub(),
+	}
+	bi := new(BoardInstance).Init(nil, pk)
+
+	var updated, errored int
+	c.OnBoardUpdated(func(got cipher.PubKey, changes *object.Changes) {
+		if got != pk {
+			t.Errorf("updated hook: got board %s, expected %s", got.Hex(), pk.Hex())
+		}
+		updated++
+	})
+	c.OnBoardError(func(got cipher.PubKey, e error) {
+		if e == nil {
+			t.Error("error hook: got nil error")
+		}
+		errored++
+	})
+
+	cases := []struct {
+		name    string
+		action  func(ctx context.Context) error
+		updated int
+		errored int
+	}{
+		{"changed", func(ctx context.Context) error { bi.broadcastUpdate(nil); return nil }, 1, 0},
+		{"unchanged", func(ctx context.Context) error { return nil }, 0, 0},
+		{"failed", func(ctx context.Context) error { return boo.New(boo.Internal, "woops") }, 0, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			updated, errored = 0, 0
+			c.doUpdate(pk, bi, tc.name, tc.action)
+			if updated != tc.updated || errored != tc.errored {
+				t.Errorf("got %d updated and %d errored calls, expected %d and %d",
+					updated, errored, tc.updated, tc.errored)
+			}
+		})
+	}
+}
+
+func TestCompiler_doUpdate_timeout(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	timeout := 1
+	c := &Compiler{
+		c:   &CompilerConfig{UpdateTimeout: &timeout},
+		l:   inform.New(true, os.Stdout, LogPrefix),
+		hub: newHub(),
+	}
+	bi := new(BoardInstance).Init(nil, pk)
+
+	var errored int
+	c.OnBoardError(func(cipher.PubKey, error) { errored++ })
+
+	var stopped bool
+	c.doUpdate(pk, bi, "Stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Millisecond * 50) // Slow to observe the cancellation.
+		stopped = true
+		return ctx.Err()
+	})
+	if !stopped {
+		t.Error("update returned before the action observed the cancellation")
+	}
+	if errored != 1 {
+		t.Errorf("got %d errored calls, expected %d", errored, 1)
+	}
+}
+
+func TestCompiler_dispatch(t *testing.T) {
+	const maxCompiles = 2
+	c := &Compiler{
+		c:     &CompilerConfig{},
+		l:     inform.New(true, os.Stdout, LogPrefix),
+		hub:   newHub(),
+		slots: make(chan struct{}, maxCompiles),
+	}
+
+	var (
+		mux     sync.Mutex
+		running = make(map[*BoardInstance]int)
+		total   int
+		peak    int
+	)
+	action := func(bi *BoardInstance) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			mux.Lock()
+			running[bi]++
+			total++
+			if running[bi] > 1 {
+				t.Error("updates of the same board overlap")
+			}
+			if total > peak {
+				peak = total
+			}
+			mux.Unlock()
+
+			// Give other boards the chance to compile alongside.
+			for deadline := time.Now().Add(time.Millisecond * 200); time.Now().Before(deadline); {
+				mux.Lock()
+				full := total == maxCompiles
+				mux.Unlock()
+				if full {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			mux.Lock()
+			running[bi]--
+			total--
+			mux.Unlock()
+			return nil
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		pk, _ := cipher.GenerateKeyPair()
+		bi := new(BoardInstance).Init(nil, pk)
+		for j := 0; j < 3; j++ {
+			c.dispatch(pk, bi, "Test", action(bi), nil)
+		}
+	}
+	c.jobs.Wait()
+
+	if peak > maxCompiles {
+		t.Errorf("got %d concurrent compiles, expected at most %d", peak, maxCompiles)
+	}
+	if peak < maxCompiles {
+		t.Errorf("got %d concurrent compiles, expected boards to compile concurrently", peak)
+	}
+}
+
+func TestCompiler_RemoveBoard(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	c := &Compiler{
+		c:      &CompilerConfig{},
+		l:      inform.New(true, os.Stdout, LogPrefix),
+		boards: make(map[cipher.PubKey]*BoardInstance),
+		hub:    newHub(),
+		slots:  make(chan struct{}, 1),
+	}
+	bi := new(BoardInstance).Init(nil, pk)
+	c.boards[pk] = bi
+
+	if boards := c.ListBoards(); len(boards) != 1 || boards[0].PubKey != pk.Hex() {
+		t.Fatalf("unexpected boards listed: %v", boards)
+	}
+
+	events, _ := c.hub.subscribe(pk, 0)
+	<-events
+
+	var errored int
+	c.OnBoardError(func(cipher.PubKey, error) { errored++ })
+
+	started := make(chan struct{})
+	c.dispatch(pk, bi, "Test", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, nil)
+	<-started
+
+	if e := c.RemoveBoard(pk); e != nil {
+		t.Fatal("failed to remove board:", e)
+	}
+	c.jobs.Wait()
+
+	if !bi.IsClosed() {
+		t.Error("board instance should be closed")
+	}
+	if errored != 0 {
+		t.Error("cancelled update of removed board should not be reported as an error")
+	}
+	if _, ok := <-events; ok {
+		t.Error("subscribers of removed board should be dropped")
+	}
+	if boards := c.ListBoards(); len(boards) != 0 {
+		t.Errorf("unexpected boards listed: %v", boards)
+	}
+	if e := c.RemoveBoard(pk); boo.Type(e) != boo.NotFound {
+		t.Errorf("removing again: got error %v, expected not found", e)
+	}
+}
+
+func TestCompiler_dispatchPublish(t *testing.T) {
+	c := &Compiler{
+		c:     &CompilerConfig{},
+		l:     inform.New(true, os.Stdout, LogPrefix),
+		hub:   newHub(),
+		slots: make(chan struct{}, 1),
+	}
+	pk, _ := cipher.GenerateKeyPair()
+	bi := new(BoardInstance).Init(nil, pk)
+
+	// Stall the board, as a long running update would.
+	bi.cMux.Lock()
+	if !c.dispatchPublish(pk, bi) {
+		t.Fatal("first publish should be dispatched")
+	}
+	for i := 0; i < 3; i++ {
+		if c.dispatchPublish(pk, bi) {
+			t.Fatal("publish should not be dispatched while the previous is pending")
+		}
+	}
+	other, _ := cipher.GenerateKeyPair()
+	if !c.dispatchPublish(other, new(BoardInstance).Init(nil, other)) {
+		t.Error("publish of other board should be dispatched")
+	}
+	bi.cMux.Unlock()
+	c.jobs.Wait()
+
+	if !c.dispatchPublish(pk, bi) {
+		t.Error("publish should be dispatched once the previous is done")
+	}
+	c.jobs.Wait()
+}
+
+func TestCompiler_Stats(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	c := &Compiler{
+		c:      &CompilerConfig{},
+		l:      inform.New(true, os.Stdout, LogPrefix),
+		boards: map[cipher.PubKey]*BoardInstance{pk: new(BoardInstance).Init(nil, pk)},
+	}
+
+	first := c.Stats()
+	if len(first.Boards) != 1 || first.Boards[0].PubKey != pk.Hex() {
+		t.Fatalf("got boards %v, expected board '%s'", first.Boards, pk.Hex())
+	}
+	first.ThreadCount = 10
+	first.Boards[0].PubKey = "modified"
+	first.Boards = append(first.Boards, &BoardInstanceStats{})
+
+	second := c.Stats()
+	if second == first || second.ThreadCount != 0 {
+		t.Errorf("got thread count %d, expected the cached stats to be unmodified", second.ThreadCount)
+	}
+	if len(second.Boards) != 1 || second.Boards[0].PubKey != pk.Hex() {
+		t.Errorf("got boards %v, expected the cached board stats to be unmodified", second.Boards)
+	}
+}
+
+func TestCompiler_BoardVersion(t *testing.T) {
+	c, instances, quit := initFeedCompiler(t, "a")
+	defer quit()
+
+	var (
+		bpk        = obtainBoardPubKey(t, instances[0])
+		pending, _ = cipher.GenerateKeyPair()
+	)
+	c.boards[pending] = new(BoardInstance).Init(nil, pending)
+
+	initial, e := c.BoardVersion(bpk)
+	if e != nil {
+		t.Fatal("failed to get board version:", e)
+	}
+	addThread(t, instances[0], 0, []byte("user"))
+	if e := instances[0].PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	// Publishing without changes keeps the version.
+	if e := instances[0].PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	if version, e := c.BoardVersion(bpk); e != nil || version != initial+1 {
+		t.Errorf("got version %d (%v), expected %d", version, e, initial+1)
+	}
+
+	for name, pk := range map[string]cipher.PubKey{
+		"unknown":      {},
+		"not_received": pending,
+	} {
+		if _, e := c.BoardVersion(pk); boo.Type(e) != boo.NotFound {
+			t.Errorf("%s board: got error %v, expected not found", name, e)
+		}
+	}
+}
+
+func TestCompiler_Stats_boards(t *testing.T) {
+	start := time.Now()
+	comp, instances, quit := initFeedCompiler(t, "a", "b")
+	defer quit()
+
+	var (
+		apk        = obtainBoardPubKey(t, instances[0])
+		bpk        = obtainBoardPubKey(t, instances[1])
+		pending, _ = cipher.GenerateKeyPair()
+	)
+	comp.boards[pending] = new(BoardInstance).Init(nil, pending)
+
+	addThread(t, instances[0], 0, []byte("user"))
+	if e := instances[0].PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	stats := comp.Stats()
+	if stats.MasterBoardCount != 2 || stats.RemoteBoardCount != 0 || stats.ThreadCount != 1 {
+		t.Errorf("got %d master boards, %d remote boards and %d threads, expected 2, 0 and 1",
+			stats.MasterBoardCount, stats.RemoteBoardCount, stats.ThreadCount)
+	}
+	byPK := make(map[string]*BoardInstanceStats)
+	for i, board := range stats.Boards {
+		if i > 0 && stats.Boards[i-1].PubKey >= board.PubKey {
+			t.Errorf("boards are not ordered by public key: %v", stats.Boards)
+		}
+		byPK[board.PubKey] = board
+	}
+
+	cases := []struct {
+		name    string
+		pk      cipher.PubKey
+		ready   bool
+		updated bool // Whether views changed since loaded.
+	}{
+		{"updated", apk, true, true},
+		{"ready", bpk, true, false},
+		{"pending", pending, false, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			board, ok := byPK[c.pk.Hex()]
+			if !ok {
+				t.Fatalf("board '%s' is not in stats", c.pk.Hex())
+			}
+			if board.IsReady != c.ready || board.IsMaster != c.ready || board.IsReceived != c.ready {
+				t.Errorf("got ready %v, master %v and received %v, expected %v",
+					board.IsReady, board.IsMaster, board.IsReceived, c.ready)
+			}
+			if board.LoadedAt.Before(start) {
+				t.Errorf("got loaded at %v, expected after %v", board.LoadedAt, start)
+			}
+			if c.updated && board.UpdatedAt.Before(board.LoadedAt) {
+				t.Errorf("got updated at %v, expected after loaded at %v", board.UpdatedAt, board.LoadedAt)
+			}
+			if !c.ready && !board.UpdatedAt.IsZero() {
+				t.Errorf("got updated at %v, expected zero", board.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestCompiler_IsSubscribed(t *testing.T) {
+	comp, instances, quit := initFeedCompiler(t, "a")
+	defer quit()
+
+	var (
+		apk         = obtainBoardPubKey(t, instances[0])
+		feedOnly, _ = cipher.GenerateKeyPair()
+		instOnly, _ = cipher.GenerateKeyPair()
+	)
+	if e := comp.node.AddFeed(feedOnly); e != nil {
+		t.Fatal("failed to add feed:", e)
+	}
+	comp.boards[instOnly] = new(BoardInstance).Init(nil, instOnly)
+
+	cases := []struct {
+		name     string
+		pk       cipher.PubKey
+		expected bool
+	}{
+		{"subscribed", apk, true},
+		{"feed_only", feedOnly, true},
+		{"instance_only", instOnly, false},
+		{"unknown", cipher.PubKey{}, false},
+	}
+	for _, c := range cases {
+		if got := comp.IsSubscribed(c.pk); got != c.expected {
+			t.Errorf("%s: got subscribed %v, expected %v", c.name, got, c.expected)
+		}
+	}
+}
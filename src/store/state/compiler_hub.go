@@ -0,0 +1,136 @@
+package state
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+	"sync"
+)
+
+// SubscriberBuffer is the number of board events a subscriber can fall behind by
+// before it is dropped.
+const SubscriberBuffer = 16
+
+// BoardEvent represents the changes of a board's views, pushed to subscribers.
+type BoardEvent struct {
+	Board   string     `json:"board"`
+	Version uint64     `json:"version"`
+	Full    bool       `json:"full"`           // Whether changes are not retained, and the board page should be obtained anew.
+	Diff    *BoardDiff `json:"diff,omitempty"` // Only set when not full.
+}
+
+// hub pushes board events to subscribers.
+type hub struct {
+	mux      sync.Mutex
+	subs     map[cipher.PubKey]map[chan *BoardEvent]struct{}
+	versions map[cipher.PubKey]uint64 // key (board), value (version of last pushed event)
+}
+
+func newHub() *hub {
+	return &hub{
+		subs:     make(map[cipher.PubKey]map[chan *BoardEvent]struct{}),
+		versions: make(map[cipher.PubKey]uint64),
+	}
+}
+
+// subscribe obtains a channel of events of the board, and a function to unsubscribe.
+// The first event is full, and states the version later events follow on from.
+// The channel is closed on unsubscribe, or if the subscriber falls behind.
+func (h *hub) subscribe(pk cipher.PubKey, version uint64) (<-chan *BoardEvent, func()) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	ch := make(chan *BoardEvent, SubscriberBuffer)
+	subs, ok := h.subs[pk]
+	if !ok {
+		subs = make(map[chan *BoardEvent]struct{})
+		h.subs[pk] = subs
+		h.versions[pk] = version
+	}
+	subs[ch] = struct{}{}
+	ch <- &BoardEvent{Board: pk.Hex(), Version: h.versions[pk], Full: true}
+
+	return ch, func() {
+		h.mux.Lock()
+		defer h.mux.Unlock()
+		h.remove(pk, ch)
+	}
+}
+
+// remove removes and closes the subscriber channel, if not already removed.
+// Should be called with 'mux' locked.
+func (h *hub) remove(pk cipher.PubKey, ch chan *BoardEvent) {
+	subs, ok := h.subs[pk]
+	if !ok {
+		return
+	}
+	if _, ok := subs[ch]; !ok {
+		return
+	}
+	delete(subs, ch)
+	close(ch)
+	if len(subs) == 0 {
+		delete(h.subs, pk)
+		delete(h.versions, pk)
+	}
+}
+
+// push sends the changes of the board since the last pushed event to it's subscribers.
+func (h *hub) push(pk cipher.PubKey, bi *BoardInstance) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	subs, ok := h.subs[pk]
+	if !ok {
+		return
+	}
+	since := h.versions[pk]
+	diff, version, ok := bi.diffSince(since)
+	if version == since {
+		return
+	}
+	h.versions[pk] = version
+
+	event := &BoardEvent{Board: pk.Hex(), Version: version, Full: !ok}
+	if ok {
+		event.Diff = diff
+	}
+	for ch := range subs {
+		select {
+		case ch <- event:
+		default:
+			h.remove(pk, ch)
+		}
+	}
+}
+
+// removeBoard closes all subscriber channels of the board.
+func (h *hub) removeBoard(pk cipher.PubKey) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	for ch := range h.subs[pk] {
+		h.remove(pk, ch)
+	}
+}
+
+// close closes all subscriber channels.
+func (h *hub) close() {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	for pk, subs := range h.subs {
+		for ch := range subs {
+			h.remove(pk, ch)
+		}
+	}
+}
+
+// SubscribeBoard obtains a channel of change events of the board's views, pushed
+// after each update. The first event is full, and holds the version to follow on from. Call the returned function to unsubscribe. The channel is
+// closed on unsubscribe, when the compiler closes, or if the subscriber falls
+// more than SubscriberBuffer events behind.
+func (c *Compiler) SubscribeBoard(pk cipher.PubKey) (<-chan *BoardEvent, func(), error) {
+	bi, e := c.GetBoard(pk)
+	if e != nil {
+		return nil, nil, e
+	}
+	ch, unsubscribe := c.hub.subscribe(pk, bi.Version())
+	return ch, unsubscribe, nil
+}
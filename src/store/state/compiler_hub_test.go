@@ -0,0 +1,36 @@
+package state
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+	"testing"
+)
+
+func TestHub_Subscribe(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	h := newHub()
+
+	ch, unsubscribe := h.subscribe(pk, 3)
+	event := <-ch
+	if !event.Full || event.Version != 3 {
+		t.Fatalf("unexpected initial event: %+v", event)
+	}
+
+	ch2, _ := h.subscribe(pk, 5)
+	if event := <-ch2; event.Version != 3 {
+		t.Errorf("initial event should follow on from pushed version 3, got %d", event.Version)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-ch; ok {
+		t.Error("channel should be closed on unsubscribe")
+	}
+
+	h.close()
+	if _, ok := <-ch2; ok {
+		t.Error("channel should be closed on hub close")
+	}
+	if len(h.subs) != 0 || len(h.versions) != 0 {
+		t.Error("hub should hold no subscribers")
+	}
+}
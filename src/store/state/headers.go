@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"github.com/skycoin/bbs/src/misc/boo"
 	"github.com/skycoin/bbs/src/store/object"
 	"github.com/skycoin/cxo/skyobject"
@@ -23,7 +24,9 @@ type Headers struct {
 	users map[string]cipher.SHA256 // key(user's public key), value(user profile hash)
 }
 
-func NewHeaders(oldHeaders *Headers, p *skyobject.Pack) (*Headers, error) {
+// NewHeaders generates headers of the given pack.
+// Generation stops early if the context is cancelled.
+func NewHeaders(ctx context.Context, oldHeaders *Headers, p *skyobject.Pack) (*Headers, error) {
 	if len(p.Root().Refs) != object.RootChildrenCount {
 		return nil, boo.New(boo.InvalidRead,
 			"invalid root")
@@ -46,6 +49,9 @@ func NewHeaders(oldHeaders *Headers, p *skyobject.Pack) (*Headers, error) {
 
 	// Fill threads header data.
 	e = pages.BoardPage.Threads.Ascend(func(i int, tpElem *skyobject.RefsElem) error {
+		if e := ctx.Err(); e != nil {
+			return e
+		}
 		tp, e := object.GetThreadPage(tpElem)
 		if e != nil {
 			return e
@@ -63,6 +69,9 @@ func NewHeaders(oldHeaders *Headers, p *skyobject.Pack) (*Headers, error) {
 
 	// Fill users header data.
 	e = pages.UsersPage.Users.Ascend(func(i int, uapElem *skyobject.RefsElem) error {
+		if e := ctx.Err(); e != nil {
+			return e
+		}
 		uap, e := object.GetUserProfile(uapElem)
 		if e != nil {
 			return e
@@ -122,6 +131,13 @@ func (h *Headers) SetThread(threadHash string, tpRef cipher.SHA256) {
 	h.threads[threadHash] = tpRef
 }
 
+func (h *Headers) DelThread(threadHash string) {
+	h.tMux.Lock()
+	defer h.tMux.Unlock()
+
+	delete(h.threads, threadHash)
+}
+
 // RangeThreadFunc is the function used to range the threads.
 // Quits range on error.
 type RangeThreadFunc func(threadHash string, tpHash cipher.SHA256) error
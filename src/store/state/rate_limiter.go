@@ -0,0 +1,144 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiterPruneSize is the number of tracked users above which users with
+// full allowances are forgotten.
+const rateLimiterPruneSize = 1024
+
+// RateLimiter limits the rate of submissions per user public key.
+// Each user has an allowance of 'burst' submissions, which is replenished at
+// 'perMinute' submissions per minute. A nil RateLimiter does not limit.
+type RateLimiter struct {
+	mux       sync.Mutex
+	perMinute int
+	burst     int
+	users     map[string]*allowance
+	now       func() time.Time
+}
+
+type allowance struct {
+	tokens float64
+	at     time.Time
+}
+
+// NewRateLimiter creates a rate limiter of 'perMinute' submissions per user per
+// minute, with an allowance of 'burst' submissions at once. Burst is at least 1.
+// Returns nil (no limit) if 'perMinute' is not positive.
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		perMinute: perMinute,
+		burst:     burst,
+		users:     make(map[string]*allowance),
+		now:       time.Now,
+	}
+}
+
+// RateLimitStatus represents the submission allowance of a user.
+type RateLimitStatus struct {
+	UserPubKey string `json:"user_public_key"`
+	Enabled    bool   `json:"enabled"`     // Whether submissions are rate limited.
+	PerMinute  int    `json:"per_minute"`  // Submissions replenished per minute.
+	Burst      int    `json:"burst"`       // Maximum submissions at once.
+	Remaining  int    `json:"remaining"`   // Submissions allowed now.
+	RetryAfter int64  `json:"retry_after"` // Seconds until the next submission is allowed, 0 if allowed now.
+}
+
+// Take uses one submission of the user's allowance.
+// Returns an error of type boo.TooManyRequests if the allowance is used up.
+func (rl *RateLimiter) Take(upk string) error {
+	if rl == nil {
+		return nil
+	}
+	rl.mux.Lock()
+	defer rl.mux.Unlock()
+
+	now := rl.now()
+	a := rl.allowance(upk, now)
+	if a.tokens < 1 {
+		return boo.Newf(boo.TooManyRequests,
+			"user '%s' exceeds %d submissions per minute, retry after %d seconds",
+			upk, rl.perMinute, rl.retryAfter(a))
+	}
+	a.tokens--
+	rl.users[upk] = a
+	if len(rl.users) > rateLimiterPruneSize {
+		rl.prune(now)
+	}
+	return nil
+}
+
+// Refund returns a submission taken with Take to the user's allowance, for
+// submissions that end up rejected.
+func (rl *RateLimiter) Refund(upk string) {
+	if rl == nil {
+		return
+	}
+	rl.mux.Lock()
+	defer rl.mux.Unlock()
+
+	a := rl.allowance(upk, rl.now())
+	a.tokens = math.Min(float64(rl.burst), a.tokens+1)
+	rl.users[upk] = a
+}
+
+// Status obtains the submission allowance of the user.
+func (rl *RateLimiter) Status(upk string) *RateLimitStatus {
+	out := &RateLimitStatus{UserPubKey: upk}
+	if rl == nil {
+		return out
+	}
+	rl.mux.Lock()
+	defer rl.mux.Unlock()
+
+	a := rl.allowance(upk, rl.now())
+	out.Enabled = true
+	out.PerMinute = rl.perMinute
+	out.Burst = rl.burst
+	out.Remaining = int(a.tokens)
+	out.RetryAfter = rl.retryAfter(a)
+	return out
+}
+
+// allowance obtains the user's allowance, replenished up to 'now'.
+func (rl *RateLimiter) allowance(upk string, now time.Time) *allowance {
+	a, ok := rl.users[upk]
+	if !ok {
+		return &allowance{tokens: float64(rl.burst), at: now}
+	}
+	if elapsed := now.Sub(a.at); elapsed > 0 {
+		a.tokens = math.Min(float64(rl.burst),
+			a.tokens+elapsed.Minutes()*float64(rl.perMinute))
+		a.at = now
+	}
+	return a
+}
+
+// retryAfter obtains the whole seconds until the allowance has a submission.
+func (rl *RateLimiter) retryAfter(a *allowance) int64 {
+	if a.tokens >= 1 {
+		return 0
+	}
+	wait := (1 - a.tokens) / float64(rl.perMinute) * 60
+	return int64(math.Ceil(wait))
+}
+
+// prune forgets users whose allowance is full.
+func (rl *RateLimiter) prune(now time.Time) {
+	for upk := range rl.users {
+		if rl.allowance(upk, now).tokens >= float64(rl.burst) {
+			delete(rl.users, upk)
+		}
+	}
+}
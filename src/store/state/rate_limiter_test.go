@@ -0,0 +1,80 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Take(t *testing.T) {
+	const (
+		perMinute = 6 // One submission every 10 seconds.
+		burst     = 2
+		user      = "user"
+	)
+	now := time.Unix(0, 0)
+	rl := NewRateLimiter(perMinute, burst)
+	rl.now = func() time.Time { return now }
+
+	for i := 0; i < burst; i++ {
+		if e := rl.Take(user); e != nil {
+			t.Fatalf("submission %d within burst: %v", i, e)
+		}
+	}
+	if e := rl.Take(user); boo.Type(e) != boo.TooManyRequests {
+		t.Fatalf("submission above burst: got %v, expected type %d", e, boo.TooManyRequests)
+	}
+	if status := rl.Status(user); status.Remaining != 0 || status.RetryAfter != 10 {
+		t.Errorf("after burst: got remaining %d, retry after %d, expected %d and %d",
+			status.Remaining, status.RetryAfter, 0, 10)
+	}
+	if e := rl.Take("other"); e != nil {
+		t.Errorf("submission of other user: %v", e)
+	}
+
+	now = now.Add(time.Second * 10)
+	if e := rl.Take(user); e != nil {
+		t.Errorf("submission after replenish: %v", e)
+	}
+
+	now = now.Add(time.Hour)
+	if status := rl.Status(user); status.Remaining != burst || status.RetryAfter != 0 {
+		t.Errorf("after an hour: got remaining %d, retry after %d, expected %d and %d",
+			status.Remaining, status.RetryAfter, burst, 0)
+	}
+}
+
+func TestRateLimiter_Refund(t *testing.T) {
+	const user = "user"
+	now := time.Unix(0, 0)
+	rl := NewRateLimiter(1, 2)
+	rl.now = func() time.Time { return now }
+
+	if e := rl.Take(user); e != nil {
+		t.Fatal("failed to take:", e)
+	}
+	rl.Refund(user)
+	if status := rl.Status(user); status.Remaining != 2 {
+		t.Errorf("after refund: got remaining %d, expected %d", status.Remaining, 2)
+	}
+	rl.Refund(user)
+	if status := rl.Status(user); status.Remaining != 2 {
+		t.Errorf("refund of full allowance: got remaining %d, expected %d", status.Remaining, 2)
+	}
+
+	var nilLimiter *RateLimiter
+	nilLimiter.Refund(user) // Should do nothing.
+}
+
+func TestRateLimiter_nil(t *testing.T) {
+	rl := NewRateLimiter(0, 10)
+	if rl != nil {
+		t.Fatal("expected no rate limiter without a rate")
+	}
+	if e := rl.Take("user"); e != nil {
+		t.Errorf("nil rate limiter should not limit: %v", e)
+	}
+	if status := rl.Status("user"); status.Enabled {
+		t.Error("nil rate limiter should not be enabled")
+	}
+}
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"github.com/skycoin/bbs/src/misc/boo"
 	"github.com/skycoin/bbs/src/misc/inform"
 	"github.com/skycoin/bbs/src/misc/typ"
@@ -8,14 +9,28 @@ import (
 	"github.com/skycoin/bbs/src/store/object"
 	"github.com/skycoin/cxo/skyobject"
 	"github.com/skycoin/skycoin/src/cipher"
-	"log"
+	"io"
 	"math"
 	"os"
+	"reflect"
+	"sort"
 	"sync"
+	"time"
 )
 
-// ErrViewerNotInitialized occurs when the Viewer is not initiated.
-var ErrViewerNotInitialized = boo.New(boo.NotFound, "viewer is not initialized")
+// LastPostPreviewLength is the maximum number of characters of the body of
+// the last post preview attached to threads of a board page.
+const LastPostPreviewLength = 140
+
+var (
+	// ErrViewerNotInitialized occurs when the Viewer is not initiated.
+	ErrViewerNotInitialized = boo.New(boo.NotFound, "viewer is not initialized")
+
+	// ErrUnknownPerspective occurs when strict perspective validation is requested
+	// and the perspective user has no submissions in the board.
+	// Without strict validation, unknown perspectives are treated as anonymous.
+	ErrUnknownPerspective = boo.New(boo.NotFound, "perspective user is unknown to board")
+)
 
 /*
 	<<< INDEXER >>>
@@ -23,21 +38,83 @@ var ErrViewerNotInitialized = boo.New(boo.NotFound, "viewer is not initialized")
 
 // Indexer is responsible for indexing and holding hashes for content.
 type Indexer struct {
-	Board         string
-	Threads       typ.Paginated
-	PostsOfThread map[string]typ.Paginated // key (hash of thread or post), value (list of posts)
-	Users         typ.Paginated
+	Board             string
+	Threads           typ.Paginated
+	PostsOfThread     map[string]typ.Paginated  // key (hash of thread or post), value (list of posts)
+	ContentOfUser     map[string]typ.Paginated  // key (creator's public key), value (list of threads and posts)
+	LastPost          map[string]string         // key (hash of thread), value (hash of latest post)
+	Participants      map[string]map[string]int // key (hash of thread), value (number of thread and posts per creator)
+	VotesOfUser       map[string]map[string]int // key (voter's public key), value (vote value of voted content hash or user)
+	VoteOfHash        map[string]*object.Body   // key (hash of vote), value (body of vote)
+	VotesByUser       map[string]typ.Paginated  // key (voter's public key), value (list of hashes of votes cast)
+	Mentions          map[string]typ.Paginated  // key (mentioned public key or lower-cased alias), value (list of post hashes)
+	AttachmentsOfPost map[string]typ.Paginated  // key (hash of post), value (list of attachment hashes)
+	RotatedFrom       map[string]string         // key (public key rotated to), value (public key rotated from)
+	RotatedTo         map[string]string         // key (public key rotated from), value (public key rotated to)
+	Pins              []string                  // Ordered hashes of pinned threads.
+	Retention         int                       // Days after which threads are archived, 0 to never archive.
+	Policy            *object.SubmissionPolicy  // Restrictions on who may submit threads and posts, nil for none.
+	Archived          bool                      // Whether the board is archived, and so does not accept submissions.
+	Registry          bool                      // Whether the board is a registry, and so accepts board announcements.
+	Users             typ.Paginated
+	UserRefs          map[string]int // key (user's public key), value (number of threads, posts and votes of or for user)
+
+	unreferenced map[string]struct{} // Users whose references dropped to zero since the last compaction.
 }
 
 // NewIndexer creates a new Indexer.
 func NewIndexer() *Indexer {
 	return &Indexer{
-		Threads:       paginatedtypes.NewSimple(),
-		PostsOfThread: make(map[string]typ.Paginated),
-		Users:         paginatedtypes.NewMapped(),
+		Threads:           paginatedtypes.NewSimple(),
+		PostsOfThread:     make(map[string]typ.Paginated),
+		ContentOfUser:     make(map[string]typ.Paginated),
+		LastPost:          make(map[string]string),
+		Participants:      make(map[string]map[string]int),
+		VotesOfUser:       make(map[string]map[string]int),
+		VoteOfHash:        make(map[string]*object.Body),
+		VotesByUser:       make(map[string]typ.Paginated),
+		Mentions:          make(map[string]typ.Paginated),
+		AttachmentsOfPost: make(map[string]typ.Paginated),
+		RotatedFrom:       make(map[string]string),
+		RotatedTo:         make(map[string]string),
+		Users:             paginatedtypes.NewMapped(),
+		UserRefs:          make(map[string]int),
+		unreferenced:      make(map[string]struct{}),
+	}
+}
+
+// RefUser records a thread, post or vote of or for the user.
+func (i *Indexer) RefUser(upk string) {
+	i.Users.Append(upk)
+	i.UserRefs[upk]++
+	delete(i.unreferenced, upk)
+}
+
+// UnrefUser removes a reference recorded with RefUser.
+// Users left unreferenced are removed on the next compaction.
+func (i *Indexer) UnrefUser(upk string) {
+	if i.UserRefs[upk]--; i.UserRefs[upk] <= 0 {
+		delete(i.UserRefs, upk)
+		i.unreferenced[upk] = struct{}{}
 	}
 }
 
+// CompactUsers removes users that became unreferenced since the last compaction
+// from the Users index, unless 'keep' is true for them.
+// Returns the public keys of the removed users.
+func (i *Indexer) CompactUsers(keep func(upk string) bool) []string {
+	var removed []string
+	for upk := range i.unreferenced {
+		if _, ok := i.UserRefs[upk]; ok || keep(upk) {
+			continue
+		}
+		i.Users.Delete(upk)
+		removed = append(removed, upk)
+	}
+	i.unreferenced = make(map[string]struct{})
+	return removed
+}
+
 // EnsureUsersOfUserVoteBody ensures that user participants of a given vote body,
 // has associated user profile indexes saved in the Indexer.
 func (i *Indexer) EnsureUsersOfUserVoteBody(body *object.Body) {
@@ -45,24 +122,128 @@ func (i *Indexer) EnsureUsersOfUserVoteBody(body *object.Body) {
 	i.Users.Append(body.OfUser)
 }
 
+// SetVoteOfUser records the current vote value of a voter for a content hash or user.
+func (i *Indexer) SetVoteOfUser(voter, of string, value int) {
+	votes, ok := i.VotesOfUser[voter]
+	if !ok {
+		votes = make(map[string]int)
+		i.VotesOfUser[voter] = votes
+	}
+	if _, ok := votes[of]; !ok {
+		i.RefUser(voter)
+	}
+	votes[of] = value
+}
+
+// SetVoteOfHash records the body of a vote, and indexes the vote under it's voter.
+// Each recorded vote references it's voter, until removed with DeleteVoteOfHash.
+func (i *Indexer) SetVoteOfHash(hash string, body *object.Body) {
+	if _, ok := i.VoteOfHash[hash]; !ok {
+		i.RefUser(body.Creator)
+	}
+	i.VoteOfHash[hash] = body
+	list, ok := i.VotesByUser[body.Creator]
+	if !ok {
+		list = paginatedtypes.NewMapped()
+		i.VotesByUser[body.Creator] = list
+	}
+	list.Append(hash)
+}
+
+// DeleteVoteOfHash removes a vote from the vote indexes, and releases it's reference
+// of the voter.
+func (i *Indexer) DeleteVoteOfHash(hash string) {
+	body, ok := i.VoteOfHash[hash]
+	if !ok {
+		return
+	}
+	delete(i.VoteOfHash, hash)
+	i.UnrefUser(body.Creator)
+	if list, ok := i.VotesByUser[body.Creator]; ok {
+		if list.Delete(hash); list.Len() == 0 {
+			delete(i.VotesByUser, body.Creator)
+		}
+	}
+}
+
+// AppendContentOfUser adds a thread or post hash to the reverse author index.
+func (i *Indexer) AppendContentOfUser(upk, hash string) {
+	list, ok := i.ContentOfUser[upk]
+	if !ok {
+		list = paginatedtypes.NewMapped()
+		i.ContentOfUser[upk] = list
+	}
+	if !list.Has(hash) {
+		list.Append(hash)
+		i.RefUser(upk)
+	}
+}
+
 /*
 	<<< CONTAINER >>>
 */
 
 // Container contains the objects the the Indexer indexes.
 type Container struct {
-	content  map[string]*object.ContentRep
-	votes    map[string]*VotesRep
-	profiles map[string]*Profile
+	content      map[string]*object.ContentRep
+	votes        map[string]*VotesRep
+	scores       map[string]*object.ContentScore // key (hash of thread or post), value (tally of votes)
+	profiles     map[string]*Profile
+	attachments  map[string]*object.ContentRep            // key (hash of attachment), value (attachment)
+	selfProfiles map[string]*object.ContentRep            // key (user's public key), value (latest self-profile submission)
+	userVotes    map[string]map[string]*object.ContentRep // key (voter's public key), value (latest vote per voted user)
+	rotations    map[string]*object.ContentRep            // key (public key rotated to), value (key rotation)
+	listings     map[string]*object.ContentRep            // key (announced board's public key), value (latest announcement)
+	voteViews    *voteViewCache                           // Views of votes, per perspective.
 }
 
 // NewContainer creates a new Container.
 func NewContainer() *Container {
 	return &Container{
-		content:  make(map[string]*object.ContentRep),
-		votes:    make(map[string]*VotesRep),
-		profiles: make(map[string]*Profile),
+		content:      make(map[string]*object.ContentRep),
+		votes:        make(map[string]*VotesRep),
+		scores:       make(map[string]*object.ContentScore),
+		profiles:     make(map[string]*Profile),
+		attachments:  make(map[string]*object.ContentRep),
+		selfProfiles: make(map[string]*object.ContentRep),
+		userVotes:    make(map[string]map[string]*object.ContentRep),
+		rotations:    make(map[string]*object.ContentRep),
+		listings:     make(map[string]*object.ContentRep),
+		voteViews:    newVoteViewCache(MaxCachedPerspectives),
+	}
+}
+
+// SetScore caches the tally of the votes of a thread or post.
+// Cached views of the votes are invalidated.
+func (c *Container) SetScore(hash string, votes *VotesRep) {
+	c.voteViews.invalidate(hash)
+	c.scores[hash] = &object.ContentScore{
+		Up:   votes.UpCount,
+		Down: votes.DownCount,
+		Net:  votes.UpCount - votes.DownCount,
+	}
+}
+
+// GetScore obtains a copy of the cached tally of the votes of a thread or post.
+// Content without votes has a zero score.
+func (c *Container) GetScore(hash string) *object.ContentScore {
+	if score, ok := c.scores[hash]; ok {
+		out := *score
+		return &out
+	}
+	return new(object.ContentScore)
+}
+
+// GetVoteView obtains a copy of the view of votes from perspective, caching it until
+// the votes change.
+func (c *Container) GetVoteView(hash, perspective string, votes *VotesRep) *VoteRepView {
+	view, ok := c.voteViews.get(perspective, hash)
+	if !ok {
+		view = votes.View(perspective)
+		c.voteViews.set(perspective, hash, view)
 	}
+	out := *view
+	return &out
 }
 
 // GetProfile obtains a profile object from the container.
@@ -83,20 +264,54 @@ func (c *Container) GetProfile(upk string) *Profile {
 
 // Viewer generates and compiles views for the board.
 type Viewer struct {
-	mux sync.Mutex
-	l   *log.Logger
-	pk  cipher.PubKey
-	i   *Indexer
-	c   *Container
+	mux    sync.Mutex
+	l      *inform.Logger
+	pk     cipher.PubKey
+	i      *Indexer
+	c      *Container
+	sortBy string              // Name of default sorter.
+	sorted map[string][]string // key (sorter name), value (all thread hashes in order). Reset on change.
+
+	debug bool                // Whether to record diagnostics on update.
+	diags []*UpdateDiagnostic // Diagnostics of last update (only if debug).
+
+	transform RepTransform // Optional, applied to reps of page outputs.
+
+	spamScorer    SpamScorer         // Scorer of threads and posts, nil for DefaultSpamScorer.
+	spamThreshold float64            // Spam score at or above which content is hidden, <= 0 to never hide.
+	spam          map[string]float64 // key (thread or post hash), value (spam score). Reset on change.
+	spamCount     int                // Number of threads and posts hidden as spam.
+}
+
+// RepTransform enriches or redacts a content rep for the given perspective before
+// it is returned in a page output. It is given a copy of the rep, which it may
+// modify and return. Returning nil keeps the rep unchanged.
+type RepTransform func(rep *object.ContentRep, perspective string) *object.ContentRep
+
+// UpdateDiagnostic describes a problem with a piece of content,
+// encountered while updating the viewer.
+type UpdateDiagnostic struct {
+	ContentHash string             `json:"content_hash"`
+	ContentType object.ContentType `json:"content_type"`
+	Creator     string             `json:"creator"`
+	Fatal       bool               `json:"fatal"` // Whether the update fails because of this.
+	Error       string             `json:"error"`
 }
 
 // NewViewer creates a new viewer with a given pack.
-func NewViewer(pack *skyobject.Pack) (*Viewer, error) {
+// 'sortBy' is the name of the default sorter for pages, empty for chronological.
+func NewViewer(pack *skyobject.Pack, sortBy string) (*Viewer, error) {
+	if _, e := GetSorter(sortBy); e != nil {
+		return nil, e
+	}
 	v := &Viewer{
-		l:  inform.NewLogger(true, os.Stdout, "STATE_VIEWER"),
-		pk: pack.Root().Pub,
-		i:  NewIndexer(),
-		c:  NewContainer(),
+		l:      inform.New(true, os.Stdout, "STATE_VIEWER"),
+		pk:     pack.Root().Pub,
+		i:      NewIndexer(),
+		c:      NewContainer(),
+		sortBy: sortBy,
+
+		spamThreshold: DefaultSpamThreshold,
 	}
 
 	pages, e := object.GetPages(pack, &object.GetPagesIn{
@@ -141,25 +356,74 @@ func NewViewer(pack *skyobject.Pack) (*Viewer, error) {
 		return uap.RangeSubmissions(func(i int, c *object.Content) error {
 			vBody, vHeader := c.GetBody(), c.GetHeader()
 			v.ensureUser(vBody.Creator)
-			if e := v.processVote(c, vBody, vHeader); e != nil {
-				return e
+
+			switch vBody.Type {
+			case object.V5UserProfileType:
+				v.setSelfProfile(c, vBody, vHeader)
+				return nil
+			case object.V5AttachmentType:
+				// Attachments of missing posts are ignored.
+				v.addAttachment(c, vBody, vHeader)
+				return nil
+			case object.V5KeyRotationType:
+				// Conflicting key rotations are ignored.
+				v.addKeyRotation(c, vBody, vHeader)
+				return nil
+			case object.V5BoardAnnouncementType:
+				// Outdated announcements are ignored.
+				v.addAnnouncement(c, vBody, vHeader)
+				return nil
 			}
+
+			// Votes of missing content are ignored.
+			v.processVote(c, vBody, vHeader)
 			return nil
 		})
 	})
 	if e != nil {
 		return nil, e
 	}
+	v.compactUsers()
 
 	return v, nil
 }
 
+// UpdateResult describes what changed in the viewer during an update.
+type UpdateResult struct {
+	BoardChanged bool // Whether the board content itself changed.
+	Threads      int  // Number of threads added.
+	Posts        int  // Number of posts added.
+	Votes        int  // Number of votes processed.
+	Profiles     int  // Number of self-profile submissions processed.
+	Attachments  int  // Number of attachments added.
+	Rotations    int  // Number of key rotations added.
+	Listings     int  // Number of board announcements added.
+	Deleted      int  // Number of threads removed as they are no longer in the board.
+	Compacted    int  // Number of users removed as they no longer have threads, posts or votes.
+	Evicted      int  // Number of posts removed as they were garbage collected from the board.
+	Diff         BoardDiff
+}
+
+// BoardDiff represents the threads of a board that changed over updates.
+type BoardDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"` // Threads with new posts or vote changes.
+}
+
+// Changed determines whether the update changed anything.
+func (r *UpdateResult) Changed() bool {
+	return r.BoardChanged || r.Threads > 0 || r.Posts > 0 || r.Votes > 0 || r.Profiles > 0 || r.Attachments > 0 || r.Rotations > 0 || r.Listings > 0 || r.Deleted > 0 || r.Evicted > 0
+}
+
 // Update updates the viewer with new pack and headers.
-func (v *Viewer) Update(pack *skyobject.Pack, headers *Headers) error {
+// Returns early with an error if the context is done.
+func (v *Viewer) Update(ctx context.Context, pack *skyobject.Pack, headers *Headers) (*UpdateResult, error) {
 	if v == nil {
-		return ErrViewerNotInitialized
+		return nil, ErrViewerNotInitialized
 	}
 	defer v.lock()()
+	v.resetSorted()
 
 	pages, e := object.GetPages(pack, &object.GetPagesIn{
 		RootPage:  false,
@@ -168,16 +432,23 @@ func (v *Viewer) Update(pack *skyobject.Pack, headers *Headers) error {
 		UsersPage: false,
 	})
 	if e != nil {
-		return e
+		return nil, e
 	}
 
 	board, e := pages.BoardPage.GetBoard()
 	if e != nil {
-		return e
+		return nil, e
+	}
+	result := &UpdateResult{
+		BoardChanged: v.setBoard(board),
 	}
-	v.setBoard(board)
+	v.diags = nil
 
+	var fatal error
 	for _, content := range headers.GetChanges().New {
+		if e := ctx.Err(); e != nil {
+			return result, boo.WrapType(e, boo.Internal, "viewer update cancelled")
+		}
 		var (
 			header = content.GetHeader()
 			body   = content.GetBody()
@@ -187,20 +458,187 @@ func (v *Viewer) Update(pack *skyobject.Pack, headers *Headers) error {
 
 		switch body.Type {
 		case object.V5ThreadType:
-			if _, e := v.addThread(content, body, header); e != nil {
-				return e
+			if tHash, e := v.addThread(content, body, header); e != nil {
+				if !v.debug {
+					return result, e
+				}
+				fatal = v.diagnose(header, body, true, e)
+			} else {
+				result.Threads++
+				result.Diff.Added = append(result.Diff.Added, tHash.Hex())
 			}
 		case object.V5PostType:
-			tHash, _ := body.GetOfThread()
-			if e := v.addPost(tHash, content, body, header); e != nil {
-				return e
+			tHash, e := body.GetOfThread()
+			if e == nil {
+				e = v.addPost(tHash, content, body, header)
+			}
+			if e != nil {
+				if !v.debug {
+					return result, e
+				}
+				fatal = v.diagnose(header, body, true, e)
+			} else {
+				result.Posts++
+				result.Diff.Changed = append(result.Diff.Changed, tHash.Hex())
+			}
+		case object.V5UserProfileType:
+			v.setSelfProfile(content, body, header)
+			result.Profiles++
+		case object.V5AttachmentType:
+			if e := v.addAttachment(content, body, header); e != nil {
+				if v.debug {
+					v.diagnose(header, body, false, e)
+				}
+			} else {
+				result.Attachments++
+				if rep, ok := v.c.content[body.OfPost]; ok {
+					result.Diff.Changed = append(result.Diff.Changed, rep.Body.(*object.Body).OfThread)
+				}
+			}
+		case object.V5KeyRotationType:
+			if e := v.addKeyRotation(content, body, header); e != nil {
+				if v.debug {
+					v.diagnose(header, body, false, e)
+				}
+			} else {
+				result.Rotations++
+			}
+		case object.V5BoardAnnouncementType:
+			if e := v.addAnnouncement(content, body, header); e != nil {
+				if v.debug {
+					v.diagnose(header, body, false, e)
+				}
+			} else {
+				result.Listings++
 			}
 		case object.V5ThreadVoteType, object.V5PostVoteType, object.V5UserVoteType:
-			v.processVote(content, body, header)
+			if e := v.processVote(content, body, header); e != nil {
+				if v.debug {
+					v.diagnose(header, body, false, e)
+				}
+			} else {
+				result.Votes++
+				if body.Type == object.V5ThreadVoteType {
+					result.Diff.Changed = append(result.Diff.Changed, body.OfThread)
+				}
+			}
 		}
 	}
 
-	return nil
+	// Remove threads that are no longer in the board.
+	tHashes, e := v.i.Threads.Get(&typ.PaginatedInput{PageSize: math.MaxUint64})
+	if e != nil {
+		return result, e
+	}
+	for _, tHash := range tHashes.Data {
+		if _, ok := headers.GetThreadPageHash(tHash); !ok {
+			v.deleteThread(tHash)
+			result.Deleted++
+			result.Diff.Removed = append(result.Diff.Removed, tHash)
+		}
+	}
+
+	// Evict posts that were garbage collected, as the diff page shrinks when they are.
+	if headers.GetChanges().NeedReset {
+		referenced, e := referencedContent(pages.BoardPage)
+		if e != nil {
+			return result, e
+		}
+		for _, tHash := range v.evictPosts(referenced) {
+			result.Evicted++
+			result.Diff.Changed = append(result.Diff.Changed, tHash)
+		}
+	}
+	result.Compacted = v.compactUsers()
+
+	return result, fatal
+}
+
+// SetDebug sets whether the viewer records diagnostics of malformed content
+// on update. When set, update continues past content errors.
+func (v *Viewer) SetDebug(debug bool) {
+	if v == nil {
+		return
+	}
+	defer v.lock()()
+	v.debug = debug
+}
+
+// SetRepTransform sets the transform applied to reps of page outputs.
+// Nil removes the transform.
+func (v *Viewer) SetRepTransform(transform RepTransform) {
+	if v == nil {
+		return
+	}
+	defer v.lock()()
+	v.transform = transform
+}
+
+// transformRep sets the identity of the creator of a copy of the rep, if they rotated
+// keys, and applies the rep transform, if any, to a copy of the rep.
+// The copy given to the transform has it's own header and body, so that
+// the transform may modify them without affecting the views.
+func (v *Viewer) transformRep(rep *object.ContentRep, perspective string) *object.ContentRep {
+	if rep == nil {
+		return rep
+	}
+	if identity := v.identityOfRep(rep); identity != "" {
+		cp := *rep
+		cp.Identity = identity
+		rep = &cp
+	}
+	if v.transform == nil {
+		return rep
+	}
+	cp := *rep
+	if rep.Header != nil {
+		header := *rep.Header
+		cp.Header = &header
+	}
+	if body, ok := rep.Body.(*object.Body); ok {
+		cp.Body = body.Copy()
+	}
+	if out := v.transform(&cp, perspective); out != nil {
+		return out
+	}
+	return rep
+}
+
+// transformReps applies the rep transform, if any, to each rep in place.
+func (v *Viewer) transformReps(reps []*object.ContentRep, perspective string) {
+	for i, rep := range reps {
+		reps[i] = v.transformRep(rep, perspective)
+	}
+}
+
+// Diagnostics obtains the problems encountered in the last update.
+// Only recorded when debug is set.
+func (v *Viewer) Diagnostics() ([]UpdateDiagnostic, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	out := make([]UpdateDiagnostic, len(v.diags))
+	for i, d := range v.diags {
+		out[i] = *d
+	}
+	return out, nil
+}
+
+// diagnose records and logs a problem with content.
+// Returns the error wrapped with the content hash.
+func (v *Viewer) diagnose(h *object.ContentHeaderData, b *object.Body, fatal bool, e error) error {
+	d := &UpdateDiagnostic{
+		ContentHash: h.Hash,
+		ContentType: b.Type,
+		Creator:     b.Creator,
+		Fatal:       fatal,
+		Error:       e.Error(),
+	}
+	v.diags = append(v.diags, d)
+	v.l.Log(inform.WarnLevel, "update diagnostic",
+		"hash", d.ContentHash, "type", d.ContentType, "creator", d.Creator, "fatal", d.Fatal, "error", e)
+	return boo.WrapTypef(e, boo.Type(e), "malformed content of hash %s", d.ContentHash)
 }
 
 func (v *Viewer) lock() func() {
@@ -208,12 +646,20 @@ func (v *Viewer) lock() func() {
 	return v.mux.Unlock
 }
 
-func (v *Viewer) setBoard(bc *object.Content) {
+// setBoard sets the board content, returning whether it changed.
+func (v *Viewer) setBoard(bc *object.Content) bool {
+	old := v.c.content[v.i.Board]
 	delete(v.c.content, v.i.Board)
 	v.i.Board = bc.GetHeader().Hash
 	rep := bc.ToRep()
 	rep.PubKey = v.pk.Hex()
 	v.c.content[v.i.Board] = rep
+	v.i.Pins = bc.GetBody().Pins
+	v.i.Retention = bc.GetBody().Retention
+	v.i.Policy = bc.GetBody().Policy
+	v.i.Archived = bc.GetBody().Archived
+	v.i.Registry = bc.GetBody().Registry
+	return old == nil || old.Header.Hash != rep.Header.Hash || !reflect.DeepEqual(old.Body, rep.Body)
 }
 
 func (v *Viewer) addThread(tc *object.Content, b *object.Body, h *object.ContentHeaderData) (cipher.SHA256, error) {
@@ -225,11 +671,163 @@ func (v *Viewer) addThread(tc *object.Content, b *object.Body, h *object.Content
 
 	tHash := h.GetHash()
 	v.i.Threads.Append(tHash.Hex())
-	v.c.content[tHash.Hex()] = tc.ToRep()
+	v.c.content[tHash.Hex()] = v.toRep(tc, b)
 	v.i.PostsOfThread[tHash.Hex()] = paginatedtypes.NewMapped()
+	v.c.GetProfile(b.Creator).ThreadCount++
+	v.i.AppendContentOfUser(b.Creator, tHash.Hex())
+	v.i.Participants[tHash.Hex()] = map[string]int{b.Creator: 1}
 	return tHash, nil
 }
 
+// deleteThread removes the thread and its posts from the views.
+// Posts of the thread that are not indexed under it (orphans) are also removed.
+// Returns the number of posts removed.
+func (v *Viewer) deleteThread(tHash string) int {
+	rep, ok := v.c.content[tHash]
+	if !ok {
+		return 0
+	}
+	creator := rep.Body.(*object.Body).Creator
+	v.resetSorted()
+	v.i.Threads.Delete(tHash)
+	v.removeContent(tHash, creator)
+	v.c.GetProfile(creator).ThreadCount--
+	delete(v.i.LastPost, tHash)
+	defer delete(v.i.Participants, tHash)
+
+	var count, orphans int
+	if posts, ok := v.i.PostsOfThread[tHash]; ok {
+		pHashes, _ := posts.Get(&typ.PaginatedInput{PageSize: math.MaxUint64})
+		for _, pHash := range pHashes.Data {
+			if v.deletePost(pHash) {
+				count++
+			}
+		}
+		delete(v.i.PostsOfThread, tHash)
+	}
+	for pHash, pRep := range v.c.content {
+		if body := pRep.Body.(*object.Body); body.Type == object.V5PostType && body.OfThread == tHash {
+			v.deletePost(pHash)
+			orphans++
+		}
+	}
+	if orphans > 0 {
+		v.l.Log(inform.WarnLevel, "removed orphaned posts of thread", "thread", tHash, "count", orphans)
+	}
+	return count + orphans
+}
+
+// deletePost removes the post and its index entries from the views.
+func (v *Viewer) deletePost(pHash string) bool {
+	rep, ok := v.c.content[pHash]
+	if !ok {
+		return false
+	}
+	body := rep.Body.(*object.Body)
+	v.removeContent(pHash, body.Creator)
+	v.deleteAttachments(pHash)
+	v.c.GetProfile(body.Creator).PostCount--
+	if participants, ok := v.i.Participants[body.OfThread]; ok {
+		if participants[body.Creator]--; participants[body.Creator] <= 0 {
+			delete(participants, body.Creator)
+		}
+	}
+	delete(v.i.PostsOfThread, pHash)
+	if replies, ok := v.i.PostsOfThread[body.OfPost]; ok {
+		replies.Delete(pHash)
+	}
+	return true
+}
+
+// evictPosts removes posts that are not in 'referenced' from the views, such as posts
+// deleted from threads that remain in the board.
+// Returns the thread of each removed post.
+func (v *Viewer) evictPosts(referenced map[string]struct{}) []string {
+	var tHashes []string
+	for pHash, rep := range v.c.content {
+		body := rep.Body.(*object.Body)
+		if body.Type != object.V5PostType {
+			continue
+		}
+		if _, ok := referenced[pHash]; ok || !v.deletePost(pHash) {
+			continue
+		}
+		if posts, ok := v.i.PostsOfThread[body.OfThread]; ok {
+			posts.Delete(pHash)
+		}
+		if v.i.LastPost[body.OfThread] == pHash {
+			v.resetLastPost(body.OfThread)
+		}
+		tHashes = append(tHashes, body.OfThread)
+	}
+	if len(tHashes) > 0 {
+		v.resetSorted()
+	}
+	return tHashes
+}
+
+// resetLastPost recalculates the latest post of the thread.
+func (v *Viewer) resetLastPost(tHash string) {
+	delete(v.i.LastPost, tHash)
+	if posts, ok := v.i.PostsOfThread[tHash]; ok {
+		for _, pHash := range listOf(posts) {
+			if rep, ok := v.c.content[pHash]; ok {
+				v.setLastPost(tHash, pHash, rep.Body.(*object.Body))
+			}
+		}
+	}
+}
+
+// removeContent removes the content rep, its votes and its author index entry.
+// Index entries of users that are left empty are removed.
+func (v *Viewer) removeContent(hash, creator string) {
+	if rep, ok := v.c.content[hash]; ok {
+		if body, ok := rep.Body.(*object.Body); ok && body.Type == object.V5PostType {
+			v.i.DeleteMentions(hash, body)
+		}
+	}
+	delete(v.c.content, hash)
+	if votes, ok := v.c.votes[hash]; ok {
+		for voter, vote := range votes.Votes {
+			v.i.DeleteVoteOfHash(vote.GetHeader().Hash)
+			if ofVoter, ok := v.i.VotesOfUser[voter]; ok {
+				if _, ok := ofVoter[hash]; ok {
+					v.i.UnrefUser(voter)
+				}
+				if delete(ofVoter, hash); len(ofVoter) == 0 {
+					delete(v.i.VotesOfUser, voter)
+				}
+			}
+		}
+		for _, report := range votes.Reports {
+			v.i.DeleteVoteOfHash(report.GetHeader().Hash)
+		}
+		delete(v.c.votes, hash)
+		delete(v.c.scores, hash)
+		v.c.voteViews.invalidate(hash)
+	}
+	if list, ok := v.i.ContentOfUser[creator]; ok && list.Has(hash) {
+		if list.Delete(hash); list.Len() == 0 {
+			delete(v.i.ContentOfUser, creator)
+		}
+		v.i.UnrefUser(creator)
+	}
+}
+
+// compactUsers removes users that no longer have threads, posts or votes of or for them
+// from the Users index, along with their profiles. The board owner and users with a
+// self-profile are kept. Returns the number of users removed.
+func (v *Viewer) compactUsers() int {
+	removed := v.i.CompactUsers(func(upk string) bool {
+		profile, ok := v.c.profiles[upk]
+		return upk == v.pk.Hex() || ok && profile.profileTS != 0
+	})
+	for _, upk := range removed {
+		delete(v.c.profiles, upk)
+	}
+	return len(removed)
+}
+
 func (v *Viewer) addPost(tHash cipher.SHA256, pc *object.Content, b *object.Body, h *object.ContentHeaderData) error {
 
 	// Check board public key.
@@ -247,7 +845,14 @@ func (v *Viewer) addPost(tHash cipher.SHA256, pc *object.Content, b *object.Body
 		return boo.Newf(boo.Internal, "thread of hash %s not found", tHash.Hex())
 	} else {
 		posts.Append(pHash)
-		v.c.content[pHash] = pc.ToRep()
+		v.c.content[pHash] = v.toRep(pc, b)
+		v.c.GetProfile(b.Creator).PostCount++
+		v.i.AppendContentOfUser(b.Creator, pHash)
+		v.setLastPost(tHash.Hex(), pHash, b)
+		v.i.AddMentions(pHash, b)
+		if participants, ok := v.i.Participants[tHash.Hex()]; ok {
+			participants[b.Creator]++
+		}
 	}
 
 	if ofPost, _ := b.GetOfPost(); ofPost != (cipher.SHA256{}) {
@@ -262,8 +867,51 @@ func (v *Viewer) addPost(tHash cipher.SHA256, pc *object.Content, b *object.Body
 	return nil
 }
 
+// setLastPost records the post as the latest of the thread, if it is newer.
+func (v *Viewer) setLastPost(tHash, pHash string, b *object.Body) {
+	if last, ok := v.i.LastPost[tHash]; ok {
+		if rep, ok := v.c.content[last]; ok && rep.Body.(*object.Body).TS > b.TS {
+			return
+		}
+	}
+	v.i.LastPost[tHash] = pHash
+}
+
+// lastPostPreview obtains a truncated representation of the latest post of thread.
+// Returns nil if thread has no posts.
+func (v *Viewer) lastPostPreview(tHash string) *object.ContentRep {
+	pHash, ok := v.i.LastPost[tHash]
+	if !ok {
+		return nil
+	}
+	rep, ok := v.c.content[pHash]
+	if !ok {
+		return nil
+	}
+	body := *rep.Body.(*object.Body)
+	body.Body = truncate(body.Body, LastPostPreviewLength)
+	body.Images = nil
+	return &object.ContentRep{
+		Header:  rep.Header,
+		Body:    &body,
+		ByOwner: rep.ByOwner,
+	}
+}
+
+// toRep obtains the representation of a thread or post.
+func (v *Viewer) toRep(c *object.Content, b *object.Body) *object.ContentRep {
+	rep := c.ToRep()
+	rep.ByOwner = b.Creator == v.pk.Hex()
+	return rep
+}
+
+// ensureUser ensures the user is indexed and has a profile. Users that end up
+// without references (see RefUser) are removed on the next compaction.
 func (v *Viewer) ensureUser(upk string) {
 	v.i.Users.Append(upk)
+	if _, ok := v.i.UserRefs[upk]; !ok {
+		v.i.unreferenced[upk] = struct{}{}
+	}
 	if _, ok := v.c.profiles[upk]; !ok {
 		v.c.profiles[upk] = NewProfile()
 	}
@@ -284,6 +932,7 @@ func (v *Viewer) processVote(c *object.Content, b *object.Body, h *object.Conten
 		cType = object.V5PostVoteType
 
 	case object.V5UserVoteType:
+		v.i.SetVoteOfHash(h.Hash, b)
 		return v.processUserVote(c, b, h)
 
 	default:
@@ -291,7 +940,7 @@ func (v *Viewer) processVote(c *object.Content, b *object.Body, h *object.Conten
 	}
 
 	if v.c.content[cHash] == nil {
-		return nil
+		return boo.Newf(boo.NotFound, "voted content of hash %s not found", cHash)
 	}
 
 	// Add to votes map.
@@ -301,10 +950,27 @@ func (v *Viewer) processVote(c *object.Content, b *object.Body, h *object.Conten
 		v.c.votes[cHash] = voteRep
 	}
 	voteRep.Add(c)
+	v.i.SetVoteOfHash(h.Hash, b)
+	if b.HasTag(object.ReportTag) {
+		// Reports are not counted as votes.
+		return nil
+	}
+	v.c.SetScore(cHash, voteRep)
+	v.i.SetVoteOfUser(b.Creator, cHash, b.Value)
 
 	return nil
 }
 
+// setSelfProfile applies a self-profile submission, and records it if it is the
+// latest of the user.
+func (v *Viewer) setSelfProfile(c *object.Content, b *object.Body, h *object.ContentHeaderData) {
+	if last, ok := v.c.selfProfiles[b.Creator]; ok && b.TS < last.Body.(*object.Body).TS {
+		return
+	}
+	v.c.selfProfiles[b.Creator] = &object.ContentRep{Header: h, Body: b, RawBody: c.Body}
+	v.c.GetProfile(b.Creator).SetSelfProfile(b.Name, b.AvatarRef, b.TS)
+}
+
 func (v *Viewer) processUserVote(c *object.Content, b *object.Body, h *object.ContentHeaderData) error {
 	var (
 		creatorProfile = v.c.GetProfile(b.Creator)
@@ -313,6 +979,15 @@ func (v *Viewer) processUserVote(c *object.Content, b *object.Body, h *object.Co
 
 	creatorProfile.ClearVotesFor(b.OfUser)
 	ofUserProfile.ClearVotesBy(b.Creator)
+	ofCreator, ok := v.c.userVotes[b.Creator]
+	if !ok {
+		ofCreator = make(map[string]*object.ContentRep)
+		v.c.userVotes[b.Creator] = ofCreator
+	}
+	ofCreator[b.OfUser] = &object.ContentRep{Header: h, Body: b, RawBody: c.Body}
+	if _, ok := v.i.VotesOfUser[b.Creator][b.OfUser]; !ok {
+		v.i.RefUser(b.OfUser)
+	}
 
 	switch b.Value {
 	case +1:
@@ -335,9 +1010,67 @@ func (v *Viewer) processUserVote(c *object.Content, b *object.Body, h *object.Co
 	case 0:
 		v.i.EnsureUsersOfUserVoteBody(b)
 	}
+	v.i.SetVoteOfUser(b.Creator, b.OfUser, b.Value)
 	return nil
 }
 
+/*
+	<<< CONTENT READER >>>
+*/
+
+// ContentReader represents the read methods of the Viewer.
+// Layers above state should depend on this to allow injection of fakes.
+type ContentReader interface {
+	HasUser(upk string) bool
+	HasThread(tHash string) bool
+	HasContent(hash string) bool
+	HasThreadVotes(tHash string) bool
+	HasPostVotes(pHash string) bool
+	CheckSubmissionPolicy(upk string) error
+	CheckNotArchived() error
+	CheckAttachment(b *object.Body) error
+	CheckKeyRotation(b *object.Body) error
+	CheckAnnouncement(b *object.Body) error
+	GetBoard() (*object.ContentRep, error)
+	GetBoardPage(in *BoardPageIn) (*BoardPageOut, error)
+	GetThreadPage(in *ThreadPageIn) (*ThreadPageOut, error)
+	GetReplies(in *RepliesIn) (*RepliesOut, error)
+	GetContentPath(hash string) (*ContentPathOut, error)
+	GetContents(in *ContentsIn) (*ContentsOut, error)
+	GetVotes(in *ContentVotesIn) (*ContentVotesOut, error)
+	GetVotesMulti(in *MultiVotesIn) (*MultiVotesOut, error)
+	GetAllThreadVotes(in *AllVotesIn) (*AllVotesOut, error)
+	GetReports(in *ReportsIn) (*ReportsOut, error)
+	GetModerationQueue(in *ModerationQueueIn) (*ModerationQueueOut, error)
+	ResolveVote(voteHash string) (*ResolvedVote, error)
+	CompactVotes(contentHash string) (*CompactVotesOut, error)
+	GetContentByVoteTag(in *VoteTagIn) (*VoteTagOut, error)
+	GetUserProfile(in *UserProfileIn) (*UserProfileOut, error)
+	GetIdentity(upk string) (*Identity, error)
+	GetAnnouncements(in *AnnouncementsIn) ([]*Announcement, error)
+	GetAnnouncement(bpk string) (*Announcement, error)
+	GetWatchedThreads(in *WatchedThreadsIn) (*WatchedThreadsOut, error)
+	GetTrustNetwork(in *TrustNetworkIn) (*TrustNetworkOut, error)
+	GetTrustScore(from, to string) (*TrustScoreOut, error)
+	GetUserVoteCount(upk string) (*UserVoteCountOut, error)
+	GetParticipants(in *ParticipantsIn) (*ParticipantsOut, error)
+	GetThreadParticipants(in *ThreadParticipantsIn) (*ThreadParticipantsOut, error)
+	GetBoardStats() (*BoardStatsOut, error)
+	RangeTrustGraph(action func(edge TrustEdge) error) error
+	SearchContent(in *SearchIn) (*SearchOut, error)
+	GetActivityFeed(in *ActivityIn) (*ActivityOut, error)
+	GetUserActivity(in *UserActivityIn) (*UserActivityOut, error)
+	GetMentions(in *MentionsIn) (*MentionsOut, error)
+	GetAttachments(pHash string) ([]*object.AttachmentRef, error)
+	GetAttachment(hash string) (*Attachment, error)
+	RangeThreadTranscript(tHash string, action func(entry *TranscriptEntry) error) error
+	ExportTrustGraph() ([]TrustEdge, error)
+	ExportBoard(w io.Writer) error
+}
+
+// Ensure that Viewer satisfies ContentReader.
+var _ ContentReader = (*Viewer)(nil)
+
 /*
 	<<< CHECK >>>
 */
@@ -367,6 +1100,31 @@ func (v *Viewer) HasContent(hash string) bool {
 	return ok
 }
 
+// HasThreadVotes reports whether votes are compiled for the thread,
+// without initializing them.
+func (v *Viewer) HasThreadVotes(tHash string) bool {
+	return v.hasVotesOfType(tHash, object.V5ThreadType)
+}
+
+// HasPostVotes reports whether votes are compiled for the post,
+// without initializing them.
+func (v *Viewer) HasPostVotes(pHash string) bool {
+	return v.hasVotesOfType(pHash, object.V5PostType)
+}
+
+func (v *Viewer) hasVotesOfType(hash string, t object.ContentType) bool {
+	if v == nil {
+		return false
+	}
+	defer v.lock()()
+	rep, ok := v.c.content[hash]
+	if !ok || !isOfType(rep, t) {
+		return false
+	}
+	_, ok = v.c.votes[hash]
+	return ok
+}
+
 /*
 	<<< GET >>>
 */
@@ -382,15 +1140,29 @@ func (v *Viewer) GetBoard() (*object.ContentRep, error) {
 
 // BoardPageIn represents the input required to obtain board page.
 type BoardPageIn struct {
-	Perspective    string
-	PaginatedInput typ.PaginatedInput
+	Perspective       string
+	HideBlockedVotes  bool    // Whether to exclude votes of users blocked by perspective.
+	StrictPerspective bool    // Whether an unknown perspective results in an error.
+	SinceUnix         int64   // If set, threads are given an unread count of posts after this time.
+	HashesOnly        bool    // Whether to only obtain thread hashes and pagination metadata.
+	SortBy            string  // Name of sorter to order threads with, empty for viewer's default.
+	NonEmptyOnly      bool    // Whether to exclude threads with no posts.
+	IncludeTopReply   bool    // Whether to attach the highest scored post of each thread.
+	IncludeArchived   bool    // Whether to include threads older than the board's retention.
+	Muted             string  // How threads of users muted by perspective are shown (see 'MutedHide' and 'MutedCollapse').
+	MinTrust          float64 // If positive, threads of users trusted by perspective less than this are excluded (see GetTrustScore).
+	ShowSpam          bool    // Whether to include threads of spam score at or above the viewer's threshold.
+	PaginatedInput    typ.PaginatedInput
 }
 
 // BoardPageOut represents the output for board page.
 type BoardPageOut struct {
-	Board *object.ContentRep `json:"board"`
-	//ThreadsMeta *typ.PaginatedOutput `json:"threads_meta"`
-	Threads []*object.ContentRep `json:"threads"`
+	Board        *object.ContentRep   `json:"board"`
+	ThreadsMeta  *typ.PaginatedOutput `json:"threads_meta,omitempty"`
+	ThreadHashes []string             `json:"thread_hashes,omitempty"`
+	Pinned       []*object.ContentRep `json:"pinned,omitempty"` // Pinned threads in order, excluded from 'threads'.
+	Threads      []*object.ContentRep `json:"threads"`
+	Policy       *PolicyView          `json:"policy"` // Submission policy, as it applies to perspective.
 }
 
 // GetBoardPage obtains a board page.
@@ -399,38 +1171,123 @@ func (v *Viewer) GetBoardPage(in *BoardPageIn) (*BoardPageOut, error) {
 		return nil, ErrViewerNotInitialized
 	}
 	defer v.lock()()
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
+	if e := checkMuted(in.Muted); e != nil {
+		return nil, e
+	}
 
-	tHashes, e := v.i.Threads.Get(&in.PaginatedInput)
+	tHashes, e := v.getThreadHashes(in)
 	if e != nil {
 		return nil, e
 	}
 
 	out := new(BoardPageOut)
-	out.Board = v.c.content[v.i.Board]
-	//out.ThreadsMeta = tHashes
+	out.Board = v.transformRep(v.c.content[v.i.Board], in.Perspective)
+	out.Policy = v.policyView(in.Perspective)
+	if in.HashesOnly {
+		out.ThreadsMeta = tHashes
+		out.ThreadHashes = tHashes.Data
+		return out, nil
+	}
+	muted := v.mutedBy(in.Perspective)
+	trusted := v.trustedBy(in.Perspective, in.MinTrust)
+	for _, tHash := range v.i.Pins {
+		if !v.i.Threads.Has(tHash) {
+			continue
+		}
+		if in.Muted == MutedHide && v.isMuted(muted, tHash) {
+			continue
+		}
+		if v.isDistrusted(trusted, in.MinTrust, tHash) {
+			continue
+		}
+		out.Pinned = append(out.Pinned, v.threadRep(tHash, in))
+	}
 	out.Threads = make([]*object.ContentRep, len(tHashes.Data))
 	for i, tHash := range tHashes.Data {
-		out.Threads[i] = v.c.content[tHash]
-		if votes, ok := v.c.votes[tHash]; ok {
-			out.Threads[i].Votes = votes.View(in.Perspective)
-		}
+		out.Threads[i] = v.threadRep(tHash, in)
 	}
+	v.transformReps(out.Pinned, in.Perspective)
+	v.transformReps(out.Threads, in.Perspective)
 	return out, nil
 }
 
+// threadRep obtains the thread representation as viewed on the board page.
+func (v *Viewer) threadRep(tHash string, in *BoardPageIn) *object.ContentRep {
+	rep := v.viewRep(tHash)
+	rep.UnreadCount = v.countPostsSince(tHash, in.SinceUnix)
+	rep.LastPost = v.lastPostPreview(tHash)
+	rep.Votes = v.viewVotes(tHash, in.Perspective, in.HideBlockedVotes)
+	rep.Score = v.c.GetScore(tHash)
+	rep.Archived = v.isArchived(tHash, time.Now())
+	rep.Pinned = v.isPinned(tHash)
+	rep.SpamScore = v.spamScore(tHash)
+	rep.Stats = v.threadStats(tHash)
+	rep.Collapsed = in.Muted == MutedCollapse && v.isMuted(v.mutedBy(in.Perspective), tHash)
+	if in.IncludeTopReply {
+		rep.TopReply = v.topReply(tHash, in)
+	}
+	return rep
+}
+
+// topReply obtains the post of the thread with the highest score (up votes - down votes).
+// Of posts with equal score, the earliest is chosen. Returns nil if the thread has no posts.
+func (v *Viewer) topReply(tHash string, in *BoardPageIn) *object.ContentRep {
+	posts, ok := v.i.PostsOfThread[tHash]
+	if !ok {
+		return nil
+	}
+	pHashes, e := posts.Get(&typ.PaginatedInput{PageSize: math.MaxUint64})
+	if e != nil {
+		return nil
+	}
+	var (
+		top      string
+		topScore int
+	)
+	for _, pHash := range pHashes.Data {
+		if _, ok := v.c.content[pHash]; !ok {
+			continue
+		}
+		score := (&SortItem{Votes: v.c.votes[pHash]}).Score()
+		if top == "" || score > topScore {
+			top, topScore = pHash, score
+		}
+	}
+	if top == "" {
+		return nil
+	}
+	rep := minimalRep(v.c.content[top])
+	rep.ByOwner = v.c.content[top].ByOwner
+	rep.Votes = v.viewVotes(top, in.Perspective, in.HideBlockedVotes)
+	rep.Score = v.c.GetScore(top)
+	return rep
+}
+
 // ThreadPageIn represents the input required to obtain thread page.
 type ThreadPageIn struct {
-	Perspective    string
-	ThreadHash     string
-	PaginatedInput typ.PaginatedInput
+	Perspective       string
+	HideBlockedVotes  bool // Whether to exclude votes of users blocked by perspective.
+	StrictPerspective bool // Whether an unknown perspective results in an error.
+	ThreadHash        string
+	SinceUnix         int64   // If set, thread is given an unread count of posts after this time.
+	HashesOnly        bool    // Whether to only obtain post hashes and pagination metadata.
+	CollapseBelow     *int    // If set, posts of lower score (up votes - down votes) are marked as collapsed.
+	Muted             string  // How posts of users muted by perspective are shown (see 'MutedHide' and 'MutedCollapse').
+	MinTrust          float64 // If positive, posts of users trusted by perspective less than this are excluded (see GetTrustScore).
+	ShowSpam          bool    // Whether to include posts of spam score at or above the viewer's threshold.
+	PaginatedInput    typ.PaginatedInput
 }
 
 // ThreadPageOut represents the output for thread page.
 type ThreadPageOut struct {
-	Board  *object.ContentRep `json:"board"`
-	Thread *object.ContentRep `json:"thread"`
-	//PostsMeta *typ.PaginatedOutput `json:"posts_meta"`
-	Posts []*object.ContentRep `json:"posts"`
+	Board      *object.ContentRep   `json:"board"`
+	Thread     *object.ContentRep   `json:"thread"`
+	PostsMeta  *typ.PaginatedOutput `json:"posts_meta,omitempty"`
+	PostHashes []string             `json:"post_hashes,omitempty"`
+	Posts      []*object.ContentRep `json:"posts"`
 }
 
 // GetThreadPage obtains the thread page.
@@ -439,72 +1296,294 @@ func (v *Viewer) GetThreadPage(in *ThreadPageIn) (*ThreadPageOut, error) {
 		return nil, ErrViewerNotInitialized
 	}
 	defer v.lock()()
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
 	out := new(ThreadPageOut)
-	out.Board = v.c.content[v.i.Board]
-	out.Thread = v.c.content[in.ThreadHash]
+	out.Board = v.transformRep(v.c.content[v.i.Board], in.Perspective)
+	out.Thread = v.viewRep(in.ThreadHash)
 
 	if out.Thread == nil {
 		return nil, boo.Newf(boo.NotFound, "thread of hash '%s' is not found in board '%s'",
 			in.ThreadHash, v.pk.Hex())
 	}
-	if votes, ok := v.c.votes[in.ThreadHash]; ok {
-		out.Thread.Votes = votes.View(in.Perspective)
+	if e := checkMuted(in.Muted); e != nil {
+		return nil, e
 	}
 
-	pHashes, e := v.i.PostsOfThread[in.ThreadHash].Get(&in.PaginatedInput)
+	muted := v.mutedBy(in.Perspective)
+	pHashes, e := v.getPostHashes(in, muted)
 	if e != nil {
 		return nil, e
 	}
+	if in.HashesOnly {
+		out.PostsMeta = pHashes
+		out.PostHashes = pHashes.Data
+		out.Thread = v.transformRep(out.Thread, in.Perspective)
+		return out, nil
+	}
+
+	out.Thread.UnreadCount = v.countPostsSince(in.ThreadHash, in.SinceUnix)
+	out.Thread.Stats = v.threadStats(in.ThreadHash)
+	out.Thread.Votes = v.viewVotes(in.ThreadHash, in.Perspective, in.HideBlockedVotes)
+	out.Thread.Score = v.c.GetScore(in.ThreadHash)
+	out.Thread.SpamScore = v.spamScore(in.ThreadHash)
+
 	out.Posts = make([]*object.ContentRep, len(pHashes.Data))
 	for i, pHash := range pHashes.Data {
-		out.Posts[i] = v.c.content[pHash]
-		if votes, ok := v.c.votes[pHash]; ok {
-			out.Posts[i].Votes = votes.View(in.Perspective)
-		}
+		out.Posts[i] = v.viewRep(pHash)
+		out.Posts[i].Votes = v.viewVotes(pHash, in.Perspective, in.HideBlockedVotes)
+		out.Posts[i].Score = v.c.GetScore(pHash)
+		out.Posts[i].Attachments = v.attachmentRefs(pHash)
+		out.Posts[i].SpamScore = v.spamScore(pHash)
+		out.Posts[i].Collapsed = in.CollapseBelow != nil &&
+			(&SortItem{Votes: v.c.votes[pHash]}).Score() < *in.CollapseBelow ||
+			in.Muted == MutedCollapse && v.isMuted(muted, pHash)
 	}
-
+	out.Thread = v.transformRep(out.Thread, in.Perspective)
+	v.transformReps(out.Posts, in.Perspective)
 	return out, nil
 }
 
-// ContentVotesIn represents the input required to obtain content votes.
-type ContentVotesIn struct {
-	Perspective string
-	ContentHash string
+// getPostHashes obtains the page of post hashes of the thread.
+// Posts of muted users are excluded before paging if 'MutedHide' is set, as are
+// posts of users trusted less than 'MinTrust' and, unless 'ShowSpam' is set, spam.
+func (v *Viewer) getPostHashes(in *ThreadPageIn, muted map[string]struct{}) (*typ.PaginatedOutput, error) {
+	posts := v.i.PostsOfThread[in.ThreadHash]
+	hide := in.Muted == MutedHide && len(muted) > 0
+	trusted := v.trustedBy(in.Perspective, in.MinTrust)
+	hideSpam := !in.ShowSpam && v.hidesSpam()
+	if !hide && trusted == nil && !hideSpam {
+		return posts.Get(&in.PaginatedInput)
+	}
+	all := listOf(posts)
+	pHashes := make([]string, 0, len(all))
+	for _, pHash := range all {
+		if hide && v.isMuted(muted, pHash) {
+			continue
+		}
+		if v.isDistrusted(trusted, in.MinTrust, pHash) {
+			continue
+		}
+		if hideSpam && v.isSpam(pHash) {
+			continue
+		}
+		pHashes = append(pHashes, pHash)
+	}
+	return typ.GetPage(&in.PaginatedInput, pHashes)
 }
 
-// ContentVotesOut represents the output for content votes.
-type ContentVotesOut struct {
-	Votes *VoteRepView `json:"votes"`
+// RepliesIn represents the input required to obtain direct replies of a post.
+type RepliesIn struct {
+	Perspective       string
+	HideBlockedVotes  bool // Whether to exclude votes of users blocked by perspective.
+	StrictPerspective bool // Whether an unknown perspective results in an error.
+	PostHash          string
+	PaginatedInput    typ.PaginatedInput
 }
 
-// GetVotes obtains content votes.
-func (v *Viewer) GetVotes(in *ContentVotesIn) (*ContentVotesOut, error) {
+// RepliesOut represents the output for direct replies of a post.
+type RepliesOut struct {
+	RepliesMeta *typ.PaginatedOutput `json:"replies_meta"`
+	Replies     []*object.ContentRep `json:"replies"`
+}
+
+// GetReplies obtains the direct replies of a post.
+func (v *Viewer) GetReplies(in *RepliesIn) (*RepliesOut, error) {
 	if v == nil {
 		return nil, ErrViewerNotInitialized
 	}
 	defer v.lock()()
-	out := new(ContentVotesOut)
-	if votes, ok := v.c.votes[in.ContentHash]; ok {
-		out.Votes = votes.View(in.Perspective)
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
+
+	if rep, ok := v.c.content[in.PostHash]; !ok || !isOfType(rep, object.V5PostType) {
+		return nil, boo.Newf(boo.NotFound, "post of hash '%s' is not found in board '%s'",
+			in.PostHash, v.pk.Hex())
+	}
+
+	out := &RepliesOut{
+		Replies: []*object.ContentRep{},
+	}
+	replies, ok := v.i.PostsOfThread[in.PostHash]
+	if !ok {
 		return out, nil
 	}
-	if _, ok := v.c.content[in.ContentHash]; ok {
-		out.Votes = &VoteRepView{
-			Ref: in.ContentHash,
+	rHashes, e := replies.Get(&in.PaginatedInput)
+	if e != nil {
+		return nil, e
+	}
+	out.RepliesMeta = rHashes
+	out.Replies = make([]*object.ContentRep, len(rHashes.Data))
+	for i, rHash := range rHashes.Data {
+		out.Replies[i] = v.viewRep(rHash)
+		out.Replies[i].Votes = v.viewVotes(rHash, in.Perspective, in.HideBlockedVotes)
+		out.Replies[i].Score = v.c.GetScore(rHash)
+	}
+	v.transformReps(out.Replies, in.Perspective)
+	return out, nil
+}
+
+// ContentsIn represents the input required to obtain multiple content.
+type ContentsIn struct {
+	Perspective       string
+	HideBlockedVotes  bool // Whether to exclude votes of users blocked by perspective.
+	StrictPerspective bool // Whether an unknown perspective results in an error.
+	ContentHashes     []string
+}
+
+// ContentsOut represents the output for multiple content.
+type ContentsOut struct {
+	Contents []*object.ContentRep `json:"contents"`
+}
+
+// GetContents obtains content of given hashes in bulk.
+// Hashes of content that is not found are skipped.
+func (v *Viewer) GetContents(in *ContentsIn) (*ContentsOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
+	out := &ContentsOut{
+		Contents: make([]*object.ContentRep, 0, len(in.ContentHashes)),
+	}
+	for _, hash := range in.ContentHashes {
+		rep := v.viewRep(hash)
+		if rep == nil {
+			continue
 		}
+		rep.Votes = v.viewVotes(hash, in.Perspective, in.HideBlockedVotes)
+		rep.Score = v.c.GetScore(hash)
+		out.Contents = append(out.Contents, rep)
+	}
+	v.transformReps(out.Contents, in.Perspective)
+	return out, nil
+}
+
+// ContentVotesIn represents the input required to obtain content votes.
+type ContentVotesIn struct {
+	Perspective       string
+	HideBlockedVotes  bool // Whether to exclude votes of users blocked by perspective.
+	StrictPerspective bool // Whether an unknown perspective results in an error.
+	ContentHash       string
+}
+
+// ContentVotesOut represents the output for content votes.
+type ContentVotesOut struct {
+	Votes *VoteRepView `json:"votes"`
+}
+
+// GetVotes obtains content votes.
+func (v *Viewer) GetVotes(in *ContentVotesIn) (*ContentVotesOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
+	out := new(ContentVotesOut)
+	if v.hasVotable(in.ContentHash) {
+		out.Votes = v.viewVotes(in.ContentHash, in.Perspective, in.HideBlockedVotes)
 		return out, nil
 	}
 	return nil, boo.Newf(boo.NotFound, "content of hash '%s' is not found",
 		in.ContentHash)
 }
 
+// MultiVotesIn represents the input required to obtain votes of multiple content.
+type MultiVotesIn struct {
+	Perspective       string
+	HideBlockedVotes  bool // Whether to exclude votes of users blocked by perspective.
+	StrictPerspective bool // Whether an unknown perspective results in an error.
+	ContentHashes     []string
+}
+
+// MultiVotesOut represents the output for votes of multiple content.
+type MultiVotesOut struct {
+	Votes map[string]*VoteRepView `json:"votes"` // key (content hash), value (votes view)
+}
+
+// GetVotesMulti obtains votes of given content hashes in bulk.
+// Hashes of content that is not found are omitted.
+func (v *Viewer) GetVotesMulti(in *MultiVotesIn) (*MultiVotesOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
+	out := &MultiVotesOut{
+		Votes: make(map[string]*VoteRepView, len(in.ContentHashes)),
+	}
+	for _, hash := range in.ContentHashes {
+		if v.hasVotable(hash) {
+			out.Votes[hash] = v.viewVotes(hash, in.Perspective, in.HideBlockedVotes)
+		}
+	}
+	return out, nil
+}
+
+// AllVotesIn represents the input required to obtain votes of threads of a board page.
+type AllVotesIn struct {
+	Perspective       string
+	HideBlockedVotes  bool   // Whether to exclude votes of users blocked by perspective.
+	StrictPerspective bool   // Whether an unknown perspective results in an error.
+	SortBy            string // Name of sorter to order threads with, empty for viewer's default.
+	PaginatedInput    typ.PaginatedInput
+}
+
+// AllVotesOut represents the output for votes of threads of a board page.
+type AllVotesOut struct {
+	ThreadsMeta *typ.PaginatedOutput    `json:"threads_meta"`
+	Votes       map[string]*VoteRepView `json:"votes"` // key (thread hash), value (votes view)
+}
+
+// GetAllThreadVotes obtains votes of all threads of a board page in one call.
+// Threads are paginated the same as GetBoardPage.
+func (v *Viewer) GetAllThreadVotes(in *AllVotesIn) (*AllVotesOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
+	tHashes, e := v.getThreadHashes(&BoardPageIn{
+		SortBy:         in.SortBy,
+		PaginatedInput: in.PaginatedInput,
+	})
+	if e != nil {
+		return nil, e
+	}
+	out := &AllVotesOut{
+		ThreadsMeta: tHashes,
+		Votes:       make(map[string]*VoteRepView, len(tHashes.Data)),
+	}
+	for _, tHash := range tHashes.Data {
+		out.Votes[tHash] = v.viewVotes(tHash, in.Perspective, in.HideBlockedVotes)
+	}
+	return out, nil
+}
+
 type UserProfileIn struct {
-	UserPubKey string
+	UserPubKey       string
+	Fields           []string // Membership lists to include, empty for all.
+	RecentContent    int      // Number of most recent threads and posts of user to include.
+	Perspective      string   // Perspective of votes of recent content.
+	HideBlockedVotes bool     // Whether to exclude votes of users blocked by perspective.
 }
 
 type UserProfileOut struct {
-	UserPubKey string       `json:"user_public_key"`
-	Profile    *ProfileView `json:"profile"`
+	UserPubKey    string               `json:"user_public_key"`
+	Identity      *Identity            `json:"identity,omitempty"` // Only if user rotated keys.
+	Profile       *ProfileView         `json:"profile"`
+	RecentContent []*object.ContentRep `json:"recent_content,omitempty"` // Newest first.
 }
 
 func (v *Viewer) GetUserProfile(in *UserProfileIn) (*UserProfileOut, error) {
@@ -521,43 +1600,695 @@ func (v *Viewer) GetUserProfile(in *UserProfileIn) (*UserProfileOut, error) {
 		return nil, boo.Newf(boo.Internal,
 			"user of public key %s is indexed but has no profile", in.UserPubKey)
 	}
-	return &UserProfileOut{
+	keys := v.identityKeys(in.UserPubKey)
+	if len(keys) > 1 {
+		profile = v.identityProfile(keys)
+	}
+	view, e := profile.ViewFields(in.Fields)
+	if e != nil {
+		return nil, e
+	}
+	out := &UserProfileOut{
 		UserPubKey: in.UserPubKey,
-		Profile:    profile.View(),
-	}, nil
+		Profile:    view,
+	}
+	if len(keys) > 1 {
+		out.Identity = &Identity{
+			Identity: keys[0],
+			Current:  keys[len(keys)-1],
+			Keys:     keys,
+		}
+	}
+	if in.RecentContent > 0 {
+		if out.RecentContent, e = v.recentContentOfUser(in); e != nil {
+			return nil, e
+		}
+	}
+	return out, nil
+}
+
+// recentContentOfUser obtains the most recent threads and posts created by the user,
+// under any key of their identity, newest first, with votes viewed from perspective.
+func (v *Viewer) recentContentOfUser(in *UserProfileIn) ([]*object.ContentRep, error) {
+	var hashes []string
+	for _, upk := range v.identityKeys(in.UserPubKey) {
+		if list, ok := v.i.ContentOfUser[upk]; ok {
+			hashes = append(hashes, listOf(list)...)
+		}
+	}
+	reps := make([]*object.ContentRep, 0, len(hashes))
+	for _, hash := range hashes {
+		if rep, ok := v.c.content[hash]; ok {
+			reps = append(reps, rep)
+		}
+	}
+	sort.SliceStable(reps, func(i, j int) bool {
+		return reps[i].Body.(*object.Body).TS > reps[j].Body.(*object.Body).TS
+	})
+	if len(reps) > in.RecentContent {
+		reps = reps[:in.RecentContent]
+	}
+	for i, rep := range reps {
+		reps[i] = minimalRep(rep)
+		reps[i].ByOwner = rep.ByOwner
+		reps[i].Votes = v.viewVotes(rep.Header.Hash, in.Perspective, in.HideBlockedVotes)
+		reps[i].Score = v.c.GetScore(rep.Header.Hash)
+	}
+	v.transformReps(reps, in.Perspective)
+	return reps, nil
+}
+
+type ParticipantsIn struct {
+	MinReputation  *int // If set, users of lower reputation are excluded.
+	PaginatedInput typ.PaginatedInput
 }
 
 type ParticipantsOut struct {
-	Participants []string `json:"participants"`
+	ParticipantsMeta *typ.PaginatedOutput `json:"participants_meta"`
+	Participants     []string             `json:"participants"`
+}
+
+func (v *Viewer) GetParticipants(in *ParticipantsIn) (*ParticipantsOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	users := v.i.Users
+	if in.MinReputation != nil {
+		all, e := v.i.Users.Get(&typ.PaginatedInput{
+			StartIndex: 0,
+			PageSize:   math.MaxUint64,
+		})
+		if e != nil {
+			return nil, e
+		}
+		users = paginatedtypes.NewSimple()
+		for _, upk := range all.Data {
+			if v.c.GetProfile(upk).Reputation() >= *in.MinReputation {
+				users.Append(upk)
+			}
+		}
+	}
+	out, e := users.Get(&in.PaginatedInput)
+	if e != nil {
+		return nil, e
+	}
+	return &ParticipantsOut{
+		ParticipantsMeta: out,
+		Participants:     out.Data,
+	}, nil
+}
+
+// ContentPathOut represents the output for the path of content.
+type ContentPathOut struct {
+	Path []*object.ContentRep `json:"path"` // Ordered from board to target.
+}
+
+// GetContentPath obtains the chain of content from the board to the given board, thread or post,
+// including the thread and ancestor posts in between.
+func (v *Viewer) GetContentPath(hash string) (*ContentPathOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+
+	rep, ok := v.c.content[hash]
+	if !ok {
+		return nil, boo.Newf(boo.NotFound, "content of hash '%s' is not found in board '%s'",
+			hash, v.pk.Hex())
+	}
+
+	// Walk up from target, collecting in reverse.
+	var (
+		path    []*object.ContentRep
+		visited = make(map[string]struct{})
+	)
+	for {
+		if _, ok := visited[hash]; ok {
+			return nil, boo.Newf(boo.InvalidRead,
+				"cyclic ancestry found at content of hash '%s'", hash)
+		}
+		visited[hash] = struct{}{}
+		path = append(path, minimalRep(rep))
+
+		body, ok := rep.Body.(*object.Body)
+		if !ok {
+			break
+		}
+		switch body.Type {
+		case object.V5PostType:
+			if hash = body.OfPost; hash == "" {
+				hash = body.OfThread
+			}
+		case object.V5ThreadType:
+			hash = v.i.Board
+		default:
+			hash = ""
+		}
+		if hash == "" {
+			break
+		}
+		if rep, ok = v.c.content[hash]; !ok {
+			return nil, boo.Newf(boo.NotFound, "ancestor of hash '%s' is not found in board '%s'",
+				hash, v.pk.Hex())
+		}
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return &ContentPathOut{Path: path}, nil
+}
+
+// WatchedThreadsIn represents the input required to obtain threads watched by a user.
+type WatchedThreadsIn struct {
+	UserPubKey string
+	LastSeen   map[string]int64 // key (thread hash), value (unix time last seen). Unseen threads have all posts unread.
+}
+
+// WatchedThread represents a thread watched by a user.
+type WatchedThread struct {
+	Thread       *object.ContentRep `json:"thread"`
+	UnreadCount  int                `json:"unread_count"`
+	LastActivity int64              `json:"last_activity"` // Timestamp of thread or latest post.
 }
 
-func (v *Viewer) GetParticipants() (*ParticipantsOut, error) {
+// WatchedThreadsOut represents the output for threads watched by a user.
+type WatchedThreadsOut struct {
+	Threads []*WatchedThread `json:"threads"`
+}
+
+// GetWatchedThreads obtains the threads that a user watches, being the threads
+// that the user created or posted in. Threads are ordered by most recent activity.
+func (v *Viewer) GetWatchedThreads(in *WatchedThreadsIn) (*WatchedThreadsOut, error) {
 	if v == nil {
 		return nil, ErrViewerNotInitialized
 	}
 	defer v.lock()()
-	out, e := v.i.Users.Get(&typ.PaginatedInput{
+
+	out := &WatchedThreadsOut{
+		Threads: []*WatchedThread{},
+	}
+	list, ok := v.i.ContentOfUser[in.UserPubKey]
+	if !ok {
+		return out, nil
+	}
+	hashes, e := list.Get(&typ.PaginatedInput{
 		StartIndex: 0,
 		PageSize:   math.MaxUint64,
 	})
 	if e != nil {
 		return nil, e
 	}
-	return &ParticipantsOut{
-		Participants: out.Data,
-	}, nil
+
+	watched := make(map[string]struct{})
+	for _, hash := range hashes.Data {
+		rep, ok := v.c.content[hash]
+		if !ok {
+			continue
+		}
+		switch body := rep.Body.(*object.Body); body.Type {
+		case object.V5ThreadType:
+			watched[hash] = struct{}{}
+		case object.V5PostType:
+			watched[body.OfThread] = struct{}{}
+		}
+	}
+
+	for tHash := range watched {
+		thread, ok := v.c.content[tHash]
+		if !ok {
+			continue
+		}
+		wt := &WatchedThread{
+			Thread:       minimalRep(thread),
+			LastActivity: thread.Body.(*object.Body).TS,
+		}
+		if pHash, ok := v.i.LastPost[tHash]; ok {
+			wt.LastActivity = v.c.content[pHash].Body.(*object.Body).TS
+		}
+		if since, ok := in.LastSeen[tHash]; ok {
+			wt.UnreadCount = v.countPostsSince(tHash, since)
+		} else if posts, ok := v.i.PostsOfThread[tHash]; ok {
+			wt.UnreadCount = posts.Len()
+		}
+		out.Threads = append(out.Threads, wt)
+	}
+	sort.Slice(out.Threads, func(i, j int) bool {
+		return out.Threads[i].LastActivity > out.Threads[j].LastActivity
+	})
+	return out, nil
+}
+
+// UserVoteCountOut represents the breakdown of votes cast by a user.
+// Neutral votes are those that have been retracted.
+type UserVoteCountOut struct {
+	UserPubKey string `json:"user_public_key"`
+	Total      int    `json:"total"`
+	Up         int    `json:"up"`
+	Down       int    `json:"down"`
+	Neutral    int    `json:"neutral"`
+}
+
+// GetUserVoteCount obtains the number of votes that a user has cast on the board,
+// counting only the current vote of the user for each thread, post or user.
+func (v *Viewer) GetUserVoteCount(upk string) (*UserVoteCountOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	out := &UserVoteCountOut{UserPubKey: upk}
+	for _, value := range v.i.VotesOfUser[upk] {
+		switch {
+		case value > 0:
+			out.Up++
+		case value < 0:
+			out.Down++
+		default:
+			out.Neutral++
+		}
+	}
+	out.Total = out.Up + out.Down + out.Neutral
+	return out, nil
+}
+
+// ThreadParticipantsIn represents the input required to obtain participants of a thread.
+type ThreadParticipantsIn struct {
+	ThreadHash     string
+	WithProfiles   bool // Whether to include the profile view of each participant.
+	PaginatedInput typ.PaginatedInput
+}
+
+// ThreadParticipantsOut represents the output for participants of a thread.
+type ThreadParticipantsOut struct {
+	ParticipantsMeta *typ.PaginatedOutput    `json:"participants_meta"`
+	Participants     []string                `json:"participants"`
+	Profiles         map[string]*ProfileView `json:"profiles,omitempty"`
+}
+
+// GetThreadParticipants obtains the distinct users who created the thread or any of it's posts.
+// Participants are ordered by first appearance, starting with the thread creator.
+func (v *Viewer) GetThreadParticipants(in *ThreadParticipantsIn) (*ThreadParticipantsOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+
+	thread, ok := v.c.content[in.ThreadHash]
+	if !ok || !isOfType(thread, object.V5ThreadType) {
+		return nil, boo.Newf(boo.NotFound, "thread of hash '%s' is not found in board '%s'",
+			in.ThreadHash, v.pk.Hex())
+	}
+
+	users := paginatedtypes.NewMapped()
+	users.Append(thread.Body.(*object.Body).Creator)
+	pHashes, e := v.i.PostsOfThread[in.ThreadHash].Get(&typ.PaginatedInput{
+		StartIndex: 0,
+		PageSize:   math.MaxUint64,
+	})
+	if e != nil {
+		return nil, e
+	}
+	for _, pHash := range pHashes.Data {
+		if rep, ok := v.c.content[pHash]; ok {
+			users.Append(rep.Body.(*object.Body).Creator)
+		}
+	}
+
+	page, e := users.Get(&in.PaginatedInput)
+	if e != nil {
+		return nil, e
+	}
+	out := &ThreadParticipantsOut{
+		ParticipantsMeta: page,
+		Participants:     page.Data,
+	}
+	if in.WithProfiles {
+		out.Profiles = make(map[string]*ProfileView, len(page.Data))
+		for _, upk := range page.Data {
+			out.Profiles[upk] = v.c.GetProfile(upk).View()
+		}
+	}
+	return out, nil
+}
+
+// BoardStatsOut represents the totals of a board.
+type BoardStatsOut struct {
+	ThreadCount      int   `json:"thread_count"`
+	PostCount        int   `json:"post_count"`
+	ParticipantCount int   `json:"participant_count"`
+	VoteCount        int   `json:"vote_count"`
+	LastActivity     int64 `json:"last_activity"` // Timestamp of board, or of latest thread or post.
+}
+
+// GetBoardStats obtains the totals of the board.
+func (v *Viewer) GetBoardStats() (*BoardStatsOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	out := &BoardStatsOut{
+		ThreadCount:      v.i.Threads.Len(),
+		ParticipantCount: v.i.Users.Len(),
+	}
+	tHashes, e := v.i.Threads.Get(&typ.PaginatedInput{
+		StartIndex: 0,
+		PageSize:   math.MaxUint64,
+	})
+	if e != nil {
+		return nil, e
+	}
+	if rep, ok := v.c.content[v.i.Board]; ok {
+		out.LastActivity = rep.Body.(*object.Body).TS
+	}
+	for _, tHash := range tHashes.Data {
+		stats := v.threadStats(tHash)
+		out.PostCount += stats.PostCount
+		if stats.LastActivity > out.LastActivity {
+			out.LastActivity = stats.LastActivity
+		}
+	}
+	for _, votes := range v.c.votes {
+		out.VoteCount += len(votes.Votes)
+	}
+	return out, nil
 }
 
 /*
 	<<< HELPER FUNCTIONS >>>
 */
 
+// contentOfUser obtains the threads, and posts (grouped by thread) created by a user.
+func (v *Viewer) contentOfUser(upk string) (map[string]struct{}, map[string]map[string]struct{}) {
+	defer v.lock()()
+	var (
+		threads = make(map[string]struct{})
+		posts   = make(map[string]map[string]struct{})
+	)
+	list, ok := v.i.ContentOfUser[upk]
+	if !ok {
+		return threads, posts
+	}
+	hashes, e := list.Get(&typ.PaginatedInput{
+		StartIndex: 0,
+		PageSize:   math.MaxUint64,
+	})
+	if e != nil {
+		return threads, posts
+	}
+	for _, hash := range hashes.Data {
+		rep, ok := v.c.content[hash]
+		if !ok {
+			continue
+		}
+		body, ok := rep.Body.(*object.Body)
+		if !ok {
+			continue
+		}
+		switch body.Type {
+		case object.V5ThreadType:
+			threads[hash] = struct{}{}
+		case object.V5PostType:
+			if _, ok := posts[body.OfThread]; !ok {
+				posts[body.OfThread] = make(map[string]struct{})
+			}
+			posts[body.OfThread][hash] = struct{}{}
+		}
+	}
+	return threads, posts
+}
+
+// getThreadHashes obtains a page of thread hashes, filtered and ordered as specified in input.
+func (v *Viewer) getThreadHashes(in *BoardPageIn) (*typ.PaginatedOutput, error) {
+	sortBy := in.SortBy
+	if sortBy == "" {
+		sortBy = v.sortBy
+	}
+	sorter, e := GetSorter(sortBy)
+	if e != nil {
+		return nil, e
+	}
+	archiving := v.i.Retention > 0 && !in.IncludeArchived
+	var muted map[string]struct{}
+	if in.Muted == MutedHide {
+		muted = v.mutedBy(in.Perspective)
+	}
+	trusted := v.trustedBy(in.Perspective, in.MinTrust)
+	hideSpam := !in.ShowSpam && v.hidesSpam()
+	if sorter == nil && !in.NonEmptyOnly && len(v.i.Pins) == 0 && !archiving && len(muted) == 0 && trusted == nil && !hideSpam {
+		return v.i.Threads.Get(&in.PaginatedInput)
+	}
+	var all []string
+	if sorter != nil {
+		all, e = v.sortedThreads(sortBy, sorter)
+	} else {
+		var out *typ.PaginatedOutput
+		if out, e = v.i.Threads.Get(&typ.PaginatedInput{
+			StartIndex: 0,
+			PageSize:   math.MaxUint64,
+		}); e == nil {
+			all = out.Data
+		}
+	}
+	if e != nil {
+		return nil, e
+	}
+	pinned := make(map[string]struct{}, len(v.i.Pins))
+	for _, tHash := range v.i.Pins {
+		pinned[tHash] = struct{}{}
+	}
+	now := time.Now()
+	tHashes := make([]string, 0, len(all))
+	for _, tHash := range all {
+		if _, ok := pinned[tHash]; ok {
+			continue
+		}
+		if archiving && v.isArchived(tHash, now) {
+			continue
+		}
+		if v.isMuted(muted, tHash) {
+			continue
+		}
+		if v.isDistrusted(trusted, in.MinTrust, tHash) {
+			continue
+		}
+		if hideSpam && v.isSpam(tHash) {
+			continue
+		}
+		if in.NonEmptyOnly {
+			if posts, ok := v.i.PostsOfThread[tHash]; !ok || posts.Len() == 0 {
+				continue
+			}
+		}
+		tHashes = append(tHashes, tHash)
+	}
+	list := paginatedtypes.NewSimple()
+	for _, tHash := range tHashes {
+		list.Append(tHash)
+	}
+	return list.Get(&in.PaginatedInput)
+}
+
+// isPinned determines whether the thread is pinned by the board owner.
+func (v *Viewer) isPinned(tHash string) bool {
+	for _, pin := range v.i.Pins {
+		if pin == tHash {
+			return true
+		}
+	}
+	return false
+}
+
+// isArchived determines whether the thread is older than the board's retention at 'now'.
+// Pinned threads are never archived.
+func (v *Viewer) isArchived(tHash string, now time.Time) bool {
+	if v.i.Retention <= 0 || v.isPinned(tHash) {
+		return false
+	}
+	rep, ok := v.c.content[tHash]
+	if !ok {
+		return false
+	}
+	body, ok := rep.Body.(*object.Body)
+	return ok && body.TS < now.AddDate(0, 0, -v.i.Retention).UnixNano()
+}
+
+// checkPerspective ensures that the perspective user is known to the board.
+// An unknown perspective is treated as anonymous unless 'strict' is set.
+func (v *Viewer) checkPerspective(perspective string, strict bool) error {
+	if !strict || perspective == "" || v.i.Users.Has(perspective) {
+		return nil
+	}
+	return ErrUnknownPerspective
+}
+
+// viewVotes obtains the view of votes of content from perspective.
+// The view always carries the content hash, even if the content has no votes.
+// If 'hideBlocked' is set, votes of users blocked by perspective are excluded.
+func (v *Viewer) viewVotes(hash, perspective string, hideBlocked bool) *VoteRepView {
+	votes, ok := v.c.votes[hash]
+	if !ok {
+		return &VoteRepView{Ref: hash}
+	}
+	var view *VoteRepView
+	if profile, ok := v.c.profiles[perspective]; hideBlocked && ok && len(profile.Blocked) > 0 {
+		view = votes.ViewExcluding(perspective, profile.Blocked)
+	} else {
+		view = v.c.GetVoteView(hash, perspective, votes)
+	}
+	view.Ref = hash
+	return view
+}
+
+// Ways of showing content of users muted by perspective.
+const (
+	MutedShow     = ""         // Shown as is.
+	MutedHide     = "hide"     // Excluded.
+	MutedCollapse = "collapse" // Marked as collapsed.
+)
+
+// checkMuted ensures that the way of showing muted content is known.
+func checkMuted(muted string) error {
+	switch muted {
+	case MutedShow, MutedHide, MutedCollapse:
+		return nil
+	default:
+		return boo.Newf(boo.InvalidInput, "invalid muted option '%s', expected '%s' or '%s'",
+			muted, MutedHide, MutedCollapse)
+	}
+}
+
+// mutedBy obtains the users muted by perspective, being those it has blocked or marked as spam.
+// Returns nil if perspective has muted no one.
+func (v *Viewer) mutedBy(perspective string) map[string]struct{} {
+	profile, ok := v.c.profiles[perspective]
+	if !ok || len(profile.Blocked)+len(profile.MarkedAsSpam) == 0 {
+		return nil
+	}
+	muted := make(map[string]struct{}, len(profile.Blocked)+len(profile.MarkedAsSpam))
+	for upk := range profile.Blocked {
+		muted[upk] = struct{}{}
+	}
+	for upk := range profile.MarkedAsSpam {
+		muted[upk] = struct{}{}
+	}
+	return muted
+}
+
+// isMuted determines whether content of hash was created by a muted user.
+func (v *Viewer) isMuted(muted map[string]struct{}, hash string) bool {
+	if len(muted) == 0 {
+		return false
+	}
+	rep, ok := v.c.content[hash]
+	if !ok {
+		return false
+	}
+	body, ok := rep.Body.(*object.Body)
+	if !ok {
+		return false
+	}
+	_, ok = muted[body.Creator]
+	return ok
+}
+
+// hasVotable determines whether content of hash exists or has votes.
+func (v *Viewer) hasVotable(hash string) bool {
+	if _, ok := v.c.votes[hash]; ok {
+		return true
+	}
+	_, ok := v.c.content[hash]
+	return ok
+}
+
+// threadStats obtains the activity of the thread.
+func (v *Viewer) threadStats(tHash string) *object.ThreadStats {
+	out := &object.ThreadStats{
+		Participants: len(v.i.Participants[tHash]),
+	}
+	if rep, ok := v.c.content[tHash]; ok {
+		out.LastActivity = rep.Body.(*object.Body).TS
+	}
+	if posts, ok := v.i.PostsOfThread[tHash]; ok {
+		out.PostCount = posts.Len()
+	}
+	if pHash, ok := v.i.LastPost[tHash]; ok {
+		if rep, ok := v.c.content[pHash]; ok {
+			out.LastActivity = rep.Body.(*object.Body).TS
+		}
+	}
+	return out
+}
+
+// countPostsSince counts the posts of thread that are created after given unix time.
+// Returns 0 if 'since' is not set.
+func (v *Viewer) countPostsSince(tHash string, since int64) int {
+	posts, ok := v.i.PostsOfThread[tHash]
+	if since <= 0 || !ok {
+		return 0
+	}
+	pHashes, e := posts.Get(&typ.PaginatedInput{
+		StartIndex: 0,
+		PageSize:   math.MaxUint64,
+	})
+	if e != nil {
+		return 0
+	}
+	var (
+		sinceNano = time.Unix(since, 0).UnixNano()
+		count     = 0
+	)
+	for _, pHash := range pHashes.Data {
+		if rep, ok := v.c.content[pHash]; ok {
+			if body, ok := rep.Body.(*object.Body); ok && body.TS > sinceNano {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// pageIndex obtains the index (within all elements) of the i'th element of a page.
+func pageIndex(in *typ.PaginatedInput, i int) int {
+	if in.Reverse {
+		return int(in.StartIndex) - i
+	}
+	return int(in.StartIndex) + i
+}
+
+// minimalRep obtains a copy of the representation without votes and counts.
+// viewRep obtains a copy of the compiled representation of content, to be decorated
+// with the state of a request. Compiled representations are shared, and are never
+// to be modified outside of compilation. Returns nil if the content is not found.
+func (v *Viewer) viewRep(hash string) *object.ContentRep {
+	rep, ok := v.c.content[hash]
+	if !ok {
+		return nil
+	}
+	cp := *rep
+	return &cp
+}
+
+func minimalRep(rep *object.ContentRep) *object.ContentRep {
+	return &object.ContentRep{
+		PubKey: rep.PubKey,
+		Header: rep.Header,
+		Body:   rep.Body,
+	}
+}
+
+func isOfType(rep *object.ContentRep, t object.ContentType) bool {
+	body, ok := rep.Body.(*object.Body)
+	return ok && body.Type == t
+}
+
 func checkBoardRef(expected cipher.PubKey, body *object.Body, what string) error {
 	if got, e := body.GetOfBoard(); e != nil {
 		return boo.WrapTypef(e, boo.InvalidRead, "corrupt %s", what)
 	} else if got != expected {
 		return boo.Newf(boo.InvalidRead,
-			"misplaced %s, unmatched board public key", what)
+			"misplaced %s, unmatched board public key: got '%s', expected '%s'",
+			what, got.Hex(), expected.Hex())
 	} else {
 		return nil
 	}
@@ -568,8 +2299,17 @@ func checkThreadRef(expected cipher.SHA256, body *object.Body, what string) erro
 		return boo.WrapTypef(e, boo.InvalidRead, "corrupt %s", what)
 	} else if got != expected {
 		return boo.Newf(boo.InvalidRead,
-			"misplaced %s, unmatched board public key", what)
+			"misplaced %s, unmatched thread reference: got '%s', expected '%s'",
+			what, got.Hex(), expected.Hex())
 	} else {
 		return nil
 	}
 }
+
+// truncate shortens a string to at most 'n' characters.
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
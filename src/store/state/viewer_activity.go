@@ -0,0 +1,191 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"sort"
+)
+
+// ActivityIn represents the input required to obtain the activity feed of a board.
+type ActivityIn struct {
+	Perspective       string
+	HideBlockedVotes  bool // Whether to exclude votes of users blocked by perspective.
+	StrictPerspective bool // Whether an unknown perspective results in an error.
+	MaxPerThread      int  // Maximum number of items of the same thread, <= 0 for no limit.
+	PaginatedInput    typ.PaginatedInput
+}
+
+// ActivityItem represents a thread or post in the activity feed.
+type ActivityItem struct {
+	Thread  string             `json:"thread"` // Hash of thread the item belongs to.
+	Content *object.ContentRep `json:"content"`
+}
+
+// ActivityOut represents the output for the activity feed of a board.
+type ActivityOut struct {
+	ItemsMeta *typ.PaginatedOutput `json:"items_meta"`
+	Items     []*ActivityItem      `json:"items"`
+}
+
+// GetActivityFeed obtains the threads and posts of the board, newest first.
+// With 'MaxPerThread' set, further items of a thread are left out so that
+// a single active thread does not fill the feed.
+func (v *Viewer) GetActivityFeed(in *ActivityIn) (*ActivityOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
+
+	var all []*ActivityItem
+	for _, rep := range v.c.content {
+		body, ok := rep.Body.(*object.Body)
+		if !ok {
+			continue
+		}
+		switch body.Type {
+		case object.V5ThreadType:
+			all = append(all, &ActivityItem{Thread: rep.Header.Hash, Content: rep})
+		case object.V5PostType:
+			all = append(all, &ActivityItem{Thread: body.OfThread, Content: rep})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		iTS, jTS := activityTS(all[i]), activityTS(all[j])
+		if iTS != jTS {
+			return iTS > jTS
+		}
+		return all[i].Content.Header.Hash < all[j].Content.Header.Hash
+	})
+
+	items := all
+	if in.MaxPerThread > 0 {
+		items = make([]*ActivityItem, 0, len(all))
+		counts := make(map[string]int)
+		for _, item := range all {
+			if counts[item.Thread] >= in.MaxPerThread {
+				continue
+			}
+			counts[item.Thread]++
+			items = append(items, item)
+		}
+	}
+
+	meta, e := typ.NewPaginatedOutput(&in.PaginatedInput, uint(len(items)))
+	if e != nil {
+		return nil, e
+	}
+	out := &ActivityOut{
+		ItemsMeta: meta,
+		Items:     make([]*ActivityItem, meta.RecordCount),
+	}
+	for i := range out.Items {
+		item := items[pageIndex(&in.PaginatedInput, i)]
+		rep := minimalRep(item.Content)
+		rep.ByOwner = item.Content.ByOwner
+		rep.Votes = v.viewVotes(item.Content.Header.Hash, in.Perspective, in.HideBlockedVotes)
+		rep.Score = v.c.GetScore(item.Content.Header.Hash)
+		out.Items[i] = &ActivityItem{
+			Thread:  item.Thread,
+			Content: v.transformRep(rep, in.Perspective),
+		}
+	}
+	return out, nil
+}
+
+func activityTS(item *ActivityItem) int64 {
+	return item.Content.Body.(*object.Body).TS
+}
+
+/*
+	<<< USER ACTIVITY >>>
+*/
+
+// UserActivityIn represents the input required to obtain the activity of a user.
+type UserActivityIn struct {
+	UserPubKey        string
+	Perspective       string
+	HideBlockedVotes  bool               // Whether to exclude votes of users blocked by perspective.
+	StrictPerspective bool               // Whether an unknown perspective results in an error.
+	PaginatedInput    typ.PaginatedInput // Applied to each of threads, posts and votes.
+}
+
+// UserActivityOut represents the threads created, posts written and votes cast by a user.
+// Each list is in the order the content is indexed.
+type UserActivityOut struct {
+	ThreadsMeta *typ.PaginatedOutput `json:"threads_meta"`
+	Threads     []*object.ContentRep `json:"threads"`
+	PostsMeta   *typ.PaginatedOutput `json:"posts_meta"`
+	Posts       []*object.ContentRep `json:"posts"`
+	VotesMeta   *typ.PaginatedOutput `json:"votes_meta"`
+	Votes       []*ResolvedVote      `json:"votes"`
+}
+
+// GetUserActivity obtains the threads, posts and votes of a user in the board,
+// under any key of their identity.
+// A user without activity has empty lists.
+func (v *Viewer) GetUserActivity(in *UserActivityIn) (*UserActivityOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
+
+	var (
+		keys             = v.identityKeys(in.UserPubKey)
+		tHashes, pHashes []string
+	)
+	for _, hash := range v.identityHashes(keys, v.i.ContentOfUser, v.contentTS) {
+		rep, ok := v.c.content[hash]
+		switch {
+		case !ok:
+		case isOfType(rep, object.V5ThreadType):
+			tHashes = append(tHashes, hash)
+		case isOfType(rep, object.V5PostType):
+			pHashes = append(pHashes, hash)
+		}
+	}
+	vHashes := v.identityHashes(keys, v.i.VotesByUser, func(hash string) int64 {
+		return v.i.VoteOfHash[hash].TS
+	})
+
+	var (
+		out = new(UserActivityOut)
+		e   error
+	)
+	if out.ThreadsMeta, out.Threads, e = v.userContentPage(in, tHashes); e != nil {
+		return nil, e
+	}
+	if out.PostsMeta, out.Posts, e = v.userContentPage(in, pHashes); e != nil {
+		return nil, e
+	}
+	if out.VotesMeta, e = typ.GetPage(&in.PaginatedInput, vHashes); e != nil {
+		return nil, e
+	}
+	out.Votes = make([]*ResolvedVote, len(out.VotesMeta.Data))
+	for i, hash := range out.VotesMeta.Data {
+		out.Votes[i] = resolveVote(hash, v.i.VoteOfHash[hash])
+	}
+	return out, nil
+}
+
+func (v *Viewer) userContentPage(in *UserActivityIn, hashes []string) (*typ.PaginatedOutput, []*object.ContentRep, error) {
+	meta, e := typ.GetPage(&in.PaginatedInput, hashes)
+	if e != nil {
+		return nil, nil, e
+	}
+	reps := make([]*object.ContentRep, len(meta.Data))
+	for i, hash := range meta.Data {
+		rep := v.c.content[hash]
+		reps[i] = minimalRep(rep)
+		reps[i].ByOwner = rep.ByOwner
+		reps[i].Votes = v.viewVotes(hash, in.Perspective, in.HideBlockedVotes)
+		reps[i].Score = v.c.GetScore(hash)
+	}
+	v.transformReps(reps, in.Perspective)
+	return meta, reps, nil
+}
@@ -0,0 +1,228 @@
+package state
+
+import (
+	"encoding/json"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/tag"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/skycoin/src/cipher"
+	"io"
+	"sort"
+	"time"
+)
+
+// ArchiveVersion is the version of the board archive format.
+// Archives of other versions are not imported.
+const ArchiveVersion = 2
+
+/*
+	<<< ARCHIVE >>>
+*/
+
+// Archive is a portable copy of a board's signed content, for migration and backup.
+// Unlike the exported CXO pages, it holds no secret key and does not depend on how
+// content is laid out in CXO.
+type Archive struct {
+	Version     int               `json:"version"`
+	Board       string            `json:"board"`    // Public key of board.
+	Exported    int64             `json:"exported"` // Time of export (unix nanoseconds).
+	Content     *ArchiveContent   `json:"content"`  // Board content.
+	Threads     []*ArchiveThread  `json:"threads"`
+	Votes       []*ArchiveContent `json:"votes"`       // Current thread, post and user votes, oldest first.
+	Profiles    []*ArchiveContent `json:"profiles"`    // Latest self-profile submission of each user.
+	Attachments []*ArchiveContent `json:"attachments"` // Attachments of posts, oldest first.
+	Rotations   []*ArchiveContent `json:"rotations"`   // Key rotations of users, oldest first.
+	Listings    []*ArchiveContent `json:"listings"`    // Latest announcement of each listed board.
+}
+
+// ArchiveThread is a thread and it's posts, in the order they were indexed.
+type ArchiveThread struct {
+	Thread *ArchiveContent   `json:"thread"`
+	Posts  []*ArchiveContent `json:"posts"`
+}
+
+// ArchiveContent is signed content of an archive. The body is kept as the bytes
+// signed by it's creator, as re-encoding it does not necessarily reproduce them.
+type ArchiveContent struct {
+	Header  *object.ContentHeaderData `json:"header"`
+	Body    *object.Body              `json:"body"`     // Decoded body, for readability.
+	RawBody []byte                    `json:"raw_body"` // Body as signed.
+}
+
+func toArchiveContent(rep *object.ContentRep) *ArchiveContent {
+	return &ArchiveContent{
+		Header:  rep.Header,
+		Body:    rep.Body.(*object.Body),
+		RawBody: rep.RawBody,
+	}
+}
+
+// ToContent obtains the content, ensuring that the raw body matches the header's hash
+// and, if the body has a creator, is signed by them. The decoded body is replaced
+// by that of the raw body.
+func (ac *ArchiveContent) ToContent() (*object.Content, error) {
+	if ac.Header == nil || len(ac.RawBody) == 0 {
+		return nil, boo.New(boo.InvalidRead, "archived content has no header or body")
+	}
+	raw := ac.RawBody
+	body, e := object.NewBody(raw)
+	if e != nil {
+		return nil, boo.WrapType(e, boo.InvalidRead, "failed to decode archived content")
+	}
+	ac.Body = body
+	if hash := cipher.SumSHA256(raw); hash.Hex() != ac.Header.Hash {
+		return nil, boo.Newf(boo.InvalidRead,
+			"archived content of hash '%s' does not match it's body", ac.Header.Hash)
+	}
+	if ac.Body.Creator != "" {
+		creator, e := ac.Body.GetCreator()
+		if e != nil {
+			return nil, boo.WrapType(e, boo.InvalidRead, "invalid archived content")
+		}
+		if e := ac.Header.Verify(creator); e != nil {
+			return nil, e
+		}
+	}
+	c := new(object.Content)
+	c.SetHeader(ac.Header)
+	c.SetBodyRaw(raw)
+	return c, nil
+}
+
+// ExportBoard writes an archive of the board's threads, posts, votes, profiles,
+// attachments, key rotations and board announcements as JSON.
+func (v *Viewer) ExportBoard(w io.Writer) error {
+	if v == nil {
+		return ErrViewerNotInitialized
+	}
+	archive, e := v.archive()
+	if e != nil {
+		return e
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if e := enc.Encode(archive); e != nil {
+		return boo.WrapType(e, boo.Internal, "failed to write archive")
+	}
+	return nil
+}
+
+// archive obtains the archive of the board.
+func (v *Viewer) archive() (*Archive, error) {
+	defer v.lock()()
+
+	board, ok := v.c.content[v.i.Board]
+	if !ok {
+		return nil, boo.New(boo.Internal, "board content not found")
+	}
+	out := &Archive{
+		Version:     ArchiveVersion,
+		Board:       v.pk.Hex(),
+		Exported:    time.Now().UnixNano(),
+		Content:     toArchiveContent(board),
+		Threads:     []*ArchiveThread{},
+		Votes:       []*ArchiveContent{},
+		Profiles:    []*ArchiveContent{},
+		Attachments: []*ArchiveContent{},
+		Rotations:   []*ArchiveContent{},
+		Listings:    []*ArchiveContent{},
+	}
+	for _, tHash := range listOf(v.i.Threads) {
+		rep, ok := v.c.content[tHash]
+		if !ok {
+			continue
+		}
+		thread := &ArchiveThread{
+			Thread: toArchiveContent(rep),
+			Posts:  []*ArchiveContent{},
+		}
+		if posts, ok := v.i.PostsOfThread[tHash]; ok {
+			for _, pHash := range listOf(posts) {
+				if rep, ok := v.c.content[pHash]; ok {
+					thread.Posts = append(thread.Posts, toArchiveContent(rep))
+				}
+			}
+		}
+		out.Threads = append(out.Threads, thread)
+	}
+	for _, votes := range v.c.votes {
+		for _, vote := range votes.Votes {
+			out.Votes = append(out.Votes, &ArchiveContent{
+				Header:  vote.GetHeader(),
+				Body:    vote.GetBody(),
+				RawBody: vote.Body,
+			})
+		}
+		for _, report := range votes.Reports {
+			out.Votes = append(out.Votes, &ArchiveContent{
+				Header:  report.GetHeader(),
+				Body:    report.GetBody(),
+				RawBody: report.Body,
+			})
+		}
+	}
+	for _, ofVoter := range v.c.userVotes {
+		for _, rep := range ofVoter {
+			out.Votes = append(out.Votes, toArchiveContent(rep))
+		}
+	}
+	for _, rep := range v.c.selfProfiles {
+		out.Profiles = append(out.Profiles, toArchiveContent(rep))
+	}
+	for _, rep := range v.c.attachments {
+		out.Attachments = append(out.Attachments, toArchiveContent(rep))
+	}
+	for _, rep := range v.c.rotations {
+		out.Rotations = append(out.Rotations, toArchiveContent(rep))
+	}
+	for _, rep := range v.c.listings {
+		out.Listings = append(out.Listings, toArchiveContent(rep))
+	}
+	sortArchiveContent(out.Votes)
+	sortArchiveContent(out.Profiles)
+	sortArchiveContent(out.Attachments)
+	sortArchiveContent(out.Rotations)
+	sortArchiveContent(out.Listings)
+	return out, nil
+}
+
+// sortArchiveContent orders content oldest first, and by hash for equal timestamps.
+func sortArchiveContent(list []*ArchiveContent) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Body.TS != list[j].Body.TS {
+			return list[i].Body.TS < list[j].Body.TS
+		}
+		return list[i].Header.Hash < list[j].Header.Hash
+	})
+}
+
+// ReadArchive reads a board archive written by ExportBoard.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	archive := new(Archive)
+	if e := json.NewDecoder(r).Decode(archive); e != nil {
+		return nil, boo.WrapType(e, boo.InvalidRead, "failed to decode archive")
+	}
+	switch {
+	case archive.Version != ArchiveVersion:
+		return nil, boo.Newf(boo.InvalidRead,
+			"archive is of version %d, expected %d", archive.Version, ArchiveVersion)
+	case archive.Content == nil:
+		return nil, boo.New(boo.InvalidRead, "archive has no board content")
+	}
+	pk, e := archive.GetPubKey()
+	if e != nil {
+		return nil, boo.WrapType(e, boo.InvalidRead, "invalid archive board public key")
+	}
+	if _, e := archive.Content.ToContent(); e != nil {
+		return nil, e
+	}
+	if e := archive.Content.Header.Verify(pk); e != nil {
+		return nil, boo.WrapType(e, boo.InvalidRead, "archived board content is not signed by board")
+	}
+	return archive, nil
+}
+
+// GetPubKey obtains the public key of the archived board.
+func (a *Archive) GetPubKey() (cipher.PubKey, error) {
+	return tag.GetPubKey(a.Board)
+}
@@ -0,0 +1,139 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ/paginatedtypes"
+	"github.com/skycoin/bbs/src/store/object"
+)
+
+// Attachment represents a file attached to a post, with it's data.
+type Attachment struct {
+	Hash      string `json:"hash"` // Hash of attachment content.
+	OfPost    string `json:"of_post"`
+	Name      string `json:"name"`
+	MediaType string `json:"media_type"`
+	Creator   string `json:"creator"`
+	TS        int64  `json:"ts"`
+	Data      []byte `json:"data"`
+}
+
+// addAttachment indexes an attachment under it's post.
+// The post needs to exist and be created by the creator of the attachment.
+func (v *Viewer) addAttachment(c *object.Content, b *object.Body, h *object.ContentHeaderData) error {
+	if e := v.checkAttachmentOf(b); e != nil {
+		return e
+	}
+	list, ok := v.i.AttachmentsOfPost[b.OfPost]
+	if !ok {
+		list = paginatedtypes.NewMapped()
+		v.i.AttachmentsOfPost[b.OfPost] = list
+	}
+	list.Append(h.Hash)
+	v.c.attachments[h.Hash] = &object.ContentRep{Header: h, Body: b, RawBody: c.Body}
+	return nil
+}
+
+// deleteAttachments removes the attachments of a post.
+func (v *Viewer) deleteAttachments(pHash string) {
+	list, ok := v.i.AttachmentsOfPost[pHash]
+	if !ok {
+		return
+	}
+	for _, aHash := range listOf(list) {
+		delete(v.c.attachments, aHash)
+	}
+	delete(v.i.AttachmentsOfPost, pHash)
+}
+
+// checkAttachmentOf ensures that the post of an attachment exists and is created by
+// the creator of the attachment.
+func (v *Viewer) checkAttachmentOf(b *object.Body) error {
+	rep, ok := v.c.content[b.OfPost]
+	if !ok {
+		return boo.Newf(boo.NotFound, "post of hash %s not found", b.OfPost)
+	}
+	post, ok := rep.Body.(*object.Body)
+	if !ok || post.Type != object.V5PostType {
+		return boo.Newf(boo.InvalidInput, "content of hash %s is not a post", b.OfPost)
+	}
+	if post.Creator != b.Creator {
+		return boo.Newf(boo.NotAllowed,
+			"only the creator of post %s can attach files to it", b.OfPost)
+	}
+	return nil
+}
+
+// CheckAttachment ensures that an attachment can be submitted to it's post.
+func (v *Viewer) CheckAttachment(b *object.Body) error {
+	if v == nil {
+		return ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if e := v.checkAttachmentOf(b); e != nil {
+		return e
+	}
+	if list, ok := v.i.AttachmentsOfPost[b.OfPost]; ok && list.Len() >= MaxAttachmentsOfPost {
+		return boo.Newf(boo.NotAllowed,
+			"post %s already has the maximum of %d attachments", b.OfPost, MaxAttachmentsOfPost)
+	}
+	return nil
+}
+
+// attachmentRefs obtains the attachments of a post, without their data.
+// Returns nil if the post has no attachments.
+func (v *Viewer) attachmentRefs(pHash string) []*object.AttachmentRef {
+	list, ok := v.i.AttachmentsOfPost[pHash]
+	if !ok {
+		return nil
+	}
+	aHashes := listOf(list)
+	out := make([]*object.AttachmentRef, len(aHashes))
+	for i, aHash := range aHashes {
+		b := v.c.attachments[aHash].Body.(*object.Body)
+		out[i] = &object.AttachmentRef{
+			Hash:      aHash,
+			Name:      b.Name,
+			MediaType: b.MediaType,
+			Size:      len(b.Data),
+		}
+	}
+	return out
+}
+
+// GetAttachments obtains the attachments of a post, without their data.
+func (v *Viewer) GetAttachments(pHash string) ([]*object.AttachmentRef, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if _, ok := v.c.content[pHash]; !ok {
+		return nil, boo.Newf(boo.NotFound, "post of hash '%s' not found", pHash)
+	}
+	out := v.attachmentRefs(pHash)
+	if out == nil {
+		out = []*object.AttachmentRef{}
+	}
+	return out, nil
+}
+
+// GetAttachment obtains an attachment with it's data.
+func (v *Viewer) GetAttachment(hash string) (*Attachment, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	rep, ok := v.c.attachments[hash]
+	if !ok {
+		return nil, boo.Newf(boo.NotFound, "attachment of hash '%s' not found", hash)
+	}
+	b := rep.Body.(*object.Body)
+	return &Attachment{
+		Hash:      hash,
+		OfPost:    b.OfPost,
+		Name:      b.Name,
+		MediaType: b.MediaType,
+		Creator:   b.Creator,
+		TS:        b.TS,
+		Data:      b.Data,
+	}, nil
+}
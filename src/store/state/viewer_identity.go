@@ -0,0 +1,179 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"sort"
+)
+
+// Identity represents a user across the public keys they rotated through.
+type Identity struct {
+	Identity string   `json:"identity"` // Canonical identity (the first public key).
+	Current  string   `json:"current"`  // Latest public key.
+	Keys     []string `json:"keys"`     // Public keys, oldest first.
+}
+
+// checkKeyRotation ensures that a key rotation is valid, and that it neither rotates
+// a key twice, binds a key twice nor forms a cycle of keys.
+func (v *Viewer) checkKeyRotation(b *object.Body) error {
+	if e := b.VerifyKeyRotation(); e != nil {
+		return e
+	}
+	from, to := b.OfUser, b.Creator
+	if next, ok := v.i.RotatedTo[from]; ok {
+		return boo.Newf(boo.AlreadyExists,
+			"key %s is already rotated to %s", from, next)
+	}
+	if prev, ok := v.i.RotatedFrom[to]; ok {
+		return boo.Newf(boo.AlreadyExists,
+			"key %s is already rotated from %s", to, prev)
+	}
+	if v.identityOf(from) == to {
+		return boo.Newf(boo.NotAllowed,
+			"rotating key %s to %s forms a cycle", from, to)
+	}
+	return nil
+}
+
+// CheckKeyRotation ensures that a key rotation can be submitted.
+func (v *Viewer) CheckKeyRotation(b *object.Body) error {
+	if v == nil {
+		return ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	return v.checkKeyRotation(b)
+}
+
+// addKeyRotation binds the key rotated to with the identity of the key rotated from.
+func (v *Viewer) addKeyRotation(c *object.Content, b *object.Body, h *object.ContentHeaderData) error {
+	if e := v.checkKeyRotation(b); e != nil {
+		return e
+	}
+	v.setKeyRotation(b.OfUser, b.Creator)
+	v.c.rotations[b.Creator] = &object.ContentRep{Header: h, Body: b, RawBody: c.Body}
+	v.i.RefUser(b.OfUser)
+	v.i.RefUser(b.Creator)
+	return nil
+}
+
+func (v *Viewer) setKeyRotation(from, to string) {
+	v.i.RotatedTo[from] = to
+	v.i.RotatedFrom[to] = from
+}
+
+// identityOf obtains the canonical identity of a public key, which is the first key
+// it was rotated from. A key that was never rotated to is it's own identity.
+func (v *Viewer) identityOf(upk string) string {
+	for i := 0; i <= len(v.i.RotatedFrom); i++ {
+		prev, ok := v.i.RotatedFrom[upk]
+		if !ok {
+			break
+		}
+		upk = prev
+	}
+	return upk
+}
+
+// identityKeys obtains the public keys of the identity of a public key, oldest first.
+func (v *Viewer) identityKeys(upk string) []string {
+	keys := []string{v.identityOf(upk)}
+	for i := 0; i < len(v.i.RotatedTo); i++ {
+		next, ok := v.i.RotatedTo[keys[len(keys)-1]]
+		if !ok {
+			break
+		}
+		keys = append(keys, next)
+	}
+	return keys
+}
+
+// GetIdentity obtains the identity of a public key.
+func (v *Viewer) GetIdentity(upk string) (*Identity, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if !v.i.Users.Has(upk) {
+		return nil, boo.Newf(boo.NotFound,
+			"user of public key %s is not found", upk)
+	}
+	keys := v.identityKeys(upk)
+	return &Identity{
+		Identity: keys[0],
+		Current:  keys[len(keys)-1],
+		Keys:     keys,
+	}, nil
+}
+
+// identityProfile merges the profiles of the keys of an identity. Counts are summed,
+// the latest self-profile is kept, and related users are replaced by their identities.
+func (v *Viewer) identityProfile(keys []string) *Profile {
+	out := NewProfile()
+	for _, upk := range keys {
+		profile, ok := v.c.profiles[upk]
+		if !ok {
+			continue
+		}
+		out.ThreadCount += profile.ThreadCount
+		out.PostCount += profile.PostCount
+		if _, ok := v.c.selfProfiles[upk]; ok {
+			out.SetSelfProfile(profile.DisplayName, profile.AvatarRef, profile.profileTS)
+		}
+		for _, rel := range []struct{ from, to map[string]struct{} }{
+			{profile.Trusted, out.Trusted},
+			{profile.MarkedAsSpam, out.MarkedAsSpam},
+			{profile.Blocked, out.Blocked},
+			{profile.TrustedBy, out.TrustedBy},
+			{profile.MarkedAsSpamBy, out.MarkedAsSpamBy},
+			{profile.BlockedBy, out.BlockedBy},
+		} {
+			for rpk := range rel.from {
+				if identity := v.identityOf(rpk); identity != keys[0] {
+					rel.to[identity] = struct{}{}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// identityHashes obtains the hashes listed under each key of an identity, ordered by
+// timestamp if the identity has multiple keys.
+func (v *Viewer) identityHashes(keys []string, lists map[string]typ.Paginated, ts func(hash string) int64) []string {
+	var out []string
+	for _, upk := range keys {
+		if list, ok := lists[upk]; ok {
+			out = append(out, listOf(list)...)
+		}
+	}
+	if len(keys) == 1 {
+		return out
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return ts(out[i]) < ts(out[j])
+	})
+	return out
+}
+
+// identityOfRep obtains the identity of the creator of a rep, if it differs from the creator.
+func (v *Viewer) identityOfRep(rep *object.ContentRep) string {
+	body, ok := rep.Body.(*object.Body)
+	if !ok || body.Creator == "" {
+		return ""
+	}
+	if identity := v.identityOf(body.Creator); identity != body.Creator {
+		return identity
+	}
+	return ""
+}
+
+// contentTS obtains the timestamp of a thread or post, 0 if not found.
+func (v *Viewer) contentTS(hash string) int64 {
+	if rep, ok := v.c.content[hash]; ok {
+		if body, ok := rep.Body.(*object.Body); ok {
+			return body.TS
+		}
+	}
+	return 0
+}
@@ -0,0 +1,140 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/tag"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/misc/typ/paginatedtypes"
+	"github.com/skycoin/bbs/src/store/object"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// mentionPattern matches '@<public key>' and '@<alias>' mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.\-]+)`)
+
+// parseMentions obtains the distinct mention keys of a body. Mentions of public
+// keys are keyed by the hex public key, and mentions of aliases by the
+// lower-cased alias. Mentions of the creator are ignored.
+func parseMentions(body *object.Body) []string {
+	var (
+		out  []string
+		seen = make(map[string]struct{})
+	)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body.Body, -1) {
+		key := mentionKey(match[1])
+		if _, ok := seen[key]; ok || key == body.Creator {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, key)
+	}
+	return out
+}
+
+// mentionKey obtains the key a mention of the given public key or alias is indexed under.
+func mentionKey(v string) string {
+	if pk, e := tag.GetPubKey(v); e == nil {
+		return pk.Hex()
+	}
+	return strings.ToLower(v)
+}
+
+// AddMentions indexes the mentions of a post.
+func (i *Indexer) AddMentions(hash string, body *object.Body) {
+	for _, key := range parseMentions(body) {
+		list, ok := i.Mentions[key]
+		if !ok {
+			list = paginatedtypes.NewMapped()
+			i.Mentions[key] = list
+		}
+		list.Append(hash)
+	}
+}
+
+// DeleteMentions removes the mentions of a post from the index.
+func (i *Indexer) DeleteMentions(hash string, body *object.Body) {
+	for _, key := range parseMentions(body) {
+		if list, ok := i.Mentions[key]; ok {
+			if list.Delete(hash); list.Len() == 0 {
+				delete(i.Mentions, key)
+			}
+		}
+	}
+}
+
+// MentionsIn represents the input required to obtain the posts mentioning a user.
+type MentionsIn struct {
+	UserPubKey        string
+	Perspective       string
+	HideBlockedVotes  bool // Whether to exclude votes of users blocked by perspective.
+	StrictPerspective bool // Whether an unknown perspective results in an error.
+	PaginatedInput    typ.PaginatedInput
+}
+
+// MentionsOut represents the posts mentioning a user.
+type MentionsOut struct {
+	MentionsMeta *typ.PaginatedOutput `json:"mentions_meta"`
+	Mentions     []*object.ContentRep `json:"mentions"`
+}
+
+// GetMentions obtains the posts that mention a user, newest first.
+// A user is mentioned by '@<public key>', or by '@<alias>' where the alias is
+// the user's current display name (case-insensitive, without whitespace).
+func (v *Viewer) GetMentions(in *MentionsIn) (*MentionsOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
+
+	keys := []string{in.UserPubKey}
+	if profile, ok := v.c.profiles[in.UserPubKey]; ok && profile.DisplayName != "" {
+		keys = append(keys, strings.ToLower(profile.DisplayName))
+	}
+	var (
+		hashes []string
+		seen   = make(map[string]struct{})
+	)
+	for _, key := range keys {
+		list, ok := v.i.Mentions[key]
+		if !ok {
+			continue
+		}
+		for _, hash := range listOf(list) {
+			if _, ok := seen[hash]; ok {
+				continue
+			}
+			rep, ok := v.c.content[hash]
+			if !ok || rep.Body.(*object.Body).Creator == in.UserPubKey {
+				continue
+			}
+			seen[hash] = struct{}{}
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.SliceStable(hashes, func(i, j int) bool {
+		return v.c.content[hashes[i]].Body.(*object.Body).TS >
+			v.c.content[hashes[j]].Body.(*object.Body).TS
+	})
+
+	meta, e := typ.GetPage(&in.PaginatedInput, hashes)
+	if e != nil {
+		return nil, e
+	}
+	out := &MentionsOut{
+		MentionsMeta: meta,
+		Mentions:     make([]*object.ContentRep, len(meta.Data)),
+	}
+	for i, hash := range meta.Data {
+		rep := v.c.content[hash]
+		out.Mentions[i] = minimalRep(rep)
+		out.Mentions[i].ByOwner = rep.ByOwner
+		out.Mentions[i].Votes = v.viewVotes(hash, in.Perspective, in.HideBlockedVotes)
+		out.Mentions[i].Score = v.c.GetScore(hash)
+	}
+	v.transformReps(out.Mentions, in.Perspective)
+	return out, nil
+}
@@ -0,0 +1,79 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/store/object"
+)
+
+// ErrBoardArchived occurs when submitting to an archived board.
+var ErrBoardArchived = boo.New(boo.NotAllowed, "board is archived and does not accept submissions")
+
+// PolicyView represents the submission policy of a board, as it applies to a user.
+type PolicyView struct {
+	Policy   *object.SubmissionPolicy `json:"policy,omitempty"` // Nil if the board has no restrictions.
+	Eligible bool                     `json:"eligible"`         // Whether the user may submit threads and posts.
+	Reason   string                   `json:"reason,omitempty"` // Why the user is not eligible.
+}
+
+// CheckSubmissionPolicy ensures that the user may submit threads and posts
+// under the board's submission policy.
+func (v *Viewer) CheckSubmissionPolicy(upk string) error {
+	if v == nil {
+		return ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	return v.checkPolicy(upk)
+}
+
+// CheckNotArchived ensures that the board is not archived, and so accepts submissions.
+func (v *Viewer) CheckNotArchived() error {
+	if v == nil {
+		return ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if v.i.Archived {
+		return ErrBoardArchived
+	}
+	return nil
+}
+
+// policyView obtains the submission policy as it applies to the perspective user.
+func (v *Viewer) policyView(perspective string) *PolicyView {
+	out := &PolicyView{Policy: v.i.Policy, Eligible: true}
+	if v.i.Archived {
+		out.Eligible = false
+		out.Reason = ErrBoardArchived.Error()
+	} else if e := v.checkPolicy(perspective); e != nil {
+		out.Eligible = false
+		out.Reason = e.Error()
+	}
+	return out
+}
+
+func (v *Viewer) checkPolicy(upk string) error {
+	policy, owner := v.i.Policy, v.pk.Hex()
+	if policy == nil || upk == owner {
+		return nil
+	}
+	if policy.TrustedOnly {
+		var trusted bool
+		if profile, ok := v.c.profiles[owner]; ok {
+			_, trusted = profile.Trusted[upk]
+		}
+		if !trusted {
+			return boo.New(boo.NotAllowed, "board only accepts submissions of users trusted by the board owner")
+		}
+	}
+	if policy.MinReputation != 0 {
+		var reputation int
+		if profile, ok := v.c.profiles[upk]; ok {
+			reputation = profile.Reputation()
+		}
+		if reputation < policy.MinReputation {
+			return boo.Newf(boo.NotAllowed,
+				"board only accepts submissions of users with reputation of at least %d, got %d",
+				policy.MinReputation, reputation)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,29 @@
 package state
 
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"math"
+	"sort"
+)
+
+const (
+	// TrustDecay is the factor that trust decays by for each step beyond direct trust.
+	TrustDecay = 0.5
+
+	// MaxTrustDepth is the maximum distance along trust that a trust score propagates.
+	MaxTrustDepth = 3
+)
+
 type Profile struct {
+	ThreadCount int // Number of threads created.
+	PostCount   int // Number of posts created.
+
+	DisplayName string // From the latest self-profile submission.
+	AvatarRef   string // From the latest self-profile submission.
+	profileTS   int64  // Timestamp of the latest self-profile submission.
+
 	Trusted      map[string]struct{}
 	MarkedAsSpam map[string]struct{}
 	Blocked      map[string]struct{}
@@ -22,6 +45,12 @@ func NewProfile() *Profile {
 }
 
 type ProfileView struct {
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarRef   string `json:"avatar_ref,omitempty"`
+	ThreadCount int    `json:"thread_count"`
+	PostCount   int    `json:"post_count"`
+	Reputation  int    `json:"reputation"`
+
 	TrustedCount      int      `json:"trusted_count"`
 	Trusted           []string `json:"trusted"`
 	MarkedAsSpamCount int      `json:"marked_as_spam_count"`
@@ -37,59 +66,103 @@ type ProfileView struct {
 	BlockedBy           []string `json:"blocked_by"`
 }
 
+// Profile view fields that can be selected.
+const (
+	TrustedField        = "trusted"
+	MarkedAsSpamField   = "marked_as_spam"
+	BlockedField        = "blocked"
+	TrustedByField      = "trusted_by"
+	MarkedAsSpamByField = "marked_as_spam_by"
+	BlockedByField      = "blocked_by"
+)
+
+// SetSelfProfile sets the display name and avatar from a self-profile submission,
+// unless a later one has already been set.
+func (p *Profile) SetSelfProfile(name, avatarRef string, ts int64) {
+	if ts < p.profileTS {
+		return
+	}
+	p.DisplayName, p.AvatarRef, p.profileTS = name, avatarRef, ts
+}
+
+// View obtains the full view of the profile.
 func (p *Profile) View() *ProfileView {
+	view, _ := p.ViewFields(nil)
+	return view
+}
+
+// ViewFields obtains a view of the profile with only the selected membership lists filled.
+// Counts are always filled. Empty 'fields' obtains the full view.
+func (p *Profile) ViewFields(fields []string) (*ProfileView, error) {
 	view := &ProfileView{
+		DisplayName:         p.DisplayName,
+		AvatarRef:           p.AvatarRef,
+		ThreadCount:         p.ThreadCount,
+		PostCount:           p.PostCount,
+		Reputation:          p.Reputation(),
 		TrustedCount:        len(p.Trusted),
-		Trusted:             make([]string, len(p.Trusted)),
 		MarkedAsSpamCount:   len(p.MarkedAsSpam),
-		MarkedAsSpam:        make([]string, len(p.MarkedAsSpam)),
 		BlockedCount:        len(p.Blocked),
-		Blocked:             make([]string, len(p.Blocked)),
 		TrustedByCount:      len(p.TrustedBy),
-		TrustedBy:           make([]string, len(p.TrustedBy)),
 		MarkedAsSpamByCount: len(p.MarkedAsSpamBy),
-		MarkedAsSpamBy:      make([]string, len(p.MarkedAsSpamBy)),
 		BlockedByCount:      len(p.BlockedBy),
-		BlockedBy:           make([]string, len(p.BlockedBy)),
-	}
-
-	i := 0
-	for k := range p.Trusted {
-		view.Trusted[i] = k
-		i++
 	}
 
-	i = 0
-	for k := range p.MarkedAsSpam {
-		view.MarkedAsSpam[i] = k
-		i++
+	if len(fields) == 0 {
+		fields = []string{
+			TrustedField, MarkedAsSpamField, BlockedField,
+			TrustedByField, MarkedAsSpamByField, BlockedByField,
+		}
 	}
 
-	i = 0
-	for k := range p.Blocked {
-		view.Blocked[i] = k
-		i++
+	for _, field := range fields {
+		switch field {
+		case TrustedField:
+			view.Trusted = keysOf(p.Trusted)
+		case MarkedAsSpamField:
+			view.MarkedAsSpam = keysOf(p.MarkedAsSpam)
+		case BlockedField:
+			view.Blocked = keysOf(p.Blocked)
+		case TrustedByField:
+			view.TrustedBy = keysOf(p.TrustedBy)
+		case MarkedAsSpamByField:
+			view.MarkedAsSpamBy = keysOf(p.MarkedAsSpamBy)
+		case BlockedByField:
+			view.BlockedBy = keysOf(p.BlockedBy)
+		default:
+			return nil, boo.Newf(boo.InvalidInput,
+				"invalid profile field '%s' requested", field)
+		}
 	}
 
-	i = 0
-	for k := range p.TrustedBy {
-		view.TrustedBy[i] = k
-		i++
-	}
+	return view, nil
+}
 
-	i = 0
-	for k := range p.MarkedAsSpamBy {
-		view.MarkedAsSpamBy[i] = k
-		i++
-	}
+// Reputation obtains the reputation score of the profile.
+// It is the number of users who trust it, less the number of users who marked it as spam or blocked it.
+func (p *Profile) Reputation() int {
+	return len(p.TrustedBy) - len(p.MarkedAsSpamBy) - len(p.BlockedBy)
+}
 
-	i = 0
-	for k := range p.BlockedBy {
-		view.BlockedBy[i] = k
-		i++
+// Relation obtains the membership list of the profile of the given field name.
+func (p *Profile) Relation(field string) (map[string]struct{}, error) {
+	switch field {
+	case TrustedField:
+		return p.Trusted, nil
+	case MarkedAsSpamField:
+		return p.MarkedAsSpam, nil
+	case BlockedField:
+		return p.Blocked, nil
+	case TrustedByField:
+		return p.TrustedBy, nil
+	case MarkedAsSpamByField:
+		return p.MarkedAsSpamBy, nil
+	case BlockedByField:
+		return p.BlockedBy, nil
+	default:
+		return nil, boo.Newf(boo.InvalidInput,
+			"invalid profile relation '%s' requested", field)
 	}
-
-	return view
 }
 
 func (p *Profile) ClearVotesFor(user string) {
@@ -103,3 +176,258 @@ func (p *Profile) ClearVotesBy(user string) {
 	delete(p.MarkedAsSpamBy, user)
 	delete(p.BlockedBy, user)
 }
+
+func keysOf(m map[string]struct{}) []string {
+	out, i := make([]string, len(m)), 0
+	for k := range m {
+		out[i] = k
+		i++
+	}
+	return out
+}
+
+/*
+	<<< TRUST GRAPH >>>
+*/
+
+// Trust graph edge relations.
+const (
+	TrustRelation = "trust"
+	SpamRelation  = "spam"
+	BlockRelation = "block"
+)
+
+// TrustEdge represents a directed trust, spam or block relation between two users.
+type TrustEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Relation string `json:"relation"`
+}
+
+// RangeTrustGraph calls 'action' for every edge of the board's trust graph.
+// Edges are ordered by creator, then relation, then target.
+// Ranging stops when 'action' returns an error, which is then returned.
+func (v *Viewer) RangeTrustGraph(action func(edge TrustEdge) error) error {
+	if v == nil {
+		return ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	users := make([]string, 0, len(v.c.profiles))
+	for upk := range v.c.profiles {
+		users = append(users, upk)
+	}
+	sort.Strings(users)
+	for _, from := range users {
+		profile := v.c.profiles[from]
+		for _, rel := range []struct {
+			name string
+			to   map[string]struct{}
+		}{
+			{TrustRelation, profile.Trusted},
+			{SpamRelation, profile.MarkedAsSpam},
+			{BlockRelation, profile.Blocked},
+		} {
+			to := keysOf(rel.to)
+			sort.Strings(to)
+			for _, upk := range to {
+				if e := action(TrustEdge{From: from, To: upk, Relation: rel.name}); e != nil {
+					return e
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ExportTrustGraph obtains all edges of the board's trust graph.
+// For large boards, prefer RangeTrustGraph.
+func (v *Viewer) ExportTrustGraph() ([]TrustEdge, error) {
+	out := []TrustEdge{}
+	e := v.RangeTrustGraph(func(edge TrustEdge) error {
+		out = append(out, edge)
+		return nil
+	})
+	return out, e
+}
+
+// TrustNetworkIn represents the input required to obtain the trust network of a user.
+type TrustNetworkIn struct {
+	UserPubKey     string
+	Relation       string // Profile field to walk along, empty for 'trusted'.
+	MaxDepth       int    // Maximum distance from user, <= 0 for 1.
+	PaginatedInput typ.PaginatedInput
+}
+
+// TrustNetworkMember represents a user reachable in a trust network.
+type TrustNetworkMember struct {
+	UserPubKey string `json:"user_public_key"`
+	Distance   int    `json:"distance"`
+}
+
+// TrustNetworkOut represents the output for the trust network of a user.
+type TrustNetworkOut struct {
+	UserPubKey  string                `json:"user_public_key"`
+	Relation    string                `json:"relation"`
+	MembersMeta *typ.PaginatedOutput  `json:"members_meta"`
+	Members     []*TrustNetworkMember `json:"members"`
+}
+
+// GetTrustNetwork walks the given relation outwards from user, up to the maximum depth.
+// Members are ordered by distance, then public key.
+func (v *Viewer) GetTrustNetwork(in *TrustNetworkIn) (*TrustNetworkOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+
+	if !v.i.Users.Has(in.UserPubKey) {
+		return nil, boo.Newf(boo.NotFound, "user of public key '%s' is not found",
+			in.UserPubKey)
+	}
+	relation, maxDepth := in.Relation, in.MaxDepth
+	if relation == "" {
+		relation = TrustedField
+	}
+	if maxDepth <= 0 {
+		maxDepth = 1
+	}
+
+	var (
+		members []*TrustNetworkMember
+		visited = map[string]struct{}{in.UserPubKey: {}}
+		current = []string{in.UserPubKey}
+	)
+	for depth := 1; depth <= maxDepth && len(current) > 0; depth++ {
+		var next []string
+		for _, upk := range current {
+			profile, ok := v.c.profiles[upk]
+			if !ok {
+				continue
+			}
+			related, e := profile.Relation(relation)
+			if e != nil {
+				return nil, e
+			}
+			for rpk := range related {
+				if _, ok := visited[rpk]; ok {
+					continue
+				}
+				visited[rpk] = struct{}{}
+				next = append(next, rpk)
+			}
+		}
+		sort.Strings(next)
+		for _, upk := range next {
+			members = append(members, &TrustNetworkMember{UserPubKey: upk, Distance: depth})
+		}
+		current = next
+	}
+
+	meta, e := typ.NewPaginatedOutput(&in.PaginatedInput, uint(len(members)))
+	if e != nil {
+		return nil, e
+	}
+	out := &TrustNetworkOut{
+		UserPubKey:  in.UserPubKey,
+		Relation:    relation,
+		MembersMeta: meta,
+		Members:     make([]*TrustNetworkMember, meta.RecordCount),
+	}
+	for i := range out.Members {
+		out.Members[i] = members[pageIndex(&in.PaginatedInput, i)]
+	}
+	return out, nil
+}
+
+// trustScores obtains the trust scores from a user to all users reachable from it
+// along trust, up to MaxTrustDepth. A user directly trusted has a score of 1, and the
+// score decays by TrustDecay for each further step. Users blocked or marked as spam
+// by 'from' are excluded. 'from' itself has a score of 1.
+func (v *Viewer) trustScores(from string) map[string]float64 {
+	scores := map[string]float64{from: 1}
+	fromProfile, ok := v.c.profiles[from]
+	if !ok {
+		return scores
+	}
+	current := []string{from}
+	for depth := 1; depth <= MaxTrustDepth && len(current) > 0; depth++ {
+		var (
+			next  []string
+			score = math.Pow(TrustDecay, float64(depth-1))
+		)
+		for _, upk := range current {
+			profile, ok := v.c.profiles[upk]
+			if !ok {
+				continue
+			}
+			for tpk := range profile.Trusted {
+				if _, ok := scores[tpk]; ok {
+					continue
+				}
+				if _, ok := fromProfile.Blocked[tpk]; ok {
+					continue
+				}
+				if _, ok := fromProfile.MarkedAsSpam[tpk]; ok {
+					continue
+				}
+				scores[tpk] = score
+				next = append(next, tpk)
+			}
+		}
+		current = next
+	}
+	return scores
+}
+
+// TrustScoreOut represents the trust of one user in another.
+type TrustScoreOut struct {
+	From  string  `json:"from"`
+	To    string  `json:"to"`
+	Score float64 `json:"score"` // 1 for direct trust, decaying by 'TrustDecay' per further step, 0 if not reachable.
+}
+
+// GetTrustScore obtains the trust score from a user to another, being transitive
+// along trust with decay (see TrustDecay and MaxTrustDepth).
+func (v *Viewer) GetTrustScore(from, to string) (*TrustScoreOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+
+	for _, upk := range []string{from, to} {
+		if !v.i.Users.Has(upk) {
+			return nil, boo.Newf(boo.NotFound, "user of public key '%s' is not found", upk)
+		}
+	}
+	return &TrustScoreOut{
+		From:  from,
+		To:    to,
+		Score: v.trustScores(from)[to],
+	}, nil
+}
+
+// trustedBy obtains the trust scores from perspective if 'minTrust' is positive.
+// Returns nil if content should not be filtered by trust.
+func (v *Viewer) trustedBy(perspective string, minTrust float64) map[string]float64 {
+	if minTrust <= 0 || perspective == "" {
+		return nil
+	}
+	return v.trustScores(perspective)
+}
+
+// isDistrusted determines whether content of hash was created by a user of lower
+// trust score than 'minTrust'. Content is not distrusted if 'scores' is nil.
+func (v *Viewer) isDistrusted(scores map[string]float64, minTrust float64, hash string) bool {
+	if scores == nil {
+		return false
+	}
+	rep, ok := v.c.content[hash]
+	if !ok {
+		return false
+	}
+	body, ok := rep.Body.(*object.Body)
+	if !ok {
+		return false
+	}
+	return scores[body.Creator] < minTrust
+}
@@ -0,0 +1,365 @@
+package state
+
+import (
+	"fmt"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/skycoin/src/cipher"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestViewer_GetUserProfile_fields(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	var (
+		apk, _ = cipher.GenerateDeterministicKeyPair([]byte("a user"))
+		bpk, _ = cipher.GenerateDeterministicKeyPair([]byte("b user"))
+		cpk, _ = cipher.GenerateDeterministicKeyPair([]byte("c user"))
+	)
+	addThread(t, bi, 0, []byte("a user"))
+	addThread(t, bi, 1, []byte("b user"))
+	addThread(t, bi, 2, []byte("c user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addUserVote(t, bi, bpk, +1, object.TrustTag, []byte("a user"))
+	addUserVote(t, bi, cpk, -1, object.BlockTag, []byte("a user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		name    string
+		upk     cipher.PubKey
+		fields  []string
+		trusted []string // Expected trusted list.
+		blocked []string // Expected blocked list.
+		errType int
+	}{
+		{"all", apk, nil, []string{bpk.Hex()}, []string{cpk.Hex()}, 0},
+		{"trusted", apk, []string{TrustedField}, []string{bpk.Hex()}, nil, 0},
+		{"blocked", apk, []string{BlockedField}, nil, []string{cpk.Hex()}, 0},
+		{"none_of_user", bpk, []string{TrustedField, BlockedField}, []string{}, []string{}, 0},
+		{"invalid_field", apk, []string{TrustedField, "unknown"}, nil, nil, boo.InvalidInput},
+		{"unknown_user", cipher.PubKey{}, nil, nil, nil, boo.NotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetUserProfile(&UserProfileIn{
+				UserPubKey: c.upk.Hex(),
+				Fields:     c.fields,
+			})
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get user profile:", e)
+			}
+			p := out.Profile
+			if !reflect.DeepEqual(p.Trusted, c.trusted) || !reflect.DeepEqual(p.Blocked, c.blocked) {
+				t.Errorf("got trusted %v and blocked %v, expected %v and %v",
+					p.Trusted, p.Blocked, c.trusted, c.blocked)
+			}
+		})
+	}
+
+	// Counts are filled regardless of the selected fields.
+	out, e := bi.Viewer().GetUserProfile(&UserProfileIn{
+		UserPubKey: apk.Hex(),
+		Fields:     []string{MarkedAsSpamField},
+	})
+	if e != nil {
+		t.Fatal("failed to get user profile:", e)
+	}
+	if p := out.Profile; p.TrustedCount != 1 || p.BlockedCount != 1 || p.Trusted != nil {
+		t.Errorf("got trusted %d %v and blocked %d, expected counts of 1 without lists",
+			p.TrustedCount, p.Trusted, p.BlockedCount)
+	}
+}
+
+func TestViewer_GetUserProfile_counts(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte("author"))
+	addThread(t, bi, 1, []byte("author"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte("author"))
+	addPost(t, bi, tHash, 1, []byte("poster"))
+	addPost(t, bi, tHash, 2, []byte("poster"))
+	addThreadVote(t, bi, tHash, +1, []byte("voter"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		seed    string
+		threads int
+		posts   int
+		errType int
+	}{
+		{"author", 2, 1, 0},
+		{"poster", 0, 2, 0},
+		{"voter", 0, 0, 0},
+		{"unknown", 0, 0, boo.NotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.seed, func(t *testing.T) {
+			upk, _ := cipher.GenerateDeterministicKeyPair([]byte(c.seed))
+			out, e := bi.Viewer().GetUserProfile(&UserProfileIn{UserPubKey: upk.Hex()})
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get user profile:", e)
+			}
+			if p := out.Profile; p.ThreadCount != c.threads || p.PostCount != c.posts {
+				t.Errorf("got %d threads and %d posts, expected %d and %d",
+					p.ThreadCount, p.PostCount, c.threads, c.posts)
+			}
+		})
+	}
+}
+
+func TestViewer_ExportTrustGraph(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	pks := make(map[string]string)
+	for i, seed := range []string{"x", "y", "z"} {
+		pk, _ := cipher.GenerateDeterministicKeyPair([]byte(seed))
+		pks[seed] = pk.Hex()
+		addThread(t, bi, i, []byte(seed))
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	votes := []struct {
+		from, to string
+		value    int
+		tag      string
+		relation string
+	}{
+		{"x", "y", +1, object.TrustTag, TrustRelation},
+		{"x", "z", -1, object.BlockTag, BlockRelation},
+		{"y", "z", -1, object.SpamTag, SpamRelation},
+		{"z", "x", +1, object.TrustTag, TrustRelation},
+	}
+	var expected []TrustEdge
+	for _, vote := range votes {
+		to, _ := cipher.PubKeyFromHex(pks[vote.to])
+		addUserVote(t, bi, to, vote.value, vote.tag, []byte(vote.from))
+		expected = append(expected, TrustEdge{From: pks[vote.from], To: pks[vote.to], Relation: vote.relation})
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	relationOrder := map[string]int{TrustRelation: 0, SpamRelation: 1, BlockRelation: 2}
+	sort.Slice(expected, func(i, j int) bool {
+		a, b := expected[i], expected[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.Relation != b.Relation {
+			return relationOrder[a.Relation] < relationOrder[b.Relation]
+		}
+		return a.To < b.To
+	})
+
+	edges, e := bi.Viewer().ExportTrustGraph()
+	if e != nil {
+		t.Fatal("failed to export trust graph:", e)
+	}
+	if !reflect.DeepEqual(edges, expected) {
+		t.Errorf("got edges %v, expected %v", edges, expected)
+	}
+
+	// Ranging stops at the first error of the action.
+	stop := boo.New(boo.Internal, "stop")
+	var count int
+	if e := bi.Viewer().RangeTrustGraph(func(edge TrustEdge) error {
+		count++
+		return stop
+	}); e != stop || count != 1 {
+		t.Errorf("stopped ranging: got %v after %d edges, expected %v after 1", e, count, stop)
+	}
+
+	if _, e := (*Viewer)(nil).ExportTrustGraph(); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
+
+func TestViewer_GetParticipants(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	pks := make(map[string]cipher.PubKey)
+	for i, seed := range []string{"x", "y", "z"} {
+		pks[seed], _ = cipher.GenerateDeterministicKeyPair([]byte(seed))
+		addThread(t, bi, i, []byte(seed))
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	// Reputations of x, y and z are 2, -1 and 0 respectively.
+	addUserVote(t, bi, pks["x"], +1, object.TrustTag, []byte("y"))
+	addUserVote(t, bi, pks["x"], +1, object.TrustTag, []byte("z"))
+	addUserVote(t, bi, pks["y"], -1, object.BlockTag, []byte("x"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	reputation := func(r int) *int { return &r }
+	cases := []struct {
+		name          string
+		minReputation *int
+		page          typ.PaginatedInput
+		included      []string // Of x, y and z.
+		errType       int
+	}{
+		{"unfiltered", nil, typ.PaginatedInput{PageSize: 10}, []string{"x", "y", "z"}, 0},
+		{"min_negative", reputation(-1), typ.PaginatedInput{PageSize: 10}, []string{"x", "y", "z"}, 0},
+		{"min_zero", reputation(0), typ.PaginatedInput{PageSize: 10}, []string{"x", "z"}, 0},
+		{"min_positive", reputation(1), typ.PaginatedInput{PageSize: 10}, []string{"x"}, 0},
+		{"min_unreached", reputation(3), typ.PaginatedInput{PageSize: 10}, nil, 0},
+		{"invalid_page", reputation(0), typ.PaginatedInput{}, nil, boo.InvalidInput},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetParticipants(&ParticipantsIn{
+				MinReputation:  c.minReputation,
+				PaginatedInput: c.page,
+			})
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get participants:", e)
+			}
+			if out.ParticipantsMeta.RecordCount != uint(len(out.Participants)) {
+				t.Errorf("got record count %d of %d participants",
+					out.ParticipantsMeta.RecordCount, len(out.Participants))
+			}
+			got := make(map[string]struct{}, len(out.Participants))
+			for _, upk := range out.Participants {
+				got[upk] = struct{}{}
+			}
+			included := make(map[string]bool)
+			for _, seed := range c.included {
+				included[seed] = true
+			}
+			for seed, pk := range pks {
+				if _, ok := got[pk.Hex()]; ok != included[seed] {
+					t.Errorf("user '%s': got included %v, expected %v", seed, ok, included[seed])
+				}
+			}
+		})
+	}
+}
+
+func TestViewer_GetTrustNetwork(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	var (
+		seeds = []string{"w", "x", "y", "z"}
+		pks   = make(map[string]cipher.PubKey)
+		names = make(map[string]string)
+	)
+	for i, seed := range seeds {
+		pks[seed], _ = cipher.GenerateDeterministicKeyPair([]byte(seed))
+		names[pks[seed].Hex()] = seed
+		addThread(t, bi, i, []byte(seed))
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	// A chain of trust from w to z, where w also blocks z.
+	addUserVote(t, bi, pks["x"], +1, object.TrustTag, []byte("w"))
+	addUserVote(t, bi, pks["y"], +1, object.TrustTag, []byte("x"))
+	addUserVote(t, bi, pks["z"], +1, object.TrustTag, []byte("y"))
+	addUserVote(t, bi, pks["z"], -1, object.BlockTag, []byte("w"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	page := typ.PaginatedInput{PageSize: 10}
+	cases := []struct {
+		name     string
+		in       *TrustNetworkIn
+		expected []string // Of "<seed>:<distance>".
+		errType  int
+	}{
+		{"default", &TrustNetworkIn{UserPubKey: pks["w"].Hex(), PaginatedInput: page},
+			[]string{"x:1"}, 0},
+		{"depth_2", &TrustNetworkIn{UserPubKey: pks["w"].Hex(), MaxDepth: 2, PaginatedInput: page},
+			[]string{"x:1", "y:2"}, 0},
+		{"depth_beyond", &TrustNetworkIn{UserPubKey: pks["w"].Hex(), MaxDepth: 10, PaginatedInput: page},
+			[]string{"x:1", "y:2", "z:3"}, 0},
+		{"blocked", &TrustNetworkIn{UserPubKey: pks["w"].Hex(), Relation: BlockedField, PaginatedInput: page},
+			[]string{"z:1"}, 0},
+		{"trusted_by", &TrustNetworkIn{UserPubKey: pks["z"].Hex(), Relation: TrustedByField, MaxDepth: 2, PaginatedInput: page},
+			[]string{"y:1", "x:2"}, 0},
+		{"none", &TrustNetworkIn{UserPubKey: pks["z"].Hex(), PaginatedInput: page},
+			[]string{}, 0},
+		{"paginated", &TrustNetworkIn{UserPubKey: pks["w"].Hex(), MaxDepth: 3,
+			PaginatedInput: typ.PaginatedInput{StartIndex: 1, PageSize: 1}},
+			[]string{"y:2"}, 0},
+		{"invalid_relation", &TrustNetworkIn{UserPubKey: pks["w"].Hex(), Relation: "unknown", PaginatedInput: page},
+			nil, boo.InvalidInput},
+		{"invalid_page", &TrustNetworkIn{UserPubKey: pks["w"].Hex()},
+			nil, boo.InvalidInput},
+		{"unknown_user", &TrustNetworkIn{UserPubKey: cipher.PubKey{}.Hex(), PaginatedInput: page},
+			nil, boo.NotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetTrustNetwork(c.in)
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get trust network:", e)
+			}
+			got := make([]string, len(out.Members))
+			for i, member := range out.Members {
+				got[i] = fmt.Sprintf("%s:%d", names[member.UserPubKey], member.Distance)
+			}
+			if !reflect.DeepEqual(got, c.expected) {
+				t.Errorf("got members %v, expected %v", got, c.expected)
+			}
+		})
+	}
+
+	if _, e := (*Viewer)(nil).GetTrustNetwork(&TrustNetworkIn{}); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
@@ -0,0 +1,162 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/store/object"
+	"sort"
+	"strings"
+)
+
+// Limits of board announcements.
+const (
+	MaxAnnouncementNameLength = 64   // Maximum number of characters of an announced board's name.
+	MaxAnnouncementBodyLength = 1024 // Maximum number of characters of an announced board's description.
+)
+
+// ErrNotRegistry occurs when announcing a board to, or discovering boards of,
+// a board that is not a registry.
+var ErrNotRegistry = boo.New(boo.NotAllowed, "board is not a registry and does not accept board announcements")
+
+// Announcement represents a board listed in a registry board.
+type Announcement struct {
+	Board string            `json:"board_public_key"`
+	Name  string            `json:"name"`
+	Body  string            `json:"body,omitempty"`
+	Meta  *object.BoardMeta `json:"meta,omitempty"`
+	TS    int64             `json:"ts"` // Time of latest announcement.
+}
+
+// checkAnnouncement ensures that a board announcement is named and within limits.
+func checkAnnouncement(body *object.Body) error {
+	switch {
+	case body.Name == "" || len([]rune(body.Name)) > MaxAnnouncementNameLength:
+		return boo.Newf(boo.InvalidInput,
+			"announced board name needs to be between 1 and %d characters", MaxAnnouncementNameLength)
+	case len([]rune(body.Body)) > MaxAnnouncementBodyLength:
+		return boo.Newf(boo.InvalidInput,
+			"announced board description exceeds %d characters", MaxAnnouncementBodyLength)
+	}
+	return nil
+}
+
+// checkAnnouncementTS ensures that a board announcement is newer than the board's
+// current announcement, if any.
+func (v *Viewer) checkAnnouncementTS(b *object.Body) error {
+	if rep, ok := v.c.listings[b.Creator]; ok && rep.Body.(*object.Body).TS >= b.TS {
+		return boo.Newf(boo.AlreadyExists,
+			"board %s already has a newer announcement", b.Creator)
+	}
+	return nil
+}
+
+// CheckAnnouncement ensures that a board announcement can be submitted.
+// Only registry boards accept board announcements.
+func (v *Viewer) CheckAnnouncement(b *object.Body) error {
+	if v == nil {
+		return ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if !v.i.Registry {
+		return ErrNotRegistry
+	}
+	return v.checkAnnouncementTS(b)
+}
+
+// addAnnouncement replaces the announcement of a board, if newer.
+func (v *Viewer) addAnnouncement(c *object.Content, b *object.Body, h *object.ContentHeaderData) error {
+	if e := v.checkAnnouncementTS(b); e != nil {
+		return e
+	}
+	v.c.listings[b.Creator] = &object.ContentRep{Header: h, Body: b, RawBody: c.Body}
+	return nil
+}
+
+// AnnouncementsIn represents the input required to search announcements.
+type AnnouncementsIn struct {
+	Query    string // Space separated terms that all need to be in an announcement, empty for all.
+	Category string // Category of announced boards (case insensitive), empty for all.
+}
+
+// GetAnnouncements obtains the boards listed in this registry board that match the
+// input, most recently announced first.
+func (v *Viewer) GetAnnouncements(in *AnnouncementsIn) ([]*Announcement, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if !v.i.Registry {
+		return nil, ErrNotRegistry
+	}
+
+	terms := strings.Fields(strings.ToLower(in.Query))
+	out := make([]*Announcement, 0, len(v.c.listings))
+	for bpk, rep := range v.c.listings {
+		body := rep.Body.(*object.Body)
+		if in.Category != "" && (body.Meta == nil || !strings.EqualFold(body.Meta.Category, in.Category)) {
+			continue
+		}
+		if !matchAnnouncement(body, terms) {
+			continue
+		}
+		out = append(out, newAnnouncement(bpk, body))
+	}
+	SortAnnouncements(out)
+	return out, nil
+}
+
+// GetAnnouncement obtains the announcement of a board listed in this registry board.
+func (v *Viewer) GetAnnouncement(bpk string) (*Announcement, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if !v.i.Registry {
+		return nil, ErrNotRegistry
+	}
+
+	rep, ok := v.c.listings[bpk]
+	if !ok {
+		return nil, boo.Newf(boo.NotFound, "board %s is not announced", bpk)
+	}
+	return newAnnouncement(bpk, rep.Body.(*object.Body)), nil
+}
+
+func newAnnouncement(bpk string, body *object.Body) *Announcement {
+	return &Announcement{
+		Board: bpk,
+		Name:  body.Name,
+		Body:  body.Body,
+		Meta:  body.Meta,
+		TS:    body.TS,
+	}
+}
+
+// SortAnnouncements sorts announcements, most recently announced first.
+func SortAnnouncements(list []*Announcement) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].TS != list[j].TS {
+			return list[i].TS > list[j].TS
+		}
+		return list[i].Board < list[j].Board
+	})
+}
+
+// matchAnnouncement determines whether all terms are in the announcement's name,
+// description or metadata.
+func matchAnnouncement(body *object.Body, terms []string) bool {
+	if len(terms) == 0 {
+		return true
+	}
+	text := []string{body.Name, body.Body}
+	if meta := body.Meta; meta != nil {
+		text = append(text, meta.Description, meta.Category)
+		text = append(text, meta.Tags...)
+	}
+	joined := strings.ToLower(strings.Join(text, " "))
+	for _, term := range terms {
+		if !strings.Contains(joined, term) {
+			return false
+		}
+	}
+	return true
+}
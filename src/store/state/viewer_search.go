@@ -0,0 +1,120 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"sort"
+	"strings"
+)
+
+// SearchIn represents the input required to search content of a board.
+type SearchIn struct {
+	Query             string // Terms are separated by whitespace and matched case-insensitively.
+	Perspective       string
+	HideBlocked       bool // Whether to exclude content created by users blocked by perspective.
+	StrictPerspective bool // Whether an unknown perspective results in an error.
+	PaginatedInput    typ.PaginatedInput
+}
+
+// SearchResult represents a thread or post that matches a search query.
+type SearchResult struct {
+	Score   int                `json:"score"`
+	Content *object.ContentRep `json:"content"`
+	Votes   *VoteRepView       `json:"votes"`
+}
+
+// SearchOut represents the output for searching content of a board.
+type SearchOut struct {
+	ResultsMeta *typ.PaginatedOutput `json:"results_meta"`
+	Results     []*SearchResult      `json:"results"`
+}
+
+// SearchContent searches the names and bodies of threads and posts of the board.
+// Each occurrence of a term scores 2 in the name and 1 in the body.
+// Results are ordered by score, then newest first.
+func (v *Viewer) SearchContent(in *SearchIn) (*SearchOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
+	terms := searchTerms(in.Query)
+	if len(terms) == 0 {
+		return nil, boo.New(boo.InvalidInput, "empty search query")
+	}
+
+	var blocked map[string]struct{}
+	if profile, ok := v.c.profiles[in.Perspective]; in.HideBlocked && ok {
+		blocked = profile.Blocked
+	}
+
+	var results []*SearchResult
+	for hash, rep := range v.c.content {
+		body, ok := rep.Body.(*object.Body)
+		if !ok || (body.Type != object.V5ThreadType && body.Type != object.V5PostType) {
+			continue
+		}
+		if _, ok := blocked[body.Creator]; ok {
+			continue
+		}
+		score := searchScore(terms, body)
+		if score == 0 {
+			continue
+		}
+		results = append(results, &SearchResult{
+			Score:   score,
+			Content: minimalRep(rep),
+			Votes:   v.viewVotes(hash, in.Perspective, in.HideBlocked),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return searchResultLess(results[i], results[j])
+	})
+
+	meta, e := typ.NewPaginatedOutput(&in.PaginatedInput, uint(len(results)))
+	if e != nil {
+		return nil, e
+	}
+	out := &SearchOut{
+		ResultsMeta: meta,
+		Results:     make([]*SearchResult, meta.RecordCount),
+	}
+	for i := range out.Results {
+		out.Results[i] = results[pageIndex(&in.PaginatedInput, i)]
+		out.Results[i].Content = v.transformRep(out.Results[i].Content, in.Perspective)
+	}
+	return out, nil
+}
+
+// searchTerms obtains the lower-cased terms of a search query.
+func searchTerms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// searchScore scores how well the body matches the terms.
+func searchScore(terms []string, body *object.Body) int {
+	var (
+		name  = strings.ToLower(body.Name)
+		text  = strings.ToLower(body.Body)
+		score int
+	)
+	for _, term := range terms {
+		score += 2*strings.Count(name, term) + strings.Count(text, term)
+	}
+	return score
+}
+
+// searchResultLess orders results by score, then newest first, then by hash.
+func searchResultLess(a, b *SearchResult) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	aTS, bTS := a.Content.Body.(*object.Body).TS, b.Content.Body.(*object.Body).TS
+	if aTS != bTS {
+		return aTS > bTS
+	}
+	return a.Content.Header.Hash < b.Content.Header.Hash
+}
@@ -0,0 +1,401 @@
+package state
+
+import (
+	"encoding/json"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/inform"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/misc/typ/paginatedtypes"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/cxo/skyobject"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+)
+
+const (
+	// SnapshotVersion is the version of the snapshot format.
+	// Snapshots of other versions are not loaded.
+	SnapshotVersion = 12
+
+	// SnapshotFileExt is the file extension of viewer snapshots.
+	SnapshotFileExt = ".snapshot"
+)
+
+/*
+	<<< SNAPSHOT >>>
+*/
+
+// Snapshot is the serialized form of a Viewer's Indexer and Container,
+// for the root it was compiled from.
+type Snapshot struct {
+	Version  int    `json:"version"`
+	Board    string `json:"board"`     // Public key of board.
+	RootHash string `json:"root_hash"` // Hash of root the views were compiled from.
+	RootSeq  uint64 `json:"root_seq"`
+
+	Indexer  *IndexerSnapshot            `json:"indexer"`
+	Content  map[string]*ContentSnapshot `json:"content"`
+	Votes    map[string]*VotesRep        `json:"votes"`
+	Profiles map[string]*ProfileSnapshot `json:"profiles"`
+
+	Attachments  map[string]*ContentSnapshot            `json:"attachments,omitempty"`
+	SelfProfiles map[string]*ContentSnapshot            `json:"self_profiles,omitempty"`
+	UserVotes    map[string]map[string]*ContentSnapshot `json:"user_votes,omitempty"`
+	Rotations    map[string]*ContentSnapshot            `json:"rotations,omitempty"`
+	Listings     map[string]*ContentSnapshot            `json:"listings,omitempty"`
+}
+
+// IndexerSnapshot is the serialized form of an Indexer.
+type IndexerSnapshot struct {
+	Board             string                    `json:"board"`
+	Threads           []string                  `json:"threads"`
+	PostsOfThread     map[string][]string       `json:"posts_of_thread"`
+	ContentOfUser     map[string][]string       `json:"content_of_user"`
+	LastPost          map[string]string         `json:"last_post"`
+	Participants      map[string]map[string]int `json:"participants"`
+	VotesOfUser       map[string]map[string]int `json:"votes_of_user"`
+	VoteOfHash        map[string]*object.Body   `json:"vote_of_hash"`
+	VotesByUser       map[string][]string       `json:"votes_by_user"`
+	Mentions          map[string][]string       `json:"mentions"`
+	AttachmentsOfPost map[string][]string       `json:"attachments_of_post,omitempty"`
+	Pins              []string                  `json:"pins,omitempty"`
+	Retention         int                       `json:"retention,omitempty"`
+	Policy            *object.SubmissionPolicy  `json:"policy,omitempty"`
+	Archived          bool                      `json:"archived,omitempty"`
+	Registry          bool                      `json:"registry,omitempty"`
+	Users             []string                  `json:"users"`
+	UserRefs          map[string]int            `json:"user_refs"`
+}
+
+// ContentSnapshot is the serialized form of a content rep held by the Container.
+type ContentSnapshot struct {
+	PubKey  string                    `json:"public_key,omitempty"`
+	Header  *object.ContentHeaderData `json:"header"`
+	Body    *object.Body              `json:"body"`
+	RawBody []byte                    `json:"raw_body,omitempty"` // Body as signed, for export.
+	ByOwner bool                      `json:"by_owner,omitempty"`
+}
+
+// ProfileSnapshot is the serialized form of a Profile.
+type ProfileSnapshot struct {
+	Profile   *Profile `json:"profile"`
+	ProfileTS int64    `json:"profile_ts"`
+}
+
+// SaveSnapshot saves a snapshot of the views, as compiled from the given root, to
+// it's file in the given directory. The snapshot shares the maps of the views, so
+// it is encoded while the viewer is locked.
+func (v *Viewer) SaveSnapshot(dir string, root *skyobject.Root) error {
+	if v == nil {
+		return ErrViewerNotInitialized
+	}
+	data, e := func() ([]byte, error) {
+		defer v.lock()()
+		snap, e := v.snapshot(root)
+		if e != nil {
+			return nil, e
+		}
+		data, e := json.Marshal(snap)
+		if e != nil {
+			return nil, boo.WrapType(e, boo.Internal, "failed to encode snapshot")
+		}
+		return data, nil
+	}()
+	if e != nil {
+		return e
+	}
+	return writeSnapshot(dir, v.pk.Hex(), data)
+}
+
+// snapshot serializes the views, as compiled from the given root.
+// The snapshot shares the maps of the views, so the viewer needs to stay locked
+// until it is encoded.
+func (v *Viewer) snapshot(root *skyobject.Root) (*Snapshot, error) {
+	snap := &Snapshot{
+		Version:  SnapshotVersion,
+		Board:    v.pk.Hex(),
+		RootHash: root.Hash.Hex(),
+		RootSeq:  root.Seq,
+		Indexer: &IndexerSnapshot{
+			Board:             v.i.Board,
+			Threads:           listOf(v.i.Threads),
+			PostsOfThread:     make(map[string][]string, len(v.i.PostsOfThread)),
+			ContentOfUser:     make(map[string][]string, len(v.i.ContentOfUser)),
+			LastPost:          v.i.LastPost,
+			Participants:      v.i.Participants,
+			VotesOfUser:       v.i.VotesOfUser,
+			VoteOfHash:        v.i.VoteOfHash,
+			VotesByUser:       make(map[string][]string, len(v.i.VotesByUser)),
+			Mentions:          make(map[string][]string, len(v.i.Mentions)),
+			AttachmentsOfPost: make(map[string][]string, len(v.i.AttachmentsOfPost)),
+			Pins:              v.i.Pins,
+			Retention:         v.i.Retention,
+			Policy:            v.i.Policy,
+			Archived:          v.i.Archived,
+			Registry:          v.i.Registry,
+			Users:             listOf(v.i.Users),
+			UserRefs:          v.i.UserRefs,
+		},
+		Content:  make(map[string]*ContentSnapshot, len(v.c.content)),
+		Votes:    v.c.votes,
+		Profiles: make(map[string]*ProfileSnapshot, len(v.c.profiles)),
+
+		Attachments:  make(map[string]*ContentSnapshot, len(v.c.attachments)),
+		SelfProfiles: make(map[string]*ContentSnapshot, len(v.c.selfProfiles)),
+		UserVotes:    make(map[string]map[string]*ContentSnapshot, len(v.c.userVotes)),
+		Rotations:    make(map[string]*ContentSnapshot, len(v.c.rotations)),
+		Listings:     make(map[string]*ContentSnapshot, len(v.c.listings)),
+	}
+	for hash, list := range v.i.PostsOfThread {
+		snap.Indexer.PostsOfThread[hash] = listOf(list)
+	}
+	for upk, list := range v.i.ContentOfUser {
+		snap.Indexer.ContentOfUser[upk] = listOf(list)
+	}
+	for upk, list := range v.i.VotesByUser {
+		snap.Indexer.VotesByUser[upk] = listOf(list)
+	}
+	for key, list := range v.i.Mentions {
+		snap.Indexer.Mentions[key] = listOf(list)
+	}
+	for pHash, list := range v.i.AttachmentsOfPost {
+		snap.Indexer.AttachmentsOfPost[pHash] = listOf(list)
+	}
+	for hash, rep := range v.c.content {
+		body, ok := rep.Body.(*object.Body)
+		if !ok {
+			return nil, boo.Newf(boo.Internal,
+				"content of hash %s has unexpected body type %T", hash, rep.Body)
+		}
+		snap.Content[hash] = &ContentSnapshot{
+			PubKey:  rep.PubKey,
+			Header:  rep.Header,
+			Body:    body,
+			RawBody: rep.RawBody,
+			ByOwner: rep.ByOwner,
+		}
+	}
+	for aHash, rep := range v.c.attachments {
+		snap.Attachments[aHash] = toContentSnapshot(rep)
+	}
+	for upk, rep := range v.c.selfProfiles {
+		snap.SelfProfiles[upk] = toContentSnapshot(rep)
+	}
+	for upk, rep := range v.c.rotations {
+		snap.Rotations[upk] = toContentSnapshot(rep)
+	}
+	for bpk, rep := range v.c.listings {
+		snap.Listings[bpk] = toContentSnapshot(rep)
+	}
+	for voter, ofVoter := range v.c.userVotes {
+		votes := make(map[string]*ContentSnapshot, len(ofVoter))
+		for upk, rep := range ofVoter {
+			votes[upk] = toContentSnapshot(rep)
+		}
+		snap.UserVotes[voter] = votes
+	}
+	for upk, profile := range v.c.profiles {
+		snap.Profiles[upk] = &ProfileSnapshot{
+			Profile:   profile,
+			ProfileTS: profile.profileTS,
+		}
+	}
+	return snap, nil
+}
+
+// NewViewerFromSnapshot creates a new viewer from a snapshot, rather than compiling
+// views from the pack. The snapshot is only trusted if it was taken of the pack's root.
+func NewViewerFromSnapshot(snap *Snapshot, pack *skyobject.Pack, sortBy string) (*Viewer, error) {
+	if _, e := GetSorter(sortBy); e != nil {
+		return nil, e
+	}
+	root := pack.Root()
+	switch {
+	case snap.Version != SnapshotVersion:
+		return nil, boo.Newf(boo.InvalidRead,
+			"snapshot is of version %d, expected %d", snap.Version, SnapshotVersion)
+	case snap.Board != root.Pub.Hex():
+		return nil, boo.Newf(boo.InvalidRead,
+			"snapshot is of board %s, expected %s", snap.Board, root.Pub.Hex())
+	case snap.RootHash != root.Hash.Hex():
+		return nil, boo.Newf(boo.InvalidRead,
+			"snapshot is of root %s (seq %d), expected %s (seq %d)",
+			snap.RootHash, snap.RootSeq, root.Hash.Hex(), root.Seq)
+	case snap.Indexer == nil:
+		return nil, boo.New(boo.InvalidRead, "snapshot has no indexer")
+	}
+
+	v := &Viewer{
+		l:      inform.New(true, os.Stdout, "STATE_VIEWER"),
+		pk:     root.Pub,
+		i:      NewIndexer(),
+		c:      NewContainer(),
+		sortBy: sortBy,
+
+		spamThreshold: DefaultSpamThreshold,
+	}
+
+	si := snap.Indexer
+	v.i.Board = si.Board
+	fillList(v.i.Threads, si.Threads)
+	for hash, list := range si.PostsOfThread {
+		v.i.PostsOfThread[hash] = fillList(paginatedtypes.NewMapped(), list)
+	}
+	for upk, list := range si.ContentOfUser {
+		v.i.ContentOfUser[upk] = fillList(paginatedtypes.NewMapped(), list)
+	}
+	for upk, list := range si.VotesByUser {
+		v.i.VotesByUser[upk] = fillList(paginatedtypes.NewMapped(), list)
+	}
+	for key, list := range si.Mentions {
+		v.i.Mentions[key] = fillList(paginatedtypes.NewMapped(), list)
+	}
+	for pHash, list := range si.AttachmentsOfPost {
+		v.i.AttachmentsOfPost[pHash] = fillList(paginatedtypes.NewMapped(), list)
+	}
+	if si.LastPost != nil {
+		v.i.LastPost = si.LastPost
+	}
+	if si.Participants != nil {
+		v.i.Participants = si.Participants
+	}
+	if si.VotesOfUser != nil {
+		v.i.VotesOfUser = si.VotesOfUser
+	}
+	if si.VoteOfHash != nil {
+		v.i.VoteOfHash = si.VoteOfHash
+	}
+	v.i.Pins = si.Pins
+	v.i.Retention = si.Retention
+	v.i.Policy = si.Policy
+	v.i.Archived = si.Archived
+	v.i.Registry = si.Registry
+	fillList(v.i.Users, si.Users)
+	if si.UserRefs != nil {
+		v.i.UserRefs = si.UserRefs
+	}
+
+	for hash, sc := range snap.Content {
+		v.c.content[hash] = sc.toRep()
+	}
+	if _, ok := v.c.content[v.i.Board]; !ok {
+		return nil, boo.New(boo.InvalidRead, "snapshot has no board content")
+	}
+	for hash, votes := range snap.Votes {
+		if votes.Votes == nil {
+			votes.Votes = make(map[string]*object.Content)
+		}
+		if votes.Reports == nil {
+			votes.Reports = make(map[string]*object.Content)
+		}
+		v.c.votes[hash] = votes
+		v.c.SetScore(hash, votes)
+	}
+	for aHash, sc := range snap.Attachments {
+		v.c.attachments[aHash] = sc.toRep()
+	}
+	for upk, sc := range snap.SelfProfiles {
+		v.c.selfProfiles[upk] = sc.toRep()
+	}
+	for upk, sc := range snap.Rotations {
+		v.c.rotations[upk] = sc.toRep()
+		v.setKeyRotation(sc.Body.OfUser, upk)
+	}
+	for bpk, sc := range snap.Listings {
+		v.c.listings[bpk] = sc.toRep()
+	}
+	for voter, votes := range snap.UserVotes {
+		ofVoter := make(map[string]*object.ContentRep, len(votes))
+		for upk, sc := range votes {
+			ofVoter[upk] = sc.toRep()
+		}
+		v.c.userVotes[voter] = ofVoter
+	}
+	for upk, sp := range snap.Profiles {
+		if sp.Profile == nil {
+			continue
+		}
+		sp.Profile.profileTS = sp.ProfileTS
+		v.c.profiles[upk] = sp.Profile
+	}
+	return v, nil
+}
+
+// toContentSnapshot obtains the serialized form of a rep whose body is known to be *object.Body.
+func toContentSnapshot(rep *object.ContentRep) *ContentSnapshot {
+	return &ContentSnapshot{
+		PubKey:  rep.PubKey,
+		Header:  rep.Header,
+		Body:    rep.Body.(*object.Body),
+		RawBody: rep.RawBody,
+		ByOwner: rep.ByOwner,
+	}
+}
+
+func (sc *ContentSnapshot) toRep() *object.ContentRep {
+	return &object.ContentRep{
+		PubKey:  sc.PubKey,
+		Header:  sc.Header,
+		Body:    sc.Body,
+		RawBody: sc.RawBody,
+		ByOwner: sc.ByOwner,
+	}
+}
+
+func listOf(list typ.Paginated) []string {
+	out, _ := list.Get(&typ.PaginatedInput{PageSize: math.MaxUint64})
+	if out == nil {
+		return []string{}
+	}
+	return out.Data
+}
+
+func fillList(list typ.Paginated, data []string) typ.Paginated {
+	for _, v := range data {
+		list.Append(v)
+	}
+	return list
+}
+
+/*
+	<<< SNAPSHOT FILES >>>
+*/
+
+// SnapshotPath obtains the path of the snapshot file of a board.
+func SnapshotPath(dir, bpk string) string {
+	return path.Join(dir, bpk+SnapshotFileExt)
+}
+
+// writeSnapshot writes an encoded snapshot to it's file in the given directory.
+// The file is replaced atomically, so an interrupted save leaves the previous snapshot intact.
+func writeSnapshot(dir, bpk string, data []byte) error {
+	if e := os.MkdirAll(dir, os.FileMode(0700)); e != nil {
+		return boo.WrapType(e, boo.Internal, "failed to create snapshot directory")
+	}
+	name := SnapshotPath(dir, bpk)
+	if e := ioutil.WriteFile(name+".tmp", data, os.FileMode(0600)); e != nil {
+		return boo.WrapType(e, boo.Internal, "failed to write snapshot")
+	}
+	if e := os.Rename(name+".tmp", name); e != nil {
+		return boo.WrapType(e, boo.Internal, "failed to replace snapshot")
+	}
+	return nil
+}
+
+// LoadSnapshot reads the snapshot of a board from the given directory.
+func LoadSnapshot(dir, bpk string) (*Snapshot, error) {
+	data, e := ioutil.ReadFile(SnapshotPath(dir, bpk))
+	if e != nil {
+		if os.IsNotExist(e) {
+			return nil, boo.WrapType(e, boo.NotFound, "snapshot not found")
+		}
+		return nil, boo.WrapType(e, boo.InvalidRead, "failed to read snapshot")
+	}
+	snap := new(Snapshot)
+	if e := json.Unmarshal(data, snap); e != nil {
+		return nil, boo.WrapType(e, boo.InvalidRead, "failed to decode snapshot")
+	}
+	return snap, nil
+}
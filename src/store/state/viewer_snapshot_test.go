@@ -0,0 +1,159 @@
+package state
+
+import (
+	"encoding/json"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestViewer_Snapshot(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	addThread(t, bi, 1, []byte(userSeed))
+	addUserProfile(t, bi, "User", 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	for i := 0; i < 3; i++ {
+		addPost(t, bi, tHash, i, []byte(userSeed))
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	dir, e := ioutil.TempDir("", "bbs_snapshot")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+
+	if e := bi.v.SaveSnapshot(dir, bi.p.Root()); e != nil {
+		t.Fatal("failed to save snapshot:", e)
+	}
+	snap, e := LoadSnapshot(dir, bi.v.pk.Hex())
+	if e != nil {
+		t.Fatal("failed to load snapshot:", e)
+	}
+
+	t.Run("restores views", func(t *testing.T) {
+		v, e := NewViewerFromSnapshot(snap, bi.p, "")
+		if e != nil {
+			t.Fatal("failed to create viewer from snapshot:", e)
+		}
+		page := typ.PaginatedInput{PageSize: 10}
+		views := []struct {
+			name string
+			get  func(v *Viewer) (interface{}, error)
+		}{
+			{"board page", func(v *Viewer) (interface{}, error) {
+				return v.GetBoardPage(&BoardPageIn{PaginatedInput: page})
+			}},
+			{"thread page", func(v *Viewer) (interface{}, error) {
+				return v.GetThreadPage(&ThreadPageIn{ThreadHash: tHash.Hex(), PaginatedInput: page})
+			}},
+			{"archive", func(v *Viewer) (interface{}, error) {
+				archive, e := v.archive()
+				if e == nil {
+					archive.Exported = 0
+				}
+				return archive, e
+			}},
+		}
+		for _, view := range views {
+			expected, e := view.get(bi.v)
+			if e != nil {
+				t.Fatalf("%s: %v", view.name, e)
+			}
+			got, e := view.get(v)
+			if e != nil {
+				t.Fatalf("%s: %v", view.name, e)
+			}
+			if !jsonEqual(t, expected, got) {
+				t.Errorf("%s: snapshot views differ from compiled views", view.name)
+			}
+		}
+		if !reflect.DeepEqual(bi.v.c.profiles, v.c.profiles) {
+			t.Error("snapshot profiles differ from compiled profiles")
+		}
+	})
+
+	t.Run("rejects other root", func(t *testing.T) {
+		other := *snap
+		other.RootHash = "0000"
+		if _, e := NewViewerFromSnapshot(&other, bi.p, ""); e == nil {
+			t.Error("expected snapshot of other root to be rejected")
+		}
+	})
+}
+
+func jsonEqual(t *testing.T, a, b interface{}) bool {
+	var decoded [2]interface{}
+	for i, v := range []interface{}{a, b} {
+		raw, e := json.Marshal(v)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if e := json.Unmarshal(raw, &decoded[i]); e != nil {
+			t.Fatal(e)
+		}
+	}
+	return reflect.DeepEqual(decoded[0], decoded[1])
+}
+
+func TestViewer_SaveSnapshot_concurrent(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addThreadVote(t, bi, tHash, +1, []byte(userSeed))
+	addThreadVote(t, bi, tHash, -1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	dir, e := ioutil.TempDir("", "bbs_snapshot")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+
+	// Views changed while saving are not to be encoded concurrently (run with -race).
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			bi.Viewer().CompactVotes(tHash.Hex())
+			bi.Viewer().GetThreadPage(&ThreadPageIn{
+				ThreadHash:     tHash.Hex(),
+				PaginatedInput: typ.PaginatedInput{PageSize: 10},
+			})
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if e := bi.v.SaveSnapshot(dir, bi.p.Root()); e != nil {
+			t.Fatal("failed to save snapshot:", e)
+		}
+	}
+	<-done
+	if _, e := LoadSnapshot(dir, bi.v.pk.Hex()); e != nil {
+		t.Error("failed to load snapshot:", e)
+	}
+}
@@ -0,0 +1,201 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Names of the built-in sorters.
+const (
+	SortChronological = "chronological" // Order in which content is indexed.
+	SortNewest        = "newest"        // Most recently created first.
+	SortScore         = "score"         // Highest (up votes - down votes) first.
+	SortControversial = "controversial" // Most votes with closest up/down split first.
+	SortActivity      = "activity"      // Most recently created or posted in first.
+	SortPosts         = "posts"         // Most posts first.
+	SortHot           = "hot"           // Highest score, decayed by age, first.
+)
+
+// HotDecay is the age difference (in seconds) over which a thread needs ten times
+// the score to keep it's 'hot' rank.
+const HotDecay = 45000
+
+// SortItem holds what a Sorter needs to order content.
+type SortItem struct {
+	Hash      string
+	Rep       *object.ContentRep
+	Votes     *VotesRep // Nil if there are no votes.
+	PostCount int
+	LastPost  *object.ContentRep // Latest post of thread, nil if there are no posts.
+}
+
+// TS obtains the creation time of the content.
+func (i *SortItem) TS() int64 {
+	if body, ok := i.Rep.Body.(*object.Body); ok {
+		return body.TS
+	}
+	return 0
+}
+
+// LastActivity obtains the creation time of the latest post, or of the content
+// if there are no posts.
+func (i *SortItem) LastActivity() int64 {
+	if i.LastPost != nil {
+		if body, ok := i.LastPost.Body.(*object.Body); ok {
+			return body.TS
+		}
+	}
+	return i.TS()
+}
+
+// Score obtains (up votes - down votes) of the content.
+func (i *SortItem) Score() int {
+	if i.Votes == nil {
+		return 0
+	}
+	return i.Votes.UpCount - i.Votes.DownCount
+}
+
+// Hot obtains the 'hot' rank of the content, which combines the magnitude of it's
+// score (logarithmically) with it's creation time. As newer content ranks higher
+// for equal scores, the rank decays with age without depending on the current time.
+func (i *SortItem) Hot() float64 {
+	score := i.Score()
+	order := math.Log10(math.Max(math.Abs(float64(score)), 1))
+	if score < 0 {
+		order = -order
+	}
+	return order + float64(i.TS()/int64(time.Second))/HotDecay
+}
+
+// Sorter reports whether item 'a' should be ordered before item 'b'.
+// A nil Sorter keeps the order in which content is indexed.
+// Orderings are cached until the views change, so a Sorter should only
+// depend on the item and not on external state such as the current time.
+type Sorter func(a, b *SortItem) bool
+
+var (
+	sortersMux sync.RWMutex
+	sorters    = map[string]Sorter{
+		SortChronological: nil,
+		SortNewest: func(a, b *SortItem) bool {
+			return a.TS() > b.TS()
+		},
+		SortScore: func(a, b *SortItem) bool {
+			return a.Score() > b.Score()
+		},
+		SortControversial: func(a, b *SortItem) bool {
+			return controversy(a) > controversy(b)
+		},
+		SortActivity: func(a, b *SortItem) bool {
+			return a.LastActivity() > b.LastActivity()
+		},
+		SortPosts: func(a, b *SortItem) bool {
+			return a.PostCount > b.PostCount
+		},
+		SortHot: func(a, b *SortItem) bool {
+			return a.Hot() > b.Hot()
+		},
+	}
+)
+
+// RegisterSorter registers a named Sorter so that it can be selected with 'SortBy'.
+func RegisterSorter(name string, sorter Sorter) error {
+	sortersMux.Lock()
+	defer sortersMux.Unlock()
+
+	if _, ok := sorters[name]; ok {
+		return boo.Newf(boo.AlreadyExists,
+			"sorter of name '%s' already exists", name)
+	}
+	sorters[name] = sorter
+	return nil
+}
+
+// GetSorter obtains a registered Sorter of name.
+// An empty name obtains the chronological sorter.
+func GetSorter(name string) (Sorter, error) {
+	if name == "" {
+		name = SortChronological
+	}
+
+	sortersMux.RLock()
+	defer sortersMux.RUnlock()
+
+	sorter, ok := sorters[name]
+	if !ok {
+		return nil, boo.Newf(boo.InvalidInput,
+			"sorter of name '%s' is not registered", name)
+	}
+	return sorter, nil
+}
+
+// sortHashes sorts content hashes with the given Sorter.
+func (v *Viewer) sortHashes(hashes []string, sorter Sorter) {
+	if sorter == nil {
+		return
+	}
+	items := make([]*SortItem, len(hashes))
+	for i, hash := range hashes {
+		items[i] = &SortItem{
+			Hash:  hash,
+			Rep:   v.c.content[hash],
+			Votes: v.c.votes[hash],
+		}
+		if posts, ok := v.i.PostsOfThread[hash]; ok {
+			items[i].PostCount = posts.Len()
+		}
+		if pHash, ok := v.i.LastPost[hash]; ok {
+			items[i].LastPost = v.c.content[pHash]
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return sorter(items[i], items[j])
+	})
+	for i, item := range items {
+		hashes[i] = item.Hash
+	}
+}
+
+// sortedThreads obtains all thread hashes ordered by the named sorter.
+// The ordering is kept until the views change.
+func (v *Viewer) sortedThreads(sortBy string, sorter Sorter) ([]string, error) {
+	if tHashes, ok := v.sorted[sortBy]; ok {
+		return tHashes, nil
+	}
+	all, e := v.i.Threads.Get(&typ.PaginatedInput{
+		StartIndex: 0,
+		PageSize:   math.MaxUint64,
+	})
+	if e != nil {
+		return nil, e
+	}
+	v.sortHashes(all.Data, sorter)
+	if v.sorted == nil {
+		v.sorted = make(map[string][]string)
+	}
+	v.sorted[sortBy] = all.Data
+	return all.Data, nil
+}
+
+// resetSorted discards cached orderings and spam scores. Should be called when the views change.
+func (v *Viewer) resetSorted() {
+	v.sorted = nil
+	v.spam = nil
+}
+
+func controversy(i *SortItem) float64 {
+	if i.Votes == nil || i.Votes.UpCount == 0 || i.Votes.DownCount == 0 {
+		return 0
+	}
+	up, down := float64(i.Votes.UpCount), float64(i.Votes.DownCount)
+	if up > down {
+		return (up + down) * down / up
+	}
+	return (up + down) * up / down
+}
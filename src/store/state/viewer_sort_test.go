@@ -0,0 +1,129 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/inform"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/skycoin/src/cipher"
+	"os"
+	"testing"
+)
+
+func TestRegisterSorter(t *testing.T) {
+	const name = "test_by_hash"
+	if e := RegisterSorter(name, func(a, b *SortItem) bool { return a.Hash < b.Hash }); e != nil {
+		t.Fatal("failed to register sorter:", e)
+	}
+
+	cases := []struct {
+		name    string
+		errType int
+	}{
+		{"", 0},
+		{SortChronological, 0},
+		{SortHot, 0},
+		{name, 0},
+		{"unknown", boo.InvalidInput},
+	}
+	for _, c := range cases {
+		_, e := GetSorter(c.name)
+		if (e == nil) != (c.errType == 0) || e != nil && boo.Type(e) != c.errType {
+			t.Errorf("sorter '%s': got error %v, expected type %d", c.name, e, c.errType)
+		}
+	}
+	if e := RegisterSorter(name, nil); boo.Type(e) != boo.AlreadyExists {
+		t.Errorf("registering sorter twice: got %v, expected already exists", e)
+	}
+	if e := RegisterSorter(SortScore, nil); boo.Type(e) != boo.AlreadyExists {
+		t.Errorf("replacing built-in sorter: got %v, expected already exists", e)
+	}
+}
+
+func TestViewer_GetBoardPage_sortBy(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	var threads []cipher.SHA256
+	for i := 0; i < 3; i++ {
+		tHash, _ := addThread(t, bi, i, []byte("user"))
+		threads = append(threads, tHash)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	// Scores of 0, +2 and -1 respectively.
+	addThreadVote(t, bi, threads[1], +1, []byte("voter 1"))
+	addThreadVote(t, bi, threads[1], +1, []byte("voter 2"))
+	addThreadVote(t, bi, threads[2], -1, []byte("voter 1"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		sortBy   string
+		expected []cipher.SHA256
+		errType  int
+	}{
+		{"", threads, 0},
+		{SortChronological, threads, 0},
+		{SortNewest, []cipher.SHA256{threads[2], threads[1], threads[0]}, 0},
+		{SortScore, []cipher.SHA256{threads[1], threads[0], threads[2]}, 0},
+		{SortControversial, threads, 0}, // None has both up and down votes.
+		{"unknown", nil, boo.InvalidInput},
+	}
+	for _, c := range cases {
+		t.Run("sort_"+c.sortBy, func(t *testing.T) {
+			page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+				SortBy:         c.sortBy,
+				HashesOnly:     true,
+				PaginatedInput: typ.PaginatedInput{PageSize: 10},
+			})
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get board page:", e)
+			}
+			if len(page.ThreadHashes) != len(c.expected) {
+				t.Fatalf("got %d threads, expected %d", len(page.ThreadHashes), len(c.expected))
+			}
+			for i, tHash := range c.expected {
+				if page.ThreadHashes[i] != tHash.Hex() {
+					t.Errorf("thread %d: got '%s', expected '%s'", i, page.ThreadHashes[i], tHash.Hex())
+				}
+			}
+		})
+	}
+}
+
+func TestCompiler_defaultSortBy(t *testing.T) {
+	var (
+		hot     = SortHot
+		unknown = "unknown"
+	)
+	cases := []struct {
+		name     string
+		sortBy   *string
+		expected string
+	}{
+		{"unset", nil, ""},
+		{"registered", &hot, SortHot},
+		{"unknown", &unknown, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			comp := &Compiler{
+				c: &CompilerConfig{DefaultSortBy: c.sortBy},
+				l: inform.New(true, os.Stdout, LogPrefix),
+			}
+			if got := comp.defaultSortBy(); got != c.expected {
+				t.Errorf("got '%s', expected '%s'", got, c.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package state
+
+import (
+	"crypto/sha256"
+	"github.com/skycoin/bbs/src/store/object"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSpamThreshold is the spam score at or above which content is hidden
+	// from board and thread pages, unless spam is requested.
+	DefaultSpamThreshold = 0.75
+
+	// SpamWindow is the period before a piece of content in which other content
+	// of it's creator counts towards it's posting frequency.
+	SpamWindow = time.Minute
+
+	// SpamFrequencyLimit is the number of threads and posts a user may create
+	// within SpamWindow before posting frequency adds to the spam score.
+	SpamFrequencyLimit = 5
+)
+
+// SpamItem holds the signals that a SpamScorer combines into a spam score,
+// for a thread or post.
+type SpamItem struct {
+	Hash           string
+	Rep            *object.ContentRep
+	Duplicates     int // Number of threads and posts with the same name and body, indexed before it.
+	Recent         int // Number of threads and posts of the creator within SpamWindow before it.
+	SpamVotes      int // Number of votes on it tagged as spam.
+	MarkedAsSpamBy int // Number of users that marked the creator as spam.
+}
+
+// SpamScorer obtains the spam score of a thread or post, from 0 (not spam) to 1.
+// Scores are cached until the views change, so a SpamScorer should only depend on
+// the item and not on external state such as the current time.
+type SpamScorer func(item *SpamItem) float64
+
+// DefaultSpamScorer scores duplicates at 0.5 each, each thread or post beyond
+// SpamFrequencyLimit within SpamWindow at 0.1, and each spam vote or spam marking
+// of the creator at 0.25. The sum is capped at 1.
+func DefaultSpamScorer(item *SpamItem) float64 {
+	score := 0.5 * float64(item.Duplicates)
+	if item.Recent > SpamFrequencyLimit {
+		score += 0.1 * float64(item.Recent-SpamFrequencyLimit)
+	}
+	score += 0.25 * float64(item.SpamVotes+item.MarkedAsSpamBy)
+	return math.Min(score, 1)
+}
+
+// SetSpamScorer sets the scorer of threads and posts, and the score at or above
+// which they are hidden. A nil scorer uses DefaultSpamScorer, and a threshold that is
+// not positive disables hiding.
+func (v *Viewer) SetSpamScorer(scorer SpamScorer, threshold float64) {
+	if v == nil {
+		return
+	}
+	defer v.lock()()
+	v.spamScorer = scorer
+	v.spamThreshold = threshold
+	v.spam = nil
+}
+
+// spamScore obtains the spam score of a thread or post.
+func (v *Viewer) spamScore(hash string) float64 {
+	v.ensureSpam()
+	return v.spam[hash]
+}
+
+// ensureSpam scores threads and posts if they are not already scored.
+func (v *Viewer) ensureSpam() {
+	if v.spam != nil {
+		return
+	}
+	v.spam = v.spamScores()
+	v.spamCount = 0
+	for hash := range v.spam {
+		if v.isSpam(hash) {
+			v.spamCount++
+		}
+	}
+}
+
+// hidesSpam determines whether any thread or post is hidden as spam.
+func (v *Viewer) hidesSpam() bool {
+	if v.spamThreshold <= 0 {
+		return false
+	}
+	v.ensureSpam()
+	return v.spamCount > 0
+}
+
+// isSpam determines whether the thread or post should be hidden as spam.
+// Content of the board owner is never hidden.
+func (v *Viewer) isSpam(hash string) bool {
+	if v.spamThreshold <= 0 {
+		return false
+	}
+	if rep, ok := v.c.content[hash]; !ok || rep.ByOwner {
+		return false
+	}
+	return v.spamScore(hash) >= v.spamThreshold
+}
+
+// spamScores scores all threads and posts, in the order they are indexed.
+func (v *Viewer) spamScores() map[string]float64 {
+	scorer := v.spamScorer
+	if scorer == nil {
+		scorer = DefaultSpamScorer
+	}
+	var (
+		out    = make(map[string]float64)
+		bodies = make(map[[sha256.Size]byte]int)
+		times  = v.creationTimes()
+	)
+	score := func(hash string) {
+		rep, ok := v.c.content[hash]
+		if !ok {
+			return
+		}
+		body := rep.Body.(*object.Body)
+		digest := sha256.Sum256([]byte(strings.TrimSpace(body.Name) + "\n" + strings.TrimSpace(body.Body)))
+		item := &SpamItem{
+			Hash:       hash,
+			Rep:        rep,
+			Duplicates: bodies[digest],
+			Recent:     countWithin(times[body.Creator], body.TS),
+		}
+		bodies[digest]++
+		if votes, ok := v.c.votes[hash]; ok {
+			for _, vote := range votes.Votes {
+				if vote.GetBody().HasTag(object.SpamTag) {
+					item.SpamVotes++
+				}
+			}
+		}
+		if profile, ok := v.c.profiles[body.Creator]; ok {
+			item.MarkedAsSpamBy = len(profile.MarkedAsSpamBy)
+		}
+		out[hash] = scorer(item)
+	}
+	for _, tHash := range listOf(v.i.Threads) {
+		score(tHash)
+		if posts, ok := v.i.PostsOfThread[tHash]; ok {
+			for _, pHash := range listOf(posts) {
+				score(pHash)
+			}
+		}
+	}
+	return out
+}
+
+// creationTimes obtains the creation times of the threads and posts of each user, in order.
+func (v *Viewer) creationTimes() map[string][]int64 {
+	out := make(map[string][]int64, len(v.i.ContentOfUser))
+	for upk, list := range v.i.ContentOfUser {
+		var times []int64
+		for _, hash := range listOf(list) {
+			rep, ok := v.c.content[hash]
+			if !ok {
+				continue
+			}
+			switch b := rep.Body.(*object.Body); b.Type {
+			case object.V5ThreadType, object.V5PostType:
+				times = append(times, b.TS)
+			}
+		}
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+		out[upk] = times
+	}
+	return out
+}
+
+// countWithin counts the times within SpamWindow before 'ts', of ordered times.
+func countWithin(times []int64, ts int64) int {
+	from := sort.Search(len(times), func(i int) bool { return times[i] >= ts-int64(SpamWindow) })
+	to := sort.Search(len(times), func(i int) bool { return times[i] >= ts })
+	return to - from
+}
@@ -0,0 +1,1774 @@
+package state
+
+import (
+	"encoding/json"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/skycoin/src/cipher"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestViewer_GetBoardPage(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	addThread(t, bi, 0, []byte(userSeed))
+	fullThread, _ := addThread(t, bi, 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, fullThread, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	t.Run("all", func(t *testing.T) {
+		page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get board page:", e)
+		}
+		if len(page.Threads) != 2 {
+			t.Fatalf("thread count: got %d, expected %d", len(page.Threads), 2)
+		}
+		for _, thread := range page.Threads {
+			hasPosts := thread.Header.Hash == fullThread.Hex()
+			if hasPosts != (thread.LastPost != nil) {
+				t.Errorf("thread '%s': unexpected last post %v", thread.Header.Hash, thread.LastPost)
+			}
+			if thread.Stats == nil || (thread.Stats.PostCount > 0) != hasPosts || thread.Stats.Participants != 1 {
+				t.Errorf("thread '%s': unexpected stats %+v", thread.Header.Hash, thread.Stats)
+			}
+		}
+	})
+
+	t.Run("non_empty_only", func(t *testing.T) {
+		page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+			NonEmptyOnly:   true,
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get board page:", e)
+		}
+		if len(page.Threads) != 1 || page.Threads[0].Header.Hash != fullThread.Hex() {
+			t.Fatalf("expected only thread '%s', got %v", fullThread.Hex(), page.Threads)
+		}
+	})
+
+	t.Run("non_empty_only_hashes", func(t *testing.T) {
+		page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+			NonEmptyOnly:   true,
+			HashesOnly:     true,
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get board page:", e)
+		}
+		if page.ThreadsMeta.RecordCount != 1 {
+			t.Errorf("record count: got %d, expected %d", page.ThreadsMeta.RecordCount, 1)
+		}
+	})
+
+	for _, sortBy := range []string{SortActivity, SortPosts} {
+		t.Run(sortBy, func(t *testing.T) {
+			page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+				SortBy:         sortBy,
+				PaginatedInput: typ.PaginatedInput{PageSize: 10},
+			})
+			if e != nil {
+				t.Fatal("failed to get board page:", e)
+			}
+			if len(page.Threads) != 2 || page.Threads[0].Header.Hash != fullThread.Hex() {
+				t.Fatalf("expected thread '%s' first, got %v", fullThread.Hex(), page.Threads)
+			}
+		})
+	}
+
+	t.Run("include_top_reply", func(t *testing.T) {
+		page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+			IncludeTopReply: true,
+			PaginatedInput:  typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get board page:", e)
+		}
+		for _, thread := range page.Threads {
+			hasPosts := thread.Header.Hash == fullThread.Hex()
+			if hasPosts != (thread.TopReply != nil) {
+				t.Errorf("thread '%s': unexpected top reply %v", thread.Header.Hash, thread.TopReply)
+			}
+		}
+	})
+}
+
+func TestViewer_StrictPerspective(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	var (
+		known, _   = cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+		unknown, _ = cipher.GenerateDeterministicKeyPair([]byte("unknown"))
+	)
+
+	get := map[string]func(perspective string, strict bool) error{
+		"GetBoardPage": func(perspective string, strict bool) error {
+			_, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+				Perspective:       perspective,
+				StrictPerspective: strict,
+				PaginatedInput:    typ.PaginatedInput{PageSize: 10},
+			})
+			return e
+		},
+		"GetThreadPage": func(perspective string, strict bool) error {
+			_, e := bi.Viewer().GetThreadPage(&ThreadPageIn{
+				Perspective:       perspective,
+				StrictPerspective: strict,
+				ThreadHash:        tHash.Hex(),
+				PaginatedInput:    typ.PaginatedInput{PageSize: 10},
+			})
+			return e
+		},
+		"GetVotes": func(perspective string, strict bool) error {
+			_, e := bi.Viewer().GetVotes(&ContentVotesIn{
+				Perspective:       perspective,
+				StrictPerspective: strict,
+				ContentHash:       tHash.Hex(),
+			})
+			return e
+		},
+	}
+
+	for name, fn := range get {
+		t.Run(name, func(t *testing.T) {
+			if e := fn(unknown.Hex(), false); e != nil {
+				t.Error("unknown perspective should be anonymous, got error:", e)
+			}
+			if e := fn(known.Hex(), true); e != nil {
+				t.Error("known perspective should pass strict check, got error:", e)
+			}
+			if e := fn("", true); e != nil {
+				t.Error("empty perspective should pass strict check, got error:", e)
+			}
+			if e := fn(unknown.Hex(), true); e != ErrUnknownPerspective {
+				t.Errorf("unknown perspective with strict check: got %v, expected %v",
+					e, ErrUnknownPerspective)
+			}
+		})
+	}
+}
+
+func TestViewer_GetThreadParticipants(t *testing.T) {
+	const boardSeed = "a"
+	var userSeeds = []string{"user0", "user1", "user2"}
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeeds[0]))
+	addThread(t, bi, 1, []byte(userSeeds[2]))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte(userSeeds[1]))
+	addPost(t, bi, tHash, 1, []byte(userSeeds[0]))
+	addPost(t, bi, tHash, 2, []byte(userSeeds[1]))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	out, e := bi.Viewer().GetThreadParticipants(&ThreadParticipantsIn{
+		ThreadHash:     tHash.Hex(),
+		WithProfiles:   true,
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get thread participants:", e)
+	}
+	if len(out.Participants) != 2 {
+		t.Fatalf("participant count: got %d, expected %d", len(out.Participants), 2)
+	}
+	for i, seed := range userSeeds[:2] {
+		upk, _ := cipher.GenerateDeterministicKeyPair([]byte(seed))
+		if out.Participants[i] != upk.Hex() {
+			t.Errorf("participant %d: got %s, expected %s", i, out.Participants[i], upk.Hex())
+		}
+		if out.Profiles[upk.Hex()] == nil {
+			t.Errorf("participant %d: missing profile", i)
+		}
+	}
+}
+
+func TestViewer_GetContentPath(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	page, e := bi.Viewer().GetThreadPage(&ThreadPageIn{
+		ThreadHash:     tHash.Hex(),
+		HashesOnly:     true,
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get thread page:", e)
+	}
+	if len(page.PostHashes) != 1 {
+		t.Fatalf("post count: got %d, expected %d", len(page.PostHashes), 1)
+	}
+
+	out, e := bi.Viewer().GetContentPath(page.PostHashes[0])
+	if e != nil {
+		t.Fatal("failed to get content path:", e)
+	}
+	expected := []string{page.Board.Header.Hash, tHash.Hex(), page.PostHashes[0]}
+	if len(out.Path) != len(expected) {
+		t.Fatalf("path length: got %d, expected %d", len(out.Path), len(expected))
+	}
+	for i, hash := range expected {
+		if out.Path[i].Header.Hash != hash {
+			t.Errorf("path[%d]: got %s, expected %s", i, out.Path[i].Header.Hash, hash)
+		}
+	}
+
+	if _, e := bi.Viewer().GetContentPath(cipher.SumSHA256([]byte("unknown")).Hex()); e == nil {
+		t.Error("expected error for unknown content")
+	}
+}
+
+func TestBoardInstance_SetPinOrder(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	var tHashes []cipher.SHA256
+	for i := 0; i < 3; i++ {
+		tHash, _ := addThread(t, bi, i, []byte(userSeed))
+		tHashes = append(tHashes, tHash)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	bi.maxPins = 2
+	if _, e := bi.SetPinOrder(tHashes); e == nil {
+		t.Error("expected error for pins over cap")
+	}
+	if _, e := bi.SetPinOrder([]cipher.SHA256{cipher.SumSHA256([]byte("unknown"))}); e == nil {
+		t.Error("expected error for unknown thread")
+	}
+
+	pins := []cipher.SHA256{tHashes[2], tHashes[0]}
+	if _, e := bi.SetPinOrder(pins); e != nil {
+		t.Fatal("failed to set pin order:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get board page:", e)
+	}
+	if len(page.Pinned) != len(pins) {
+		t.Fatalf("pinned count: got %d, expected %d", len(page.Pinned), len(pins))
+	}
+	for i, pin := range pins {
+		if page.Pinned[i].Header.Hash != pin.Hex() {
+			t.Errorf("pinned[%d]: got %s, expected %s", i, page.Pinned[i].Header.Hash, pin.Hex())
+		}
+		if !page.Pinned[i].Pinned {
+			t.Errorf("pinned[%d]: expected pinned flag to be set", i)
+		}
+	}
+	if len(page.Threads) != 1 || page.Threads[0].Header.Hash != tHashes[1].Hex() {
+		t.Errorf("expected only unpinned thread '%s', got %v", tHashes[1].Hex(), page.Threads)
+	} else if page.Threads[0].Pinned {
+		t.Error("expected unpinned thread to not have pinned flag set")
+	}
+}
+
+func TestViewer_RangeThreadTranscript(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte(userSeed))
+	addPost(t, bi, tHash, 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	var entries []*TranscriptEntry
+	e := bi.Viewer().RangeThreadTranscript(tHash.Hex(), func(entry *TranscriptEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if e != nil {
+		t.Fatal("failed to range thread transcript:", e)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("entry count: got %d, expected %d", len(entries), 3)
+	}
+	if entries[0].Hash != tHash.Hex() || entries[0].Depth != 0 {
+		t.Errorf("first entry should be thread '%s' of depth 0, got '%s' of depth %d",
+			tHash.Hex(), entries[0].Hash, entries[0].Depth)
+	}
+	for i, entry := range entries[1:] {
+		if entry.Depth != 1 {
+			t.Errorf("post %d: got depth %d, expected %d", i, entry.Depth, 1)
+		}
+		if entry.TS < entries[i].TS {
+			t.Errorf("post %d: out of order", i)
+		}
+	}
+
+	if e := bi.Viewer().RangeThreadTranscript(cipher.SumSHA256([]byte("unknown")).Hex(),
+		func(*TranscriptEntry) error { return nil }); e == nil {
+		t.Error("expected error for unknown thread")
+	}
+}
+
+func TestViewer_SearchContent(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	addThread(t, bi, 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		query    string
+		expected int
+	}{
+		{"THREAD", 2},
+		{"post", 1},
+		{"index", 3},
+		{"nothing", 0},
+	}
+	for _, c := range cases {
+		out, e := bi.Viewer().SearchContent(&SearchIn{
+			Query:          c.query,
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatalf("query '%s': failed to search: %v", c.query, e)
+		}
+		if len(out.Results) != c.expected {
+			t.Errorf("query '%s': got %d results, expected %d", c.query, len(out.Results), c.expected)
+		}
+		for _, result := range out.Results {
+			if result.Votes == nil || result.Votes.Ref != result.Content.Header.Hash {
+				t.Errorf("query '%s': result '%s' has unmatched votes", c.query, result.Content.Header.Hash)
+			}
+		}
+	}
+
+	if _, e := bi.Viewer().SearchContent(&SearchIn{Query: " "}); e == nil {
+		t.Error("expected error for empty query")
+	}
+}
+
+func TestViewer_deleteThread(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+		otherSeed = "other"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	addThread(t, bi, 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte(userSeed))
+	addPost(t, bi, tHash, 1, []byte(otherSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	v := bi.v
+	defer v.lock()()
+
+	var (
+		threadCount  = v.i.Threads.Len()
+		indexCount   = len(v.i.PostsOfThread)
+		contentCount = len(v.c.content)
+	)
+	if count := v.deleteThread(tHash.Hex()); count != 2 {
+		t.Errorf("deleted posts: got %d, expected %d", count, 2)
+	}
+	if got := v.i.Threads.Len(); got != threadCount-1 {
+		t.Errorf("thread count: got %d, expected %d", got, threadCount-1)
+	}
+	if got := len(v.i.PostsOfThread); got >= indexCount {
+		t.Errorf("posts of thread index did not shrink: got %d, was %d", got, indexCount)
+	}
+	if _, ok := v.i.PostsOfThread[tHash.Hex()]; ok {
+		t.Error("posts of deleted thread are still indexed")
+	}
+	if got := len(v.c.content); got != contentCount-3 {
+		t.Errorf("content count: got %d, expected %d", got, contentCount-3)
+	}
+	upk, _ := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+	if profile := v.c.GetProfile(upk.Hex()); profile.ThreadCount != 1 || profile.PostCount != 0 {
+		t.Errorf("profile counts: got %d threads and %d posts, expected 1 and 0",
+			profile.ThreadCount, profile.PostCount)
+	}
+	opk, _ := cipher.GenerateDeterministicKeyPair([]byte(otherSeed))
+	if _, ok := v.i.ContentOfUser[opk.Hex()]; ok {
+		t.Error("content index of user without remaining content is not removed")
+	}
+}
+
+func TestViewer_SelfProfile(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	now := time.Now().UnixNano()
+	addUserProfile(t, bi, "newer", now, []byte(userSeed))
+	addUserProfile(t, bi, "older", now-int64(time.Hour), []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	upk, _ := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+	out, e := bi.Viewer().GetUserProfile(&UserProfileIn{UserPubKey: upk.Hex()})
+	if e != nil {
+		t.Fatal("failed to get user profile:", e)
+	}
+	if out.Profile.DisplayName != "newer" || out.Profile.AvatarRef != "newer.png" {
+		t.Errorf("got display name '%s' and avatar '%s', expected latest by timestamp",
+			out.Profile.DisplayName, out.Profile.AvatarRef)
+	}
+}
+
+func TestViewer_EmptyResults(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	page := typ.PaginatedInput{PageSize: 10}
+	v := bi.Viewer()
+
+	boardPage, e := v.GetBoardPage(&BoardPageIn{PaginatedInput: page})
+	if e != nil {
+		t.Fatal("failed to get board page:", e)
+	}
+	if boardPage.Threads == nil {
+		t.Error("board page: got nil threads")
+	}
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	threadPage, e := v.GetThreadPage(&ThreadPageIn{ThreadHash: tHash.Hex(), PaginatedInput: page})
+	if e != nil {
+		t.Fatal("failed to get thread page:", e)
+	}
+	if threadPage.Posts == nil {
+		t.Error("thread page: got nil posts")
+	}
+
+	watched, e := v.GetWatchedThreads(&WatchedThreadsIn{UserPubKey: "unknown"})
+	if e != nil {
+		t.Fatal("failed to get watched threads:", e)
+	}
+	if watched.Threads == nil {
+		t.Error("watched threads: got nil threads")
+	}
+
+	reports, e := v.GetReports(&ReportsIn{PaginatedInput: page})
+	if e != nil {
+		t.Fatal("failed to get reports:", e)
+	}
+	if reports.Reported == nil {
+		t.Error("reports: got nil reported")
+	}
+
+	edges, e := v.ExportTrustGraph()
+	if e != nil {
+		t.Fatal("failed to export trust graph:", e)
+	}
+	if edges == nil {
+		t.Error("trust graph: got nil edges")
+	}
+}
+
+func TestBoardInstance_SetRetention(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	oldThread, _ := addThread(t, bi, 0, []byte(userSeed))
+	newThread, _ := addThread(t, bi, 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	if _, e := bi.SetRetention(-1); e == nil {
+		t.Error("expected error for negative retention")
+	}
+	if _, e := bi.SetRetention(1); e != nil {
+		t.Fatal("failed to set retention:", e)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	// Age the thread beyond retention.
+	bi.v.c.content[oldThread.Hex()].Body.(*object.Body).TS = time.Now().AddDate(0, 0, -2).UnixNano()
+
+	page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get board page:", e)
+	}
+	if len(page.Threads) != 1 || page.Threads[0].Header.Hash != newThread.Hex() {
+		t.Fatalf("expected only thread '%s', got %v", newThread.Hex(), page.Threads)
+	}
+
+	page, e = bi.Viewer().GetBoardPage(&BoardPageIn{
+		IncludeArchived: true,
+		PaginatedInput:  typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get board page:", e)
+	}
+	if len(page.Threads) != 2 {
+		t.Fatalf("thread count: got %d, expected %d", len(page.Threads), 2)
+	}
+	for _, thread := range page.Threads {
+		if archived := thread.Header.Hash == oldThread.Hex(); archived != thread.Archived {
+			t.Errorf("thread '%s': got archived %v, expected %v", thread.Header.Hash, thread.Archived, archived)
+		}
+	}
+}
+
+func TestViewer_SetRepTransform(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	bi.v.SetRepTransform(func(rep *object.ContentRep, perspective string) *object.ContentRep {
+		rep.PubKey = perspective
+		rep.Header.Sig = ""
+		body := rep.Body.(*object.Body)
+		body.Body = "[redacted]"
+		body.Tags = append(body.Tags[:0], "redacted")
+		return rep
+	})
+	page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+		Perspective:    "perspective",
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get board page:", e)
+	}
+	if len(page.Threads) != 1 || page.Threads[0].PubKey != "perspective" {
+		t.Fatalf("expected transformed thread, got %v", page.Threads)
+	}
+	if page.Board.PubKey != "perspective" {
+		t.Errorf("expected transformed board, got public key '%s'", page.Board.PubKey)
+	}
+	stored := bi.v.c.content[tHash.Hex()]
+	if stored.PubKey == "perspective" || stored.Header.Sig == "" {
+		t.Error("transform modified the stored rep")
+	}
+	if body := stored.Body.(*object.Body); body.Body == "[redacted]" {
+		t.Error("transform modified the stored body")
+	}
+}
+
+func TestViewer_GetActivityFeed(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	busyThread, _ := addThread(t, bi, 0, []byte(userSeed))
+	addThread(t, bi, 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	for i := 0; i < 3; i++ {
+		addPost(t, bi, busyThread, i, []byte(userSeed))
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		name         string
+		maxPerThread int
+		expected     int
+	}{
+		{"no limit", 0, 5},
+		{"limited", 2, 3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetActivityFeed(&ActivityIn{
+				MaxPerThread:   c.maxPerThread,
+				PaginatedInput: typ.PaginatedInput{PageSize: 10},
+			})
+			if e != nil {
+				t.Fatal("failed to get activity feed:", e)
+			}
+			if len(out.Items) != c.expected {
+				t.Fatalf("item count: got %d, expected %d", len(out.Items), c.expected)
+			}
+			counts := make(map[string]int)
+			for _, item := range out.Items {
+				if counts[item.Thread]++; c.maxPerThread > 0 && counts[item.Thread] > c.maxPerThread {
+					t.Errorf("thread '%s' exceeds %d items", item.Thread, c.maxPerThread)
+				}
+			}
+		})
+	}
+}
+
+func TestViewer_GetUserProfile_RecentContent(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	var tHashes []cipher.SHA256
+	for i := 0; i < 3; i++ {
+		tHash, _ := addThread(t, bi, i, []byte(userSeed))
+		tHashes = append(tHashes, tHash)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	upk, _ := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+	out, e := bi.Viewer().GetUserProfile(&UserProfileIn{
+		UserPubKey:    upk.Hex(),
+		RecentContent: 2,
+	})
+	if e != nil {
+		t.Fatal("failed to get user profile:", e)
+	}
+	expected := []string{tHashes[2].Hex(), tHashes[1].Hex()}
+	if len(out.RecentContent) != len(expected) {
+		t.Fatalf("recent content count: got %d, expected %d", len(out.RecentContent), len(expected))
+	}
+	for i, hash := range expected {
+		if out.RecentContent[i].Header.Hash != hash {
+			t.Errorf("recent content[%d]: got %s, expected %s", i, out.RecentContent[i].Header.Hash, hash)
+		}
+		if out.RecentContent[i].Votes == nil {
+			t.Errorf("recent content[%d]: expected votes", i)
+		}
+	}
+}
+
+func TestViewer_Scores(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	votedThread, _ := addThread(t, bi, 0, []byte("user"))
+	addThread(t, bi, 1, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	for _, voterSeed := range []string{"voter1", "voter2", "voter3"} {
+		addThreadVote(t, bi, votedThread, +1, []byte(voterSeed))
+	}
+	addThreadVote(t, bi, votedThread, -1, []byte("voter4"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		sortBy string
+		first  bool // Whether voted thread is first.
+	}{
+		{SortNewest, false},
+		{SortHot, true},
+	}
+	for _, c := range cases {
+		t.Run(c.sortBy, func(t *testing.T) {
+			page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+				SortBy:         c.sortBy,
+				PaginatedInput: typ.PaginatedInput{PageSize: 10},
+			})
+			if e != nil {
+				t.Fatal("failed to get board page:", e)
+			}
+			if len(page.Threads) != 2 {
+				t.Fatalf("thread count: got %d, expected %d", len(page.Threads), 2)
+			}
+			if first := page.Threads[0].Header.Hash == votedThread.Hex(); first != c.first {
+				t.Errorf("voted thread first: got %v, expected %v", first, c.first)
+			}
+			for _, thread := range page.Threads {
+				expected := object.ContentScore{}
+				if thread.Header.Hash == votedThread.Hex() {
+					expected = object.ContentScore{Up: 3, Down: 1, Net: 2}
+				}
+				if thread.Score == nil || *thread.Score != expected {
+					t.Errorf("thread '%s': got score %+v, expected %+v", thread.Header.Hash, thread.Score, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestViewer_GetUserActivity(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+		otherSeed = "other"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	userThread, _ := addThread(t, bi, 0, []byte(userSeed))
+	otherThread, _ := addThread(t, bi, 1, []byte(otherSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, otherThread, 0, []byte(userSeed))
+	addPost(t, bi, otherThread, 1, []byte(userSeed))
+	addPost(t, bi, userThread, 2, []byte(otherSeed))
+	addThreadVote(t, bi, otherThread, +1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	upk, _ := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+	out, e := bi.Viewer().GetUserActivity(&UserActivityIn{
+		UserPubKey:     upk.Hex(),
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get user activity:", e)
+	}
+	if len(out.Threads) != 1 || out.Threads[0].Header.Hash != userThread.Hex() {
+		t.Errorf("expected only thread '%s', got %v", userThread.Hex(), out.Threads)
+	}
+	if len(out.Posts) != 2 {
+		t.Errorf("post count: got %d, expected %d", len(out.Posts), 2)
+	}
+	if len(out.Votes) != 1 || out.Votes[0].Of != otherThread.Hex() || out.Votes[0].Value != +1 {
+		t.Errorf("expected up vote of thread '%s', got %v", otherThread.Hex(), out.Votes)
+	}
+
+	unknown, _ := cipher.GenerateDeterministicKeyPair([]byte("unknown"))
+	out, e = bi.Viewer().GetUserActivity(&UserActivityIn{
+		UserPubKey:     unknown.Hex(),
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get user activity:", e)
+	}
+	if out.Threads == nil || out.Posts == nil || out.Votes == nil ||
+		len(out.Threads)+len(out.Posts)+len(out.Votes) != 0 {
+		t.Errorf("expected empty activity, got %+v", out)
+	}
+}
+
+func TestViewer_parseMentions(t *testing.T) {
+	creator, _ := cipher.GenerateDeterministicKeyPair([]byte("creator"))
+	user, _ := cipher.GenerateDeterministicKeyPair([]byte("user"))
+
+	body := &object.Body{
+		Body: "@Alice and @" + user.Hex() + ", thanks! cc @alice, " +
+			"me@example.com, @" + creator.Hex(),
+		Creator: creator.Hex(),
+	}
+	got := parseMentions(body)
+	expected := []string{"alice", user.Hex()}
+	if len(got) != len(expected) {
+		t.Fatalf("mentions: got %v, expected %v", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("mention %d: got '%s', expected '%s'", i, got[i], expected[i])
+		}
+	}
+
+	i := NewIndexer()
+	i.AddMentions("post", body)
+	if list, ok := i.Mentions[user.Hex()]; !ok || list.Len() != 1 {
+		t.Errorf("expected post to be indexed under '%s'", user.Hex())
+	}
+	i.DeleteMentions("post", body)
+	if len(i.Mentions) != 0 {
+		t.Errorf("expected no mentions after delete, got %v", i.Mentions)
+	}
+}
+
+func TestViewer_Muted(t *testing.T) {
+	const (
+		boardSeed   = "a"
+		userSeed    = "user"
+		blockedSeed = "blocked"
+		spamSeed    = "spam"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	addThread(t, bi, 1, []byte(blockedSeed))
+	addThread(t, bi, 2, []byte(spamSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte(blockedSeed))
+	addPost(t, bi, tHash, 1, []byte(userSeed))
+	addPost(t, bi, tHash, 2, []byte(spamSeed))
+	blocked, _ := cipher.GenerateDeterministicKeyPair([]byte(blockedSeed))
+	spam, _ := cipher.GenerateDeterministicKeyPair([]byte(spamSeed))
+	addUserVote(t, bi, blocked, -1, object.BlockTag, []byte(userSeed))
+	addUserVote(t, bi, spam, -1, object.SpamTag, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	upk, _ := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+	cases := []struct {
+		muted     string
+		count     int // Expected number of threads, and of posts.
+		collapsed int // Expected number of collapsed threads, and of posts.
+	}{
+		{MutedShow, 3, 0},
+		{MutedHide, 1, 0},
+		{MutedCollapse, 3, 2},
+	}
+	for _, c := range cases {
+		t.Run("muted_"+c.muted, func(t *testing.T) {
+			board, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+				Perspective:    upk.Hex(),
+				Muted:          c.muted,
+				PaginatedInput: typ.PaginatedInput{PageSize: 10},
+			})
+			if e != nil {
+				t.Fatal("failed to get board page:", e)
+			}
+			thread, e := bi.Viewer().GetThreadPage(&ThreadPageIn{
+				Perspective:    upk.Hex(),
+				ThreadHash:     tHash.Hex(),
+				Muted:          c.muted,
+				PaginatedInput: typ.PaginatedInput{PageSize: 10},
+			})
+			if e != nil {
+				t.Fatal("failed to get thread page:", e)
+			}
+			for name, reps := range map[string][]*object.ContentRep{
+				"threads": board.Threads,
+				"posts":   thread.Posts,
+			} {
+				var collapsed int
+				for _, rep := range reps {
+					if rep.Collapsed {
+						collapsed++
+					}
+				}
+				if len(reps) != c.count || collapsed != c.collapsed {
+					t.Errorf("%s: got %d (%d collapsed), expected %d (%d collapsed)",
+						name, len(reps), collapsed, c.count, c.collapsed)
+				}
+			}
+		})
+	}
+
+	if _, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+		Muted:          "invalid",
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	}); e == nil {
+		t.Error("expected error for invalid muted option")
+	}
+}
+
+func TestViewer_GetTrustScore(t *testing.T) {
+	const boardSeed = "a"
+	seeds := []string{"a", "b", "c", "d", "e"} // Each trusts the next.
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	upks := make([]cipher.PubKey, len(seeds))
+	for i, seed := range seeds {
+		upks[i], _ = cipher.GenerateDeterministicKeyPair([]byte(seed))
+		addThread(t, bi, i, []byte(seed))
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	for i := 0; i < len(seeds)-1; i++ {
+		addUserVote(t, bi, upks[i+1], +1, object.TrustTag, []byte(seeds[i]))
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	expected := []float64{1, 1, 0.5, 0.25, 0}
+	for i, exp := range expected {
+		out, e := bi.Viewer().GetTrustScore(upks[0].Hex(), upks[i].Hex())
+		if e != nil {
+			t.Fatal("failed to get trust score:", e)
+		}
+		if out.Score != exp {
+			t.Errorf("trust score of '%s' in '%s': got %v, expected %v",
+				seeds[0], seeds[i], out.Score, exp)
+		}
+	}
+
+	board, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+		Perspective:    upks[0].Hex(),
+		MinTrust:       0.5,
+		PaginatedInput: typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get board page:", e)
+	}
+	if len(board.Threads) != 3 {
+		t.Errorf("threads of minimum trust: got %d, expected %d", len(board.Threads), 3)
+	}
+}
+
+func TestViewer_Spam(t *testing.T) {
+	const boardSeed = "a"
+	seeds := []string{"x", "y", "z"} // Each creates the same thread.
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	for _, seed := range seeds {
+		addThread(t, bi, 0, []byte(seed))
+		if e := bi.PublishChanges(); e != nil {
+			t.Fatal("failed to publish changes:", e)
+		}
+	}
+	addThread(t, bi, 1, []byte(seeds[0]))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	getThreads := func(showSpam bool) []*object.ContentRep {
+		board, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+			ShowSpam:       showSpam,
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get board page:", e)
+		}
+		return board.Threads
+	}
+
+	threads := getThreads(true)
+	if len(threads) != 4 {
+		t.Fatalf("threads with spam: got %d, expected %d", len(threads), 4)
+	}
+	for i, exp := range []float64{0, 0.5, 1, 0} {
+		if threads[i].SpamScore != exp {
+			t.Errorf("spam score of thread %d: got %v, expected %v", i, threads[i].SpamScore, exp)
+		}
+	}
+	if threads := getThreads(false); len(threads) != 3 {
+		t.Errorf("threads without spam: got %d, expected %d", len(threads), 3)
+	}
+
+	bi.v.SetSpamScorer(func(item *SpamItem) float64 { return 0 }, DefaultSpamThreshold)
+	if threads := getThreads(false); len(threads) != 4 {
+		t.Errorf("threads without spam of custom scorer: got %d, expected %d", len(threads), 4)
+	}
+}
+
+func TestViewer_voteViews(t *testing.T) {
+	const (
+		boardSeed = "a"
+		voterSeed = "voter"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	voterPK, _ := cipher.GenerateDeterministicKeyPair([]byte(voterSeed))
+
+	check := func(expected VoteRepView) {
+		page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+			Perspective:    voterPK.Hex(),
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get board page:", e)
+		}
+		view, ok := page.Threads[0].Votes.(*VoteRepView)
+		if !ok {
+			t.Fatalf("unexpected votes %v", page.Threads[0].Votes)
+		}
+		if view.Ref != tHash.Hex() || view.Up != expected.Up || view.Down != expected.Down {
+			t.Errorf("got votes %+v, expected %+v", view, expected)
+		}
+	}
+
+	addThreadVote(t, bi, tHash, +1, []byte(voterSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	check(VoteRepView{Up: X{Voted: true, Count: 1}})
+	check(VoteRepView{Up: X{Voted: true, Count: 1}})
+
+	// Cached view is invalidated as the vote changes.
+	addThreadVote(t, bi, tHash, -1, []byte(voterSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	check(VoteRepView{Down: X{Voted: true, Count: 1}})
+
+	// Least recently used perspective is evicted.
+	cache := newVoteViewCache(2)
+	cache.set("a", tHash.Hex(), &VoteRepView{})
+	cache.set("b", tHash.Hex(), &VoteRepView{})
+	cache.get("a", tHash.Hex())
+	cache.set("c", tHash.Hex(), &VoteRepView{})
+	for perspective, expected := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := cache.get(perspective, tHash.Hex()); ok != expected {
+			t.Errorf("perspective '%s' cached: got %v, expected %v", perspective, ok, expected)
+		}
+	}
+}
+
+func TestViewer_requestState(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	// plainContent obtains the thread as viewed by a request with no options.
+	plainContent := func(t *testing.T, hash string) *object.ContentRep {
+		out, e := bi.Viewer().GetContents(&ContentsIn{ContentHashes: []string{hash}})
+		if e != nil {
+			t.Fatal("failed to get contents:", e)
+		}
+		if len(out.Contents) != 1 {
+			t.Fatalf("content count: got %d, expected %d", len(out.Contents), 1)
+		}
+		return out.Contents[0]
+	}
+
+	t.Run("board_page", func(t *testing.T) {
+		page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+			SinceUnix:      1,
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get board page:", e)
+		}
+		if len(page.Threads) != 1 || page.Threads[0].UnreadCount != 1 || page.Threads[0].LastPost == nil {
+			t.Fatalf("expected a thread with an unread count and last post, got %v", page.Threads)
+		}
+		if rep := plainContent(t, tHash.Hex()); rep.UnreadCount != 0 || rep.LastPost != nil || rep.Stats != nil {
+			t.Errorf("state of board page leaked: got unread count %d, last post %v and stats %v",
+				rep.UnreadCount, rep.LastPost, rep.Stats)
+		}
+	})
+
+	t.Run("top_reply", func(t *testing.T) {
+		page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+			IncludeTopReply: true,
+			PaginatedInput:  typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get board page:", e)
+		}
+		if len(page.Threads) != 1 || page.Threads[0].TopReply == nil {
+			t.Fatalf("expected a thread with a top reply, got %v", page.Threads)
+		}
+		if rep := plainContent(t, tHash.Hex()); rep.TopReply != nil {
+			t.Errorf("top reply of board page leaked: got %v", rep.TopReply)
+		}
+		page, e = bi.Viewer().GetBoardPage(&BoardPageIn{
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get board page:", e)
+		}
+		if page.Threads[0].TopReply != nil {
+			t.Error("thread has a top reply without include_top_reply")
+		}
+	})
+
+	t.Run("thread_page", func(t *testing.T) {
+		page, e := bi.Viewer().GetThreadPage(&ThreadPageIn{
+			ThreadHash:     tHash.Hex(),
+			SinceUnix:      1,
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get thread page:", e)
+		}
+		if page.Thread.UnreadCount != 1 {
+			t.Fatalf("unread count: got %d, expected %d", page.Thread.UnreadCount, 1)
+		}
+		if rep := plainContent(t, tHash.Hex()); rep.UnreadCount != 0 || rep.Stats != nil {
+			t.Errorf("state of thread page leaked: got unread count %d and stats %v",
+				rep.UnreadCount, rep.Stats)
+		}
+	})
+
+	t.Run("collapse_below", func(t *testing.T) {
+		collapseBelow := 1
+		page, e := bi.Viewer().GetThreadPage(&ThreadPageIn{
+			ThreadHash:     tHash.Hex(),
+			CollapseBelow:  &collapseBelow,
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get thread page:", e)
+		}
+		if len(page.Posts) != 1 || !page.Posts[0].Collapsed {
+			t.Fatalf("expected a collapsed post, got %v", page.Posts)
+		}
+		pHash := page.Posts[0].Header.Hash
+		if rep := plainContent(t, pHash); rep.Collapsed || rep.Attachments != nil {
+			t.Error("post is collapsed for other requests")
+		}
+		page, e = bi.Viewer().GetThreadPage(&ThreadPageIn{
+			ThreadHash:     tHash.Hex(),
+			PaginatedInput: typ.PaginatedInput{PageSize: 10},
+		})
+		if e != nil {
+			t.Fatal("failed to get thread page:", e)
+		}
+		if page.Posts[0].Collapsed {
+			t.Error("post is collapsed without collapse_below")
+		}
+	})
+}
+
+func TestViewer_HashesOnly(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	var threads []cipher.SHA256
+	for i := 0; i < 3; i++ {
+		tHash, _ := addThread(t, bi, i, []byte(userSeed))
+		threads = append(threads, tHash)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	for i := 0; i < 3; i++ {
+		addPost(t, bi, threads[0], i, []byte(userSeed))
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	posts := listOf(bi.v.i.PostsOfThread[threads[0].Hex()])
+
+	t.Run("board_page", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			page    typ.PaginatedInput
+			count   int
+			errType int
+		}{
+			{"all", typ.PaginatedInput{PageSize: 10}, 3, 0},
+			{"paginated", typ.PaginatedInput{StartIndex: 1, PageSize: 1}, 1, 0},
+			{"invalid_start", typ.PaginatedInput{StartIndex: 4, PageSize: 1}, 0, boo.InvalidInput},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				page, e := bi.Viewer().GetBoardPage(&BoardPageIn{HashesOnly: true, PaginatedInput: c.page})
+				if c.errType != 0 {
+					if boo.Type(e) != c.errType {
+						t.Errorf("got error %v, expected type %d", e, c.errType)
+					}
+					return
+				}
+				if e != nil {
+					t.Fatal("failed to get board page:", e)
+				}
+				full, e := bi.Viewer().GetBoardPage(&BoardPageIn{PaginatedInput: c.page})
+				if e != nil {
+					t.Fatal("failed to get board page:", e)
+				}
+				if page.Threads != nil || page.ThreadsMeta == nil || page.ThreadsMeta.RecordCount != uint(c.count) {
+					t.Fatalf("got threads %v and meta %+v, expected %d hashes only",
+						page.Threads, page.ThreadsMeta, c.count)
+				}
+				for i, thread := range full.Threads {
+					if page.ThreadHashes[i] != thread.Header.Hash {
+						t.Errorf("thread hash %d: got '%s', expected '%s'", i, page.ThreadHashes[i], thread.Header.Hash)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("thread_page", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			tHash    string
+			page     typ.PaginatedInput
+			expected []string
+			errType  int
+		}{
+			{"all", threads[0].Hex(), typ.PaginatedInput{PageSize: 10}, posts, 0},
+			{"paginated", threads[0].Hex(), typ.PaginatedInput{StartIndex: 1, PageSize: 1}, posts[1:2], 0},
+			{"no_posts", threads[1].Hex(), typ.PaginatedInput{PageSize: 10}, nil, 0},
+			{"invalid_size", threads[0].Hex(), typ.PaginatedInput{}, nil, boo.InvalidInput},
+			{"unknown_thread", "unknown", typ.PaginatedInput{PageSize: 10}, nil, boo.NotFound},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				page, e := bi.Viewer().GetThreadPage(&ThreadPageIn{
+					ThreadHash:     c.tHash,
+					HashesOnly:     true,
+					PaginatedInput: c.page,
+				})
+				if c.errType != 0 {
+					if boo.Type(e) != c.errType {
+						t.Errorf("got error %v, expected type %d", e, c.errType)
+					}
+					return
+				}
+				if e != nil {
+					t.Fatal("failed to get thread page:", e)
+				}
+				if page.Posts != nil || page.Thread == nil || page.PostsMeta == nil {
+					t.Fatalf("got posts %v, thread %v and meta %v, expected thread and hashes only",
+						page.Posts, page.Thread, page.PostsMeta)
+				}
+				if len(page.PostHashes) != len(c.expected) {
+					t.Fatalf("got post hashes %v, expected %v", page.PostHashes, c.expected)
+				}
+				for i, pHash := range c.expected {
+					if page.PostHashes[i] != pHash {
+						t.Errorf("post hash %d: got '%s', expected '%s'", i, page.PostHashes[i], pHash)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("contents", func(t *testing.T) {
+		out, e := bi.Viewer().GetContents(&ContentsIn{
+			ContentHashes: []string{posts[2], "unknown", threads[1].Hex()},
+		})
+		if e != nil {
+			t.Fatal("failed to get contents:", e)
+		}
+		if len(out.Contents) != 2 || out.Contents[0].Header.Hash != posts[2] ||
+			out.Contents[1].Header.Hash != threads[1].Hex() {
+			t.Errorf("got %v, expected the post and thread in order, without unknown content", out.Contents)
+		}
+		if _, e := bi.Viewer().GetContents(&ContentsIn{
+			Perspective:       "unknown",
+			StrictPerspective: true,
+		}); e == nil {
+			t.Error("expected error of unknown strict perspective")
+		}
+	})
+}
+
+// addReply adds a post to the thread in reply to the post of hash 'pHash'.
+func addReply(t *testing.T, bi *BoardInstance, tHash cipher.SHA256, pHash string, userSeed []byte) {
+	cpk, csk := cipher.GenerateDeterministicKeyPair(userSeed)
+	raw, _ := json.Marshal(&object.Body{
+		Type:     object.V5PostType,
+		TS:       time.Now().UnixNano(),
+		OfBoard:  obtainBoardPubKey(t, bi).Hex(),
+		OfThread: tHash.Hex(),
+		OfPost:   pHash,
+		Body:     "A reply.",
+		Creator:  cpk.Hex(),
+	})
+	transport, e := object.NewTransport(raw, cipher.SignHash(cipher.SumSHA256(raw), csk))
+	if e != nil {
+		t.Fatal("failed to generate transport:", e)
+	}
+	if _, e := bi.Submit(transport); e != nil {
+		t.Fatal("failed to create reply:", e)
+	}
+}
+
+func TestViewer_GetReplies(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte(userSeed))
+	addPost(t, bi, tHash, 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	posts := listOf(bi.v.i.PostsOfThread[tHash.Hex()])
+	parent, other := posts[0], posts[1]
+	for i := 0; i < 3; i++ {
+		addReply(t, bi, tHash, parent, []byte(userSeed))
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	replies := listOf(bi.v.i.PostsOfThread[parent])
+	if len(replies) != 3 {
+		t.Fatalf("got %d replies indexed, expected %d", len(replies), 3)
+	}
+
+	cases := []struct {
+		name     string
+		pHash    string
+		page     typ.PaginatedInput
+		expected []string
+		errType  int
+	}{
+		{"all", parent, typ.PaginatedInput{PageSize: 10}, replies, 0},
+		{"paginated", parent, typ.PaginatedInput{StartIndex: 1, PageSize: 1}, replies[1:2], 0},
+		{"no_replies", other, typ.PaginatedInput{PageSize: 10}, nil, 0},
+		{"invalid_start", parent, typ.PaginatedInput{StartIndex: 4, PageSize: 1}, nil, boo.InvalidInput},
+		{"thread", tHash.Hex(), typ.PaginatedInput{PageSize: 10}, nil, boo.NotFound},
+		{"unknown", "unknown", typ.PaginatedInput{PageSize: 10}, nil, boo.NotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetReplies(&RepliesIn{PostHash: c.pHash, PaginatedInput: c.page})
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get replies:", e)
+			}
+			if out.Replies == nil || len(out.Replies) != len(c.expected) {
+				t.Fatalf("got replies %v, expected %v", out.Replies, c.expected)
+			}
+			for i, rHash := range c.expected {
+				if got := out.Replies[i].Header.Hash; got != rHash {
+					t.Errorf("reply %d: got '%s', expected '%s'", i, got, rHash)
+				}
+			}
+		})
+	}
+}
+
+func TestViewer_ByOwner(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	ownerThread, _ := addThread(t, bi, 0, []byte(boardSeed))
+	userThread, _ := addThread(t, bi, 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, userThread, 0, []byte(boardSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, userThread, 1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	posts := listOf(bi.v.i.PostsOfThread[userThread.Hex()])
+
+	cases := []struct {
+		name    string
+		hash    string
+		byOwner bool
+	}{
+		{"owner_thread", ownerThread.Hex(), true},
+		{"user_thread", userThread.Hex(), false},
+		{"owner_post", posts[0], true},
+		{"user_post", posts[1], false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetContents(&ContentsIn{ContentHashes: []string{c.hash}})
+			if e != nil {
+				t.Fatal("failed to get contents:", e)
+			}
+			if len(out.Contents) != 1 {
+				t.Fatalf("got %d contents, expected %d", len(out.Contents), 1)
+			}
+			if got := out.Contents[0].ByOwner; got != c.byOwner {
+				t.Errorf("by owner: got %v, expected %v", got, c.byOwner)
+			}
+		})
+	}
+
+	// The top reply, earliest of equal score, keeps the mark.
+	page, e := bi.Viewer().GetBoardPage(&BoardPageIn{
+		IncludeTopReply: true,
+		PaginatedInput:  typ.PaginatedInput{PageSize: 10},
+	})
+	if e != nil {
+		t.Fatal("failed to get board page:", e)
+	}
+	for _, thread := range page.Threads {
+		if thread.Header.Hash != userThread.Hex() {
+			continue
+		}
+		if thread.TopReply == nil || !thread.TopReply.ByOwner {
+			t.Errorf("got top reply %+v, expected the post of the owner", thread.TopReply)
+		}
+	}
+}
+
+// unverifiedReader is a ContentReader that reports all content as existing,
+// letting votes of missing content through to the views.
+type unverifiedReader struct {
+	ContentReader
+}
+
+func (unverifiedReader) HasContent(hash string) bool { return true }
+
+func TestViewer_Diagnostics(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+	missing := cipher.SumSHA256([]byte("missing post"))
+
+	cases := []struct {
+		name  string
+		debug bool
+		diags int
+	}{
+		{"debug", true, 1},
+		{"no_debug", false, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bi, quit := initInstance(t, boardSeed)
+			defer quit()
+
+			tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+			if e := bi.PublishChanges(); e != nil {
+				t.Fatal("failed to publish changes:", e)
+			}
+			bi.v.SetDebug(c.debug)
+
+			cpk, csk := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+			raw, _ := json.Marshal(&object.Body{
+				Type:     object.V5PostVoteType,
+				TS:       time.Now().UnixNano(),
+				OfBoard:  obtainBoardPubKey(t, bi).Hex(),
+				OfThread: tHash.Hex(),
+				OfPost:   missing.Hex(),
+				Value:    +1,
+				Creator:  cpk.Hex(),
+			})
+			transport, e := object.NewTransport(raw, cipher.SignHash(cipher.SumSHA256(raw), csk))
+			if e != nil {
+				t.Fatal("failed to generate transport:", e)
+			}
+			bi.SetContentReader(unverifiedReader{bi.Viewer()})
+			if _, e := bi.Submit(transport); e != nil {
+				t.Fatal("failed to vote on missing post:", e)
+			}
+			bi.SetContentReader(nil)
+
+			// Votes of missing content do not fail the update.
+			if e := bi.PublishChanges(); e != nil {
+				t.Fatal("failed to publish changes:", e)
+			}
+			diags, e := bi.v.Diagnostics()
+			if e != nil {
+				t.Fatal("failed to get diagnostics:", e)
+			}
+			if len(diags) != c.diags {
+				t.Fatalf("got diagnostics %+v, expected %d", diags, c.diags)
+			}
+			if c.diags == 0 {
+				return
+			}
+			if d := diags[0]; d.ContentHash != transport.Header.Hash || d.ContentType != object.V5PostVoteType ||
+				d.Creator != cpk.Hex() || d.Fatal || d.Error == "" {
+				t.Errorf("got diagnostic %+v, expected non-fatal diagnostic of vote '%s'",
+					d, transport.Header.Hash)
+			}
+		})
+	}
+
+	if _, e := (*Viewer)(nil).Diagnostics(); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
+
+func TestUpdateResult_Changed(t *testing.T) {
+	cases := []struct {
+		name     string
+		result   UpdateResult
+		expected bool
+	}{
+		{"empty", UpdateResult{}, false},
+		{"compacted_only", UpdateResult{Compacted: 2}, false},
+		{"board", UpdateResult{BoardChanged: true}, true},
+		{"threads", UpdateResult{Threads: 1}, true},
+		{"posts", UpdateResult{Posts: 1}, true},
+		{"votes", UpdateResult{Votes: 1}, true},
+		{"profiles", UpdateResult{Profiles: 1}, true},
+		{"deleted", UpdateResult{Deleted: 1}, true},
+		{"evicted", UpdateResult{Evicted: 1}, true},
+	}
+	for _, c := range cases {
+		if got := c.result.Changed(); got != c.expected {
+			t.Errorf("%s: got changed %v, expected %v", c.name, got, c.expected)
+		}
+	}
+}
+
+func TestViewer_GetThreadPage_collapseBelow(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	for i := 0; i < 3; i++ {
+		addPost(t, bi, tHash, i, []byte(userSeed))
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	// Scores of +1, 0 and -1 respectively.
+	posts := listOf(bi.v.i.PostsOfThread[tHash.Hex()])
+	addPostVote(t, bi, tHash, posts[0], +1, []byte("voter"))
+	addPostVote(t, bi, tHash, posts[2], -1, []byte("voter"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	below := func(score int) *int { return &score }
+	cases := []struct {
+		name          string
+		threadHash    string
+		collapseBelow *int
+		page          typ.PaginatedInput
+		collapsed     []bool
+		errType       int
+	}{
+		{"unset", tHash.Hex(), nil, typ.PaginatedInput{PageSize: 10}, []bool{false, false, false}, 0},
+		{"below_lowest", tHash.Hex(), below(-1), typ.PaginatedInput{PageSize: 10}, []bool{false, false, false}, 0},
+		{"below_neutral", tHash.Hex(), below(0), typ.PaginatedInput{PageSize: 10}, []bool{false, false, true}, 0},
+		{"below_highest", tHash.Hex(), below(1), typ.PaginatedInput{PageSize: 10}, []bool{false, true, true}, 0},
+		{"above_highest", tHash.Hex(), below(2), typ.PaginatedInput{PageSize: 10}, []bool{true, true, true}, 0},
+		{"unknown_thread", cipher.SHA256{}.Hex(), below(0), typ.PaginatedInput{PageSize: 10}, nil, boo.NotFound},
+		{"invalid_page", tHash.Hex(), below(0), typ.PaginatedInput{}, nil, boo.InvalidInput},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			page, e := bi.Viewer().GetThreadPage(&ThreadPageIn{
+				ThreadHash:     c.threadHash,
+				CollapseBelow:  c.collapseBelow,
+				PaginatedInput: c.page,
+			})
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get thread page:", e)
+			}
+			collapsed := make([]bool, len(page.Posts))
+			for i, post := range page.Posts {
+				collapsed[i] = post.Collapsed
+			}
+			if !reflect.DeepEqual(collapsed, c.collapsed) {
+				t.Errorf("got collapsed %v, expected %v", collapsed, c.collapsed)
+			}
+		})
+	}
+
+	if _, e := (*Viewer)(nil).GetThreadPage(&ThreadPageIn{}); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
+
+func TestCheckRefs(t *testing.T) {
+	var (
+		bpk, _    = cipher.GenerateDeterministicKeyPair([]byte("board"))
+		opk, _    = cipher.GenerateDeterministicKeyPair([]byte("other board"))
+		tHash     = cipher.SumSHA256([]byte("thread"))
+		oHash     = cipher.SumSHA256([]byte("other thread"))
+		misplaced = func(what, got, exp string) []string {
+			return []string{"misplaced " + what, "got '" + got + "'", "expected '" + exp + "'"}
+		}
+	)
+	cases := []struct {
+		name     string
+		check    func() error
+		contains []string // Substrings of the error message, nil for no error.
+	}{
+		{"board_matched", func() error {
+			return checkBoardRef(bpk, &object.Body{OfBoard: bpk.Hex()}, "post")
+		}, nil},
+		{"board_misplaced", func() error {
+			return checkBoardRef(bpk, &object.Body{OfBoard: opk.Hex()}, "post")
+		}, append(misplaced("post", opk.Hex(), bpk.Hex()), "board public key")},
+		{"board_corrupt", func() error {
+			return checkBoardRef(bpk, &object.Body{OfBoard: "corrupt"}, "post")
+		}, []string{"corrupt post"}},
+		{"thread_matched", func() error {
+			return checkThreadRef(tHash, &object.Body{OfThread: tHash.Hex()}, "post")
+		}, nil},
+		{"thread_misplaced", func() error {
+			return checkThreadRef(tHash, &object.Body{OfThread: oHash.Hex()}, "post")
+		}, append(misplaced("post", oHash.Hex(), tHash.Hex()), "thread reference")},
+		{"thread_corrupt", func() error {
+			return checkThreadRef(tHash, &object.Body{OfThread: "corrupt"}, "post")
+		}, []string{"corrupt post"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := c.check()
+			if c.contains == nil {
+				if e != nil {
+					t.Errorf("got error %v, expected none", e)
+				}
+				return
+			}
+			if boo.Type(e) != boo.InvalidRead {
+				t.Fatalf("got error %v, expected invalid read", e)
+			}
+			for _, s := range c.contains {
+				if !strings.Contains(e.Error(), s) {
+					t.Errorf("got error '%v', expected it to contain '%s'", e, s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package state
+
+import (
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"math"
+)
+
+// TranscriptEntry represents a thread or post of a thread transcript.
+type TranscriptEntry struct {
+	Depth   int                `json:"depth"` // 0 for the thread, 1 for top-level posts, and so on.
+	Hash    string             `json:"hash"`
+	Creator string             `json:"creator"`
+	TS      int64              `json:"ts"`
+	Content *object.ContentRep `json:"content"`
+}
+
+// RangeThreadTranscript calls 'action' for the thread, followed by all its posts
+// in reply-order (depth-first, with replies directly after the post they reply to).
+// Only the order of hashes is held in memory; each entry is obtained when it is
+// reached, so the viewer is not locked while 'action' runs. Posts removed while
+// ranging are skipped. Ranging stops when 'action' returns an error, which is
+// then returned.
+func (v *Viewer) RangeThreadTranscript(tHash string, action func(entry *TranscriptEntry) error) error {
+	if v == nil {
+		return ErrViewerNotInitialized
+	}
+	order, e := v.transcriptOrder(tHash)
+	if e != nil {
+		return e
+	}
+	for _, item := range order {
+		entry, ok := v.transcriptEntry(item.hash, item.depth)
+		if !ok {
+			continue
+		}
+		if e := action(entry); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+type transcriptItem struct {
+	hash  string
+	depth int
+}
+
+// transcriptOrder obtains the hashes of the thread and its posts in reply-order.
+func (v *Viewer) transcriptOrder(tHash string) ([]transcriptItem, error) {
+	defer v.lock()()
+
+	if rep, ok := v.c.content[tHash]; !ok || !isOfType(rep, object.V5ThreadType) {
+		return nil, boo.Newf(boo.NotFound, "thread of hash '%s' is not found in board '%s'",
+			tHash, v.pk.Hex())
+	}
+	posts, e := v.i.PostsOfThread[tHash].Get(&typ.PaginatedInput{PageSize: math.MaxUint64})
+	if e != nil {
+		return nil, e
+	}
+
+	// Posts that reply to no post (or to a post that is not of this thread) are top-level.
+	inThread := make(map[string]struct{}, len(posts.Data))
+	for _, pHash := range posts.Data {
+		inThread[pHash] = struct{}{}
+	}
+	order := make([]transcriptItem, 0, len(posts.Data)+1)
+	order = append(order, transcriptItem{hash: tHash})
+
+	var walk func(pHash string, depth int)
+	walk = func(pHash string, depth int) {
+		order = append(order, transcriptItem{hash: pHash, depth: depth})
+		replies, ok := v.i.PostsOfThread[pHash]
+		if !ok {
+			return
+		}
+		rHashes, e := replies.Get(&typ.PaginatedInput{PageSize: math.MaxUint64})
+		if e != nil {
+			return
+		}
+		for _, rHash := range rHashes.Data {
+			walk(rHash, depth+1)
+		}
+	}
+	for _, pHash := range posts.Data {
+		body := v.c.content[pHash].Body.(*object.Body)
+		if _, ok := inThread[body.OfPost]; ok {
+			continue
+		}
+		walk(pHash, 1)
+	}
+	return order, nil
+}
+
+func (v *Viewer) transcriptEntry(hash string, depth int) (*TranscriptEntry, bool) {
+	defer v.lock()()
+	rep, ok := v.c.content[hash]
+	if !ok {
+		return nil, false
+	}
+	body := rep.Body.(*object.Body)
+	return &TranscriptEntry{
+		Depth:   depth,
+		Hash:    hash,
+		Creator: body.Creator,
+		TS:      body.TS,
+		Content: rep,
+	}, true
+}
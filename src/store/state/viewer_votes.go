@@ -1,8 +1,12 @@
 package state
 
 import (
+	"container/list"
 	"encoding/json"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
 	"github.com/skycoin/bbs/src/store/object"
+	"sort"
 )
 
 type VotesRep struct {
@@ -10,6 +14,7 @@ type VotesRep struct {
 	Type object.ContentType
 
 	Votes     map[string]*object.Content // Key: pk string, Value: vote.
+	Reports   map[string]*object.Content // Key: pk string, Value: report (not counted in tallies).
 	UpCount   int
 	DownCount int
 }
@@ -36,11 +41,27 @@ func (r *VotesRep) Fill(refType object.ContentType, refHash string) *VotesRep {
 	r.Ref = refHash
 	r.Type = refType
 	r.Votes = make(map[string]*object.Content)
+	r.Reports = make(map[string]*object.Content)
 	return r
 }
 
+// Add adds a vote, replacing the previous vote of it's creator.
+// Votes tagged as reports are kept apart from the votes, so that reporting
+// content does not replace (or count as) the creator's up/down vote.
+// A report with a value of 0 withdraws the creator's report.
 func (r *VotesRep) Add(c *object.Content) {
 	creator := c.GetBody().Creator
+	if c.GetBody().HasTag(object.ReportTag) {
+		if r.Reports == nil {
+			r.Reports = make(map[string]*object.Content)
+		}
+		if r.GetValue(c) == 0 {
+			delete(r.Reports, creator)
+		} else {
+			r.Reports[creator] = c
+		}
+		return
+	}
 	if oldC, has := r.Votes[creator]; has {
 		switch r.GetValue(oldC) {
 		case +1:
@@ -67,9 +88,16 @@ type X struct {
 }
 
 type VoteRepView struct {
-	Ref  string `json:"ref"`
-	Up   X      `json:"up_votes"`
-	Down X      `json:"down_votes"`
+	Ref  string      `json:"ref"`
+	Up   X           `json:"up_votes"`
+	Down X           `json:"down_votes"`
+	Raw  *VoteRepRaw `json:"raw,omitempty"` // Only set when votes are excluded.
+}
+
+// VoteRepRaw represents the global vote totals, before any votes are excluded.
+type VoteRepRaw struct {
+	UpCount   int `json:"up_count"`
+	DownCount int `json:"down_count"`
 }
 
 func (r *VotesRep) View(user string) *VoteRepView {
@@ -89,3 +117,459 @@ func (r *VotesRep) View(user string) *VoteRepView {
 		},
 	}
 }
+
+// ViewExcluding obtains a view of votes from perspective of user,
+// where votes of creators in 'excluded' are not counted.
+func (r *VotesRep) ViewExcluding(user string, excluded map[string]struct{}) *VoteRepView {
+	if r == nil {
+		return nil
+	}
+	view := r.View(user)
+	view.Raw = &VoteRepRaw{
+		UpCount:   r.UpCount,
+		DownCount: r.DownCount,
+	}
+	for creator, c := range r.Votes {
+		if _, ok := excluded[creator]; !ok {
+			continue
+		}
+		switch r.GetValue(c) {
+		case +1:
+			view.Up.Count--
+		case -1:
+			view.Down.Count--
+		}
+	}
+	return view
+}
+
+// CompactVotesOut represents the output of compacting the votes of content.
+type CompactVotesOut struct {
+	ContentHash string `json:"content_hash"`
+	Reclaimed   int    `json:"reclaimed"` // Number of superseded votes discarded.
+}
+
+// CompactVotes discards the superseded votes of the given content, keeping only
+// the current vote (and report) of each voter. Final tallies are unchanged, while
+// discarded votes can no longer be resolved and no longer appear in user activity.
+// Each discarded vote releases it's reference of the voter, so voters left without
+// content or votes are removed on the next compaction of users.
+// Nothing is removed from the board's root, so what is reclaimed does not persist:
+// discarded votes return when the viewer is reset or the board is recompiled.
+func (v *Viewer) CompactVotes(contentHash string) (*CompactVotesOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if _, ok := v.c.content[contentHash]; !ok {
+		return nil, boo.Newf(boo.NotFound, "content of hash '%s' is not found",
+			contentHash)
+	}
+
+	current := make(map[string]struct{})
+	if votes, ok := v.c.votes[contentHash]; ok {
+		for _, c := range votes.Votes {
+			current[c.GetHeader().Hash] = struct{}{}
+		}
+		for _, c := range votes.Reports {
+			current[c.GetHeader().Hash] = struct{}{}
+		}
+	}
+	var superseded []string
+	for hash, b := range v.i.VoteOfHash {
+		switch {
+		case b.Type == object.V5ThreadVoteType && b.OfThread == contentHash:
+		case b.Type == object.V5PostVoteType && b.OfPost == contentHash:
+		default:
+			continue
+		}
+		if _, ok := current[hash]; !ok {
+			superseded = append(superseded, hash)
+		}
+	}
+	for _, hash := range superseded {
+		v.i.DeleteVoteOfHash(hash) // Also unreferences the voter.
+	}
+	return &CompactVotesOut{ContentHash: contentHash, Reclaimed: len(superseded)}, nil
+}
+
+/*
+	<<< REPORTS >>>
+*/
+
+// Report represents a report of content by a user.
+// Reasons are the tags of the reporting vote, other than the report tag.
+type Report struct {
+	Reporter string   `json:"reporter"`
+	Reasons  []string `json:"reasons,omitempty"`
+	TS       int64    `json:"ts"`
+}
+
+// ReportedContent represents content along with it's reports.
+type ReportedContent struct {
+	Content     *object.ContentRep `json:"content"`
+	ReportCount int                `json:"report_count"`
+	Reports     []*Report          `json:"reports"`
+}
+
+// ReportsIn represents the input required to obtain reported content.
+type ReportsIn struct {
+	PaginatedInput typ.PaginatedInput
+}
+
+// ReportsOut represents the output for reported content.
+type ReportsOut struct {
+	ReportsMeta *typ.PaginatedOutput `json:"reports_meta"`
+	Reported    []*ReportedContent   `json:"reported"`
+}
+
+// GetReports obtains content reported via thread/post votes tagged as reports,
+// ordered by report count (descending). Reports are only for the board owner, which the
+// caller is to ensure by checking that the board is mastered by this node.
+func (v *Viewer) GetReports(in *ReportsIn) (*ReportsOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+
+	all := v.reportedContent()
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].ReportCount != all[j].ReportCount {
+			return all[i].ReportCount > all[j].ReportCount
+		}
+		return all[i].Content.Header.Hash < all[j].Content.Header.Hash
+	})
+
+	meta, e := typ.NewPaginatedOutput(&in.PaginatedInput, uint(len(all)))
+	if e != nil {
+		return nil, e
+	}
+	out := &ReportsOut{
+		ReportsMeta: meta,
+		Reported:    make([]*ReportedContent, meta.RecordCount),
+	}
+	for i := range out.Reported {
+		out.Reported[i] = all[pageIndex(&in.PaginatedInput, i)]
+	}
+	return out, nil
+}
+
+// reportedContent obtains existing content that has reports, in no particular order.
+// Reports of each are ordered oldest first.
+func (v *Viewer) reportedContent() []*ReportedContent {
+	var all []*ReportedContent
+	for hash, votes := range v.c.votes {
+		rc := &ReportedContent{Content: v.c.content[hash]}
+		for creator, c := range votes.Reports {
+			body := c.GetBody()
+			report := &Report{Reporter: creator, TS: body.TS}
+			for _, tag := range body.Tags {
+				if tag != object.ReportTag {
+					report.Reasons = append(report.Reasons, tag)
+				}
+			}
+			rc.Reports = append(rc.Reports, report)
+		}
+		if rc.ReportCount = len(rc.Reports); rc.ReportCount == 0 || rc.Content == nil {
+			continue
+		}
+		sort.Slice(rc.Reports, func(i, j int) bool {
+			return rc.Reports[i].TS < rc.Reports[j].TS
+		})
+		all = append(all, rc)
+	}
+	return all
+}
+
+// ModerationQueueIn represents the input required to obtain the moderation queue.
+type ModerationQueueIn struct {
+	PaginatedInput typ.PaginatedInput
+}
+
+// ModerationItem represents reported content awaiting moderation.
+type ModerationItem struct {
+	Content       *object.ContentRep `json:"content"`
+	Severity      int                `json:"severity"` // Report count, with reports for spam counted twice.
+	ReporterCount int                `json:"reporter_count"`
+	Reasons       map[string]int     `json:"reasons"` // key (reason), value (number of reports giving reason)
+}
+
+// ModerationQueueOut represents the output for the moderation queue.
+type ModerationQueueOut struct {
+	QueueMeta *typ.PaginatedOutput `json:"queue_meta"`
+	Queue     []*ModerationItem    `json:"queue"`
+}
+
+// GetModerationQueue obtains reported content that is yet to be acted upon, ordered by
+// severity (descending). Deleted content is not reported, and content is considered acted
+// upon once the board owner votes on it or blocks it's creator. The queue is only for the
+// board owner, which the caller is to ensure by checking that the board is mastered by this node.
+func (v *Viewer) GetModerationQueue(in *ModerationQueueIn) (*ModerationQueueOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+
+	var (
+		owner      = v.pk.Hex()
+		ownerVotes = v.i.VotesOfUser[owner]
+		blocked    map[string]struct{}
+		queue      []*ModerationItem
+	)
+	if profile, ok := v.c.profiles[owner]; ok {
+		blocked = profile.Blocked
+	}
+	for _, rc := range v.reportedContent() {
+		if _, ok := ownerVotes[rc.Content.Header.Hash]; ok {
+			continue
+		}
+		if _, ok := blocked[rc.Content.Body.(*object.Body).Creator]; ok {
+			continue
+		}
+		item := &ModerationItem{
+			Content: rc.Content,
+			Reasons: make(map[string]int),
+		}
+		reporters := make(map[string]struct{}, len(rc.Reports))
+		for _, report := range rc.Reports {
+			reporters[report.Reporter] = struct{}{}
+			item.Severity++
+			for _, reason := range report.Reasons {
+				item.Reasons[reason]++
+				if reason == object.SpamTag {
+					item.Severity++
+				}
+			}
+		}
+		item.ReporterCount = len(reporters)
+		queue = append(queue, item)
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		if queue[i].Severity != queue[j].Severity {
+			return queue[i].Severity > queue[j].Severity
+		}
+		return queue[i].Content.Header.Hash < queue[j].Content.Header.Hash
+	})
+
+	meta, e := typ.NewPaginatedOutput(&in.PaginatedInput, uint(len(queue)))
+	if e != nil {
+		return nil, e
+	}
+	out := &ModerationQueueOut{
+		QueueMeta: meta,
+		Queue:     make([]*ModerationItem, meta.RecordCount),
+	}
+	for i := range out.Queue {
+		out.Queue[i] = queue[pageIndex(&in.PaginatedInput, i)]
+	}
+	return out, nil
+}
+
+/*
+	<<< VOTE LOOKUP >>>
+*/
+
+// ResolvedVote represents what a vote is cast on, and by whom.
+type ResolvedVote struct {
+	VoteHash string             `json:"vote_hash"`
+	Type     object.ContentType `json:"type"`
+	Of       string             `json:"of"` // Hash of voted thread or post, or public key of voted user.
+	Voter    string             `json:"voter"`
+	Value    int                `json:"value"`
+	Tags     []string           `json:"tags"`
+	TS       int64              `json:"ts"`
+}
+
+// ResolveVote obtains the content or user that a vote of given hash is cast on,
+// along with the voter, value and tags of the vote.
+func (v *Viewer) ResolveVote(voteHash string) (*ResolvedVote, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	b, ok := v.i.VoteOfHash[voteHash]
+	if !ok {
+		return nil, boo.Newf(boo.NotFound, "vote of hash '%s' is not found in board '%s'",
+			voteHash, v.pk.Hex())
+	}
+	return resolveVote(voteHash, b), nil
+}
+
+func resolveVote(voteHash string, b *object.Body) *ResolvedVote {
+	out := &ResolvedVote{
+		VoteHash: voteHash,
+		Type:     b.Type,
+		Voter:    b.Creator,
+		Value:    b.Value,
+		Tags:     append([]string{}, b.Tags...),
+		TS:       b.TS,
+	}
+	switch b.Type {
+	case object.V5ThreadVoteType:
+		out.Of = b.OfThread
+	case object.V5PostVoteType:
+		out.Of = b.OfPost
+	case object.V5UserVoteType:
+		out.Of = b.OfUser
+	}
+	return out
+}
+
+/*
+	<<< VOTE TAGS >>>
+*/
+
+// VoteTagIn represents the input required to obtain content by vote tag.
+type VoteTagIn struct {
+	Tag               string
+	Perspective       string
+	HideBlockedVotes  bool // Whether to exclude votes of users blocked by perspective.
+	StrictPerspective bool // Whether an unknown perspective results in an error.
+	PaginatedInput    typ.PaginatedInput
+}
+
+// TaggedContent represents content along with the number of votes of a tag.
+type TaggedContent struct {
+	Content  *object.ContentRep `json:"content"`
+	TagCount int                `json:"tag_count"`
+	Votes    *VoteRepView       `json:"votes"`
+}
+
+// VoteTagOut represents the output for content by vote tag.
+type VoteTagOut struct {
+	Tag         string               `json:"tag"`
+	ContentMeta *typ.PaginatedOutput `json:"content_meta"`
+	Content     []*TaggedContent     `json:"content"`
+}
+
+// GetContentByVoteTag obtains threads and posts that have at least one vote of the tag,
+// ordered by the number of such votes (descending).
+func (v *Viewer) GetContentByVoteTag(in *VoteTagIn) (*VoteTagOut, error) {
+	if v == nil {
+		return nil, ErrViewerNotInitialized
+	}
+	defer v.lock()()
+	if e := v.checkPerspective(in.Perspective, in.StrictPerspective); e != nil {
+		return nil, e
+	}
+	if in.Tag == "" {
+		return nil, boo.New(boo.InvalidInput, "empty vote tag")
+	}
+
+	var blocked map[string]struct{}
+	if profile, ok := v.c.profiles[in.Perspective]; in.HideBlockedVotes && ok {
+		blocked = profile.Blocked
+	}
+
+	var all []*TaggedContent
+	for hash, votes := range v.c.votes {
+		if votes.Type != object.V5ThreadVoteType && votes.Type != object.V5PostVoteType {
+			continue
+		}
+		rep, ok := v.c.content[hash]
+		if !ok {
+			continue
+		}
+		var count int
+		for creator, c := range votes.Votes {
+			if _, ok := blocked[creator]; ok {
+				continue
+			}
+			if c.GetBody().HasTag(in.Tag) {
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		all = append(all, &TaggedContent{
+			Content:  minimalRep(rep),
+			TagCount: count,
+			Votes:    v.viewVotes(hash, in.Perspective, in.HideBlockedVotes),
+		})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].TagCount != all[j].TagCount {
+			return all[i].TagCount > all[j].TagCount
+		}
+		return all[i].Content.Header.Hash < all[j].Content.Header.Hash
+	})
+
+	meta, e := typ.NewPaginatedOutput(&in.PaginatedInput, uint(len(all)))
+	if e != nil {
+		return nil, e
+	}
+	out := &VoteTagOut{
+		Tag:         in.Tag,
+		ContentMeta: meta,
+		Content:     make([]*TaggedContent, meta.RecordCount),
+	}
+	for i := range out.Content {
+		out.Content[i] = all[pageIndex(&in.PaginatedInput, i)]
+		out.Content[i].Content = v.transformRep(out.Content[i].Content, in.Perspective)
+	}
+	return out, nil
+}
+
+/*
+	<<< VOTE VIEW CACHE >>>
+*/
+
+// MaxCachedPerspectives is the maximum number of perspectives that views of votes
+// are cached for, per board.
+const MaxCachedPerspectives = 256
+
+// voteViewCache caches views of votes per perspective. When full, the perspective
+// least recently used is evicted.
+type voteViewCache struct {
+	max   int
+	order *list.List               // Perspectives, most recently used first.
+	elems map[string]*list.Element // key (perspective), value (element of 'order')
+}
+
+type voteViewEntry struct {
+	perspective string
+	views       map[string]*VoteRepView // key (hash of voted content, or public key of voted user)
+}
+
+func newVoteViewCache(max int) *voteViewCache {
+	return &voteViewCache{
+		max:   max,
+		order: list.New(),
+		elems: make(map[string]*list.Element),
+	}
+}
+
+func (c *voteViewCache) get(perspective, hash string) (*VoteRepView, bool) {
+	elem, ok := c.elems[perspective]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	view, ok := elem.Value.(*voteViewEntry).views[hash]
+	return view, ok
+}
+
+func (c *voteViewCache) set(perspective, hash string, view *VoteRepView) {
+	elem, ok := c.elems[perspective]
+	if !ok {
+		elem = c.order.PushFront(&voteViewEntry{
+			perspective: perspective,
+			views:       make(map[string]*VoteRepView),
+		})
+		c.elems[perspective] = elem
+		if c.order.Len() > c.max {
+			last := c.order.Back()
+			c.order.Remove(last)
+			delete(c.elems, last.Value.(*voteViewEntry).perspective)
+		}
+	}
+	elem.Value.(*voteViewEntry).views[hash] = view
+}
+
+// invalidate removes cached views of votes of given hash, of all perspectives.
+func (c *voteViewCache) invalidate(hash string) {
+	for _, elem := range c.elems {
+		delete(elem.Value.(*voteViewEntry).views, hash)
+	}
+}
@@ -0,0 +1,1032 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/misc/typ"
+	"github.com/skycoin/bbs/src/store/object"
+	"github.com/skycoin/skycoin/src/cipher"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func newVote(creator string, value int, tags ...string) *object.Content {
+	c := new(object.Content)
+	c.SetBody(&object.Body{
+		Type:    object.V5PostVoteType,
+		Value:   value,
+		Tags:    tags,
+		Creator: creator,
+	})
+	return c
+}
+
+func TestVotesRep_Add_report(t *testing.T) {
+	r := new(VotesRep).Fill(object.V5PostVoteType, "post")
+
+	r.Add(newVote("a", +1))
+	r.Add(newVote("a", -1, object.ReportTag, object.SpamTag))
+	r.Add(newVote("b", -1, object.ReportTag))
+
+	if r.UpCount != 1 || r.DownCount != 0 {
+		t.Errorf("tallies: got %d up and %d down, expected 1 and 0", r.UpCount, r.DownCount)
+	}
+	if v, ok := r.Votes["a"]; !ok || r.GetValue(v) != +1 {
+		t.Error("report replaced the vote of it's creator")
+	}
+	if _, ok := r.Votes["b"]; ok {
+		t.Error("report is recorded as a vote")
+	}
+	if len(r.Reports) != 2 {
+		t.Errorf("report count: got %d, expected %d", len(r.Reports), 2)
+	}
+
+	r.Add(newVote("a", -1))
+	if r.UpCount != 0 || r.DownCount != 1 {
+		t.Errorf("tallies: got %d up and %d down, expected 0 and 1", r.UpCount, r.DownCount)
+	}
+	if _, ok := r.Reports["a"]; !ok {
+		t.Error("vote replaced the report of it's creator")
+	}
+
+	r.Add(newVote("a", 0, object.ReportTag))
+	if _, ok := r.Reports["a"]; ok {
+		t.Error("report is not withdrawn")
+	}
+}
+
+func TestViewer_CompactVotes(t *testing.T) {
+	const (
+		boardSeed = "a"
+		userSeed  = "user"
+	)
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addThreadVote(t, bi, tHash, +1, []byte(userSeed))
+	addThreadVote(t, bi, tHash, -1, []byte(userSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	// A reporter that withdraws it's report is left with nothing but superseded votes.
+	addThreadReport(t, bi, tHash, "", []byte("reporter"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addThreadReportValue(t, bi, tHash, 0, []byte("reporter"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	upk, _ := cipher.GenerateDeterministicKeyPair([]byte(userSeed))
+	rpk, _ := cipher.GenerateDeterministicKeyPair([]byte("reporter"))
+	refs := bi.v.i.UserRefs[upk.Hex()]
+
+	out, e := bi.Viewer().CompactVotes(tHash.Hex())
+	if e != nil {
+		t.Fatal("failed to compact votes:", e)
+	}
+	if out.Reclaimed != 3 {
+		t.Errorf("reclaimed: got %d, expected %d", out.Reclaimed, 3)
+	}
+	if got := bi.v.i.UserRefs[upk.Hex()]; got != refs-1 {
+		t.Errorf("references of voter: got %d, expected %d", got, refs-1)
+	}
+	if got, ok := bi.v.i.UserRefs[rpk.Hex()]; ok {
+		t.Errorf("references of reporter: got %d, expected none", got)
+	}
+	if _, ok := bi.v.i.unreferenced[rpk.Hex()]; !ok {
+		t.Error("reporter is not to be removed on the next compaction of users")
+	}
+	if got := bi.v.i.VotesByUser[upk.Hex()].Len(); got != 1 {
+		t.Errorf("votes of user: got %d, expected %d", got, 1)
+	}
+	current := bi.v.c.votes[tHash.Hex()].Votes[upk.Hex()]
+	if _, e := bi.Viewer().ResolveVote(current.GetHeader().Hash); e != nil {
+		t.Error("current vote can no longer be resolved:", e)
+	}
+	if votes := bi.v.c.votes[tHash.Hex()]; votes.UpCount != 0 || votes.DownCount != 1 {
+		t.Errorf("tallies: got %d up and %d down, expected 0 and 1", votes.UpCount, votes.DownCount)
+	}
+
+	if out, e := bi.Viewer().CompactVotes(tHash.Hex()); e != nil || out.Reclaimed != 0 {
+		t.Errorf("compacting again: got %v and %v, expected nothing reclaimed", out, e)
+	}
+	if _, e := bi.Viewer().CompactVotes("unknown"); boo.Type(e) != boo.NotFound {
+		t.Errorf("compacting unknown content: got %v, expected not found", e)
+	}
+}
+
+func addPostVote(t *testing.T, bi *BoardInstance, threadHash cipher.SHA256, postHash string, value int, userSeed []byte, tags ...string) uint64 {
+	cpk, csk := cipher.GenerateDeterministicKeyPair(userSeed)
+	body := &object.Body{
+		Type:     object.V5PostVoteType,
+		TS:       time.Now().UnixNano(),
+		OfBoard:  obtainBoardPubKey(t, bi).Hex(),
+		OfThread: threadHash.Hex(),
+		OfPost:   postHash,
+		Value:    value,
+		Tags:     tags,
+		Creator:  cpk.Hex(),
+	}
+	raw, _ := json.Marshal(body)
+	sig := cipher.SignHash(cipher.SumSHA256(raw), csk)
+	transport, e := object.NewTransport(raw, sig)
+	if e != nil {
+		t.Fatal("failed to generate transport:", e)
+	}
+	goal, e := bi.Submit(transport)
+	if e != nil {
+		t.Fatal("failed to vote on post:", e)
+	}
+	return goal
+}
+
+func addThreadReport(t *testing.T, bi *BoardInstance, threadHash cipher.SHA256, reason string, userSeed []byte) uint64 {
+	return submitThreadReport(t, bi, threadHash, -1, reason, userSeed)
+}
+
+// addThreadReportValue reports a thread with the given value, where 0 withdraws the report.
+func addThreadReportValue(t *testing.T, bi *BoardInstance, threadHash cipher.SHA256, value int, userSeed []byte) uint64 {
+	return submitThreadReport(t, bi, threadHash, value, "", userSeed)
+}
+
+func submitThreadReport(t *testing.T, bi *BoardInstance, threadHash cipher.SHA256, value int, reason string, userSeed []byte) uint64 {
+	tags := []string{object.ReportTag}
+	if reason != "" {
+		tags = append(tags, reason)
+	}
+	return addTaggedThreadVote(t, bi, threadHash, value, tags, userSeed)
+}
+
+func addTaggedThreadVote(t *testing.T, bi *BoardInstance, threadHash cipher.SHA256, value int, tags []string, userSeed []byte) uint64 {
+	cpk, csk := cipher.GenerateDeterministicKeyPair(userSeed)
+	body := &object.Body{
+		Type:     object.V5ThreadVoteType,
+		TS:       time.Now().UnixNano(),
+		OfBoard:  obtainBoardPubKey(t, bi).Hex(),
+		OfThread: threadHash.Hex(),
+		Value:    value,
+		Tags:     tags,
+		Creator:  cpk.Hex(),
+	}
+	raw, _ := json.Marshal(body)
+	sig := cipher.SignHash(cipher.SumSHA256(raw), csk)
+	transport, e := object.NewTransport(raw, sig)
+	if e != nil {
+		t.Fatal("failed to generate transport:", e)
+	}
+	goal, e := bi.Submit(transport)
+	if e != nil {
+		t.Fatal("failed to vote on thread:", e)
+	}
+	return goal
+}
+
+func TestViewer_GetReports(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	once, _ := addThread(t, bi, 0, []byte("user"))
+	twice, _ := addThread(t, bi, 1, []byte("user"))
+	addThread(t, bi, 2, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addThreadReport(t, bi, once, "", []byte("reporter 1"))
+	addThreadReport(t, bi, twice, object.SpamTag, []byte("reporter 1"))
+	addThreadReport(t, bi, twice, "", []byte("reporter 2"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		name     string
+		page     typ.PaginatedInput
+		expected []cipher.SHA256
+		errType  int
+	}{
+		{"all", typ.PaginatedInput{PageSize: 10}, []cipher.SHA256{twice, once}, 0},
+		{"paginated", typ.PaginatedInput{StartIndex: 1, PageSize: 1}, []cipher.SHA256{once}, 0},
+		{"invalid_start", typ.PaginatedInput{StartIndex: 3, PageSize: 1}, nil, boo.InvalidInput},
+		{"invalid_size", typ.PaginatedInput{}, nil, boo.InvalidInput},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetReports(&ReportsIn{PaginatedInput: c.page})
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get reports:", e)
+			}
+			if len(out.Reported) != len(c.expected) {
+				t.Fatalf("got %d reported, expected %d", len(out.Reported), len(c.expected))
+			}
+			for i, hash := range c.expected {
+				if got := out.Reported[i].Content.Header.Hash; got != hash.Hex() {
+					t.Errorf("reported[%d]: got '%s', expected '%s'", i, got, hash.Hex())
+				}
+			}
+		})
+	}
+
+	out, e := bi.Viewer().GetReports(&ReportsIn{PaginatedInput: typ.PaginatedInput{PageSize: 1}})
+	if e != nil {
+		t.Fatal("failed to get reports:", e)
+	}
+	if r := out.Reported[0]; r.ReportCount != 2 || len(r.Reports[0].Reasons) != 1 ||
+		r.Reports[0].Reasons[0] != object.SpamTag || len(r.Reports[1].Reasons) != 0 {
+		t.Errorf("got reports %+v, expected a spam report followed by one without reasons", r.Reports)
+	}
+
+	if _, e := (*Viewer)(nil).GetReports(&ReportsIn{}); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
+
+func TestViewer_GetModerationQueue(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	spam, _ := addThread(t, bi, 0, []byte("user"))
+	plain, _ := addThread(t, bi, 1, []byte("user"))
+	voted, _ := addThread(t, bi, 2, []byte("user"))
+	blocked, _ := addThread(t, bi, 3, []byte("blocked user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addThreadReport(t, bi, spam, object.SpamTag, []byte("reporter 1"))
+	addThreadReport(t, bi, spam, object.SpamTag, []byte("reporter 2"))
+	addThreadReport(t, bi, plain, "", []byte("reporter 1"))
+	addThreadReport(t, bi, voted, "", []byte("reporter 1"))
+	addThreadReport(t, bi, blocked, "", []byte("reporter 1"))
+
+	// The board owner acts upon content by voting on it, or blocking it's creator.
+	addThreadVote(t, bi, voted, -1, []byte(boardSeed))
+	bpk, _ := cipher.GenerateDeterministicKeyPair([]byte("blocked user"))
+	addUserVote(t, bi, bpk, -1, object.BlockTag, []byte(boardSeed))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		name     string
+		page     typ.PaginatedInput
+		expected []cipher.SHA256
+		errType  int
+	}{
+		{"all", typ.PaginatedInput{PageSize: 10}, []cipher.SHA256{spam, plain}, 0},
+		{"reversed", typ.PaginatedInput{StartIndex: 1, PageSize: 10, Reverse: true}, []cipher.SHA256{plain, spam}, 0},
+		{"invalid_start", typ.PaginatedInput{StartIndex: 3, PageSize: 1}, nil, boo.InvalidInput},
+		{"invalid_size", typ.PaginatedInput{}, nil, boo.InvalidInput},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetModerationQueue(&ModerationQueueIn{PaginatedInput: c.page})
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get moderation queue:", e)
+			}
+			if len(out.Queue) != len(c.expected) {
+				t.Fatalf("got %d queued, expected %d", len(out.Queue), len(c.expected))
+			}
+			for i, hash := range c.expected {
+				if got := out.Queue[i].Content.Header.Hash; got != hash.Hex() {
+					t.Errorf("queue[%d]: got '%s', expected '%s'", i, got, hash.Hex())
+				}
+			}
+		})
+	}
+
+	out, e := bi.Viewer().GetModerationQueue(&ModerationQueueIn{PaginatedInput: typ.PaginatedInput{PageSize: 1}})
+	if e != nil {
+		t.Fatal("failed to get moderation queue:", e)
+	}
+	if item := out.Queue[0]; item.Severity != 4 || item.ReporterCount != 2 || item.Reasons[object.SpamTag] != 2 {
+		t.Errorf("got severity %d from %d reporters with reasons %v, expected 4 from 2 with 2 spam",
+			item.Severity, item.ReporterCount, item.Reasons)
+	}
+
+	if _, e := (*Viewer)(nil).GetModerationQueue(&ModerationQueueIn{}); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
+
+func TestViewer_GetVotes_hideBlocked(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte("author"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addThreadVote(t, bi, tHash, +1, []byte("friend"))
+	addThreadVote(t, bi, tHash, -1, []byte("blocked"))
+	addThreadVote(t, bi, tHash, +1, []byte("moderator"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	blocked, _ := cipher.GenerateDeterministicKeyPair([]byte("blocked"))
+	addUserVote(t, bi, blocked, -1, object.BlockTag, []byte("moderator"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	var (
+		mpk, _ = cipher.GenerateDeterministicKeyPair([]byte("moderator"))
+		apk, _ = cipher.GenerateDeterministicKeyPair([]byte("author"))
+		upk, _ = cipher.GenerateDeterministicKeyPair([]byte("unknown"))
+	)
+
+	cases := []struct {
+		name        string
+		perspective string
+		hide        bool
+		strict      bool
+		up, down    int
+		raw         bool // Whether raw totals are expected.
+		err         error
+	}{
+		{"shown", mpk.Hex(), false, false, 2, 1, false, nil},
+		{"hidden", mpk.Hex(), true, false, 2, 0, true, nil},
+		{"none_blocked", apk.Hex(), true, false, 2, 1, false, nil},
+		{"unknown_perspective", upk.Hex(), true, false, 2, 1, false, nil},
+		{"strict_unknown_perspective", upk.Hex(), true, true, 0, 0, false, ErrUnknownPerspective},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetVotes(&ContentVotesIn{
+				Perspective:       c.perspective,
+				HideBlockedVotes:  c.hide,
+				StrictPerspective: c.strict,
+				ContentHash:       tHash.Hex(),
+			})
+			if c.err != nil {
+				if e != c.err {
+					t.Errorf("got error %v, expected %v", e, c.err)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get votes:", e)
+			}
+			view := out.Votes
+			if view.Up.Count != c.up || view.Down.Count != c.down {
+				t.Errorf("got %d up and %d down, expected %d and %d",
+					view.Up.Count, view.Down.Count, c.up, c.down)
+			}
+			if (view.Raw != nil) != c.raw {
+				t.Errorf("got raw totals %+v, expected raw totals: %v", view.Raw, c.raw)
+			}
+			if c.raw && (view.Raw.UpCount != 2 || view.Raw.DownCount != 1) {
+				t.Errorf("got raw totals %+v, expected 2 up and 1 down", view.Raw)
+			}
+		})
+	}
+
+	if _, e := bi.Viewer().GetVotes(&ContentVotesIn{ContentHash: "unknown"}); boo.Type(e) != boo.NotFound {
+		t.Errorf("votes of unknown content: got %v, expected not found", e)
+	}
+}
+
+func TestViewer_GetAllThreadVotes(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	var threads []cipher.SHA256
+	for i := 0; i < 3; i++ {
+		tHash, _ := addThread(t, bi, i, []byte("user"))
+		threads = append(threads, tHash)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addThreadVote(t, bi, threads[1], +1, []byte("voter 1"))
+	addThreadVote(t, bi, threads[1], +1, []byte("voter 2"))
+	addThreadVote(t, bi, threads[2], -1, []byte("voter 1"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	upk, _ := cipher.GenerateDeterministicKeyPair([]byte("unknown"))
+
+	cases := []struct {
+		name     string
+		in       AllVotesIn
+		expected map[cipher.SHA256][2]int // Up and down counts of each thread.
+		err      error
+		errType  int
+	}{
+		{
+			name: "all",
+			in:   AllVotesIn{PaginatedInput: typ.PaginatedInput{PageSize: 10}},
+			expected: map[cipher.SHA256][2]int{
+				threads[0]: {0, 0}, threads[1]: {2, 0}, threads[2]: {0, 1},
+			},
+		},
+		{
+			name:     "sorted_page",
+			in:       AllVotesIn{SortBy: SortScore, PaginatedInput: typ.PaginatedInput{PageSize: 1}},
+			expected: map[cipher.SHA256][2]int{threads[1]: {2, 0}},
+		},
+		{
+			name:    "unknown_sorter",
+			in:      AllVotesIn{SortBy: "unknown", PaginatedInput: typ.PaginatedInput{PageSize: 10}},
+			errType: boo.InvalidInput,
+		},
+		{
+			name:    "invalid_page",
+			in:      AllVotesIn{},
+			errType: boo.InvalidInput,
+		},
+		{
+			name: "strict_unknown_perspective",
+			in: AllVotesIn{Perspective: upk.Hex(), StrictPerspective: true,
+				PaginatedInput: typ.PaginatedInput{PageSize: 10}},
+			err: ErrUnknownPerspective,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetAllThreadVotes(&c.in)
+			switch {
+			case c.err != nil:
+				if e != c.err {
+					t.Errorf("got error %v, expected %v", e, c.err)
+				}
+				return
+			case c.errType != 0:
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			case e != nil:
+				t.Fatal("failed to get votes of threads:", e)
+			}
+			if len(out.Votes) != len(c.expected) || out.ThreadsMeta.RecordCount != uint(len(c.expected)) {
+				t.Fatalf("got votes %v and meta %+v, expected votes of %d threads",
+					out.Votes, out.ThreadsMeta, len(c.expected))
+			}
+			for tHash, counts := range c.expected {
+				view, ok := out.Votes[tHash.Hex()]
+				if !ok {
+					t.Errorf("votes of thread '%s' are missing", tHash.Hex())
+					continue
+				}
+				if view.Ref != tHash.Hex() || view.Up.Count != counts[0] || view.Down.Count != counts[1] {
+					t.Errorf("thread '%s': got %+v, expected %d up and %d down",
+						tHash.Hex(), view, counts[0], counts[1])
+				}
+			}
+		})
+	}
+}
+
+func TestViewer_GetUserVoteCount(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	var threads []cipher.SHA256
+	for i := 0; i < 3; i++ {
+		tHash, _ := addThread(t, bi, i, []byte("author"))
+		threads = append(threads, tHash)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	apk, _ := cipher.GenerateDeterministicKeyPair([]byte("author"))
+	addThreadVote(t, bi, threads[0], +1, []byte("voter"))
+	addThreadVote(t, bi, threads[1], -1, []byte("voter"))
+	addThreadVote(t, bi, threads[2], +1, []byte("voter"))
+	addUserVote(t, bi, apk, +1, object.TrustTag, []byte("voter"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	// Retracted votes are neutral.
+	addThreadVote(t, bi, threads[2], 0, []byte("voter"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		seed     string
+		expected UserVoteCountOut
+	}{
+		{"voter", UserVoteCountOut{Total: 4, Up: 2, Down: 1, Neutral: 1}},
+		{"author", UserVoteCountOut{}},
+		{"unknown", UserVoteCountOut{}},
+	}
+	for _, c := range cases {
+		t.Run(c.seed, func(t *testing.T) {
+			upk, _ := cipher.GenerateDeterministicKeyPair([]byte(c.seed))
+			out, e := bi.Viewer().GetUserVoteCount(upk.Hex())
+			if e != nil {
+				t.Fatal("failed to get user vote count:", e)
+			}
+			c.expected.UserPubKey = upk.Hex()
+			if *out != c.expected {
+				t.Errorf("got %+v, expected %+v", *out, c.expected)
+			}
+		})
+	}
+
+	if _, e := (*Viewer)(nil).GetUserVoteCount(""); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
+
+func TestViewer_GetVotesMulti(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte("user"))
+	otherHash, _ := addThread(t, bi, 1, []byte("voter 2"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	var (
+		pHash     = listOf(bi.v.i.PostsOfThread[tHash.Hex()])[0]
+		upk, _    = cipher.GenerateDeterministicKeyPair([]byte("user"))
+		vpk, _    = cipher.GenerateDeterministicKeyPair([]byte("voter 2"))
+		unknown   = cipher.SHA256{}.Hex()
+		stranger  = cipher.PubKey{}.Hex()
+		allHashes = []string{tHash.Hex(), pHash, otherHash.Hex()}
+	)
+	addThreadVote(t, bi, tHash, +1, []byte("voter 1"))
+	addThreadVote(t, bi, tHash, +1, []byte("voter 2"))
+	addPostVote(t, bi, tHash, pHash, -1, []byte("voter 2"))
+	addUserVote(t, bi, vpk, -1, object.BlockTag, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		name     string
+		in       MultiVotesIn
+		expected map[string][2]int // Up and down counts of each content.
+		err      error
+	}{
+		{
+			name: "all",
+			in:   MultiVotesIn{ContentHashes: allHashes},
+			expected: map[string][2]int{
+				tHash.Hex(): {2, 0}, pHash: {0, 1}, otherHash.Hex(): {0, 0},
+			},
+		},
+		{
+			name:     "unknown_omitted",
+			in:       MultiVotesIn{ContentHashes: []string{unknown, pHash}},
+			expected: map[string][2]int{pHash: {0, 1}},
+		},
+		{
+			name:     "none",
+			in:       MultiVotesIn{},
+			expected: map[string][2]int{},
+		},
+		{
+			name: "hide_blocked",
+			in:   MultiVotesIn{Perspective: upk.Hex(), HideBlockedVotes: true, ContentHashes: allHashes},
+			expected: map[string][2]int{
+				tHash.Hex(): {1, 0}, pHash: {0, 0}, otherHash.Hex(): {0, 0},
+			},
+		},
+		{
+			name: "strict_unknown_perspective",
+			in:   MultiVotesIn{Perspective: stranger, StrictPerspective: true, ContentHashes: allHashes},
+			err:  ErrUnknownPerspective,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetVotesMulti(&c.in)
+			if c.err != nil {
+				if e != c.err {
+					t.Errorf("got error %v, expected %v", e, c.err)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to get votes:", e)
+			}
+			if len(out.Votes) != len(c.expected) {
+				t.Fatalf("got votes %v, expected votes of %d content", out.Votes, len(c.expected))
+			}
+			for hash, counts := range c.expected {
+				view, ok := out.Votes[hash]
+				if !ok {
+					t.Errorf("votes of content '%s' are missing", hash)
+					continue
+				}
+				if view.Ref != hash || view.Up.Count != counts[0] || view.Down.Count != counts[1] {
+					t.Errorf("content '%s': got %+v, expected %d up and %d down",
+						hash, view, counts[0], counts[1])
+				}
+			}
+		})
+	}
+
+	if _, e := (*Viewer)(nil).GetVotesMulti(&MultiVotesIn{}); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
+
+func TestViewer_viewVotes_ref(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	pHash := listOf(bi.v.i.PostsOfThread[tHash.Hex()])[0]
+	addReply(t, bi, tHash, pHash, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	var (
+		rHash  = listOf(bi.v.i.PostsOfThread[pHash])[0]
+		upk, _ = cipher.GenerateDeterministicKeyPair([]byte("user"))
+		page   = typ.PaginatedInput{PageSize: 10}
+	)
+
+	// None of the content has votes, yet all views refer to their content.
+	cases := []struct {
+		name  string
+		hash  string
+		votes func() (interface{}, error)
+	}{
+		{"board_page", tHash.Hex(), func() (interface{}, error) {
+			out, e := bi.Viewer().GetBoardPage(&BoardPageIn{Perspective: upk.Hex(), PaginatedInput: page})
+			if e != nil {
+				return nil, e
+			}
+			return out.Threads[0].Votes, nil
+		}},
+		{"thread_page", tHash.Hex(), func() (interface{}, error) {
+			out, e := bi.Viewer().GetThreadPage(&ThreadPageIn{ThreadHash: tHash.Hex(), PaginatedInput: page})
+			if e != nil {
+				return nil, e
+			}
+			return out.Thread.Votes, nil
+		}},
+		{"thread_page_post", pHash, func() (interface{}, error) {
+			out, e := bi.Viewer().GetThreadPage(&ThreadPageIn{ThreadHash: tHash.Hex(), PaginatedInput: page})
+			if e != nil {
+				return nil, e
+			}
+			return out.Posts[0].Votes, nil
+		}},
+		{"replies", rHash, func() (interface{}, error) {
+			out, e := bi.Viewer().GetReplies(&RepliesIn{PostHash: pHash, PaginatedInput: page})
+			if e != nil {
+				return nil, e
+			}
+			return out.Replies[0].Votes, nil
+		}},
+		{"contents", pHash, func() (interface{}, error) {
+			out, e := bi.Viewer().GetContents(&ContentsIn{ContentHashes: []string{pHash}})
+			if e != nil {
+				return nil, e
+			}
+			return out.Contents[0].Votes, nil
+		}},
+		{"votes", rHash, func() (interface{}, error) {
+			out, e := bi.Viewer().GetVotes(&ContentVotesIn{ContentHash: rHash, Perspective: upk.Hex()})
+			if e != nil {
+				return nil, e
+			}
+			return out.Votes, nil
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			votes, e := c.votes()
+			if e != nil {
+				t.Fatal("failed to get votes:", e)
+			}
+			view, ok := votes.(*VoteRepView)
+			if !ok || view == nil {
+				t.Fatalf("got votes %v, expected a vote view", votes)
+			}
+			if view.Ref != c.hash || view.Up != (X{}) || view.Down != (X{}) {
+				t.Errorf("got %+v, expected an empty view of '%s'", view, c.hash)
+			}
+		})
+	}
+
+	if _, e := bi.Viewer().GetVotes(&ContentVotesIn{ContentHash: cipher.SHA256{}.Hex()}); boo.Type(e) != boo.NotFound {
+		t.Errorf("votes of unknown content: got %v, expected not found", e)
+	}
+}
+
+func TestViewer_GetContentByVoteTag(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	var threads []cipher.SHA256
+	for i, seed := range []string{"user", "user", "blocked"} {
+		tHash, _ := addThread(t, bi, i, []byte(seed))
+		threads = append(threads, tHash)
+	}
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, threads[0], 0, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	var (
+		pHash  = listOf(bi.v.i.PostsOfThread[threads[0].Hex()])[0]
+		upk, _ = cipher.GenerateDeterministicKeyPair([]byte("user"))
+		bpk, _ = cipher.GenerateDeterministicKeyPair([]byte("blocked"))
+		spam   = []string{object.SpamTag}
+		page   = typ.PaginatedInput{PageSize: 10}
+	)
+	addTaggedThreadVote(t, bi, threads[0], -1, spam, []byte("voter 1"))
+	addTaggedThreadVote(t, bi, threads[0], -1, spam, []byte("voter 2"))
+	addTaggedThreadVote(t, bi, threads[1], -1, spam, []byte("voter 1"))
+	addThreadVote(t, bi, threads[1], +1, []byte("voter 2"))
+	addThreadVote(t, bi, threads[2], -1, []byte("voter 1"))
+	addPostVote(t, bi, threads[0], pHash, -1, []byte("blocked"), object.SpamTag)
+	addUserVote(t, bi, bpk, -1, object.BlockTag, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	// tagged orders content of given tag counts as expected, by count then hash.
+	tagged := func(counts map[string]int) []string {
+		out := make([]string, 0, len(counts))
+		for hash := range counts {
+			out = append(out, hash)
+		}
+		sort.Slice(out, func(i, j int) bool {
+			if counts[out[i]] != counts[out[j]] {
+				return counts[out[i]] > counts[out[j]]
+			}
+			return out[i] < out[j]
+		})
+		for i, hash := range out {
+			out[i] = fmt.Sprintf("%s:%d", hash, counts[hash])
+		}
+		return out
+	}
+
+	cases := []struct {
+		name     string
+		in       VoteTagIn
+		expected []string // Of "<hash>:<tag count>".
+		err      error
+		errType  int
+	}{
+		{
+			name:     "spam",
+			in:       VoteTagIn{Tag: object.SpamTag, PaginatedInput: page},
+			expected: tagged(map[string]int{threads[0].Hex(): 2, threads[1].Hex(): 1, pHash: 1}),
+		},
+		{
+			name: "hide_blocked",
+			in: VoteTagIn{Tag: object.SpamTag, Perspective: upk.Hex(), HideBlockedVotes: true,
+				PaginatedInput: page},
+			expected: tagged(map[string]int{threads[0].Hex(): 2, threads[1].Hex(): 1}),
+		},
+		{
+			name:     "paginated",
+			in:       VoteTagIn{Tag: object.SpamTag, PaginatedInput: typ.PaginatedInput{PageSize: 1}},
+			expected: tagged(map[string]int{threads[0].Hex(): 2}),
+		},
+		{
+			name:     "unused_tag",
+			in:       VoteTagIn{Tag: "unused", PaginatedInput: page},
+			expected: []string{},
+		},
+		{
+			name:    "empty_tag",
+			in:      VoteTagIn{PaginatedInput: page},
+			errType: boo.InvalidInput,
+		},
+		{
+			name:    "invalid_page",
+			in:      VoteTagIn{Tag: object.SpamTag},
+			errType: boo.InvalidInput,
+		},
+		{
+			name: "strict_unknown_perspective",
+			in: VoteTagIn{Tag: object.SpamTag, Perspective: cipher.PubKey{}.Hex(), StrictPerspective: true,
+				PaginatedInput: page},
+			err: ErrUnknownPerspective,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().GetContentByVoteTag(&c.in)
+			switch {
+			case c.err != nil:
+				if e != c.err {
+					t.Errorf("got error %v, expected %v", e, c.err)
+				}
+				return
+			case c.errType != 0:
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			case e != nil:
+				t.Fatal("failed to get content by vote tag:", e)
+			}
+			got := make([]string, len(out.Content))
+			for i, content := range out.Content {
+				got[i] = fmt.Sprintf("%s:%d", content.Content.Header.Hash, content.TagCount)
+				if content.Votes == nil || content.Votes.Ref != content.Content.Header.Hash {
+					t.Errorf("got votes %+v of content '%s'", content.Votes, content.Content.Header.Hash)
+				}
+			}
+			if !reflect.DeepEqual(got, c.expected) {
+				t.Errorf("got content %v, expected %v", got, c.expected)
+			}
+		})
+	}
+
+	if _, e := (*Viewer)(nil).GetContentByVoteTag(&VoteTagIn{}); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
+
+func TestViewer_HasVotes(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	voted, _ := addThread(t, bi, 0, []byte("user"))
+	unvoted, _ := addThread(t, bi, 1, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, voted, 0, []byte("user"))
+	addPost(t, bi, voted, 1, []byte("user"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	posts := listOf(bi.v.i.PostsOfThread[voted.Hex()])
+	addThreadVote(t, bi, voted, +1, []byte("voter"))
+	addPostVote(t, bi, voted, posts[0], +1, []byte("voter"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	cases := []struct {
+		name     string
+		has      func(string) bool
+		hash     string
+		expected bool
+	}{
+		{"thread_voted", bi.v.HasThreadVotes, voted.Hex(), true},
+		{"thread_unvoted", bi.v.HasThreadVotes, unvoted.Hex(), false},
+		{"thread_of_post", bi.v.HasThreadVotes, posts[0], false},
+		{"thread_unknown", bi.v.HasThreadVotes, cipher.SHA256{}.Hex(), false},
+		{"post_voted", bi.v.HasPostVotes, posts[0], true},
+		{"post_unvoted", bi.v.HasPostVotes, posts[1], false},
+		{"post_of_thread", bi.v.HasPostVotes, voted.Hex(), false},
+		{"post_unknown", bi.v.HasPostVotes, cipher.SHA256{}.Hex(), false},
+		{"uninitialized", (*Viewer)(nil).HasThreadVotes, voted.Hex(), false},
+	}
+	for _, c := range cases {
+		if got := c.has(c.hash); got != c.expected {
+			t.Errorf("%s: got %v, expected %v", c.name, got, c.expected)
+		}
+	}
+
+	// Checks do not initialize votes.
+	for _, hash := range []string{unvoted.Hex(), posts[1]} {
+		if _, ok := bi.v.c.votes[hash]; ok {
+			t.Errorf("votes of '%s' were initialized", hash)
+		}
+	}
+}
+
+func TestViewer_ResolveVote(t *testing.T) {
+	const boardSeed = "a"
+
+	bi, quit := initInstance(t, boardSeed)
+	defer quit()
+
+	tHash, _ := addThread(t, bi, 0, []byte("author"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	addPost(t, bi, tHash, 0, []byte("author"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	var (
+		pHash  = listOf(bi.v.i.PostsOfThread[tHash.Hex()])[0]
+		apk, _ = cipher.GenerateDeterministicKeyPair([]byte("author"))
+		vpk, _ = cipher.GenerateDeterministicKeyPair([]byte("voter"))
+	)
+	addThreadVote(t, bi, tHash, +1, []byte("voter"))
+	addPostVote(t, bi, tHash, pHash, -1, []byte("voter"), object.SpamTag)
+	addUserVote(t, bi, apk, +1, object.TrustTag, []byte("voter"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+	superseded := bi.v.c.votes[tHash.Hex()].Votes[vpk.Hex()].GetHeader().Hash
+	addThreadVote(t, bi, tHash, -1, []byte("voter"))
+	if e := bi.PublishChanges(); e != nil {
+		t.Fatal("failed to publish changes:", e)
+	}
+
+	// voteHash obtains the hash of the current vote of voter of given type.
+	voteHash := func(voteType object.ContentType) string {
+		for hash, b := range bi.v.i.VoteOfHash {
+			if b.Type == voteType && b.Creator == vpk.Hex() && hash != superseded {
+				return hash
+			}
+		}
+		t.Fatalf("vote of type '%s' is not found", voteType)
+		return ""
+	}
+
+	cases := []struct {
+		name     string
+		hash     string
+		voteType object.ContentType
+		of       string
+		value    int
+		tags     []string
+		errType  int
+	}{
+		{"thread", voteHash(object.V5ThreadVoteType), object.V5ThreadVoteType, tHash.Hex(), -1, []string{}, 0},
+		{"superseded", superseded, object.V5ThreadVoteType, tHash.Hex(), +1, []string{}, 0},
+		{"post", voteHash(object.V5PostVoteType), object.V5PostVoteType, pHash, -1, []string{object.SpamTag}, 0},
+		{"user", voteHash(object.V5UserVoteType), object.V5UserVoteType, apk.Hex(), +1, []string{object.TrustTag}, 0},
+		{"unknown", cipher.SHA256{}.Hex(), "", "", 0, nil, boo.NotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, e := bi.Viewer().ResolveVote(c.hash)
+			if c.errType != 0 {
+				if boo.Type(e) != c.errType {
+					t.Errorf("got error %v, expected type %d", e, c.errType)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal("failed to resolve vote:", e)
+			}
+			if out.VoteHash != c.hash || out.Type != c.voteType || out.Of != c.of ||
+				out.Voter != vpk.Hex() || out.Value != c.value || !reflect.DeepEqual(out.Tags, c.tags) {
+				t.Errorf("got %+v, expected %s vote of '%s' with value %d and tags %v",
+					out, c.voteType, c.of, c.value, c.tags)
+			}
+			// Tags are copied.
+			if len(out.Tags) > 0 {
+				out.Tags[0] = "modified"
+				if b := bi.v.i.VoteOfHash[c.hash]; b.Tags[0] == "modified" {
+					t.Error("tags of resolved vote are shared with the compiled vote")
+				}
+			}
+		})
+	}
+
+	if _, e := (*Viewer)(nil).ResolveVote(""); e != ErrViewerNotInitialized {
+		t.Errorf("uninitialized viewer: got %v, expected %v", e, ErrViewerNotInitialized)
+	}
+}
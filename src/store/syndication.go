@@ -0,0 +1,212 @@
+package store
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/skycoin/bbs/src/misc/boo"
+	"github.com/skycoin/bbs/src/store/object"
+	"time"
+)
+
+// Syndication feed formats.
+const (
+	SyndicationRSS  = "rss"  // RSS 2.0.
+	SyndicationAtom = "atom" // Atom 1.0.
+)
+
+const (
+	DefaultSyndicationLimit = 20
+	MaxSyndicationLimit     = 200
+)
+
+// Syndication is a rendered syndication feed.
+type Syndication struct {
+	ContentType string
+	Data        []byte
+}
+
+// syndicationEntry is a thread or post, as it appears in a syndication feed.
+type syndicationEntry struct {
+	id      string
+	title   string
+	body    string
+	creator string
+	ts      int64 // Unix nanoseconds.
+}
+
+// syndicationSource is a board or thread, and it's most recent entries.
+type syndicationSource struct {
+	id      string
+	title   string
+	desc    string
+	link    string
+	entries []*syndicationEntry
+}
+
+func toSyndicationEntry(rep *object.ContentRep) *syndicationEntry {
+	entry := &syndicationEntry{}
+	if rep.Header != nil {
+		entry.id = rep.Header.Hash
+	}
+	if body, ok := rep.Body.(*object.Body); ok {
+		entry.title = body.Name
+		entry.body = body.Body
+		entry.creator = body.Creator
+		entry.ts = body.TS
+	}
+	return entry
+}
+
+// render renders the source in the given format.
+func (s *syndicationSource) render(format string) (*Syndication, error) {
+	var (
+		out = &Syndication{}
+		v   interface{}
+	)
+	switch format {
+	case SyndicationRSS:
+		out.ContentType = "application/rss+xml; charset=utf-8"
+		v = s.rss()
+	case SyndicationAtom:
+		out.ContentType = "application/atom+xml; charset=utf-8"
+		v = s.atom()
+	default:
+		return nil, boo.Newf(boo.InvalidInput,
+			"unknown feed format '%s'", format)
+	}
+	data, e := xml.MarshalIndent(v, "", "  ")
+	if e != nil {
+		return nil, boo.WrapType(e, boo.Internal,
+			"failed to render feed")
+	}
+	out.Data = append([]byte(xml.Header), data...)
+	return out, nil
+}
+
+// updated obtains the time of the most recent entry.
+func (s *syndicationSource) updated() time.Time {
+	var ts int64
+	for _, entry := range s.entries {
+		if entry.ts > ts {
+			ts = entry.ts
+		}
+	}
+	return time.Unix(0, ts).UTC()
+}
+
+/*
+	<<< RSS 2.0 >>>
+*/
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Description string  `xml:"description"`
+	Author      string  `xml:"author,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func (s *syndicationSource) rss() *rssFeed {
+	feed := &rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       s.title,
+			Link:        s.link,
+			Description: s.desc,
+			Items:       make([]*rssItem, len(s.entries)),
+		},
+	}
+	if len(s.entries) > 0 {
+		feed.Channel.LastBuildDate = s.updated().Format(time.RFC1123Z)
+	}
+	for i, entry := range s.entries {
+		feed.Channel.Items[i] = &rssItem{
+			Title:       entry.title,
+			Description: entry.body,
+			Author:      entry.creator,
+			GUID:        rssGUID{Value: entry.id},
+			PubDate:     time.Unix(0, entry.ts).UTC().Format(time.RFC1123Z),
+		}
+	}
+	return feed
+}
+
+/*
+	<<< ATOM 1.0 >>>
+*/
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string       `xml:"id"`
+	Title   string       `xml:"title"`
+	Updated string       `xml:"updated"`
+	Link    atomLink     `xml:"link"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  *atomPerson `xml:"author,omitempty"`
+	Content atomContent `xml:"content"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (s *syndicationSource) atom() *atomFeed {
+	feed := &atomFeed{
+		ID:      atomID(s.id),
+		Title:   s.title,
+		Updated: s.updated().Format(time.RFC3339),
+		Link:    atomLink{Href: s.link},
+		Entries: make([]*atomEntry, len(s.entries)),
+	}
+	for i, entry := range s.entries {
+		feed.Entries[i] = &atomEntry{
+			ID:      atomID(entry.id),
+			Title:   entry.title,
+			Updated: time.Unix(0, entry.ts).UTC().Format(time.RFC3339),
+			Content: atomContent{Type: "text", Value: entry.body},
+		}
+		if entry.creator != "" {
+			feed.Entries[i].Author = &atomPerson{Name: entry.creator}
+		}
+	}
+	return feed
+}
+
+// atomID obtains an IRI for a public key or hash, as atom ids are required to be IRIs.
+func atomID(id string) string {
+	return fmt.Sprintf("urn:skycoin:bbs:%s", id)
+}
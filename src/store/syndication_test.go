@@ -0,0 +1,63 @@
+package store
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestSyndicationSource_Render(t *testing.T) {
+	src := &syndicationSource{
+		id:    "board",
+		title: "Board <One>",
+		link:  "/api/boards/board",
+		entries: []*syndicationEntry{
+			{id: "a", title: "Thread A", body: "Body & more", creator: "alice", ts: 2e18},
+			{id: "b", title: "Thread B", ts: 1e18},
+		},
+	}
+
+	t.Run("rss", func(t *testing.T) {
+		out, e := src.render(SyndicationRSS)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !strings.HasPrefix(out.ContentType, "application/rss+xml") {
+			t.Errorf("unexpected content type '%s'", out.ContentType)
+		}
+		var feed rssFeed
+		if e := xml.Unmarshal(out.Data, &feed); e != nil {
+			t.Fatal(e)
+		}
+		if feed.Channel.Title != src.title || len(feed.Channel.Items) != 2 {
+			t.Fatalf("unexpected channel: %+v", feed.Channel)
+		}
+		if item := feed.Channel.Items[0]; item.Description != "Body & more" || item.GUID.Value != "a" {
+			t.Errorf("unexpected item: %+v", item)
+		}
+	})
+
+	t.Run("atom", func(t *testing.T) {
+		out, e := src.render(SyndicationAtom)
+		if e != nil {
+			t.Fatal(e)
+		}
+		var feed atomFeed
+		if e := xml.Unmarshal(out.Data, &feed); e != nil {
+			t.Fatal(e)
+		}
+		if len(feed.Entries) != 2 || feed.Entries[1].Author != nil {
+			t.Fatalf("unexpected entries: %+v", feed.Entries)
+		}
+		if feed.Updated != feed.Entries[0].Updated {
+			t.Errorf("feed updated '%s' should match latest entry '%s'",
+				feed.Updated, feed.Entries[0].Updated)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		if _, e := src.render("json"); e == nil {
+			t.Error("expected error for unknown format")
+		}
+	})
+}